@@ -0,0 +1,400 @@
+// Copyright 2026 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package cli
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
+	"github.com/cockroachdb/errors"
+)
+
+// bytesExpr is the parsed value of a bytesOrPercentageValue. It is either a
+// size, e.g. 16GiB, a percentage, e.g. 25% or .25, or an expression combining
+// them with + and - and the min() and max() functions of two operands, e.g.
+// min(25%,16GiB) or 25%+1GiB.
+//
+// A percentage followed by a subtraction reserves some headroom, e.g.
+// 100%-10GiB is everything but 10GiB, and can go up to 100% even if the flag
+// otherwise accepts less. The expression must then still resolve to at most
+// the maximum percentage of the flag, so that 100%-1 is rejected where 99% is
+// the maximum.
+type bytesExpr interface {
+	// eval evaluates the expression, using resolver to turn the percentages
+	// into sizes.
+	eval(resolver percentResolverFunc) (int64, error)
+	// hasPercent returns whether the expression contains a percentage, which
+	// can only be evaluated with a resolver.
+	hasPercent() bool
+}
+
+type bytesLit int64
+
+type percentLit float64
+
+// bytesBinExpr is a sum or difference.
+type bytesBinExpr struct {
+	op          byte
+	left, right bytesExpr
+}
+
+// bytesFuncExpr is a call to min or max.
+type bytesFuncExpr struct {
+	name        string
+	left, right bytesExpr
+}
+
+func (e bytesLit) eval(percentResolverFunc) (int64, error) { return int64(e), nil }
+func (e bytesLit) hasPercent() bool                        { return false }
+
+func (e percentLit) eval(resolver percentResolverFunc) (int64, error) {
+	return resolver(float64(e))
+}
+func (e percentLit) hasPercent() bool { return true }
+
+func (e *bytesBinExpr) eval(resolver percentResolverFunc) (int64, error) {
+	l, r, err := evalOperands(e.left, e.right, resolver)
+	if err != nil {
+		return 0, err
+	}
+	if e.op == '-' {
+		if r > l {
+			return 0, errors.Newf("cannot subtract %s from %s",
+				humanizeutil.IBytes(r), humanizeutil.IBytes(l))
+		}
+		return l - r, nil
+	}
+	if r > math.MaxInt64-l {
+		return 0, errors.Newf("%s + %s is too large",
+			humanizeutil.IBytes(l), humanizeutil.IBytes(r))
+	}
+	return l + r, nil
+}
+func (e *bytesBinExpr) hasPercent() bool { return e.left.hasPercent() || e.right.hasPercent() }
+
+func (e *bytesFuncExpr) eval(resolver percentResolverFunc) (int64, error) {
+	l, r, err := evalOperands(e.left, e.right, resolver)
+	if err != nil {
+		return 0, err
+	}
+	if e.name == "min" {
+		return min(l, r), nil
+	}
+	return max(l, r), nil
+}
+func (e *bytesFuncExpr) hasPercent() bool { return e.left.hasPercent() || e.right.hasPercent() }
+
+// reservedExpr is an expression with a percentage above the maximum of the
+// flag, allowed because a reservation is subtracted from it. It checks that
+// the reservation brings the value back to at most maxPercent.
+type reservedExpr struct {
+	bytesExpr
+	s          string
+	maxPercent float64
+}
+
+func (e reservedExpr) eval(resolver percentResolverFunc) (int64, error) {
+	v, err := e.bytesExpr.eval(resolver)
+	if err != nil {
+		return 0, err
+	}
+	limit, err := resolver(e.maxPercent)
+	if err != nil {
+		return 0, err
+	}
+	if v > limit {
+		return 0, errors.Newf("%q resolves to %s, above %s%% (%s); subtract a larger reservation",
+			e.s, humanizeutil.IBytes(v), formatPercent(e.maxPercent), humanizeutil.IBytes(limit))
+	}
+	return v, nil
+}
+
+func evalOperands(
+	left, right bytesExpr, resolver percentResolverFunc,
+) (l, r int64, err error) {
+	if l, err = left.eval(resolver); err != nil {
+		return 0, 0, err
+	}
+	if r, err = right.eval(resolver); err != nil {
+		return 0, 0, err
+	}
+	return l, r, nil
+}
+
+// byteUnits is the family of the unit of a size.
+type byteUnits int
+
+const (
+	// noByteUnits is a size in bytes, e.g. 1024 or 1024B.
+	noByteUnits byteUnits = iota
+	// siByteUnits are the powers of 1000, e.g. 1GB or 1G.
+	siByteUnits
+	// iecByteUnits are the powers of 1024, e.g. 1GiB or 1Gi.
+	iecByteUnits
+)
+
+// byteUnitPolicy is how a bytesOrPercentageValue treats the units of its
+// sizes. Like go-humanize, the flags read the SI units as powers of 1000,
+// so that 32GB is only 29.8 GiB, which surprises the operators who expect
+// 32GB to be 32 GiB.
+type byteUnitPolicy int
+
+const (
+	// anyByteUnits accepts the units of both families.
+	anyByteUnits byteUnitPolicy = iota
+	// warnSIByteUnits accepts the units of both families, and reports the
+	// sizes given with SI units so that a warning suggesting the IEC unit can
+	// be logged.
+	warnSIByteUnits
+	// iecByteUnitsOnly rejects the SI units.
+	iecByteUnitsOnly
+	// siByteUnitsOnly rejects the IEC units.
+	siByteUnitsOnly
+)
+
+// bytesExprParser is a recursive descent parser for the grammar:
+//
+//	sum  = term { ("+" | "-") term }
+//	term = ("min" | "max") "(" sum "," sum ")" | size | percentage
+//
+// Spaces are allowed between the tokens.
+type bytesExprParser struct {
+	s      string
+	pos    int
+	bounds percentBounds
+	units  byteUnitPolicy
+
+	// siSizes are the sizes given with SI units, when units is
+	// warnSIByteUnits.
+	siSizes []string
+	// reserved is set if a percentage above bounds.max was accepted because
+	// it is followed by a reservation.
+	reserved bool
+}
+
+// parseBytesExpr parses s, rejecting the percentages outside of bounds and
+// the sizes whose units units does not accept. It also returns the sizes
+// given with SI units if units is warnSIByteUnits.
+func parseBytesExpr(
+	s string, bounds percentBounds, units byteUnitPolicy,
+) (_ bytesExpr, siSizes []string, _ error) {
+	p := bytesExprParser{s: s, bounds: bounds, units: units}
+	e, err := p.parseSum()
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.skipSpaces(); p.pos < len(p.s) {
+		return nil, nil, p.errorf(p.pos, "unexpected %q", p.s[p.pos:p.pos+1])
+	}
+	if p.reserved {
+		e = reservedExpr{bytesExpr: e, s: s, maxPercent: bounds.max}
+	}
+	return e, p.siSizes, nil
+}
+
+func (p *bytesExprParser) parseSum() (bytesExpr, error) {
+	e, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpaces()
+		if p.pos == len(p.s) || (p.s[p.pos] != '+' && p.s[p.pos] != '-') {
+			return e, nil
+		}
+		op := p.s[p.pos]
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		e = &bytesBinExpr{op: op, left: e, right: right}
+	}
+}
+
+func (p *bytesExprParser) parseTerm() (bytesExpr, error) {
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.s) && !strings.ContainsRune("+-(),", rune(p.s[p.pos])) {
+		p.pos++
+	}
+	tok := strings.TrimSpace(p.s[start:p.pos])
+	if tok == "" {
+		if p.pos == len(p.s) {
+			return nil, p.errorf(p.pos, "expected a size or a percentage")
+		}
+		return nil, p.errorf(p.pos, "unexpected %q, expected a size or a percentage", p.s[p.pos:p.pos+1])
+	}
+	if p.pos < len(p.s) && p.s[p.pos] == '(' {
+		if tok != "min" && tok != "max" {
+			return nil, p.errorf(start, "unknown function %q, expected min or max", tok)
+		}
+		p.pos++
+		left, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(','); err != nil {
+			return nil, err
+		}
+		right, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		return &bytesFuncExpr{name: tok, left: left, right: right}, nil
+	}
+	bounds := p.bounds
+	if p.peek() == '-' {
+		// The percentage is followed by a reservation, e.g. 100%-10GiB.
+		bounds.max = max(bounds.max, 100)
+	}
+	e, err := p.parseOperand(tok, bounds)
+	if pct, ok := e.(percentLit); ok && float64(pct) > p.bounds.max {
+		p.reserved = true
+	}
+	if err != nil {
+		if start == 0 && p.pos == len(p.s) {
+			// The whole value is a single operand.
+			return nil, err
+		}
+		return nil, errors.Wrapf(err, "invalid expression %q at position %d", p.s, start+1)
+	}
+	return e, nil
+}
+
+// parseOperand parses a size or a percentage. A percentage is given with a %
+// suffix or as a fraction, e.g. .25 or 0.25.
+func (p *bytesExprParser) parseOperand(tok string, bounds percentBounds) (bytesExpr, error) {
+	if !strings.HasSuffix(tok, "%") && !fractionRE.MatchString(tok) {
+		v, err := humanizeutil.ParseBytes(tok)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.checkUnits(tok); err != nil {
+			return nil, err
+		}
+		return bytesLit(v), nil
+	}
+	multiplier := 100.0
+	if tok[len(tok)-1] == '%' {
+		multiplier = 1.0
+		tok = tok[:len(tok)-1]
+	}
+	frac, err := strconv.ParseFloat(tok, 64)
+	if err != nil {
+		return nil, err
+	}
+	// The percentage is kept as a float until it is resolved, so that 12.5%
+	// is not truncated to 12%.
+	percent := frac * multiplier
+	if percent < bounds.min || percent > bounds.max {
+		return nil, errors.Newf("percentage %s%% out of range %s", formatPercent(percent), bounds)
+	}
+	return percentLit(percent), nil
+}
+
+// checkUnits checks the unit of size against the unit policy of the parser.
+func (p *bytesExprParser) checkUnits(size string) error {
+	switch units := sizeUnits(size); {
+	case units == siByteUnits && p.units == iecByteUnitsOnly:
+		return errors.WithHintf(
+			errors.Newf("size %q has an SI unit, a power of 1000; only IEC units are accepted", size),
+			"use %s for the IEC unit, a power of 1024", withSizeUnits(size, iecByteUnits))
+	case units == iecByteUnits && p.units == siByteUnitsOnly:
+		return errors.WithHintf(
+			errors.Newf("size %q has an IEC unit, a power of 1024; only SI units are accepted", size),
+			"use %s for the SI unit, a power of 1000", withSizeUnits(size, siByteUnits))
+	case units == siByteUnits && p.units == warnSIByteUnits:
+		p.siSizes = append(p.siSizes, size)
+	}
+	return nil
+}
+
+// splitSize splits a size accepted by humanizeutil.ParseBytes into its number
+// and its unit, e.g. "32" and "GB".
+func splitSize(size string) (number, unit string) {
+	i := strings.IndexFunc(size, func(r rune) bool {
+		return !strings.ContainsRune("0123456789. ", r)
+	})
+	if i < 0 {
+		return strings.TrimSpace(size), ""
+	}
+	return strings.TrimSpace(size[:i]), strings.TrimSpace(size[i:])
+}
+
+// sizeUnits returns the family of the unit of a size accepted by
+// humanizeutil.ParseBytes.
+func sizeUnits(size string) byteUnits {
+	_, unit := splitSize(size)
+	switch unit = strings.ToLower(unit); {
+	case unit == "" || unit == "b":
+		return noByteUnits
+	case strings.HasSuffix(unit, "i") || strings.HasSuffix(unit, "ib"):
+		return iecByteUnits
+	default:
+		return siByteUnits
+	}
+}
+
+// withSizeUnits returns size with the unit of the same magnitude in the
+// units family, e.g. 32GiB for 32GB and IEC units. Note that the value
+// changes: 32GiB is 32*2^30 bytes and 32GB is 32*10^9 bytes.
+func withSizeUnits(size string, units byteUnits) string {
+	number, unit := splitSize(size)
+	if sizeUnits(size) == noByteUnits {
+		return size
+	}
+	prefix := strings.ToUpper(unit[:1])
+	if units == iecByteUnits {
+		return number + prefix + "iB"
+	}
+	if prefix == "K" {
+		// The SI prefix of the thousands is lower case.
+		prefix = "k"
+	}
+	return number + prefix + "B"
+}
+
+func (p *bytesExprParser) expect(c byte) error {
+	p.skipSpaces()
+	if p.pos == len(p.s) {
+		return p.errorf(p.pos, "expected %q", c)
+	}
+	if p.s[p.pos] != c {
+		return p.errorf(p.pos, "unexpected %q, expected %q", p.s[p.pos:p.pos+1], c)
+	}
+	p.pos++
+	return nil
+}
+
+// peek returns the next character that is not a space, or 0 at the end of the
+// input.
+func (p *bytesExprParser) peek() byte {
+	for i := p.pos; i < len(p.s); i++ {
+		if p.s[i] != ' ' {
+			return p.s[i]
+		}
+	}
+	return 0
+}
+
+func (p *bytesExprParser) skipSpaces() {
+	for p.pos < len(p.s) && p.s[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// errorf returns an error about the token at pos, counted from 1 in the
+// error message.
+func (p *bytesExprParser) errorf(pos int, format string, args ...interface{}) error {
+	return errors.Wrapf(errors.Newf(format, args...),
+		"invalid expression %q at position %d", p.s, pos+1)
+}
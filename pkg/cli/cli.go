@@ -16,6 +16,7 @@ import (
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 
 	"github.com/cockroachdb/cockroach/pkg/build"
 	"github.com/cockroachdb/cockroach/pkg/cli/clierror"
@@ -295,8 +296,22 @@ func hasParentCmd(cmd, refParent *cobra.Command) bool {
 	return hasParent
 }
 
+// flagCompletions holds the result of the registration of the flag
+// completions, which is done once all the commands and their flags are
+// defined, i.e. after the init functions of the package have run.
+var flagCompletions struct {
+	once sync.Once
+	err  error
+}
+
 // Run ...
 func Run(args []string) error {
+	flagCompletions.once.Do(func() {
+		flagCompletions.err = registerFlagCompletions(cockroachCmd)
+	})
+	if flagCompletions.err != nil {
+		return flagCompletions.err
+	}
 	cockroachCmd.SetArgs(args)
 	return cockroachCmd.Execute()
 }
@@ -6,14 +6,23 @@
 package cli
 
 import (
+	"context"
+	"encoding/hex"
 	"fmt"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/storage/fs"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
 	"github.com/stretchr/testify/require"
 )
 
@@ -66,6 +75,73 @@ func TestDebugKeysHex(t *testing.T) {
 	}
 }
 
+// TestDebugRangeDescriptorsFilters checks that the --key flag of debug
+// range-descriptors, which takes a key without a timestamp, and its --ranges
+// flag, which takes range IDs, restrict the output to the descriptors of the
+// matching ranges.
+func TestDebugRangeDescriptorsFilters(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	baseDir, dirCleanupFn := testutils.TempDir(t)
+	defer dirCleanupFn()
+
+	storePath := filepath.Join(baseDir, "store")
+	createStore(t, storePath)
+
+	codec := keys.SystemSQLCodec
+	func() {
+		stopper := stop.NewStopper()
+		defer stopper.Stop(context.Background())
+		db, err := OpenEngine(storePath, stopper, fs.ReadWrite)
+		require.NoError(t, err)
+		for i, tableID := range []uint32{104, 105} {
+			desc := roachpb.RangeDescriptor{
+				RangeID:  roachpb.RangeID(i + 1),
+				StartKey: roachpb.RKey(codec.TablePrefix(tableID)),
+				EndKey:   roachpb.RKey(codec.TablePrefix(tableID + 1)),
+			}
+			require.NoError(t, storage.MVCCPutProto(context.Background(), db,
+				keys.RangeDescriptorKey(desc.StartKey), hlc.Timestamp{WallTime: 1}, &desc,
+				storage.MVCCWriteOptions{}))
+		}
+	}()
+
+	const r1, r2 = "/Local/Range/Table/104/RangeDescriptor", "/Local/Range/Table/105/RangeDescriptor"
+	for _, td := range []struct {
+		flags  string
+		expOut []string
+	}{
+		{"", []string{r1, r2}},
+		{"--key human:/Table/105/1", []string{r2}},
+		{"--key hex:" + hex.EncodeToString(codec.IndexPrefix(105, 1)), []string{r2}},
+		{"--ranges r1", []string{r1}},
+		{"--ranges 2-r3", []string{r2}},
+		{"--ranges r1 --ranges r2", []string{r1, r2}},
+		{"--ranges r1 --key human:/Table/105/1", nil},
+		{"--ranges 3", nil},
+	} {
+		t.Run(td.flags, func(t *testing.T) {
+			initCLIDefaults()
+			out, err := TestCLI{}.RunWithCapture("debug range-descriptors " + storePath + " " + td.flags)
+			require.NoError(t, err)
+			for _, desc := range []string{r1, r2} {
+				if slices.Contains(td.expOut, desc) {
+					require.Contains(t, out, desc)
+				} else {
+					require.NotContains(t, out, desc)
+				}
+			}
+		})
+	}
+
+	// A key with a timestamp is rejected.
+	out, err := TestCLI{}.RunWithCapture("debug range-descriptors " + storePath +
+		" --key human:/Table/105/1@1.000000000,0")
+	require.NoError(t, err)
+	require.Contains(t, out, "this flag takes a key without a timestamp")
+}
+
 func TestDebugDecodeKeys(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
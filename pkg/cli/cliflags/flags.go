@@ -170,7 +170,17 @@ Maximum memory capacity available to store temporary data for SQL clients,
 including prepared queries and intermediate data rows during query execution.
 Accepts numbers interpreted as bytes, size suffixes (e.g. 1GB and 1GiB) or a
 percentage of physical memory (e.g. .25). If left unspecified, defaults to 25% of
-physical memory.`,
+physical memory. With "auto", a quarter of physical memory is used, reduced if
+--cache=auto needs more than a quarter for the stores.`,
+	}
+
+	SQLMemIncludeSwap = FlagInfo{
+		Name: "max-sql-memory-include-swap",
+		Description: `
+When specified, a percentage given with --max-sql-memory is a percentage of the
+physical memory plus the swap space, e.g. on hosts whose memory is
+overcommitted. The swap space is not counted, with a warning, on the platforms
+that do not report its size.`,
 	}
 
 	GoMemLimit = FlagInfo{
@@ -227,17 +237,20 @@ left unspecified, defaults to 32GiB.
 </PRE>
 The location of the temporary files is within the first store dir (see --store).
 If expressed as a percentage, --max-disk-temp-storage is interpreted relative to
-the size of the storage device on which the first store is placed. The temp
-space usage is never counted towards any store usage (although it does share the
-device with the first store) so, when configuring this, make sure that the size
-of this temp storage plus the size of the first store don't exceed the capacity
-of the storage device.
+the space available, when the node starts, on the storage device on which the
+first store is placed. The temp space usage is never counted towards any store
+usage (although it does share the device with the first store) so, when
+configuring this, make sure that the size of this temp storage plus the size of
+the first store don't exceed the capacity of the storage device. Up to 100% is
+accepted, for a dedicated scratch device. Some headroom can be reserved by
+subtracting it from a percentage, e.g. 100%-10GiB uses all the available space
+but 10GiB. The temp storage must be at least 64MiB.
 <PRE>
 
 </PRE>
 If the first store is an in-memory one (i.e. type=mem), then this temporary
 "disk" data is also kept in-memory. A percentage value is interpreted as a
-percentage of the available internal memory. If not specified, the default
+percentage of the size of the in-memory store. If not specified, the default
 shifts to 100MiB when the first store is in-memory.
 `,
 	}
@@ -259,9 +272,15 @@ without additional details and decoration.`,
 		Name: "cache",
 		Description: `
 Total size in bytes for caches, shared evenly if there are multiple
-storage devices. Size suffixes are supported (e.g. 1GB and 1GiB).
-If left unspecified, defaults to 128MiB. A percentage of physical memory
-can also be specified (e.g. .25).`,
+storage devices. Size suffixes are supported (e.g. 1GB and 1GiB); note
+that 1GB is 1000^3 bytes and 1GiB is 1024^3 bytes, and a warning is logged
+for the sizes given in GB. If left unspecified, defaults to 128MiB. A percentage of physical memory
+can also be specified (e.g. .25), as well as an expression combining sizes
+and percentages with +, - and the min() and max() functions
+(e.g. min(25%,16GiB) or 25%+1GiB). With "auto", a quarter of physical memory
+is used, but at least 128MiB per store, up to half of physical memory. Some
+headroom can be reserved by subtracting it from a percentage, e.g. 100%-10GiB
+uses all of physical memory but 10GiB. The cache must be at least 64MiB.`,
 	}
 
 	ClientHost = FlagInfo{
@@ -290,26 +309,6 @@ example [::1]:26257 or [fe80::f6f2:::]:26257.`,
 		Description: `The name of the database to connect to.`,
 	}
 
-	DumpMode = FlagInfo{
-		Name: "dump-mode",
-		Description: `
-What to dump. "schema" dumps the schema only. "data" dumps the data only.
-"both" (default) dumps the schema then the data.`,
-	}
-
-	ReadTime = FlagInfo{
-		Name: "as-of",
-		Description: `
-Reads the data as of the specified timestamp. Formats supported are the same
-as the timestamp type.`,
-	}
-
-	DumpAll = FlagInfo{
-		Name: "dump-all",
-		Description: `
-Dumps all databases, for each non-system database provides dump of all available tables.`,
-	}
-
 	Execute = FlagInfo{
 		Name:      "execute",
 		Shorthand: "e",
@@ -709,14 +708,27 @@ information.
 		Description: `
 List of ports to advertise to other CockroachDB nodes for intra-cluster
 communication for some locality. This should be specified as a comma
-separated list of locality@address. Addresses can also include ports.
-For example:
+separated list of locality@address. Addresses can also include ports; the
+port defaults to the port of --advertise-addr. The address follows the last @
+of each item, so that the locality values can contain @ and = characters. The
+flag can also be repeated, in which case the lists of all its occurrences are
+combined. If --locality is specified, each locality of the list should be one
+of its tiers; a warning is logged otherwise, or the server fails to start with
+--strict-locality-advertise-addr. For example:
 <PRE>
 
   "region=us-west@127.0.0.1,zone=us-west-1b@127.0.0.1"
   "region=us-west@127.0.0.1:26257,zone=us-west-1b@127.0.0.1:26258"</PRE>`,
 	}
 
+	StrictLocalityAdvertiseAddr = FlagInfo{
+		Name: "strict-locality-advertise-addr",
+		Description: `
+When specified, the server fails to start if a locality of
+--locality-advertise-addr is not a tier of --locality, instead of logging a
+warning.`,
+	}
+
 	ListenHTTPAddrAlias = FlagInfo{
 		Name:        "http-host",
 		Description: `Alias for --http-addr. Deprecated.`,
@@ -1237,17 +1249,79 @@ user, database, insecure, certs-dir.`,
 	From = FlagInfo{
 		Name: "from",
 		Description: `
-Start key and format as [<format>:]<key>. Supported formats: raw, hex, human,
-rangeID. The raw format supports escaped text. For example, "raw:\x01k" is the
-prefix for range local keys. The hex format takes an encoded MVCCKey.`,
+Start key and format as [<format>:]<key>. Supported formats: raw, escaped, hex,
+b64 (or base64), human, pretty, rangeID, lock. The raw format supports escaped
+text. For example, "raw:\x01k" is the prefix for range local keys. A raw key can
+also be given as plain hex bytes, e.g. "raw-hex:016b". The hex and
+b64 formats take an encoded MVCCKey; b64 accepts both the standard and the
+URL-safe alphabets, with or without padding. The escaped format takes a key as
+quoted in logs, e.g. 'escaped:"\x89\xf7\x01"'. The other formats accept an
+optional @<walltime>[,<logical>] suffix designating a version of the key, e.g.
+"human:/Table/104/1/42@1712345678.000000001,0". The rangeID format takes a range
+ID, optionally followed by the name of one of its range-ID local keys, e.g.
+"rangeID:42/hardstate" or "rangeID:42/raftlog/100". The lock format designates
+the lock-table key of a key given in another format, e.g.
+"lock:human:/Table/104/1/42". The pretty format accepts the keys as printed by
+the debug commands, e.g. "pretty:/Table/104/1/42/1712345678.000000001,0"; unlike
+with the human format, a last segment containing a comma is taken as the
+timestamp. The redaction markers of a key copied from the logs, e.g.
+"human:‹/Table/104/1/42›", are ignored.`,
 	}
 
 	To = FlagInfo{
 		Name: "to",
 		Description: `
-Exclusive end key and format as [<format>:]<key>. Supported formats: raw, hex,
-human, rangeID. The raw format supports escaped text. For example, "raw:\x01k"
-is the prefix for range local keys. The hex format takes an encoded MVCCKey.`}
+Exclusive end key and format as [<format>:]<key>. Supported formats: raw,
+escaped, hex, b64 (or base64), human, pretty, rangeID, lock. The raw format
+supports escaped text. For example, "raw:\x01k" is the prefix for range local
+keys. A raw key can also be given as plain hex bytes, e.g. "raw-hex:016b". The
+hex and b64 formats take an encoded MVCCKey; b64 accepts both the
+standard and the URL-safe alphabets, with or without padding. The escaped format
+takes a key as quoted in logs, e.g. 'escaped:"\x89\xf7\x01"'. The other formats
+accept an optional @<walltime>[,<logical>] suffix designating a version of the
+key, e.g. "human:/Table/104/1/42@1712345678.000000001,0". The rangeID format
+takes a range ID, optionally followed by the name of one of its range-ID local
+keys, e.g. "rangeID:42/hardstate" or "rangeID:42/raftlog/100". The lock format
+designates the lock-table key of a key given in another format, e.g.
+"lock:human:/Table/104/1/42". The pretty format accepts the keys as printed by
+the debug commands, e.g. "pretty:/Table/104/1/42/1712345678.000000001,0"; unlike
+with the human format, a last segment containing a comma is taken as the
+timestamp. The redaction markers of a key copied from the logs, e.g.
+"human:‹/Table/104/1/42›", are ignored.`}
+
+	KeyRange = FlagInfo{
+		Name: "range",
+		Description: `
+Start and exclusive end keys as <start>,<end>, in the formats of --from and
+--to, e.g. "human:/Table/104,human:/Table/105". Either key can be omitted to
+leave that side of the range open, e.g. ",human:/Table/105". The start key must
+sort before the end key. This is an alternative to --from and --to; the last of
+these flags given takes precedence.`,
+	}
+
+	RangeDescriptorKey = FlagInfo{
+		Name: "key",
+		Description: `
+Only print the descriptors of the ranges that contain the key, given as
+[<format>:]<key> in the formats of --from, without a timestamp. Unlike with
+--from, the hex and b64 formats take the bytes of the key itself rather than
+an encoded MVCCKey, e.g. "hex:f089" for /Table/104/1.`,
+	}
+
+	RangeDescriptorRanges = FlagInfo{
+		Name: "ranges",
+		Description: `
+Only print the descriptors of the given ranges, as a comma-separated list of
+range IDs and inclusive ranges of range IDs, with or without the r prefix of
+the logs, e.g. "r100-r110,42". The flag can be repeated.`,
+	}
+
+	PrintKey = FlagInfo{
+		Name: "print-key",
+		Description: `
+Print the keys given with --from, --to or --range in all the supported formats,
+as they were decoded, and exit without opening the store.`,
+	}
 
 	Limit = FlagInfo{
 		Name:        "limit",
@@ -1304,7 +1378,9 @@ to local keys, unless specified`,
 When non-zero, wait for at most the specified amount of time for the node to
 drain all active client connections and migrate away range leases.
 If zero, the command waits until the last client has disconnected and
-all range leases have been migrated away.`,
+all range leases have been migrated away. The wait can also be given as a
+percentage, from 100% to 1000%, of the time that the server.shutdown.*_wait
+cluster settings of the node require, e.g. 150%.`,
 	}
 
 	Wait = FlagInfo{
@@ -1316,11 +1392,18 @@ Takes any of the following values:
 
   - all   waits until all target nodes' replica counts have dropped to zero and
           marks the nodes as fully decommissioned. This is the default.
+  - live  like all, but does not wait for the replicas of the ranges that are
+          unavailable because a quorum of their replicas is on non-live nodes.
+          If only such replicas remain, the nodes are left decommissioning,
+          the unavailable ranges are reported and the command fails.
   - none  marks the targets as decommissioning, but does not wait for the
           replica counts to drop to zero before returning. If the replica counts
           are found to be zero, nodes are marked as fully decommissioned. Use
           when polling manually from an external system.
-</PRE>`,
+</PRE>
+The all and live values accept a timeout suffix, e.g. all:30m. If the replicas
+have not moved off the target nodes when the timeout expires, the command
+reports the remaining replicas and exits with status 125.`,
 	}
 
 	Timeout = FlagInfo{
@@ -1371,7 +1454,40 @@ Takes any of the following values:
   - strict   use strict readiness evaluation mode prior to node decommission.
   - skip     skip readiness checks and immediately request node decommission.
              Use when rerunning node decommission.
-</PRE>`,
+  - dry-run  evaluate readiness and report the ranges blocking node
+             decommission, without starting it.
+</PRE>
+The checks can also be given as a comma-separated list of:
+<PRE>
+
+  - constraints   stores satisfying the range constraints can take the
+                  replicas to move.
+  - capacity      these stores are neither throttled nor full.
+  - action        the ranges are available and need more than a rebalance.
+  - replace-only  the ranges only need their replicas to be replaced or
+                  removed.
+  - dry-run       do not start node decommission.
+</PRE>
+enabled is equivalent to constraints,capacity,action and strict adds
+replace-only to it.`,
+	}
+
+	NodeDecommissionChecksFormat = FlagInfo{
+		Name: "checks-format",
+		Description: `
+Specifies how to report the ranges blocking node decommission found by the
+readiness checks. Takes any of the following values:
+<PRE>
+
+  - table  report them as text after the readiness of the nodes.
+  - json   write them to the standard output as a JSON array of objects
+           with the fields node_id, range_id, action, blocking_constraints,
+           candidate_stores and error.
+  - csv    write them to the standard output as CSV with the same columns.
+</PRE>
+With json and csv, the readiness of the nodes is printed to the standard
+error instead, and an empty array or a header alone is written if no range
+blocks the decommission.`,
 	}
 
 	NodeDecommissionDryRun = FlagInfo{
@@ -1468,8 +1584,11 @@ can also be specified (e.g. .25).`,
 	}
 
 	DemoNameGenOpts = FlagInfo{
-		Name:        "name-gen-options",
-		Description: `Use the specified options for the name generation during schema expansion (JSON syntax).`,
+		Name: "name-gen-options",
+		Description: `
+Use the specified options for the name generation during schema expansion
+(JSON syntax). The options can be read from a file with @<file>, or from the
+standard input with -.`,
 	}
 
 	DemoWorkloadMaxQPS = FlagInfo{
@@ -1675,8 +1794,11 @@ this flag is applied.`,
 Limit file collection to those files modified after the
 specified timestamp, inclusive.
 The timestamp can be expressed as YYYY-MM-DD,
-YYYY-MM-DD HH:MM or YYYY-MM-DD HH:MM:SS and is interpreted
-in the UTC time zone.
+YYYY-MM-DD HH:MM or YYYY-MM-DD HH:MM:SS, which are interpreted
+in the UTC time zone (a date alone is midnight UTC), as an
+RFC3339 timestamp, e.g. 2024-04-05T12:30:00Z, as an offset from
+the current time, e.g. -1h30m, or as an HLC timestamp, e.g.
+1712345678.000000001,0.
 The default value for this flag is 48 hours before now.
 <PRE>
 
@@ -1697,8 +1819,11 @@ this flag is applied.`,
 Limit file collection to those files created before the
 specified timestamp, inclusive.
 The timestamp can be expressed as YYYY-MM-DD,
-YYYY-MM-DD HH:MM or YYYY-MM-DD HH:MM:SS and is interpreted
-in the UTC time zone.
+YYYY-MM-DD HH:MM or YYYY-MM-DD HH:MM:SS, which are interpreted
+in the UTC time zone (a date alone is midnight UTC), as an
+RFC3339 timestamp, e.g. 2024-04-05T12:30:00Z, as an offset from
+the current time, e.g. -1h30m, or as an HLC timestamp, e.g.
+1712345678.000000001,0.
 The default value for this flag is some time beyond
 the current time, to ensure files created during
 the collection are also included.
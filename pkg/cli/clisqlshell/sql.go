@@ -2525,18 +2525,23 @@ func (c *cliState) serverSideParse(sql string) (helpText string, err error) {
 				hint = row[1]
 			case "code":
 				code = row[1]
+			case "help":
+				helpText = row[1]
 			}
 		}
 		// Is it a help text?
-		//
-		// The string here must match the constant string
-		// parser.specialHelpErrorPrefix. The second string must match
-		// the constant string parser.helpHintPrefix.
-		//
-		// However, we cannot include the 'parser' package here because it
-		// would incur a huge dependency overhead.
-		if strings.HasPrefix(message, "help token in input") && strings.HasPrefix(hint, "help:") {
-			// Yes: return it.
+		if helpText != "" {
+			// Yes: the help text is also included in the hint; don't
+			// report it twice.
+			hint = ""
+		} else if strings.HasPrefix(message, "help token in input") && strings.HasPrefix(hint, "help:") {
+			// Servers that predate the "help" field only report help texts
+			// in the hint. The string here must match the constant string
+			// parser.specialHelpErrorPrefix. The second string must match
+			// the constant string parser.helpHintPrefix.
+			//
+			// However, we cannot include the 'parser' package here because
+			// it would incur a huge dependency overhead.
 			helpText = hint[6:]
 			hint = ""
 		}
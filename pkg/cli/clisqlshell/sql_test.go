@@ -344,13 +344,13 @@ func Example_includes() {
 	// ?column?
 	// 456
 	// sql -f testdata/i_multiline.sql
-	// ERROR: at or near "\": syntax error
+	// ERROR: at or near "SELECT \": syntax error
 	// SQLSTATE: 42601
 	// DETAIL: source SQL:
 	// SELECT -- incomplete statement, \i invalid
 	// \i testdata/i_twolevels2.sql
 	// ^
-	// ERROR: at or near "\": syntax error
+	// ERROR: at or near "SELECT \": syntax error
 	// SQLSTATE: 42601
 	// DETAIL: source SQL:
 	// SELECT -- incomplete statement, \i invalid
@@ -14,6 +14,7 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/cli/clicfg"
+	"github.com/cockroachdb/cockroach/pkg/cli/cliflags"
 	"github.com/cockroachdb/cockroach/pkg/cli/clisqlcfg"
 	"github.com/cockroachdb/cockroach/pkg/cli/clisqlclient"
 	"github.com/cockroachdb/cockroach/pkg/cli/clisqlexec"
@@ -49,7 +50,6 @@ func initCLIDefaults() {
 	setSQLExecContextDefaults()
 	setSQLContextDefaults()
 	setZipContextDefaults()
-	setDumpContextDefaults()
 	setDebugContextDefaults()
 	setStartContextDefaults()
 	setDrainContextDefaults()
@@ -400,30 +400,8 @@ func setZipContextDefaults() {
 	// that files created during the zip operation are
 	// also included.
 	now := timeutil.Now()
-	zipCtx.files.startTimestamp = timestampValue(now.Add(-48 * time.Hour))
-	zipCtx.files.endTimestamp = timestampValue(now.Add(24 * time.Hour))
-}
-
-// dumpCtx captures the command-line parameters of the `dump` command.
-// See below for defaults.
-var dumpCtx struct {
-	// dumpMode determines which part of the database should be dumped.
-	dumpMode dumpMode
-
-	// asOf determines the time stamp at which the dump should be taken.
-	asOf string
-
-	// dumpAll determines whenever we going to dump all databases
-	dumpAll bool
-}
-
-// setDumpContextDefaults set the default values in dumpCtx.  This
-// function is called by initCLIDefaults() and thus re-called in every
-// test that exercises command-line parsing.
-func setDumpContextDefaults() {
-	dumpCtx.dumpMode = dumpBoth
-	dumpCtx.asOf = ""
-	dumpCtx.dumpAll = false
+	zipCtx.files.startTimestamp = timestampValue{WallTime: now.Add(-48 * time.Hour).UnixNano()}
+	zipCtx.files.endTimestamp = timestampValue{WallTime: now.Add(24 * time.Hour).UnixNano()}
 }
 
 // authCtx captures the command-line parameters of the `auth-session`
@@ -455,6 +433,9 @@ var debugCtx struct {
 	decodeAsTableDesc string
 	verbose           bool
 	keyTypes          keyTypeFilter
+	printKey          bool
+	descKey           roachpb.Key
+	descRangeIDs      []roachpb.RangeID
 }
 
 // setDebugContextDefaults set the default values in debugCtx.  This
@@ -473,6 +454,9 @@ func setDebugContextDefaults() {
 	debugCtx.decodeAsTableDesc = ""
 	debugCtx.verbose = false
 	debugCtx.keyTypes = showAll
+	debugCtx.printKey = false
+	debugCtx.descKey = nil
+	debugCtx.descRangeIDs = nil
 }
 
 // startCtx captures the command-line arguments for the `start` command.
@@ -495,6 +479,11 @@ var startCtx struct {
 	// and disables TLS on the HTTP listener.
 	unencryptedLocalhostHTTP bool
 
+	// if specified, the tiers of --locality-advertise-addr that are not
+	// tiers of --locality prevent the server from starting instead of
+	// causing a warning.
+	strictLocalityAdvertiseAddr bool
+
 	// temporary directory to use to spill computation results to disk.
 	tempDir string
 
@@ -526,6 +515,10 @@ var startCtx struct {
 	diskTempStorageSizeValue bytesOrPercentageValue
 	tsdbSizeValue            bytesOrPercentageValue
 
+	// sqlMemIncludeSwap makes the percentages of sqlSizeValue count the
+	// swap space; see bytesOrPercentageValue.withSwap.
+	sqlMemIncludeSwap bool
+
 	// goGCPercent is used to specify the runtime garbage collection target
 	// percentage. Also configurable with the GOGC environment variable.
 	goGCPercent int
@@ -542,17 +535,32 @@ func setStartContextDefaults() {
 	startCtx.serverNodeCertDN = ""
 	startCtx.serverListenAddr = ""
 	startCtx.unencryptedLocalhostHTTP = false
+	startCtx.strictLocalityAdvertiseAddr = false
 	startCtx.tempDir = ""
 	startCtx.externalIODir = ""
 	startCtx.listeningURLFile = ""
 	startCtx.pidFile = ""
 	startCtx.inBackground = false
 	startCtx.geoLibsDir = "/usr/local/lib/cockroach"
-	startCtx.cacheSizeValue = makeBytesOrPercentageValue(&serverCfg.CacheSize, memoryPercentResolver)
-	startCtx.sqlSizeValue = makeBytesOrPercentageValue(&serverCfg.MemoryPoolSize, memoryPercentResolver)
-	startCtx.goMemLimitValue = makeBytesOrPercentageValue(&goMemLimit, memoryPercentResolver)
-	startCtx.diskTempStorageSizeValue = makeBytesOrPercentageValue(nil /* v */, nil /* percentResolver */)
-	startCtx.tsdbSizeValue = makeBytesOrPercentageValue(&serverCfg.TimeSeriesServerConfig.QueryMemoryMax, memoryPercentResolver)
+	startCtx.cacheSizeValue = makeBytesOrPercentageValue(&serverCfg.CacheSize, memoryPercentResolver, cacheSizeAutoResolver, defaultPercentBounds).
+		withSizeBounds(cliflags.Cache.Name, sizeBounds{min: minCacheSize}).withByteUnits(warnSIByteUnits).withCapacity(memoryCapacity)
+	startCtx.sqlSizeValue = makeBytesOrPercentageValue(&serverCfg.MemoryPoolSize, memoryPercentResolver, sqlMemoryAutoResolver, defaultPercentBounds).
+		withByteUnits(warnSIByteUnits).withCapacity(memoryCapacity)
+	startCtx.goMemLimitValue = makeBytesOrPercentageValue(&goMemLimit, memoryPercentResolver, nil /* autoResolver */, defaultPercentBounds).
+		withByteUnits(warnSIByteUnits).withCapacity(memoryCapacity)
+	// The temp storage can use the whole scratch disk. Its capacity is only
+	// known once the stores are; see initTempStorageConfig.
+	startCtx.diskTempStorageSizeValue = makeBytesOrPercentageValue(
+		nil /* v */, nil /* percentResolver */, nil /* autoResolver */, percentBounds{min: 1, max: 100}).
+		withSizeBounds(cliflags.SQLTempStorage.Name, sizeBounds{min: minTempStorageSize})
+	startCtx.tsdbSizeValue = makeBytesOrPercentageValue(&serverCfg.TimeSeriesServerConfig.QueryMemoryMax, memoryPercentResolver, nil /* autoResolver */, defaultPercentBounds).
+		withByteUnits(warnSIByteUnits).withCapacity(memoryCapacity)
+	startCtx.sqlMemIncludeSwap = false
+	registerResolvedFlag(cliflags.Cache.Name, &startCtx.cacheSizeValue)
+	registerResolvedFlag(cliflags.SQLMem.Name, &startCtx.sqlSizeValue)
+	registerResolvedFlag(cliflags.GoMemLimit.Name, &startCtx.goMemLimitValue)
+	registerResolvedFlag(cliflags.SQLTempStorage.Name, &startCtx.diskTempStorageSizeValue)
+	registerResolvedFlag(cliflags.TSDBMem.Name, &startCtx.tsdbSizeValue)
 	startCtx.goGCPercent = 0
 }
 
@@ -563,6 +571,10 @@ var drainCtx struct {
 	// drainWait is the amount of time to wait for the server
 	// to drain. Set to 0 to disable a timeout (let the server decide).
 	drainWait time.Duration
+	// drainWaitValue is the value of --drain-wait, which writes drainWait.
+	// It can be a percentage of the time that the shutdown cluster
+	// settings of the server require, which is resolved by doDrain.
+	drainWaitValue durationOrPercentageValue
 	// nodeDrainSelf indicates that the command should target
 	// the node we're connected to (this is the default behavior).
 	nodeDrainSelf bool
@@ -575,6 +587,9 @@ var drainCtx struct {
 // test that exercises command-line parsing.
 func setDrainContextDefaults() {
 	drainCtx.drainWait = 10 * time.Minute
+	// A drain wait shorter than the time the cluster settings require is
+	// raised to it anyway.
+	drainCtx.drainWaitValue = makeDurationOrPercentageValue(&drainCtx.drainWait, percentBounds{min: 100, max: 1000})
 	drainCtx.nodeDrainSelf = false
 	drainCtx.shutdown = false
 }
@@ -582,23 +597,25 @@ func setDrainContextDefaults() {
 // nodeCtx captures the command-line parameters of the `node` command.
 // See below for defaults.
 var nodeCtx struct {
-	nodeDecommissionWait   nodeDecommissionWaitType
-	nodeDecommissionSelf   bool
-	nodeDecommissionChecks nodeDecommissionCheckMode
-	nodeDecommissionDryRun bool
-	statusShowRanges       bool
-	statusShowStats        bool
-	statusShowDecommission bool
-	statusShowAll          bool
+	nodeDecommissionWait         nodeDecommissionWaitType
+	nodeDecommissionSelf         bool
+	nodeDecommissionChecks       nodeDecommissionCheckMode
+	nodeDecommissionChecksFormat checksFormat
+	nodeDecommissionDryRun       bool
+	statusShowRanges             bool
+	statusShowStats              bool
+	statusShowDecommission       bool
+	statusShowAll                bool
 }
 
 // setNodeContextDefaults set the default values in nodeCtx.  This
 // function is called by initCLIDefaults() and thus re-called in every
 // test that exercises command-line parsing.
 func setNodeContextDefaults() {
-	nodeCtx.nodeDecommissionWait = nodeDecommissionWaitAll
+	nodeCtx.nodeDecommissionWait = nodeDecommissionWaitType{mode: nodeDecommissionWaitAll}
 	nodeCtx.nodeDecommissionSelf = false
 	nodeCtx.nodeDecommissionChecks = nodeDecommissionChecksEnabled
+	nodeCtx.nodeDecommissionChecksFormat = checksFormatTable
 	nodeCtx.nodeDecommissionDryRun = false
 	nodeCtx.statusShowRanges = false
 	nodeCtx.statusShowStats = false
@@ -685,10 +702,14 @@ func setDemoContextDefaults() {
 	demoCtx.demoNodeCacheSizeValue = makeBytesOrPercentageValue(
 		&demoCtx.CacheSize,
 		memoryPercentResolver,
+		nil, /* autoResolver */
+		defaultPercentBounds,
 	)
 	demoCtx.demoNodeSQLMemSizeValue = makeBytesOrPercentageValue(
 		&demoCtx.SQLPoolMemorySize,
 		memoryPercentResolver,
+		nil, /* autoResolver */
+		defaultPercentBounds,
 	)
 }
 
@@ -17,6 +17,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -80,7 +81,13 @@ var debugKeysCmd = &cobra.Command{
 	Long: `
 Pretty-prints all keys in a store.
 `,
-	Args: cobra.ExactArgs(1),
+	Args: func(cmd *cobra.Command, args []string) error {
+		if debugCtx.printKey {
+			// The store is not opened.
+			return cobra.MaximumNArgs(1)(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
 	RunE: clierrorplus.MaybeDecorateError(runDebugKeys),
 }
 
@@ -105,8 +112,10 @@ func parsePositiveInt(arg string) (int64, error) {
 	return i, nil
 }
 
+// parseRangeID parses a positive range ID, with or without the r prefix of
+// the logs, e.g. 42 or r42.
 func parseRangeID(arg string) (roachpb.RangeID, error) {
-	rangeIDInt, err := parsePositiveInt(arg)
+	rangeIDInt, err := parsePositiveInt(strings.TrimPrefix(arg, "r"))
 	if err != nil {
 		return 0, err
 	}
@@ -277,7 +286,40 @@ var keyTypeParams = map[keyTypeFilter]struct {
 	},
 }
 
+// printDebugKeyFlags prints the keys given with --from, --to or --range
+// in all the supported encodings; see --print-key.
+func printDebugKeyFlags(cmd *cobra.Command) error {
+	f := cmd.Flags()
+	keyRange := f.Changed(cliflags.KeyRange.Name)
+	printed := false
+	for _, k := range []struct {
+		flag string
+		key  *storage.MVCCKey
+	}{
+		{cliflags.From.Name, &debugCtx.startKey},
+		{cliflags.To.Name, &debugCtx.endKey},
+	} {
+		if !keyRange && !f.Changed(k.flag) {
+			continue
+		}
+		if printed {
+			fmt.Println()
+		}
+		fmt.Printf("--%s:\n%s", k.flag, (*mvccKey)(k.key).describe())
+		printed = true
+	}
+	if !printed {
+		return errors.Newf("--%s requires --%s, --%s or --%s",
+			cliflags.PrintKey.Name, cliflags.From.Name, cliflags.To.Name, cliflags.KeyRange.Name)
+	}
+	return nil
+}
+
 func runDebugKeys(cmd *cobra.Command, args []string) error {
+	if debugCtx.printKey {
+		return printDebugKeyFlags(cmd)
+	}
+
 	stopper := stop.NewStopper()
 	defer stopper.Stop(context.Background())
 
@@ -539,7 +581,9 @@ var debugRangeDescriptorsCmd = &cobra.Command{
 	Use:   "range-descriptors <directory>",
 	Short: "print all range descriptors in a store",
 	Long: `
-Prints all range descriptors in a store with a history of changes.
+Prints all range descriptors in a store with a history of changes. With
+--key, only the descriptors of the ranges that contain the key are printed,
+and with --ranges, only those of the given ranges.
 `,
 	Args: cobra.ExactArgs(1),
 	RunE: clierrorplus.MaybeDecorateError(runDebugRangeDescriptors),
@@ -598,6 +642,14 @@ func runDebugRangeDescriptors(cmd *cobra.Command, args []string) error {
 	stopper := stop.NewStopper()
 	defer stopper.Stop(context.Background())
 
+	var descKey roachpb.RKey
+	if len(debugCtx.descKey) > 0 {
+		var err error
+		if descKey, err = keys.Addr(debugCtx.descKey); err != nil {
+			return errors.Wrapf(err, "invalid --%s", cliflags.RangeDescriptorKey.Name)
+		}
+	}
+
 	db, err := OpenEngine(args[0], stopper, fs.ReadOnly, storage.MustExist)
 	if err != nil {
 		return err
@@ -613,11 +665,44 @@ func runDebugRangeDescriptors(cmd *cobra.Command, args []string) error {
 			if kvserver.IsRangeDescriptorKey(kv.Key) != nil {
 				return nil
 			}
+			if (descKey != nil || len(debugCtx.descRangeIDs) > 0) &&
+				!rangeDescriptorMatches(kv, descKey, debugCtx.descRangeIDs) {
+				return nil
+			}
 			kvserver.PrintMVCCKeyValue(kv)
 			return nil
 		})
 }
 
+// rangeDescriptorMatches returns whether kv is a version of the descriptor
+// of a range that contains key, unless key is nil, and whose ID is one of the
+// sorted rangeIDs, unless there are none. The intents and the deletions never
+// match, as they hold no descriptor.
+func rangeDescriptorMatches(
+	kv storage.MVCCKeyValue, key roachpb.RKey, rangeIDs []roachpb.RangeID,
+) bool {
+	if kv.Key.Timestamp.IsEmpty() {
+		return false
+	}
+	v, err := storage.DecodeMVCCValue(kv.Value)
+	if err != nil || v.IsTombstone() {
+		return false
+	}
+	var desc roachpb.RangeDescriptor
+	if err := v.Value.GetProto(&desc); err != nil {
+		return false
+	}
+	if key != nil && !desc.ContainsKey(key) {
+		return false
+	}
+	if len(rangeIDs) > 0 {
+		if _, found := slices.BinarySearch(rangeIDs, desc.RangeID); !found {
+			return false
+		}
+	}
+	return true
+}
+
 var decodeKeyOptions struct {
 	encoding keyFormat
 	userKey  bool
@@ -1602,7 +1687,7 @@ func init() {
 	f.Var(&debugLogChanSel, "only-channels", "selection of channels to include in the output diagram.")
 
 	f = debugTimeSeriesDumpCmd.Flags()
-	f.Var(&debugTimeSeriesDumpOpts.format, "format", "output format (text, csv, tsv, raw, openmetrics)")
+	f.Var(makeEnumFlag(&debugTimeSeriesDumpOpts.format, "format", tsDumpFormats), "format", "output format (text, csv, tsv, raw, openmetrics)")
 	f.Var(&debugTimeSeriesDumpOpts.from, "from", "oldest timestamp to include (inclusive)")
 	f.Var(&debugTimeSeriesDumpOpts.to, "to", "newest timestamp to include (inclusive)")
 	f.StringVar(&debugTimeSeriesDumpOpts.clusterLabel, "cluster-label",
@@ -276,7 +276,7 @@ func TestLossOfQuorumRecovery(t *testing.T) {
 	adminClient := tcAfter.Server(0).GetAdminClient(t)
 
 	require.NoError(t, runDecommissionNodeImpl(
-		ctx, adminClient, nodeDecommissionWaitNone, nodeDecommissionChecksSkip, false,
+		ctx, adminClient, nodeDecommissionWaitType{mode: nodeDecommissionWaitNone}, nodeDecommissionChecksSkip, false,
 		[]roachpb.NodeID{roachpb.NodeID(2), roachpb.NodeID(3)}, tcAfter.Server(0).NodeID()),
 		"Failed to decommission removed nodes")
 
@@ -72,3 +72,9 @@ func Killed() Code { return Code{138} }
 // DoctorValidationFailed indicates that the 'doctor' command has detected
 // an inconsistency in the SQL metaschema.
 func DoctorValidationFailed() Code { return Code{125} }
+
+// 'node decommission' exit codes.
+
+// DecommissionTimedOut indicates that the replicas did not move off the
+// nodes being decommissioned within the timeout given with --wait.
+func DecommissionTimedOut() Code { return Code{125} }
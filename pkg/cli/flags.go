@@ -514,6 +514,7 @@ func init() {
 			// addresses, for multi-region support.
 			// See: https://github.com/cockroachdb/cockroach/issues/90172
 			cliflagcfg.VarFlag(f, &localityAdvertiseHosts, cliflags.LocalityAdvertiseAddr)
+			cliflagcfg.BoolFlag(f, &startCtx.strictLocalityAdvertiseAddr, cliflags.StrictLocalityAdvertiseAddr)
 		}
 
 		cliflagcfg.VarFlag(f, &serverCfg.Locality, cliflags.Locality)
@@ -573,6 +574,7 @@ func init() {
 		// Engine flags.
 		cliflagcfg.VarFlag(f, &startCtx.cacheSizeValue, cliflags.Cache)
 		cliflagcfg.VarFlag(f, &startCtx.sqlSizeValue, cliflags.SQLMem)
+		cliflagcfg.BoolFlag(f, &startCtx.sqlMemIncludeSwap, cliflags.SQLMemIncludeSwap)
 		cliflagcfg.VarFlag(f, &startCtx.goMemLimitValue, cliflags.GoMemLimit)
 		cliflagcfg.VarFlag(f, &startCtx.tsdbSizeValue, cliflags.TSDBMem)
 		cliflagcfg.IntFlag(f, &startCtx.goGCPercent, cliflags.GoGCPercent)
@@ -778,6 +780,9 @@ func init() {
 
 	// Decommission pre-check flags.
 	cliflagcfg.VarFlag(decommissionNodeCmd.Flags(), &nodeCtx.nodeDecommissionChecks, cliflags.NodeDecommissionChecks)
+	cliflagcfg.VarFlag(decommissionNodeCmd.Flags(),
+		makeEnumFlag(&nodeCtx.nodeDecommissionChecksFormat, cliflags.NodeDecommissionChecksFormat.Name, checksFormats),
+		cliflags.NodeDecommissionChecksFormat)
 	cliflagcfg.BoolFlag(decommissionNodeCmd.Flags(), &nodeCtx.nodeDecommissionDryRun, cliflags.NodeDecommissionDryRun)
 
 	// Decommission and recommission share --self.
@@ -789,7 +794,7 @@ func init() {
 	// node drain command.
 	{
 		f := drainNodeCmd.Flags()
-		cliflagcfg.DurationFlag(f, &drainCtx.drainWait, cliflags.DrainWait)
+		cliflagcfg.VarFlag(f, &drainCtx.drainWaitValue, cliflags.DrainWait)
 		cliflagcfg.BoolFlag(f, &drainCtx.nodeDrainSelf, cliflags.NodeDrainSelf)
 		cliflagcfg.BoolFlag(f, &drainCtx.shutdown, cliflags.NodeDrainShutdown)
 	}
@@ -880,7 +885,7 @@ func init() {
 		cliflagcfg.IntFlag(f, &demoCtx.NumNodes, cliflags.DemoNodes)
 		cliflagcfg.BoolFlag(f, &demoCtx.RunWorkload, cliflags.RunDemoWorkload)
 		cliflagcfg.IntFlag(f, &demoCtx.ExpandSchema, cliflags.ExpandDemoSchema)
-		cliflagcfg.StringFlag(f, &demoCtx.NameGenOptions, cliflags.DemoNameGenOpts)
+		cliflagcfg.VarFlag(f, makeJSONOrFileValue(&demoCtx.NameGenOptions), cliflags.DemoNameGenOpts)
 		cliflagcfg.IntFlag(f, &demoCtx.WorkloadMaxQPS, cliflags.DemoWorkloadMaxQPS)
 		cliflagcfg.VarFlag(f, &demoCtx.Localities, cliflags.DemoNodeLocality)
 		cliflagcfg.BoolFlag(f, &demoCtx.GeoPartitionedReplicas, cliflags.DemoGeoPartitionedReplicas)
@@ -970,16 +975,24 @@ func init() {
 		f := debugKeysCmd.Flags()
 		cliflagcfg.VarFlag(f, (*mvccKey)(&debugCtx.startKey), cliflags.From)
 		cliflagcfg.VarFlag(f, (*mvccKey)(&debugCtx.endKey), cliflags.To)
+		cliflagcfg.VarFlag(f, makeKeyRangeValue(&debugCtx.startKey, &debugCtx.endKey), cliflags.KeyRange)
 		cliflagcfg.IntFlag(f, &debugCtx.maxResults, cliflags.Limit)
 		cliflagcfg.BoolFlag(f, &debugCtx.values, cliflags.Values)
 		cliflagcfg.BoolFlag(f, &debugCtx.sizes, cliflags.Sizes)
 		cliflagcfg.StringFlag(f, &debugCtx.decodeAsTableDesc, cliflags.DecodeAsTable)
 		cliflagcfg.VarFlag(f, &debugCtx.keyTypes, cliflags.FilterKeys)
+		cliflagcfg.BoolFlag(f, &debugCtx.printKey, cliflags.PrintKey)
+		_ = f.MarkHidden(cliflags.PrintKey.Name)
 	}
 	{
 		f := debugCheckLogConfigCmd.Flags()
 		cliflagcfg.VarFlag(f, &storeSpecs, cliflags.Store)
 	}
+	{
+		f := debugRangeDescriptorsCmd.Flags()
+		cliflagcfg.VarFlag(f, makePlainKeyValue(&debugCtx.descKey), cliflags.RangeDescriptorKey)
+		cliflagcfg.VarFlag(f, makeRangeIDListValue(&debugCtx.descRangeIDs), cliflags.RangeDescriptorRanges)
+	}
 	{
 		f := debugRangeDataCmd.Flags()
 		cliflagcfg.BoolFlag(f, &debugCtx.replicated, cliflags.Replicated)
@@ -1306,14 +1319,14 @@ func extraServerFlagInit(cmd *cobra.Command) error {
 	serverCfg.HTTPAdvertiseAddr = net.JoinHostPort(serverHTTPAdvertiseAddr, serverHTTPAdvertisePort)
 
 	// Fill the advertise port into the locality advertise addresses.
-	for i, a := range localityAdvertiseHosts {
+	for i, a := range localityAdvertiseHosts.addrs {
 		host, port, err := addr.SplitHostPort(a.Address.AddressField, serverAdvertisePort)
 		if err != nil {
 			return err
 		}
-		localityAdvertiseHosts[i].Address.AddressField = net.JoinHostPort(host, port)
+		localityAdvertiseHosts.addrs[i].Address.AddressField = net.JoinHostPort(host, port)
 	}
-	serverCfg.LocalityAddresses = localityAdvertiseHosts
+	serverCfg.LocalityAddresses = localityAdvertiseHosts.addrs
 
 	// Ensure that diagnostic reporting is enabled for server startup commands.
 	serverCfg.StartDiagnosticsReporting = true
@@ -1334,6 +1347,44 @@ func extraServerFlagInit(cmd *cobra.Command) error {
 		if err := tryReadLocalityFileFlag(fs); err != nil {
 			return err
 		}
+		if err := validateLocalityAdvertiseAddrs(serverCfg.Locality, serverCfg.LocalityAddresses); err != nil &&
+			startCtx.strictLocalityAdvertiseAddr {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateLocalityAdvertiseAddrs checks that the tiers of the
+// --locality-advertise-addr flag are tiers of the locality of the node. The
+// advertised addresses are meant for the nodes that share a tier with this
+// node, so a tier that the node does not have is most likely a typo, which
+// would otherwise go unnoticed as the address would never be used. The check
+// is skipped if the locality of the node is not specified.
+//
+// The mismatches only prevent the server from starting with
+// --strict-locality-advertise-addr; otherwise hintServerCmdFlags logs them as
+// a warning.
+//
+// This must be called once both the --locality (or --locality-file) and the
+// --locality-advertise-addr flags have been processed.
+func validateLocalityAdvertiseAddrs(
+	locality roachpb.Locality, addrs []roachpb.LocalityAddress,
+) error {
+	if len(locality.Tiers) == 0 {
+		return nil
+	}
+	for _, a := range addrs {
+		if v, ok := locality.Find(a.LocalityTier.Key); ok && v == a.LocalityTier.Value {
+			continue
+		}
+		return errors.WithHintf(
+			errors.Newf("--%s: tier %s is not a tier of --%s=%s",
+				cliflags.LocalityAdvertiseAddr.Name, a.LocalityTier,
+				cliflags.Locality.Name, locality),
+			"the addresses are advertised to the nodes that share a tier with this node; "+
+				"check the spelling of the tier",
+		)
 	}
 	return nil
 }
@@ -1430,6 +1481,20 @@ func extraStoreFlagInit(cmd *cobra.Command) error {
 			return err
 		}
 	}
+
+	// --cache=auto and --max-sql-memory=auto depend on the number of stores,
+	// so they are resolved once the stores are known.
+	if err := startCtx.cacheSizeValue.Resolve(&serverCfg.CacheSize, memoryPercentResolver); err != nil {
+		return errors.Wrapf(err, "invalid --%s", cliflags.Cache.Name)
+	}
+	sqlMemPercentResolver := memoryPercentResolver
+	if startCtx.sqlMemIncludeSwap {
+		startCtx.sqlSizeValue = startCtx.sqlSizeValue.withSwap()
+		sqlMemPercentResolver = startCtx.sqlSizeValue.percentResolver
+	}
+	if err := startCtx.sqlSizeValue.Resolve(&serverCfg.MemoryPoolSize, sqlMemPercentResolver); err != nil {
+		return errors.Wrapf(err, "invalid --%s", cliflags.SQLMem.Name)
+	}
 	return nil
 }
 
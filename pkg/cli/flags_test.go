@@ -8,9 +8,11 @@ package cli
 import (
 	"bytes"
 	"context"
+	gohex "encoding/hex"
 	"flag"
 	"fmt"
 	"io"
+	"math"
 	"net"
 	"os"
 	"path/filepath"
@@ -24,16 +26,27 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/cli/cliflags"
+	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/security/securitytest"
 	"github.com/cockroachdb/cockroach/pkg/server/status"
+	"github.com/cockroachdb/cockroach/pkg/storage"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/skip"
 	"github.com/cockroachdb/cockroach/pkg/util"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble/vfs"
+	"github.com/cockroachdb/redact"
 	"github.com/pmezard/go-difflib/difflib"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -836,6 +849,12 @@ func TestLocalityAdvAddrFlag(t *testing.T) {
 			"[{{tcp [::2]:26257} zone=1} {{tcp 123.0.0.5:26257} zone=2}]"},
 		{[]string{"start", "--host", "127.0.0.1", "--locality-advertise-addr", "zone=1@[::2]:1234"},
 			"[{{tcp [::2]:1234} zone=1}]"},
+		{[]string{"start", "--host", "127.0.0.1", "--locality-advertise-addr", "region=eu@[2001:db8::1]:26258,zone=2@localhost"},
+			"[{{tcp [2001:db8::1]:26258} region=eu} {{tcp localhost:26257} zone=2}]"},
+		{[]string{"start", "--host", "127.0.0.1",
+			"--locality-advertise-addr", "region=us@235.0.0.5",
+			"--locality-advertise-addr", "zone=1@[::2],zone=2@123.0.0.5:1234"},
+			"[{{tcp 235.0.0.5:26257} region=us} {{tcp [::2]:26257} zone=1} {{tcp 123.0.0.5:1234} zone=2}]"},
 	}
 
 	for i, td := range testData {
@@ -867,6 +886,231 @@ func TestLocalityAdvAddrFlag(t *testing.T) {
 	}
 }
 
+// TestLocalityListRoundTrip checks that the string of a localityList can be
+// set back into it.
+func TestLocalityListRoundTrip(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	roundTrip := func(t *testing.T, value string) {
+		var l localityList
+		require.NoError(t, l.Set(value))
+		require.Equal(t, value, l.String())
+		var l2 localityList
+		require.NoError(t, l2.Set(l.String()))
+		require.Equal(t, l, l2)
+
+		// Setting the string of a default value back into it leaves it
+		// unchanged, as the first Set replaces the default entries.
+		var l3 localityList
+		require.NoError(t, l3.Replace(l.GetSlice()))
+		require.NoError(t, l3.Set(l3.String()))
+		require.Equal(t, l.addrs, l3.addrs)
+	}
+
+	for _, value := range []string{
+		"zone=1@235.0.0.5",
+		"zone=1@235.0.0.5:1234,region=us-east1@123.0.0.5",
+		"zone=1@[::2]",
+		"zone=1@[::2]:1234,zone=2@[2001:db8::1]:26257",
+		"zone=dGVzdA==@localhost:26257",
+		"zone=a@b@[::1]",
+		"zone=@host",
+	} {
+		t.Run(value, func(t *testing.T) {
+			roundTrip(t, value)
+		})
+	}
+
+	rng, _ := randutil.NewTestRand()
+	const valueChars = "abcXYZ019-_.=@:"
+	addrs := []string{
+		"127.0.0.1", "127.0.0.1:26257", "[::1]", "[::1]:26257", "[fe80::1%eth0]:1234", "example.com:80",
+	}
+	for i := 0; i < 100; i++ {
+		var entries []string
+		for j := rng.Intn(3); j >= 0; j-- {
+			val := make([]byte, rng.Intn(8))
+			for k := range val {
+				val[k] = valueChars[rng.Intn(len(valueChars))]
+			}
+			entries = append(entries, fmt.Sprintf("k%d=%s@%s", j, val, addrs[rng.Intn(len(addrs))]))
+		}
+		roundTrip(t, strings.Join(entries, ","))
+	}
+
+	var l localityList
+	for _, value := range []string{"zone=1", "zone@1.2.3.4", "zone=1@1.2.3.4,"} {
+		require.Error(t, l.Set(value), value)
+	}
+}
+
+// TestLocalityListValidation checks that localityList rejects the empty and
+// the duplicate tiers.
+func TestLocalityListValidation(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testData := []struct {
+		value  string
+		expErr string
+	}{
+		{"region=us-east1@10.0.0.1,region=us-east1@10.0.0.2", `duplicate locality region=us-east1`},
+		{"zone=a@10.0.0.1,region=us-east1@[::1],zone=a@[::2]", `duplicate locality zone=a`},
+		{"region=us-east1@10.0.0.1,region=us-west1@10.0.0.2", ``},
+		{"region=us-east1@10.0.0.1,zone=us-east1@10.0.0.2", ``},
+		{"=us-east1@10.0.0.1", `=us-east1@10.0.0.1: empty locality key`},
+		{"region=us-east1@10.0.0.1,=x@10.0.0.2", `empty locality key`},
+	}
+	for _, td := range testData {
+		t.Run(td.value, func(t *testing.T) {
+			var l localityList
+			err := l.Set(td.value)
+			if td.expErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, td.expErr)
+			}
+		})
+	}
+}
+
+// TestLocalityListAddresses checks the parsing of the addresses of a
+// localityList.
+func TestLocalityListAddresses(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testData := []struct {
+		addr    string
+		expAddr string
+		expErr  string
+	}{
+		{"10.0.0.1", "10.0.0.1", ``},
+		{"10.0.0.1:26257", "10.0.0.1:26257", ``},
+		{"localhost", "localhost", ``},
+		{"db.example.com:26258", "db.example.com:26258", ``},
+		{"[2001:db8::1]", "[2001:db8::1]", ``},
+		{"[2001:db8::1]:26257", "[2001:db8::1]:26257", ``},
+		{"[fe80::1%eth0]:1234", "[fe80::1%eth0]:1234", ``},
+		{"node1.internal", "node1.internal", ``},
+		{"node1.internal.", "node1.internal.", ``},
+		{"roach_1", "roach_1", ``},
+		{"node1..internal", "", `invalid host name "node1..internal": empty label`},
+		{"node 1:26257", "", `invalid host name "node 1": invalid character ' '`},
+		{"-node1", "", `label "-node1" starts or ends with '-'`},
+		{strings.Repeat("a", 64) + ".com", "", `is longer than 63 characters`},
+		{strings.Repeat("a.", 127) + "a", "", `is longer than 253 characters`},
+		{"[::g]", "", `invalid IPv6 address "::g"`},
+		{"2001:db8::1", "", `invalid address format`},
+		{"[2001:db8::1", "", `missing ']' in address`},
+		{":26257", "", `missing host`},
+		{"", "", `missing host`},
+	}
+	for _, td := range testData {
+		t.Run(td.addr, func(t *testing.T) {
+			var l localityList
+			err := l.Set("region=eu@" + td.addr)
+			if td.expErr != "" {
+				require.ErrorContains(t, err, td.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, l.addrs, 1)
+			require.Equal(t, td.expAddr, l.addrs[0].Address.AddressField)
+			require.Equal(t, "region=eu@"+td.expAddr, l.String())
+		})
+	}
+}
+
+// TestLocalityListRepeated checks that the first occurrence of a
+// localityList flag replaces the default entries, and that the entries of the
+// following occurrences accumulate.
+func TestLocalityListRepeated(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	var l localityList
+	require.NoError(t, l.Replace([]string{"region=us@10.0.0.9,zone=a@10.0.0.8"}))
+	require.NoError(t, l.Set("region=us@10.0.0.1"))
+	require.Equal(t, []string{"region=us@10.0.0.1"}, l.GetSlice())
+	require.NoError(t, l.Set("zone=a@10.0.0.2,zone=b@10.0.0.3"))
+	require.Equal(t, []string{"region=us@10.0.0.1", "zone=a@10.0.0.2", "zone=b@10.0.0.3"}, l.GetSlice())
+	require.Equal(t, "region=us@10.0.0.1,zone=a@10.0.0.2,zone=b@10.0.0.3", l.String())
+
+	// The duplicates are detected across occurrences, and an invalid
+	// occurrence does not add any of its entries.
+	require.ErrorContains(t, l.Set("zone=c@10.0.0.4,region=us@10.0.0.5"), `duplicate locality region=us`)
+	require.ErrorContains(t, l.Append("zone=c@10.0.0.4,zone@10.0.0.5"), `invalid value`)
+	require.Len(t, l.addrs, 3)
+	require.NoError(t, l.Append("region=eu@10.0.0.4"))
+	require.Len(t, l.addrs, 4)
+
+	require.NoError(t, l.Replace([]string{"zone=a@[::1]", "zone=b@[::2],zone=c@[::3]"}))
+	require.Equal(t, []string{"zone=a@[::1]", "zone=b@[::2]", "zone=c@[::3]"}, l.GetSlice())
+	require.ErrorContains(t, l.Replace([]string{"zone=a@[::1]", "zone=a@[::2]"}), `duplicate locality zone=a`)
+	require.Len(t, l.addrs, 3)
+}
+
+// TestLocalityAdvAddrMatchesLocality checks that the tiers of
+// --locality-advertise-addr are checked against --locality, and that the
+// mismatches only prevent the startup with --strict-locality-advertise-addr.
+func TestLocalityAdvAddrMatchesLocality(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Avoid leaking configuration changes after the tests end.
+	defer initCLIDefaults()
+
+	f := startCmd.Flags()
+	testData := []struct {
+		locality string
+		advAddrs string
+		expErr   string
+	}{
+		// All the tiers match.
+		{"region=us-east1,zone=a", "region=us-east1@10.0.0.1", ``},
+		{"region=us-east1,zone=a", "region=us-east1@10.0.0.1,zone=a@10.0.1.1", ``},
+		// No tier matches.
+		{"region=us-east1,zone=a", "regoin=us-east1@10.0.0.1",
+			`tier regoin=us-east1 is not a tier of --locality=region=us-east1,zone=a`},
+		{"region=us-east1,zone=a", "region=us-west1@10.0.0.1",
+			`tier region=us-west1 is not a tier of --locality=region=us-east1,zone=a`},
+		// Some of the tiers match.
+		{"region=us-east1,zone=a", "region=us-east1@10.0.0.1,zone=b@10.0.1.1",
+			`tier zone=b is not a tier of --locality=region=us-east1,zone=a`},
+		// There is nothing to check without --locality.
+		{"", "regoin=us-east1@10.0.0.1", ``},
+	}
+	for _, td := range testData {
+		t.Run(td.locality+" "+td.advAddrs, func(t *testing.T) {
+			initCLIDefaults()
+			args := []string{"start", "--host", "127.0.0.1", "--locality-advertise-addr", td.advAddrs}
+			if td.locality != "" {
+				args = append(args, "--locality", td.locality)
+			}
+			require.NoError(t, f.Parse(args))
+			// The mismatches are only logged as a warning by default.
+			require.NoError(t, extraServerFlagInit(startCmd))
+			err := validateLocalityAdvertiseAddrs(serverCfg.Locality, serverCfg.LocalityAddresses)
+			if td.expErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, td.expErr)
+			}
+
+			initCLIDefaults()
+			require.NoError(t, f.Parse(append(args, "--strict-locality-advertise-addr")))
+			err = extraServerFlagInit(startCmd)
+			if td.expErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, td.expErr)
+			}
+		})
+	}
+}
+
 func TestLocalityFileFlag(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
@@ -1737,3 +1981,2343 @@ func TestTenantIDFromFile(t *testing.T) {
 			require.Eventually(t, func() bool { return runSuccessfuly.Load() }, 10*time.Second, 10*time.Millisecond)
 		})
 }
+
+func TestMVCCKeyHumanFlagValue(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	tenant5 := keys.MakeSQLCodec(roachpb.MustMakeTenantID(5))
+	testData := []struct {
+		value  string
+		expKey roachpb.Key
+		expErr string
+	}{
+		{"/Tenant/5", keys.MakeTenantPrefix(roachpb.MustMakeTenantID(5)), ``},
+		{"/Tenant/5/Table/104", tenant5.TablePrefix(104), ``},
+		{"/Tenant/5/Table/104/1", tenant5.IndexPrefix(104, 1), ``},
+		{"/Tenant/5/Table/104/1/42", encoding.EncodeVarintAscending(tenant5.IndexPrefix(104, 1), 42), ``},
+		{"/Tenant/5/Table/104/2/-7/42",
+			encoding.EncodeVarintAscending(encoding.EncodeVarintAscending(tenant5.IndexPrefix(104, 2), -7), 42), ``},
+		// The system tenant equivalents.
+		{"/Table/104", keys.SystemSQLCodec.TablePrefix(104), ``},
+		{"/Table/104/1/42", encoding.EncodeVarintAscending(keys.SystemSQLCodec.IndexPrefix(104, 1), 42), ``},
+		// Other keys are still scanned.
+		{"/Meta2/Max", keys.Meta2KeyMax, ``},
+		// Malformed keys.
+		{"/Tenant/x/Table/104", nil, `invalid tenant ID "x"`},
+		{"/Tenant/0/Table/104", nil, `invalid tenant ID "0"`},
+		{"/Tenant/5/Table/t", nil, `invalid table ID "t"`},
+		{"/Table/104/pk", nil, `invalid index ID "pk"`},
+		{"/Table/104/1/'foo'", nil, `invalid index column value "'foo'"`},
+	}
+	for _, td := range testData {
+		t.Run(td.value, func(t *testing.T) {
+			var k mvccKey
+			err := k.Set("human:" + td.value)
+			if td.expErr != "" {
+				require.ErrorContains(t, err, td.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, td.expKey, k.Key)
+		})
+	}
+}
+
+func TestMVCCKeyBase64FlagValue(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// The encoding of this key uses the characters that differ between the
+	// standard and the URL-safe alphabets.
+	key := storage.MakeMVCCMetadataKey(roachpb.Key("\xfb\xff\xfe"))
+	testData := []struct {
+		value  string
+		expKey storage.MVCCKey
+		expErr string
+	}{
+		{"b64:+//+AA==", key, ``},
+		{"b64:+//+AA", key, ``},
+		{"b64:-__-AA==", key, ``},
+		{"base64:-__-AA", key, ``},
+		{"BASE64:YWIA", storage.MakeMVCCMetadataKey(roachpb.Key("ab")), ``},
+		{"b64:+_/-AA", storage.MVCCKey{}, `invalid base64 key "\+_/-AA"`},
+		{"b64:!!!!", storage.MVCCKey{}, `invalid base64 key "!!!!"`},
+		{"b64:Zm9v", storage.MVCCKey{},
+			`perhaps this is just a base64-encoded key \(.*foo.*\); .* here's one with a zero timestamp: Zm9vAA==`},
+	}
+	for _, td := range testData {
+		t.Run(td.value, func(t *testing.T) {
+			var k mvccKey
+			err := k.Set(td.value)
+			if td.expErr != "" {
+				require.Error(t, err)
+				require.Regexp(t, td.expErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, td.expKey, storage.MVCCKey(k))
+		})
+	}
+}
+
+func TestMVCCKeyTimestampFlagValue(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rowKey := encoding.EncodeVarintAscending(keys.SystemSQLCodec.IndexPrefix(104, 1), 42)
+	testData := []struct {
+		value  string
+		expKey storage.MVCCKey
+		expErr string
+	}{
+		{"human:/Table/104/1/42@1712345678.000000001,0",
+			storage.MVCCKey{Key: rowKey, Timestamp: hlc.Timestamp{WallTime: 1712345678000000001}}, ``},
+		{"human:/Table/104/1/42@1712345678.000000001,3",
+			storage.MVCCKey{Key: rowKey, Timestamp: hlc.Timestamp{WallTime: 1712345678000000001, Logical: 3}}, ``},
+		{"human:/Table/104/1/42@1712345678",
+			storage.MVCCKey{Key: rowKey, Timestamp: hlc.Timestamp{WallTime: 1712345678000000000}}, ``},
+		{"raw:foo@12.000000005",
+			storage.MVCCKey{Key: roachpb.Key("foo"), Timestamp: hlc.Timestamp{WallTime: 12000000005}}, ``},
+		{"foo\\x40bar@0,1",
+			storage.MVCCKey{Key: roachpb.Key("foo@bar"), Timestamp: hlc.Timestamp{Logical: 1}}, ``},
+		{"rangeID:5@1,0",
+			storage.MVCCKey{Key: keys.MakeRangeIDPrefix(5), Timestamp: hlc.Timestamp{WallTime: 1e9}}, ``},
+		{"raw:foo", storage.MakeMVCCMetadataKey(roachpb.Key("foo")), ``},
+		{"raw:foo@", storage.MVCCKey{}, `invalid timestamp suffix in key "raw:foo@"`},
+		{"raw:foo@bar", storage.MVCCKey{}, `invalid timestamp suffix`},
+		{"human:/Table/104/1/42@1.2.3", storage.MVCCKey{}, `invalid timestamp suffix`},
+		{"human:/Table/104/1/42@1,x", storage.MVCCKey{}, `invalid timestamp suffix`},
+		{"hex:666f6f00@1,0", storage.MVCCKey{}, `a hex key encodes its own timestamp`},
+		{"b64:Zm9vAA==@1,0", storage.MVCCKey{}, `a b64 key encodes its own timestamp`},
+	}
+	for _, td := range testData {
+		t.Run(td.value, func(t *testing.T) {
+			var k mvccKey
+			err := k.Set(td.value)
+			if td.expErr != "" {
+				require.ErrorContains(t, err, td.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, td.expKey, storage.MVCCKey(k))
+
+			// The string of the key can be set back.
+			var k2 mvccKey
+			require.NoError(t, k2.Set(k.String()))
+			require.Equal(t, k, k2)
+		})
+	}
+
+	var empty mvccKey
+	require.Equal(t, "", empty.String())
+}
+
+func TestMVCCKeyHexFlagValue(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	indexKey := keys.SystemSQLCodec.IndexPrefix(104, 1)
+	testData := []struct {
+		value  string
+		expKey storage.MVCCKey
+		expErr string
+	}{
+		{"hex:f08900", storage.MakeMVCCMetadataKey(indexKey), ``},
+		{"hex:0xf08900", storage.MakeMVCCMetadataKey(indexKey), ``},
+		{"hex: 0XF0 89 00\n", storage.MakeMVCCMetadataKey(indexKey), ``},
+		// A bare table key.
+		{"hex:f089", storage.MVCCKey{},
+			`perhaps this is just a hex-encoded key \(/Table/104/1\); .* here's one with a zero timestamp: f08900`},
+		{"hex:0xf089", storage.MVCCKey{},
+			`perhaps this is just a hex-encoded key \(/Table/104/1\); .* here's one with a zero timestamp: f08900`},
+		// Garbage.
+		{"hex:0102", storage.MVCCKey{}, `perhaps this is just a hex-encoded key`},
+		{"hex:xyz", storage.MVCCKey{}, `invalid hex key "xyz"`},
+		{"hex:f0 8", storage.MVCCKey{}, `invalid hex key "f0 8"`},
+	}
+	for _, td := range testData {
+		t.Run(td.value, func(t *testing.T) {
+			var k mvccKey
+			err := k.Set(td.value)
+			if td.expErr != "" {
+				require.Error(t, err)
+				require.Regexp(t, td.expErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, td.expKey, storage.MVCCKey(k))
+		})
+	}
+}
+
+func TestMVCCKeyRangeIDFlagValue(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testData := []struct {
+		value  string
+		expKey roachpb.Key
+		expStr string
+		expErr string
+	}{
+		{"rangeID:42", keys.MakeRangeIDPrefix(42), "rangeID:42", ``},
+		{"rangeid:42/appliedstate", keys.RangeAppliedStateKey(42), "rangeID:42/appliedstate", ``},
+		{"rangeid:42/forceflush", keys.RangeForceFlushKey(42), "rangeID:42/forceflush", ``},
+		{"rangeid:42/gchint", keys.RangeGCHintKey(42), "rangeID:42/gchint", ``},
+		{"rangeid:42/gcthreshold", keys.RangeGCThresholdKey(42), "rangeID:42/gcthreshold", ``},
+		{"rangeid:42/hardstate", keys.RaftHardStateKey(42), "rangeID:42/hardstate", ``},
+		{"rangeid:42/HardState", keys.RaftHardStateKey(42), "rangeID:42/hardstate", ``},
+		{"rangeid:42/lastreplicagc", keys.RangeLastReplicaGCTimestampKey(42), "rangeID:42/lastreplicagc", ``},
+		{"rangeid:42/lease", keys.RangeLeaseKey(42), "rangeID:42/lease", ``},
+		{"rangeid:42/priorreadsummary", keys.RangePriorReadSummaryKey(42), "rangeID:42/priorreadsummary", ``},
+		{"rangeid:42/raftlog", keys.RaftLogPrefix(42), "rangeID:42/raftlog", ``},
+		{"rangeid:42/raftlog/100", keys.RaftLogKey(42, 100), "rangeID:42/raftlog/100", ``},
+		{"rangeid:42/replicaid", keys.RaftReplicaIDKey(42), "rangeID:42/replicaid", ``},
+		{"rangeid:42/tombstone", keys.RangeTombstoneKey(42), "rangeID:42/tombstone", ``},
+		{"rangeid:42/truncatedstate", keys.RaftTruncatedStateKey(42), "rangeID:42/truncatedstate", ``},
+		{"rangeid:42/version", keys.RangeVersionKey(42), "rangeID:42/version", ``},
+		{"rangeid:42/hardstate@1,0", keys.RaftHardStateKey(42), "rangeID:42/hardstate@1.000000000,0", ``},
+		{"rangeid:42/raftlog/x", nil, "", `invalid raft log index "x"`},
+		{"rangeid:42/raftlog/-1", nil, "", `invalid raft log index "-1"`},
+		{"rangeid:42/hardstate/1", nil, "", `unknown range-ID key suffix "hardstate/1"`},
+		{"rangeid:42/rangedesc", nil, "",
+			`unknown range-ID key suffix "rangedesc"; supported suffixes: appliedstate, .*, raftlog\[/<log index>\], `},
+		{"rangeid:0/hardstate", nil, "", `illegal val`},
+	}
+	for _, td := range testData {
+		t.Run(td.value, func(t *testing.T) {
+			var k mvccKey
+			err := k.Set(td.value)
+			if td.expErr != "" {
+				require.Error(t, err)
+				require.Regexp(t, td.expErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, td.expKey, k.Key)
+			require.Equal(t, td.expStr, k.String())
+
+			var k2 mvccKey
+			require.NoError(t, k2.Set(k.String()))
+			require.Equal(t, k, k2)
+		})
+	}
+}
+
+func TestMVCCKeyLockFlagValue(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	lockKey := func(key roachpb.Key) roachpb.Key {
+		k, _ := keys.LockTableSingleKey(key, nil /* buf */)
+		return k
+	}
+	rowKey := encoding.EncodeVarintAscending(keys.SystemSQLCodec.IndexPrefix(104, 1), 42)
+	testData := []struct {
+		value  string
+		expKey roachpb.Key
+		expStr string
+		expErr string
+	}{
+		{"lock:human:/Table/104/1/42", lockKey(rowKey), "lock:human:/Table/104/1/42", ``},
+		{"LOCK:hex:f08900", lockKey(keys.SystemSQLCodec.IndexPrefix(104, 1)), "lock:human:/Table/104/1", ``},
+		{"lock:raw:foo", lockKey(roachpb.Key("foo")), "lock:raw:foo", ``},
+		{"lock:rangeID:42/hardstate", lockKey(keys.RaftHardStateKey(42)), "lock:rangeID:42/hardstate", ``},
+		// A lock-table key given in hex is rendered with its locked key.
+		{"hex:" + gohex.EncodeToString(storage.EncodeMVCCKey(storage.MakeMVCCMetadataKey(lockKey(rowKey)))),
+			lockKey(rowKey), "lock:human:/Table/104/1/42", ``},
+		{"lock:lock:human:/Table/104/1/42", nil, "", `the locked key cannot be a lock-table key`},
+		{"lock:human:/Table/104/1/42@1,0", nil, "", `the locked key cannot have a timestamp`},
+		{"lock:human:/Table/104/x", nil, "", `invalid locked key: .*invalid index ID "x"`},
+	}
+	for _, td := range testData {
+		t.Run(td.value, func(t *testing.T) {
+			var k mvccKey
+			err := k.Set(td.value)
+			if td.expErr != "" {
+				require.Error(t, err)
+				require.Regexp(t, td.expErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, td.expKey, k.Key)
+			require.True(t, k.Timestamp.IsEmpty())
+			require.Equal(t, td.expStr, k.String())
+
+			var k2 mvccKey
+			require.NoError(t, k2.Set(k.String()))
+			require.Equal(t, k, k2)
+		})
+	}
+}
+
+// TestMVCCKeyPrettyFlagValue checks that the keys printed by MVCCKey.String
+// can be pasted into a key flag.
+func TestMVCCKeyPrettyFlagValue(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	rowKey := encoding.EncodeVarintAscending(keys.SystemSQLCodec.IndexPrefix(104, 1), 42)
+	tenantKey := encoding.EncodeVarintAscending(
+		keys.MakeSQLCodec(roachpb.MustMakeTenantID(5)).IndexPrefix(104, 2), -7)
+	for _, key := range []storage.MVCCKey{
+		{Key: rowKey, Timestamp: hlc.Timestamp{WallTime: 1712345678000000001}},
+		{Key: rowKey, Timestamp: hlc.Timestamp{WallTime: 1712345678000000001, Logical: 2}},
+		{Key: rowKey},
+		{Key: tenantKey, Timestamp: hlc.Timestamp{WallTime: 1}},
+		{Key: keys.SystemSQLCodec.TablePrefix(104), Timestamp: hlc.Timestamp{Logical: 1}},
+	} {
+		// MVCCKey.Format renders the zero timestamp too, unlike String.
+		for _, s := range []string{key.String(), fmt.Sprintf("%s", key)} {
+			t.Run(s, func(t *testing.T) {
+				var k mvccKey
+				require.NoError(t, k.Set("pretty:"+s))
+				require.Equal(t, key, storage.MVCCKey(k))
+			})
+		}
+	}
+
+	testData := []struct {
+		value  string
+		expKey storage.MVCCKey
+		expErr string
+	}{
+		// The @ suffix is accepted too.
+		{"pretty:/Table/104/1/42@1,0", storage.MVCCKey{Key: rowKey, Timestamp: hlc.Timestamp{WallTime: 1e9}}, ``},
+		// Without a comma, the last segment is part of the key.
+		{"pretty:/Table/104/1/42", storage.MVCCKey{Key: rowKey}, ``},
+		{"pretty:/Table/104/1/42/1,0@1,0", storage.MVCCKey{}, `the timestamp cannot be given twice`},
+		{"pretty:/Table/104/1/42/1,x", storage.MVCCKey{}, `invalid timestamp suffix`},
+		// The human type does not take a timestamp segment.
+		{"human:/Table/104/1/42/1,0", storage.MVCCKey{}, `invalid index column value "1,0"`},
+	}
+	for _, td := range testData {
+		t.Run(td.value, func(t *testing.T) {
+			var k mvccKey
+			err := k.Set(td.value)
+			if td.expErr != "" {
+				require.ErrorContains(t, err, td.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, td.expKey, storage.MVCCKey(k))
+		})
+	}
+}
+
+func TestParseKeyType(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const validTypes = `(valid types: b64, base64, escaped, hex, human, lock, pretty, rangeID, raw)`
+	testData := []struct {
+		value  string
+		exp    keyType
+		expErr string
+	}{
+		// Exact names, in any case.
+		{"hex", hex, ``},
+		{"Human", human, ``},
+		{"RANGEID", rangeID, ``},
+		{"base64", b64, ``},
+		{"pretty", prettyKey, ``},
+		// Unambiguous prefixes.
+		{"hu", human, ``},
+		{"he", hex, ``},
+		{"ran", rangeID, ``},
+		{"P", prettyKey, ``},
+		// b64 and its alias base64 name the same type.
+		{"b", b64, ``},
+		// Ambiguous prefixes.
+		{"h", 0, `ambiguous key type 'h': could be hex, human`},
+		{"ra", 0, `ambiguous key type 'ra': could be rangeID, raw`},
+		// Typos.
+		{"hexa", 0, `unknown key type 'hexa'; did you mean 'hex'? ` + validTypes},
+		{"humna", 0, `unknown key type 'humna'; did you mean 'human'? ` + validTypes},
+		{"lokc", 0, `unknown key type 'lokc'; did you mean 'lock'? ` + validTypes},
+		// Nothing close enough.
+		{"x", 0, `unknown key type 'x' ` + validTypes},
+		{"foobar", 0, `unknown key type 'foobar' ` + validTypes},
+		{"", 0, `unknown key type '' ` + validTypes},
+	}
+	for _, td := range testData {
+		t.Run(td.value, func(t *testing.T) {
+			typ, err := parseKeyType(td.value)
+			if td.expErr != "" {
+				require.EqualError(t, err, td.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, td.exp, typ)
+		})
+	}
+}
+
+func TestMVCCKeyEscapedFlagValue(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	for _, key := range []roachpb.Key{
+		roachpb.Key("\x89\xf7\x01"),
+		keys.RangeDescriptorKey(roachpb.RKey("a")),
+		keys.SystemSQLCodec.IndexPrefix(104, 1),
+		roachpb.Key("a@b"),
+		roachpb.Key(`quote"and\backslash`),
+		roachpb.Key("caf\xc3\xa9\x00\xff"),
+	} {
+		renderings := []string{
+			fmt.Sprintf("%q", []byte(key)),
+			fmt.Sprintf("%+q", []byte(key)),
+		}
+		if s := string(key); strconv.CanBackquote(s) {
+			renderings = append(renderings, "`"+s+"`")
+		}
+		if q := strconv.Quote(string(key)); !strings.Contains(q, "@") {
+			// Without the quotes, an '@' would start the timestamp.
+			renderings = append(renderings, q[1:len(q)-1])
+		}
+		for _, s := range renderings {
+			t.Run(s, func(t *testing.T) {
+				var k mvccKey
+				require.NoError(t, k.Set("escaped:"+s))
+				require.Equal(t, storage.MVCCKey{Key: key}, storage.MVCCKey(k))
+
+				ts := hlc.Timestamp{WallTime: 12, Logical: 3}
+				require.NoError(t, k.Set("escaped:"+s+"@"+ts.String()))
+				require.Equal(t, storage.MVCCKey{Key: key, Timestamp: ts}, storage.MVCCKey(k))
+			})
+		}
+	}
+
+	testData := []struct {
+		value  string
+		expErr string
+	}{
+		{`escaped:"\x8"`, `invalid escaped key "\x8"`},
+		{`escaped:"abc`, `invalid escaped key "abc`},
+		{`escaped:\q`, `invalid argument "\\q"`},
+		{`escaped:"abc"@x`, `invalid timestamp suffix`},
+	}
+	for _, td := range testData {
+		t.Run(td.value, func(t *testing.T) {
+			var k mvccKey
+			require.ErrorContains(t, k.Set(td.value), td.expErr)
+		})
+	}
+}
+
+func TestNodeDecommissionWaitFlagValue(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testData := []struct {
+		value  string
+		exp    nodeDecommissionWaitType
+		expStr string
+		expErr string
+	}{
+		{"all", nodeDecommissionWaitType{mode: nodeDecommissionWaitAll}, "all", ``},
+		{"live", nodeDecommissionWaitType{mode: nodeDecommissionWaitLive}, "live", ``},
+		{"none", nodeDecommissionWaitType{mode: nodeDecommissionWaitNone}, "none", ``},
+		{"all:30m", nodeDecommissionWaitType{mode: nodeDecommissionWaitAll, timeout: 30 * time.Minute}, "all:30m0s", ``},
+		{"live:1h30m", nodeDecommissionWaitType{mode: nodeDecommissionWaitLive, timeout: 90 * time.Minute}, "live:1h30m0s", ``},
+		{"all:500ms", nodeDecommissionWaitType{mode: nodeDecommissionWaitAll, timeout: 500 * time.Millisecond}, "all:500ms", ``},
+		// A zero timeout waits indefinitely.
+		{"all:0", nodeDecommissionWaitType{mode: nodeDecommissionWaitAll}, "all", ``},
+		{"all:0s", nodeDecommissionWaitType{mode: nodeDecommissionWaitAll}, "all", ``},
+		{"none:30m", nodeDecommissionWaitType{}, "", `none does not wait, so it does not accept a timeout`},
+		{"none:0s", nodeDecommissionWaitType{}, "", `none does not wait, so it does not accept a timeout`},
+		{"all:", nodeDecommissionWaitType{}, "", `invalid node decommission parameter: all:: time: invalid duration`},
+		{"all:30", nodeDecommissionWaitType{}, "", `missing unit in duration`},
+		{"all:soon", nodeDecommissionWaitType{}, "", `invalid duration "soon"`},
+		{"all:-1m", nodeDecommissionWaitType{}, "", `the timeout cannot be negative`},
+		{"Live", nodeDecommissionWaitType{}, "", `invalid node decommission parameter: Live; did you mean live\? \(possible values: all, live, none`},
+		{"", nodeDecommissionWaitType{}, "", `invalid node decommission parameter: `},
+	}
+	for _, td := range testData {
+		t.Run(td.value, func(t *testing.T) {
+			w := nodeDecommissionWaitType{mode: nodeDecommissionWaitLive, timeout: time.Second}
+			err := w.Set(td.value)
+			if td.expErr != "" {
+				require.Regexp(t, td.expErr, err)
+				require.Equal(t, nodeDecommissionWaitType{mode: nodeDecommissionWaitLive, timeout: time.Second}, w)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, td.exp, w)
+			require.Equal(t, td.expStr, w.String())
+		})
+	}
+}
+
+func TestNodeDecommissionChecksFlagValue(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testData := []struct {
+		value  string
+		exp    nodeDecommissionCheckMode
+		expStr string
+		expErr string
+	}{
+		// Aliases.
+		{"skip", nodeDecommissionChecksSkip, "skip", ``},
+		{"enabled", nodeDecommissionChecksEnabled, "enabled", ``},
+		{"strict", nodeDecommissionChecksStrict, "strict", ``},
+		{"dry-run", nodeDecommissionChecksDryRun, "dry-run", ``},
+		{"strict,dry-run", nodeDecommissionChecksStrict | nodeDecommissionCheckDryRun, "strict,dry-run", ``},
+		// Explicit lists.
+		{"constraints", nodeDecommissionCheckConstraints, "constraints", ``},
+		{"capacity,constraints", nodeDecommissionCheckConstraints | nodeDecommissionCheckCapacity, "constraints,capacity", ``},
+		{"constraints,capacity,action", nodeDecommissionChecksEnabled, "enabled", ``},
+		{"enabled,replace-only", nodeDecommissionChecksStrict, "strict", ``},
+		{"action,dry-run", nodeDecommissionCheckAction | nodeDecommissionCheckDryRun, "action,dry-run", ``},
+		{"replace-only,replace-only", nodeDecommissionCheckReplaceOnly, "replace-only", ``},
+		// Invalid input.
+		{"skip,constraints", 0, "", `skip cannot be combined with other checks`},
+		{"dry-run,skip", 0, "", `skip cannot be combined with other checks`},
+		{"disk", 0, "", `invalid node decommission parameter: disk \(possible values: action, capacity, constraints, dry-run, enabled, replace-only, skip, strict, or a comma-separated list of them\)`},
+		{"constraints,", 0, "", `invalid node decommission parameter: constraints,`},
+		{"", 0, "", `invalid node decommission parameter: `},
+	}
+	for _, td := range testData {
+		t.Run(td.value, func(t *testing.T) {
+			m := nodeDecommissionChecksEnabled
+			err := m.Set(td.value)
+			if td.expErr != "" {
+				require.Regexp(t, td.expErr, err)
+				require.Equal(t, nodeDecommissionChecksEnabled, m)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, td.exp, m)
+			require.Equal(t, td.expStr, m.String())
+			var m2 nodeDecommissionCheckMode
+			require.NoError(t, m2.Set(m.String()))
+			require.Equal(t, m, m2)
+		})
+	}
+}
+
+func TestBytesOrPercentageValueBounds(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// The resolver maps 1% to 1000 bytes.
+	resolver := func(percent float64) (int64, error) {
+		return int64(percent * 1000), nil
+	}
+	testData := []struct {
+		bounds percentBounds
+		value  string
+		exp    int64
+		expErr string
+	}{
+		{defaultPercentBounds, "1%", 1000, ``},
+		{defaultPercentBounds, "99%", 99000, ``},
+		{defaultPercentBounds, ".25", 25000, ``},
+		{defaultPercentBounds, "12.5%", 12500, ``},
+		{defaultPercentBounds, "100%", 0, `percentage 100% out of range 1% - 99%`},
+		{defaultPercentBounds, "0.5%", 0, `percentage 0.5% out of range 1% - 99%`},
+		{defaultPercentBounds, "0.005", 0, `percentage 0.5% out of range 1% - 99%`},
+		{defaultPercentBounds, "1024", 1024, ``},
+		// A flag allowing the whole resource.
+		{percentBounds{min: 1, max: 100}, "100%", 100000, ``},
+		{percentBounds{min: 1, max: 100}, "101%", 0, `percentage 101% out of range 1% - 100%`},
+		// A flag allowing fractions of a percent.
+		{percentBounds{min: 0.1, max: 99}, "0.005", 500, ``},
+		{percentBounds{min: 0.1, max: 99}, "0.5%", 500, ``},
+		{percentBounds{min: 0.1, max: 99}, ".0005", 0, `percentage 0.05% out of range 0.1% - 99%`},
+	}
+	for _, td := range testData {
+		t.Run(fmt.Sprintf("%s/%s", td.bounds, td.value), func(t *testing.T) {
+			var v int64
+			b := makeBytesOrPercentageValue(&v, resolver, nil /* autoResolver */, td.bounds)
+			err := b.Set(td.value)
+			if td.expErr != "" {
+				require.EqualError(t, err, td.expErr)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, td.exp, v)
+			}
+
+			// Resolve validates the value with the same bounds.
+			deferred := makeBytesOrPercentageValue(nil /* v */, nil /* percentResolver */, nil /* autoResolver */, td.bounds)
+			err = deferred.Set(td.value)
+			if td.expErr != "" {
+				require.EqualError(t, err, td.expErr)
+				return
+			}
+			require.NoError(t, err)
+			var resolved int64
+			require.NoError(t, deferred.Resolve(&resolved, resolver))
+			require.Equal(t, td.exp, resolved)
+		})
+	}
+}
+
+func TestBytesOrPercentageValueFractions(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const total = 16<<30 + 7
+	resolver := func(percent float64) (int64, error) {
+		return percentOf(total, percent), nil
+	}
+
+	// The integer percentages resolve as they did when the percentages were
+	// truncated to integers.
+	for percent := 1; percent <= 99; percent++ {
+		var v int64
+		b := makeBytesOrPercentageValue(&v, resolver, nil /* autoResolver */, defaultPercentBounds)
+		require.NoError(t, b.Set(fmt.Sprintf("%d%%", percent)))
+		require.Equal(t, total*int64(percent)/100, v)
+	}
+
+	testData := []struct {
+		value  string
+		exp    int64
+		expStr string
+	}{
+		{"12.5%", total / 8, "12.5% (2.0 GiB)"},
+		{"0.125", total / 8, "0.125 (2.0 GiB)"},
+		{"33.33%", 5726050401, "33.33% (5.3 GiB)"},
+		{"25%", total / 4, "25% (4.0 GiB)"},
+		{"1GiB", 1 << 30, "1.0 GiB"},
+	}
+	for _, td := range testData {
+		t.Run(td.value, func(t *testing.T) {
+			var v int64
+			b := makeBytesOrPercentageValue(&v, resolver, nil /* autoResolver */, defaultPercentBounds)
+			require.NoError(t, b.Set(td.value))
+			require.Equal(t, td.exp, v)
+			require.Equal(t, td.expStr, b.String())
+
+			// Before it is resolved, a percentage renders as given.
+			deferred := makeBytesOrPercentageValue(nil /* v */, nil /* percentResolver */, nil /* autoResolver */, defaultPercentBounds)
+			require.NoError(t, deferred.Set(td.value))
+			if strings.HasSuffix(td.value, "%") || td.value[0] == '0' {
+				require.Equal(t, td.value, deferred.String())
+			}
+			var resolved int64
+			require.NoError(t, deferred.Resolve(&resolved, resolver))
+			require.Equal(t, td.exp, resolved)
+			require.Equal(t, td.expStr, deferred.String())
+		})
+	}
+}
+
+func TestBytesOrPercentageValueExpressions(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// The resolver maps 1% to 1GiB.
+	resolver := func(percent float64) (int64, error) {
+		return percentOf(100<<30, percent), nil
+	}
+	testData := []struct {
+		value  string
+		exp    int64
+		expErr string
+	}{
+		{"25%+1GiB", 26 << 30, ``},
+		{"25% + 1GiB", 26 << 30, ``},
+		{"25%-1GiB", 24 << 30, ``},
+		{"1GiB+2GiB-512MiB", 2<<30 + 512<<20, ``},
+		{"min(25%,16GiB)", 16 << 30, ``},
+		{"min(10%, 16GiB)", 10 << 30, ``},
+		{"max(.1,16GiB)", 16 << 30, ``},
+		{"max(.2,16GiB)", 20 << 30, ``},
+		// Nesting.
+		{"min(max(10%,16GiB),20GiB)", 16 << 30, ``},
+		{"max(min(25%,16GiB)+1GiB, 5%)", 17 << 30, ``},
+		{"min(25%+1GiB,50%-20GiB)", 26 << 30, ``},
+		// Invalid input.
+		{"1GiB-2GiB", 0, `cannot subtract 2.0 GiB from 1.0 GiB`},
+		{"25%+", 0, `invalid expression "25%\+" at position 5: expected a size or a percentage`},
+		{"+25%", 0, `invalid expression "\+25%" at position 1: unexpected "\+", expected a size or a percentage`},
+		{"25%*2", 0, `unhandled size name: %\*2`},
+		{"min(25%)", 0, `invalid expression "min\(25%\)" at position 8: unexpected "\)", expected ','`},
+		{"min(25%,16GiB", 0, `invalid expression "min\(25%,16GiB" at position 14: expected '\)'`},
+		{"min(25%,16GiB))", 0, `invalid expression "min\(25%,16GiB\)\)" at position 15: unexpected "\)"`},
+		{"avg(25%,16GiB)", 0, `invalid expression "avg\(25%,16GiB\)" at position 1: unknown function "avg", expected min or max`},
+		{"min(100%,16GiB)", 0, `invalid expression "min\(100%,16GiB\)" at position 5: percentage 100% out of range 1% - 99%`},
+		{"1GiB+lots", 0, `invalid expression "1GiB\+lots" at position 6: .*invalid syntax`},
+		{"", 0, `^empty value; specify a size, a percentage or an expression$`},
+		{" ", 0, `^empty value`},
+	}
+	for _, td := range testData {
+		t.Run(td.value, func(t *testing.T) {
+			var v int64
+			b := makeBytesOrPercentageValue(&v, resolver, nil /* autoResolver */, defaultPercentBounds)
+			err := b.Set(td.value)
+			if td.expErr != "" {
+				require.Regexp(t, td.expErr, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, td.exp, v)
+			require.Equal(t, fmt.Sprintf("%s (%s)", td.value, humanizeutil.IBytes(td.exp)), b.String())
+
+			// The syntax is validated before the percentages can be resolved.
+			deferred := makeBytesOrPercentageValue(nil /* v */, nil /* percentResolver */, nil /* autoResolver */, defaultPercentBounds)
+			require.NoError(t, deferred.Set(td.value))
+			var resolved int64
+			require.NoError(t, deferred.Resolve(&resolved, resolver))
+			require.Equal(t, td.exp, resolved)
+		})
+	}
+
+	// Syntax errors are reported when the value is set, before it is resolved.
+	deferred := makeBytesOrPercentageValue(nil /* v */, nil /* percentResolver */, nil /* autoResolver */, defaultPercentBounds)
+	require.Regexp(t, `invalid expression "min\(25%,16GiB" at position 14`, deferred.Set("min(25%,16GiB"))
+}
+
+func TestBytesOrPercentageValueAuto(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	resolver := func(percent float64) (int64, error) {
+		return percentOf(100<<30, percent), nil
+	}
+	autoResolver := func() (int64, error) { return 12 << 30, nil }
+
+	t.Run("resolved", func(t *testing.T) {
+		var v int64
+		b := makeBytesOrPercentageValue(&v, resolver, autoResolver, defaultPercentBounds)
+		require.NoError(t, b.Set("auto"))
+		// The value is only computed by Resolve.
+		require.True(t, b.IsSet())
+		require.Equal(t, int64(0), v)
+		require.Equal(t, "auto", b.String())
+
+		require.NoError(t, b.Resolve(&v, resolver))
+		require.True(t, b.IsSet())
+		require.Equal(t, int64(12<<30), v)
+		require.Equal(t, "auto (resolved: 12 GiB)", b.String())
+	})
+
+	t.Run("resolver error", func(t *testing.T) {
+		var v int64
+		b := makeBytesOrPercentageValue(&v, resolver, func() (int64, error) {
+			return 0, fmt.Errorf("boom")
+		}, defaultPercentBounds)
+		require.NoError(t, b.Set("auto"))
+		require.EqualError(t, b.Resolve(&v, resolver), "resolving auto: boom")
+	})
+
+	t.Run("not supported", func(t *testing.T) {
+		var v int64
+		b := makeBytesOrPercentageValue(&v, resolver, nil /* autoResolver */, defaultPercentBounds)
+		require.EqualError(t, b.Set("auto"),
+			`"auto" is not supported by this flag; specify a size or a percentage`)
+
+		// A deferred flag reports the error when it is resolved.
+		deferred := makeBytesOrPercentageValue(nil /* v */, nil /* percentResolver */, nil /* autoResolver */, defaultPercentBounds)
+		require.NoError(t, deferred.Set("auto"))
+		require.EqualError(t, deferred.Resolve(&v, resolver),
+			`"auto" is not supported by this flag; specify a size or a percentage`)
+	})
+
+	t.Run("heuristics", func(t *testing.T) {
+		testData := []struct {
+			totalMemory int64
+			numStores   int
+			expCache    int64
+			expSQL      int64
+		}{
+			{64 << 30, 1, 16 << 30, 16 << 30},
+			{64 << 30, 8, 16 << 30, 16 << 30},
+			{2 << 30, 1, 512 << 20, 512 << 20},
+			// The cache needs more than a quarter of the memory for the stores.
+			{2 << 30, 6, 768 << 20, 256 << 20},
+			// The cache is limited to half of the memory.
+			{1 << 30, 8, 512 << 20, 256 << 20},
+		}
+		for _, td := range testData {
+			require.Equal(t, td.expCache, autoCacheSize(td.totalMemory, td.numStores),
+				"memory %d, %d stores", td.totalMemory, td.numStores)
+			require.Equal(t, td.expSQL, autoSQLMemorySize(td.totalMemory, td.numStores),
+				"memory %d, %d stores", td.totalMemory, td.numStores)
+		}
+	})
+}
+
+func TestBytesOrPercentageValueHeadroom(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	diskResolver := func(capacity int64) percentResolverFunc {
+		return func(percent float64) (int64, error) {
+			return percentOf(capacity, percent), nil
+		}
+	}
+	testData := []struct {
+		value    string
+		capacity int64
+		exp      int64
+		expErr   string
+	}{
+		{"100%-10GiB", 64 << 30, 54 << 30, ``},
+		{"100% - 10GiB", 64 << 30, 54 << 30, ``},
+		{"50%-512MiB", 64 << 30, 31<<30 + 512<<20, ``},
+		{".5-512MiB", 64 << 30, 31<<30 + 512<<20, ``},
+		{"100%-8GiB", 8 << 30, 0, ``},
+		// The reservation exceeds the resolved percentage on a small disk.
+		{"100%-10GiB", 8 << 30, 0, `cannot subtract 10 GiB from 8.0 GiB`},
+		// Only a percentage followed by a reservation can go up to 100%.
+		{"100%", 64 << 30, 0, `percentage 100% out of range 1% - 99%`},
+		{"100%+1GiB", 64 << 30, 0, `invalid expression "100%\+1GiB" at position 1: percentage 100% out of range 1% - 99%`},
+		{"101%-1GiB", 64 << 30, 0, `invalid expression "101%-1GiB" at position 1: percentage 101% out of range 1% - 100%`},
+		// The reservation must bring the value back to the maximum percentage.
+		{"100%-1", 64 << 30, 0, `"100%-1" resolves to 64 GiB, above 99% \(63 GiB\); subtract a larger reservation`},
+		{"100%-1GiB+1GiB", 64 << 30, 0, `"100%-1GiB\+1GiB" resolves to 64 GiB, above 99%`},
+		{"max(100%-10GiB,1GiB)", 64 << 30, 54 << 30, ``},
+		// A sum that does not fit in an int64 is rejected.
+		{"7EiB+7EiB", 64 << 30, 0, `7.0 EiB \+ 7.0 EiB is too large`},
+	}
+	for _, td := range testData {
+		t.Run(fmt.Sprintf("%s/%s", td.value, humanizeutil.IBytes(td.capacity)), func(t *testing.T) {
+			var v int64
+			b := makeBytesOrPercentageValue(&v, diskResolver(td.capacity), nil /* autoResolver */, defaultPercentBounds)
+			err := b.Set(td.value)
+			if td.expErr != "" {
+				require.Regexp(t, td.expErr, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, td.exp, v)
+			require.Equal(t, fmt.Sprintf("%s (%s)", td.value, humanizeutil.IBytes(td.exp)), b.String())
+		})
+	}
+
+	// A deferred flag reports the exceeded reservation when it is resolved.
+	deferred := makeBytesOrPercentageValue(nil /* v */, nil /* percentResolver */, nil /* autoResolver */, defaultPercentBounds)
+	require.NoError(t, deferred.Set("100%-10GiB"))
+	var v int64
+	require.EqualError(t, deferred.Resolve(&v, diskResolver(8<<30)), `cannot subtract 10 GiB from 8.0 GiB`)
+	require.NoError(t, deferred.Resolve(&v, diskResolver(64<<30)))
+	require.Equal(t, int64(54<<30), v)
+	require.Equal(t, "100%-10GiB (54 GiB)", deferred.String())
+}
+
+func TestBytesOrPercentageValueFormat(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	resolver := func(percent float64) (int64, error) {
+		return percentOf(32<<30, percent), nil
+	}
+	testData := []struct {
+		value       string
+		expDeferred string
+		expResolved string
+	}{
+		{"25%", "25%", "25% (8.0 GiB)"},
+		{".25", ".25", ".25 (8.0 GiB)"},
+		{"min(25%,4GiB)", "min(25%,4GiB)", "min(25%,4GiB) (4.0 GiB)"},
+		{"8GiB", "8.0 GiB", "8.0 GiB"},
+		{"1000", "1000 B", "1000 B"},
+	}
+	for _, td := range testData {
+		t.Run(td.value, func(t *testing.T) {
+			checkFormat := func(b *bytesOrPercentageValue, exp string) {
+				t.Helper()
+				require.Equal(t, exp, b.String())
+				// The value is safe for the logs.
+				require.EqualValues(t, exp, redact.Sprint(b))
+			}
+
+			var v int64
+			b := makeBytesOrPercentageValue(&v, resolver, nil /* autoResolver */, defaultPercentBounds)
+			require.NoError(t, b.Set(td.value))
+			checkFormat(&b, td.expResolved)
+
+			deferred := makeBytesOrPercentageValue(nil /* v */, nil /* percentResolver */, nil /* autoResolver */, defaultPercentBounds)
+			require.NoError(t, deferred.Set(td.value))
+			checkFormat(&deferred, td.expDeferred)
+			require.NoError(t, deferred.Resolve(&v, resolver))
+			checkFormat(&deferred, td.expResolved)
+		})
+	}
+}
+
+func TestBytesOrPercentageValueSizeBounds(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	resolver := func(capacity int64) percentResolverFunc {
+		return func(percent float64) (int64, error) {
+			return percentOf(capacity, percent), nil
+		}
+	}
+	bounds := sizeBounds{min: 64 << 20, max: 64 << 30}
+	testData := []struct {
+		value    string
+		capacity int64
+		exp      int64
+		expErr   string
+	}{
+		{"64MiB", 1 << 30, 64 << 20, ``},
+		{"64GiB", 1 << 30, 64 << 30, ``},
+		{"10%", 1 << 30, 1 << 30 / 10, ``},
+		// Floor.
+		{"32MiB", 1 << 30, 0, `--foo=32MiB is below the minimum of 64 MiB`},
+		// Ceiling.
+		{"65GiB", 1 << 30, 0, `--foo=65GiB is above the maximum of 64 GiB`},
+		// Percentages resolving outside of the bounds on a small or a large
+		// machine.
+		{"5%", 1 << 30, 0, `--foo=5% resolves to 51 MiB, below the minimum of 64 MiB; use --foo=64MiB or more`},
+		{"min(5%,1GiB)", 1 << 30, 0, `--foo=min\(5%,1GiB\) resolves to 51 MiB, below the minimum of 64 MiB; use --foo=64MiB or more`},
+		{"50%", 256 << 30, 0, `--foo=50% resolves to 128 GiB, above the maximum of 64 GiB`},
+	}
+	for _, td := range testData {
+		t.Run(fmt.Sprintf("%s/%s", td.value, humanizeutil.IBytes(td.capacity)), func(t *testing.T) {
+			var v int64
+			b := makeBytesOrPercentageValue(&v, resolver(td.capacity), nil /* autoResolver */, defaultPercentBounds).
+				withSizeBounds("foo", bounds)
+			err := b.Set(td.value)
+			if td.expErr != "" {
+				require.Regexp(t, td.expErr, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, td.exp, v)
+			}
+
+			// A deferred flag reports the violation when it is resolved.
+			deferred := makeBytesOrPercentageValue(nil /* v */, nil /* percentResolver */, nil /* autoResolver */, defaultPercentBounds).
+				withSizeBounds("foo", bounds)
+			if err := deferred.Set(td.value); err != nil {
+				// Only the sizes can be checked before the flag is resolved.
+				require.Regexp(t, td.expErr, err)
+				return
+			}
+			err = deferred.Resolve(&v, resolver(td.capacity))
+			if td.expErr != "" {
+				require.Regexp(t, td.expErr, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, td.exp, v)
+			}
+		})
+	}
+
+	t.Run("auto", func(t *testing.T) {
+		var v int64
+		b := makeBytesOrPercentageValue(&v, resolver(1<<30), func() (int64, error) {
+			return 16 << 20, nil
+		}, defaultPercentBounds).withSizeBounds("foo", bounds)
+		require.NoError(t, b.Set("auto"))
+		require.EqualError(t, b.Resolve(&v, resolver(1<<30)),
+			`--foo=auto resolves to 16 MiB, below the minimum of 64 MiB; use --foo=64MiB or more`)
+	})
+}
+
+func TestBytesOrPercentageValueDescribePercentages(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const cgroupLimit = 4 << 30
+	resolver := func(percent float64) (int64, error) {
+		return percentOf(cgroupLimit, percent), nil
+	}
+	testData := []struct {
+		value  string
+		exp    string
+		expSet bool
+	}{
+		{"25%", "25% of cgroup limit (4.0 GiB) = 1.0 GiB", true},
+		{".25", "25% of cgroup limit (4.0 GiB) = 1.0 GiB", true},
+		{"min(25%,512MiB)", "min(25%,512MiB) with percentages of cgroup limit (4.0 GiB) = 512 MiB", true},
+		{"1GiB", "", false},
+	}
+	for _, td := range testData {
+		t.Run(td.value, func(t *testing.T) {
+			var v int64
+			b := makeBytesOrPercentageValue(&v, resolver, nil /* autoResolver */, defaultPercentBounds)
+			require.NoError(t, b.Set(td.value))
+			desc, ok := b.describePercentages(memoryCapacityDescription(status.CgroupMemoryLimit, cgroupLimit))
+			require.Equal(t, td.expSet, ok)
+			require.EqualValues(t, td.exp, desc)
+		})
+	}
+
+	// A deferred flag is only described once it is resolved.
+	deferred := makeBytesOrPercentageValue(nil /* v */, nil /* percentResolver */, nil /* autoResolver */, defaultPercentBounds)
+	require.NoError(t, deferred.Set("25%"))
+	_, ok := deferred.describePercentages(memoryCapacityDescription(status.PhysicalMemory, cgroupLimit))
+	require.False(t, ok)
+	var v int64
+	require.NoError(t, deferred.Resolve(&v, resolver))
+	desc, ok := deferred.describePercentages(memoryCapacityDescription(status.PhysicalMemory, cgroupLimit))
+	require.True(t, ok)
+	require.EqualValues(t, "25% of physical memory (4.0 GiB) = 1.0 GiB", desc)
+}
+
+type fakeDiskUsageFS struct {
+	vfs.FS
+	du vfs.DiskUsage
+}
+
+func (fs fakeDiskUsageFS) GetDiskUsage(string) (vfs.DiskUsage, error) {
+	return fs.du, nil
+}
+
+func TestDiskPercentResolverFactory(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	fs := fakeDiskUsageFS{FS: vfs.NewMem(), du: vfs.DiskUsage{
+		AvailBytes: 120 << 30,
+		TotalBytes: 500 << 30,
+		UsedBytes:  380 << 30,
+	}}
+	testData := []struct {
+		kind    diskCapacityKind
+		exp     int64
+		expDesc string
+	}{
+		{diskTotalCapacity, 250 << 30, "50% of total (500 GiB) = 250 GiB"},
+		{diskAvailableCapacity, 60 << 30, "50% of available (120 GiB of 500 GiB total) = 60 GiB"},
+	}
+	for _, td := range testData {
+		resolver, capacity, err := diskPercentResolverFactory(fs, "/store", td.kind)
+		require.NoError(t, err)
+
+		b := makeBytesOrPercentageValue(nil /* v */, nil /* percentResolver */, nil /* autoResolver */, defaultPercentBounds)
+		require.NoError(t, b.Set("50%"))
+		var v int64
+		require.NoError(t, b.Resolve(&v, resolver))
+		require.Equal(t, td.exp, v)
+		desc, ok := b.describePercentages(capacity)
+		require.True(t, ok)
+		require.EqualValues(t, td.expDesc, desc)
+	}
+
+	// A full device leaves no room for the temp storage.
+	fs.du.AvailBytes = 0
+	resolver, _, err := diskPercentResolverFactory(fs, "/store", diskAvailableCapacity)
+	require.NoError(t, err)
+	b := makeBytesOrPercentageValue(nil /* v */, nil /* percentResolver */, nil /* autoResolver */, defaultPercentBounds).
+		withSizeBounds(cliflags.SQLTempStorage.Name, sizeBounds{min: minTempStorageSize})
+	require.NoError(t, b.Set("50%"))
+	var v int64
+	require.EqualError(t, b.Resolve(&v, resolver),
+		"--max-disk-temp-storage=50% resolves to 0 B, below the minimum of 64 MiB; use --max-disk-temp-storage=64MiB or more")
+}
+
+func TestNearestExistingDir(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	baseDir, dirCleanupFn := testutils.TempDir(t)
+	defer dirCleanupFn()
+	baseDir, err := filepath.EvalSymlinks(baseDir)
+	require.NoError(t, err)
+
+	// baseDir/
+	//   target/
+	//   dangling -> target/missing
+	//   rel -> target
+	require.NoError(t, os.Mkdir(filepath.Join(baseDir, "target"), 0755))
+	require.NoError(t, os.Symlink(filepath.Join(baseDir, "target", "missing"), filepath.Join(baseDir, "dangling")))
+	require.NoError(t, os.Symlink("target", filepath.Join(baseDir, "rel")))
+
+	testData := []struct {
+		dir string
+		exp string
+	}{
+		{"target", "target"},
+		{"target/store", "target"},
+		{"missing/a/b/store", ""},
+		{"rel", "target"},
+		{"rel/store", "target"},
+		{"dangling", "target"},
+		{"dangling/store", "target"},
+	}
+	for _, td := range testData {
+		t.Run(td.dir, func(t *testing.T) {
+			dir, err := nearestExistingDir(osFS{FS: vfs.Default}, filepath.Join(baseDir, td.dir))
+			require.NoError(t, err)
+			require.Equal(t, filepath.Join(baseDir, td.exp), dir)
+		})
+	}
+
+	// The percentages of a store that is not created yet are resolved against
+	// the device of its nearest existing ancestor.
+	du, err := vfs.Default.GetDiskUsage(baseDir)
+	require.NoError(t, err)
+	resolver, _, err := diskPercentResolverFactory(osFS{FS: vfs.Default}, filepath.Join(baseDir, "dangling", "store"), diskTotalCapacity)
+	require.NoError(t, err)
+	resolved, err := resolver(50)
+	require.NoError(t, err)
+	require.Equal(t, percentOf(int64(du.TotalBytes), 50), resolved)
+
+	// The paths of a filesystem without symlinks are only looked up in it,
+	// not in the filesystem of the OS.
+	memFS := vfs.NewMem()
+	require.NoError(t, memFS.MkdirAll(filepath.Join(baseDir, "mem"), 0755))
+	for _, td := range []struct {
+		dir string
+		exp string
+	}{
+		{"mem/store", "mem"},
+		{"rel/store", ""},
+		{"dangling/store", ""},
+	} {
+		dir, err := nearestExistingDir(memFS, filepath.Join(baseDir, td.dir))
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(baseDir, td.exp), dir, td.dir)
+	}
+}
+
+func TestStorePercentResolverFactory(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	fs := fakeDiskUsageFS{FS: vfs.NewMem(), du: vfs.DiskUsage{
+		AvailBytes: 120 << 30,
+		TotalBytes: 500 << 30,
+		UsedBytes:  380 << 30,
+	}}
+	memStore, err := base.NewStoreSpec("type=mem,size=1GiB")
+	require.NoError(t, err)
+	diskStore, err := base.NewStoreSpec("path=/store")
+	require.NoError(t, err)
+	unsizedMemStore := base.StoreSpec{InMemory: true}
+
+	testData := []struct {
+		name    string
+		stores  []base.StoreSpec
+		value   string
+		exp     int64
+		expDesc string
+		expErr  string
+	}{
+		{"mem store percent", []base.StoreSpec{memStore}, "10%", 1 << 30 / 10,
+			"10% of in-memory store (1.0 GiB) = 102 MiB", ``},
+		{"mem store bytes", []base.StoreSpec{memStore}, "2GiB", 2 << 30, ``, ``},
+		{"unsized mem store percent", []base.StoreSpec{unsizedMemStore}, "10%", 0, ``,
+			`--max-disk-temp-storage cannot be a percentage of the in-memory store, which has no size; ` +
+				`specify a size in --store or an absolute --max-disk-temp-storage`},
+		{"unsized mem store bytes", []base.StoreSpec{unsizedMemStore}, "2GiB", 2 << 30, ``, ``},
+		// The temp storage is on the disk store, whatever the order of the stores.
+		{"mem and disk stores", []base.StoreSpec{memStore, diskStore}, "10%", 12 << 30,
+			"10% of available (120 GiB of 500 GiB total) = 12 GiB", ``},
+		{"disk and mem stores", []base.StoreSpec{diskStore, memStore}, "10%", 12 << 30,
+			"10% of available (120 GiB of 500 GiB total) = 12 GiB", ``},
+	}
+	for _, td := range testData {
+		t.Run(td.name, func(t *testing.T) {
+			store := tempStorageStore(base.StoreSpecList{Specs: td.stores})
+			resolver, capacity, err := storePercentResolverFactory(
+				fs, store, diskAvailableCapacity, cliflags.SQLTempStorage.Name)
+			require.NoError(t, err)
+
+			b := makeBytesOrPercentageValue(nil /* v */, nil /* percentResolver */, nil /* autoResolver */, defaultPercentBounds)
+			require.NoError(t, b.Set(td.value))
+			var v int64
+			err = b.Resolve(&v, resolver)
+			if td.expErr != "" {
+				require.EqualError(t, err, td.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, td.exp, v)
+			desc, _ := b.describePercentages(capacity)
+			require.EqualValues(t, td.expDesc, desc)
+		})
+	}
+}
+
+func TestEnumFlag(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	type color int
+	const (
+		red color = iota
+		green
+		blue
+	)
+	colors := enumValues[color]{
+		names:   map[string]color{"red": red, "green": green, "blue": blue},
+		aliases: map[string]color{"lime": green},
+	}
+
+	testData := []struct {
+		value  string
+		exp    color
+		expStr string
+		expErr string
+	}{
+		{"red", red, "red", ``},
+		{"green", green, "green", ``},
+		{"blue", blue, "blue", ``},
+		// An alias is rendered by its canonical name.
+		{"lime", green, "green", ``},
+		{"Red", 0, "", `invalid value for --color: Red; did you mean red? (possible values: blue, green, lime, red)`},
+		{"", 0, "", `invalid value for --color:  (possible values: blue, green, lime, red)`},
+	}
+	for _, td := range testData {
+		t.Run(td.value, func(t *testing.T) {
+			c := blue
+			f := makeEnumFlag(&c, "color", colors)
+			err := f.Set(td.value)
+			if td.expErr != "" {
+				require.EqualError(t, err, td.expErr)
+				require.Equal(t, blue, c)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, td.exp, c)
+			require.Equal(t, td.expStr, f.String())
+		})
+	}
+
+	t.Run("unnamed value", func(t *testing.T) {
+		c := color(7)
+		require.Equal(t, "7", makeEnumFlag(&c, "color", colors).String())
+	})
+	t.Run("ordered", func(t *testing.T) {
+		require.Equal(t, []string{"red", "green", "blue"}, colors.ordered())
+	})
+}
+
+func TestDurationOrPercentageValue(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const base = time.Minute
+	testData := []struct {
+		value       string
+		bounds      percentBounds
+		exp         time.Duration
+		expDeferred string
+		expResolved string
+		expErr      string
+	}{
+		{"30s", defaultPercentBounds, 30 * time.Second, "30s", "30s", ``},
+		{"1h30m", defaultPercentBounds, 90 * time.Minute, "1h30m0s", "1h30m0s", ``},
+		{"0s", defaultPercentBounds, 0, "0s", "0s", ``},
+		{"50%", defaultPercentBounds, 30 * time.Second, "50%", "50% (30s)", ``},
+		{"12.5%", defaultPercentBounds, 7500 * time.Millisecond, "12.5%", "12.5% (7.5s)", ``},
+		{"150%", percentBounds{min: 1, max: 200}, 90 * time.Second, "150%", "150% (1m30s)", ``},
+		{"150%", defaultPercentBounds, 0, "", "", `percentage 150% out of range 1% - 99%`},
+		{"0%", defaultPercentBounds, 0, "", "", `percentage 0% out of range 1% - 99%`},
+		{"NaN%", defaultPercentBounds, 0, "", "", `percentage NaN% out of range 1% - 99%`},
+		{"abc%", defaultPercentBounds, 0, "", "", `parsing "abc": invalid syntax`},
+		{"-1s", defaultPercentBounds, 0, "", "", `duration -1s cannot be negative`},
+		{"30", defaultPercentBounds, 0, "", "", `missing unit in duration`},
+		{"", defaultPercentBounds, 0, "", "", `invalid duration`},
+	}
+	for _, td := range testData {
+		t.Run(td.value, func(t *testing.T) {
+			checkFormat := func(d *durationOrPercentageValue, exp string) {
+				t.Helper()
+				require.Equal(t, exp, d.String())
+				// The value is safe for the logs.
+				require.EqualValues(t, exp, redact.Sprint(d))
+			}
+
+			v := time.Hour
+			d := makeDurationOrPercentageValue(&v, td.bounds)
+			err := d.Set(td.value)
+			if td.expErr != "" {
+				require.ErrorContains(t, err, td.expErr)
+				require.False(t, d.IsSet())
+				require.Equal(t, time.Hour, v)
+				return
+			}
+			require.NoError(t, err)
+			// A percentage is set when it is parsed, but only written once it
+			// is resolved.
+			require.True(t, d.IsSet())
+			checkFormat(&d, td.expDeferred)
+			if td.expDeferred != td.expResolved {
+				require.Equal(t, time.Hour, v)
+			}
+			require.NoError(t, d.Resolve(base))
+			require.Equal(t, td.exp, v)
+			checkFormat(&d, td.expResolved)
+		})
+	}
+
+	t.Run("not set", func(t *testing.T) {
+		v := time.Hour
+		d := makeDurationOrPercentageValue(&v, defaultPercentBounds)
+		require.False(t, d.IsSet())
+		require.NoError(t, d.Resolve(base))
+		require.Equal(t, time.Hour, v)
+		require.Equal(t, "1h0m0s", d.String())
+	})
+
+	t.Run("negative base", func(t *testing.T) {
+		var v time.Duration
+		d := makeDurationOrPercentageValue(&v, defaultPercentBounds)
+		require.NoError(t, d.Set("50%"))
+		require.EqualError(t, d.Resolve(-time.Second), `cannot resolve 50% of a negative duration -1s`)
+	})
+}
+
+// TestDrainWaitFlag checks that --drain-wait accepts a duration, or a
+// percentage of the time required by the shutdown cluster settings that is
+// only written once resolved.
+func TestDrainWaitFlag(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Avoid leaking configuration changes after the tests end.
+	defer initCLIDefaults()
+
+	const minWait = time.Minute
+	f := drainNodeCmd.Flags()
+	testData := []struct {
+		args   []string
+		exp    time.Duration
+		expErr string
+	}{
+		{nil, 10 * time.Minute, ``},
+		{[]string{"--drain-wait", "30s"}, 30 * time.Second, ``},
+		{[]string{"--drain-wait", "0"}, 0, ``},
+		{[]string{"--drain-wait", "150%"}, 90 * time.Second, ``},
+		{[]string{"--drain-wait", "1000%"}, 10 * time.Minute, ``},
+		{[]string{"--drain-wait", "50%"}, 0, `percentage 50% out of range 100% - 1000%`},
+		{[]string{"--drain-wait", "1001%"}, 0, `percentage 1001% out of range 100% - 1000%`},
+	}
+	for _, td := range testData {
+		t.Run(strings.Join(td.args, " "), func(t *testing.T) {
+			initCLIDefaults()
+			err := f.Parse(append([]string{"node", "drain"}, td.args...))
+			if td.expErr != "" {
+				require.ErrorContains(t, err, td.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, drainCtx.drainWaitValue.Resolve(minWait))
+			require.Equal(t, td.exp, drainCtx.drainWait)
+		})
+	}
+}
+
+func TestKeyRangeFlagValue(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	table := func(id uint32) storage.MVCCKey {
+		return storage.MakeMVCCMetadataKey(keys.SystemSQLCodec.TablePrefix(id))
+	}
+	at := func(k storage.MVCCKey, wallTime int64) storage.MVCCKey {
+		k.Timestamp = hlc.Timestamp{WallTime: wallTime}
+		return k
+	}
+	raw := func(s string) storage.MVCCKey {
+		return storage.MakeMVCCMetadataKey(roachpb.Key(s))
+	}
+	testData := []struct {
+		value    string
+		expStart storage.MVCCKey
+		expEnd   storage.MVCCKey
+		expStr   string
+		expErr   string
+	}{
+		{"human:/Table/104,human:/Table/105", table(104), table(105), "human:/Table/104,human:/Table/105", ``},
+		{"hex:f08900,raw:\\xf1", storage.MakeMVCCMetadataKey(keys.SystemSQLCodec.IndexPrefix(104, 1)), raw("\xf1"),
+			"human:/Table/104/1,human:/Table/105", ``},
+		// Open ranges.
+		{",human:/Table/105", storage.NilKey, table(105), ",human:/Table/105", ``},
+		{"human:/Table/104,", table(104), storage.NilKey, "human:/Table/104,", ``},
+		{",", storage.NilKey, storage.NilKey, ",", ``},
+		// The timestamps and the raw keys can contain commas.
+		{"human:/Table/104@1712345678.000000001,0,human:/Table/105", at(table(104), 1712345678000000001), table(105),
+			"human:/Table/104@1712345678.000000001,0,human:/Table/105", ``},
+		{"raw:a\\x2cb,raw:c", raw("a,b"), raw("c"), "raw:a\\x2cb,raw:c", ``},
+		{"a,b", raw("a"), raw("b"), "raw:a,raw:b", ``},
+		// The newer versions of a key sort first.
+		{"raw:a@2,raw:a@1", at(raw("a"), 2e9), at(raw("a"), 1e9), "raw:a@2.000000000,0,raw:a@1.000000000,0", ``},
+		// Invalid ranges.
+		{"human:/Table/104", storage.NilKey, storage.NilKey, "", `invalid key range "human:/Table/104": expected <start>,<end>`},
+		{"bogus:x,human:/Table/105", storage.NilKey, storage.NilKey, "", `invalid start key "bogus:x": unknown key type 'bogus'`},
+		{"human:/Table/104,human:/Table/x", storage.NilKey, storage.NilKey, "", `invalid end key "human:/Table/x"`},
+		{"human:/Table/105,human:/Table/104", storage.NilKey, storage.NilKey, "",
+			`the start key human:/Table/105 does not sort before the end key human:/Table/104`},
+		{"human:/Table/104,human:/Table/104", storage.NilKey, storage.NilKey, "", `does not sort before`},
+		{"raw:a@1,raw:a@2", storage.NilKey, storage.NilKey, "", `does not sort before`},
+		{"a,b,c", storage.NilKey, storage.NilKey, "", `ambiguous key range "a,b,c"`},
+		{"a@1.000000000,0,b", storage.NilKey, storage.NilKey, "", `ambiguous key range`},
+	}
+	for _, td := range testData {
+		t.Run(td.value, func(t *testing.T) {
+			start, end := storage.NilKey, storage.NilKey
+			r := makeKeyRangeValue(&start, &end)
+			err := r.Set(td.value)
+			if td.expErr != "" {
+				require.ErrorContains(t, err, td.expErr)
+				require.Equal(t, storage.NilKey, start)
+				require.Equal(t, storage.NilKey, end)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, td.expStart, start)
+			require.Equal(t, td.expEnd, end)
+			require.Equal(t, td.expStr, r.String())
+
+			// The string of the range can be set back.
+			var start2, end2 storage.MVCCKey
+			require.NoError(t, makeKeyRangeValue(&start2, &end2).Set(r.String()))
+			require.Equal(t, start, start2)
+			require.Equal(t, end, end2)
+		})
+	}
+}
+
+func TestNodeIDListFlagValue(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testData := []struct {
+		values []string
+		exp    []roachpb.NodeID
+		expStr string
+		expErr string
+	}{
+		{[]string{"1"}, []roachpb.NodeID{1}, "1", ``},
+		{[]string{"1-3,7,9-12"}, []roachpb.NodeID{1, 2, 3, 7, 9, 10, 11, 12}, "1-3,7,9-12", ``},
+		{[]string{" 3 , 1-2"}, []roachpb.NodeID{1, 2, 3}, "1-3", ``},
+		{[]string{"5-5"}, []roachpb.NodeID{5}, "5", ``},
+		{[]string{""}, nil, "", ``},
+		// Duplicates and overlapping ranges.
+		{[]string{"2,2,1"}, []roachpb.NodeID{1, 2}, "1-2", ``},
+		{[]string{"1-5,3-8,8"}, []roachpb.NodeID{1, 2, 3, 4, 5, 6, 7, 8}, "1-8", ``},
+		{[]string{"10-12,1-3,2-11"}, []roachpb.NodeID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}, "1-12", ``},
+		// The flag can be repeated.
+		{[]string{"1-3", "7", "2-4"}, []roachpb.NodeID{1, 2, 3, 4, 7}, "1-4,7", ``},
+		// The largest node IDs.
+		{[]string{"2147483645-2147483647"}, []roachpb.NodeID{math.MaxInt32 - 2, math.MaxInt32 - 1, math.MaxInt32}, "2147483645-2147483647", ``},
+		{[]string{"2147483647-2147483647"}, []roachpb.NodeID{math.MaxInt32}, "2147483647", ``},
+		// Invalid values.
+		{[]string{"0"}, nil, "", `invalid node ID "0": node IDs are positive`},
+		{[]string{"-1"}, nil, "", `invalid node ID "-1": node IDs are positive`},
+		{[]string{"0-3"}, nil, "", `invalid node ID "0": node IDs are positive`},
+		{[]string{"3-1"}, nil, "", `invalid node ID range "3-1": the start 3 is greater than the end 1`},
+		{[]string{"1-"}, nil, "", `invalid node ID range "1-": empty node ID`},
+		{[]string{"1-3-5"}, nil, "", `invalid node ID range "1-3-5": invalid node ID "3-5"`},
+		{[]string{"1,,2"}, nil, "", `empty node ID`},
+		{[]string{"n1"}, nil, "", `invalid node ID "n1"`},
+		{[]string{"1-100000"}, nil, "", `invalid node ID range "1-100000": more than 65536 nodes`},
+		{[]string{"1", "x"}, nil, "", `invalid node ID "x"`},
+	}
+	for _, td := range testData {
+		t.Run(strings.Join(td.values, " "), func(t *testing.T) {
+			ids := []roachpb.NodeID{42}
+			l := makeNodeIDListValue(&ids)
+			var err error
+			for _, v := range td.values {
+				if err = l.Set(v); err != nil {
+					break
+				}
+			}
+			if td.expErr != "" {
+				require.ErrorContains(t, err, td.expErr)
+				return
+			}
+			require.NoError(t, err)
+			// The first occurrence replaces the default.
+			require.Equal(t, td.exp, ids)
+			require.Equal(t, td.expStr, l.String())
+
+			// The string of the list can be set back.
+			var ids2 []roachpb.NodeID
+			require.NoError(t, makeNodeIDListValue(&ids2).Set(l.String()))
+			require.Equal(t, ids, ids2)
+		})
+	}
+
+	t.Run("slice", func(t *testing.T) {
+		var ids []roachpb.NodeID
+		l := makeNodeIDListValue(&ids)
+		require.NoError(t, l.Replace([]string{"4-6", "1"}))
+		require.NoError(t, l.Append("3"))
+		require.Equal(t, []roachpb.NodeID{1, 3, 4, 5, 6}, ids)
+		require.Equal(t, []string{"1", "3-6"}, l.GetSlice())
+	})
+}
+
+func TestJSONOrFileValue(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	dir, dirCleanupFn := testutils.TempDir(t)
+	defer dirCleanupFn()
+	writeFile := func(name string, contents []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, contents, 0644))
+		return path
+	}
+	valid := writeFile("valid.json", []byte(`{"suffix": true}`))
+	invalid := writeFile("invalid.json", []byte("{\n  \"suffix\": tru\n}"))
+	oversized := writeFile("oversized.json",
+		[]byte(`"`+strings.Repeat("x", maxJSONPayloadSize)+`"`))
+
+	testData := []struct {
+		value  string
+		stdin  string
+		exp    string
+		expStr string
+		expErr string
+	}{
+		// Literal payloads.
+		{`{"number": false}`, ``, `{"number": false}`, `{"number": false}`, ``},
+		{`[1, 2]`, ``, `[1, 2]`, `[1, 2]`, ``},
+		{``, ``, ``, ``, ``},
+		{`{"number": }`, ``, ``, ``,
+			`invalid JSON at line 1, column 12: invalid character '}' looking for beginning of value`},
+		{`{"number": false`, ``, ``, ``, `invalid JSON at line 1, column 16: unexpected end of JSON input`},
+		{`{} x`, ``, ``, ``, `invalid JSON at line 1, column 4: invalid character 'x' after top-level value`},
+		// Files.
+		{"@" + valid, ``, `{"suffix": true}`, "@" + valid, ``},
+		{"@" + invalid, ``, ``, ``,
+			`invalid JSON in @` + invalid + ` at line 2, column 16: invalid character '\n' in literal true (expecting 'e')`},
+		{"@" + oversized, ``, ``, ``,
+			`the JSON payload in ` + oversized + ` is larger than the maximum of 1.0 MiB`},
+		{"@" + filepath.Join(dir, "missing.json"), ``, ``, ``,
+			`cannot read the JSON payload: open ` + filepath.Join(dir, "missing.json") + `: no such file or directory`},
+		{"@", ``, ``, ``, `missing file name after @`},
+		// Standard input.
+		{"-", "{\"a\":\n [1]}\n", "{\"a\":\n [1]}\n", "-", ``},
+		{"-", "{\"a\":\n [1,]}", ``, ``,
+			`invalid JSON in - at line 2, column 5: invalid character ']' looking for beginning of value`},
+	}
+	for _, td := range testData {
+		t.Run(td.value, func(t *testing.T) {
+			payload := "default"
+			v := makeJSONOrFileValue(&payload)
+			v.stdin = strings.NewReader(td.stdin)
+			err := v.Set(td.value)
+			if td.expErr != "" {
+				require.EqualError(t, err, td.expErr)
+				require.Equal(t, "default", payload)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, td.exp, payload)
+			require.Equal(t, td.expStr, v.String())
+		})
+	}
+}
+
+func TestMVCCKeyRawFlagValue(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testData := []struct {
+		value  string
+		expKey storage.MVCCKey
+		expErr string
+	}{
+		{`raw:a\x00b`, storage.MakeMVCCMetadataKey(roachpb.Key("a\x00b")), ``},
+		{`raw:\000\x00`, storage.MakeMVCCMetadataKey(roachpb.Key("\x00\x00")), ``},
+		{`raw:say "hi"`, storage.MakeMVCCMetadataKey(roachpb.Key(`say "hi"`)), ``},
+		{`raw:say \"hi\"`, storage.MakeMVCCMetadataKey(roachpb.Key(`say "hi"`)), ``},
+		{`raw:café\xff`, storage.MakeMVCCMetadataKey(roachpb.Key("caf\xc3\xa9\xff")), ``},
+		{`raw:raw-hex:610022`, storage.MakeMVCCMetadataKey(roachpb.Key("a\x00\"")), ``},
+		{`raw-hex:610022`, storage.MakeMVCCMetadataKey(roachpb.Key("a\x00\"")), ``},
+		{`raw-hex:5c7840@1,0`,
+			storage.MVCCKey{Key: roachpb.Key(`\x@`), Timestamp: hlc.Timestamp{WallTime: 1e9}}, ``},
+		{`raw-hex:`, storage.MakeMVCCMetadataKey(roachpb.Key("")), ``},
+		{`raw:ab\q`, storage.MVCCKey{}, `invalid argument "ab\\q": invalid escape sequence at offset 2`},
+		{`raw:\x0`, storage.MVCCKey{}, `invalid escape sequence at offset 0`},
+		{`raw:abc\`, storage.MVCCKey{}, `invalid escape sequence at offset 3`},
+		{`raw:a\x00\u12`, storage.MVCCKey{}, `invalid escape sequence at offset 5`},
+		{`raw-hex:6`, storage.MVCCKey{}, `invalid argument "raw-hex:6": encoding/hex: odd length hex string`},
+		{`raw-hex:zz`, storage.MVCCKey{}, `invalid byte: U+007A 'z'`},
+		{`escaped:a\q`, storage.MVCCKey{}, `invalid escape sequence at offset 1`},
+	}
+	for _, td := range testData {
+		t.Run(td.value, func(t *testing.T) {
+			var k mvccKey
+			err := k.Set(td.value)
+			if td.expErr != "" {
+				require.ErrorContains(t, err, td.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, td.expKey, storage.MVCCKey(k))
+
+			// The rendering of the key is accepted by Set.
+			var k2 mvccKey
+			require.NoError(t, k2.Set(k.String()))
+			require.Equal(t, td.expKey, storage.MVCCKey(k2))
+		})
+	}
+}
+
+func TestMVCCKeyDescribe(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testData := []struct {
+		value string
+		exp   string
+	}{
+		{"human:/Table/104/1/42", `key:       human:/Table/104/1/42
+pretty:    /Table/104/1/42
+hex:       hex:f089b200
+base64:    b64:8ImyAA==
+raw key:   raw-hex:f089b2
+timestamp: none
+`},
+		{"rangeID:42", `key:       rangeID:42
+pretty:    /Local/RangeID/42
+hex:       hex:0169b200
+base64:    b64:AWmyAA==
+raw key:   raw-hex:0169b2
+timestamp: none
+`},
+		{"human:/Table/104/1/42@1712345678.000000001,0", `key:       human:/Table/104/1/42@1712345678.000000001,0
+pretty:    /Table/104/1/42
+hex:       hex:f089b20017c379525ddd8c0109
+base64:    b64:8ImyABfDeVJd3YwBCQ==
+raw key:   raw-hex:f089b2@1712345678.000000001,0
+timestamp: 1712345678.000000001,0
+`},
+	}
+	for _, td := range testData {
+		t.Run(td.value, func(t *testing.T) {
+			var k mvccKey
+			require.NoError(t, k.Set(td.value))
+			out := k.describe()
+			require.Equal(t, td.exp, out)
+
+			// The encodings of the key can be set back.
+			for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+				name, value, _ := strings.Cut(line, ":")
+				if name == "pretty" || name == "timestamp" {
+					continue
+				}
+				var k2 mvccKey
+				require.NoError(t, k2.Set(strings.TrimSpace(value)), name)
+				require.Equal(t, k, k2, name)
+			}
+		})
+	}
+}
+
+func TestMemoryAndSwapPercentResolver(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	readMemory := func() (int64, status.MemorySource, string, error) {
+		return 16 << 30, status.CgroupMemoryLimit, "", nil
+	}
+	testData := []struct {
+		name       string
+		readMemory func() (int64, status.MemorySource, string, error)
+		readSwap   func() (int64, error)
+		exp        int64
+		expDesc    string
+		expWarning string
+		expErr     string
+	}{
+		{"swap", readMemory, func() (int64, error) { return 4 << 30, nil },
+			5 << 30, "cgroup limit (16 GiB) + swap (4.0 GiB)", "", ""},
+		{"no swap", readMemory, func() (int64, error) { return 0, nil },
+			4 << 30, "cgroup limit (16 GiB) + swap (0 B)", "", ""},
+		{"swap unavailable", readMemory, func() (int64, error) { return 0, fmt.Errorf("not implemented") },
+			4 << 30, "cgroup limit (16 GiB), swap not counted",
+			"the size of the swap space is unavailable, counting the memory only: not implemented", ""},
+		{"memory unavailable",
+			func() (int64, status.MemorySource, string, error) {
+				return 0, status.PhysicalMemory, "", fmt.Errorf("boom")
+			},
+			func() (int64, error) { return 4 << 30, nil },
+			0, "", "", "boom"},
+	}
+	for _, td := range testData {
+		t.Run(td.name, func(t *testing.T) {
+			_, desc, warning, err := getMemoryAndSwap(td.readMemory, td.readSwap)
+			resolved, resolveErr := memoryAndSwapPercentResolverFactory(td.readMemory, td.readSwap)(25)
+			if td.expErr != "" {
+				require.ErrorContains(t, err, td.expErr)
+				require.ErrorContains(t, resolveErr, td.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, resolveErr)
+			require.Equal(t, td.exp, resolved)
+			require.EqualValues(t, td.expDesc, desc)
+			require.Equal(t, td.expWarning, warning)
+
+			// The log line says which components were counted.
+			var v int64
+			b := makeBytesOrPercentageValue(&v, memoryAndSwapPercentResolverFactory(td.readMemory, td.readSwap),
+				nil /* autoResolver */, defaultPercentBounds)
+			require.NoError(t, b.Set("25%"))
+			line, ok := b.describePercentages(desc)
+			require.True(t, ok)
+			require.EqualValues(t, fmt.Sprintf("25%% of %s = %s", td.expDesc, humanizeutil.IBytes(td.exp)), line)
+		})
+	}
+
+	// The flags opt in to counting the swap space.
+	var v int64
+	b := makeBytesOrPercentageValue(&v, memoryPercentResolver, nil /* autoResolver */, defaultPercentBounds)
+	require.False(t, b.countsSwap)
+	require.True(t, b.withSwap().countsSwap)
+}
+
+// TestSQLMemIncludeSwapFlag checks that --max-sql-memory-include-swap makes
+// the percentages of --max-sql-memory count the swap space.
+func TestSQLMemIncludeSwapFlag(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Avoid leaking configuration changes after the tests end.
+	defer initCLIDefaults()
+
+	memory, err := memoryPercentResolver(25)
+	require.NoError(t, err)
+	memoryAndSwap, err := memoryAndSwapPercentResolverFactory(status.GetTotalMemoryWithSource, systemSwap)(25)
+	require.NoError(t, err)
+
+	f := startCmd.Flags()
+	testData := []struct {
+		args      []string
+		exp       int64
+		countSwap bool
+	}{
+		{[]string{"--max-sql-memory", "25%"}, memory, false},
+		{[]string{"--max-sql-memory", "25%", "--max-sql-memory-include-swap"}, memoryAndSwap, true},
+		{[]string{"--max-sql-memory", "1GiB", "--max-sql-memory-include-swap"}, 1 << 30, true},
+	}
+	for _, td := range testData {
+		t.Run(strings.Join(td.args, " "), func(t *testing.T) {
+			initCLIDefaults()
+			require.NoError(t, f.Parse(append([]string{"start", "--host", "127.0.0.1"}, td.args...)))
+			require.NoError(t, extraServerFlagInit(startCmd))
+			require.Equal(t, td.exp, serverCfg.MemoryPoolSize)
+			require.Equal(t, td.countSwap, startCtx.sqlSizeValue.countsSwap)
+		})
+	}
+}
+
+func TestBytesOrPercentageValueDefault(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	resolver := func(percent float64) (int64, error) {
+		return percentOf(4<<30, percent), nil
+	}
+	bounds := sizeBounds{min: 64 << 20}
+	testData := []struct {
+		name       string
+		defaultVal string
+		value      string
+		exp        int64
+		expSet     bool
+		expErr     string
+	}{
+		{"no default", "", "", 0, false, ``},
+		{"default as bytes", "128MiB", "", 128 << 20, false, ``},
+		{"default as percent", "25%", "", 1 << 30, false, ``},
+		{"default as expression", "min(25%,512MiB)", "", 512 << 20, false, ``},
+		{"user override of bytes", "128MiB", "1GiB", 1 << 30, true, ``},
+		{"user override of percent", "25%", "10%", 429496729, true, ``},
+		// The default goes through the same checks as the user input.
+		{"default below the minimum", "1%", "", 0, false,
+			`the default --foo=1% resolves to 41 MiB, below the minimum of 64 MiB`},
+		{"invalid default", "lots", "", 0, false, `invalid`},
+		{"user value below the minimum", "25%", "1%", 0, true,
+			`^--foo=1% resolves to 41 MiB, below the minimum of 64 MiB`},
+	}
+	for _, td := range testData {
+		t.Run(td.name, func(t *testing.T) {
+			b := makeBytesOrPercentageValue(nil /* v */, nil /* percentResolver */, nil /* autoResolver */, defaultPercentBounds).
+				withSizeBounds("foo", bounds).withDefault(td.defaultVal)
+			if td.value != "" {
+				require.NoError(t, b.Set(td.value))
+			}
+			var v int64
+			err := b.Resolve(&v, resolver)
+			if td.expErr != "" {
+				require.Error(t, err)
+				require.Regexp(t, td.expErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, td.exp, v)
+			// A flag resolved to its default is not set.
+			require.Equal(t, td.expSet, b.IsSet())
+		})
+	}
+}
+
+// TestLocalityAdvAddrDefaultPort checks that the addresses of
+// --locality-advertise-addr without a port get the advertised port, and
+// that the flag then renders the addresses as they are advertised.
+func TestLocalityAdvAddrDefaultPort(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// Avoid leaking configuration changes after the tests end.
+	defer initCLIDefaults()
+
+	f := startCmd.Flags()
+	testData := []struct {
+		args   []string
+		expStr string
+	}{
+		{[]string{"--locality-advertise-addr", "region=us-east1@node1.internal"},
+			"region=us-east1@node1.internal:26257"},
+		{[]string{"--locality-advertise-addr", "region=us-east1@node1.internal:26300"},
+			"region=us-east1@node1.internal:26300"},
+		{[]string{"--listen-addr", ":26300", "--locality-advertise-addr", "region=us-east1@node1.internal"},
+			"region=us-east1@node1.internal:26300"},
+		{[]string{"--advertise-addr", "node1:26400", "--locality-advertise-addr", "region=us-east1@node1.internal,zone=a@[::1]"},
+			"region=us-east1@node1.internal:26400,zone=a@[::1]:26400"},
+	}
+	for _, td := range testData {
+		t.Run(strings.Join(td.args, " "), func(t *testing.T) {
+			initCLIDefaults()
+			require.NoError(t, f.Parse(append([]string{"start"}, td.args...)))
+			require.NoError(t, extraServerFlagInit(startCmd))
+			require.Equal(t, td.expStr, f.Lookup(cliflags.LocalityAdvertiseAddr.Name).Value.String())
+			require.Equal(t, td.expStr, (&localityList{addrs: serverCfg.LocalityAddresses}).String())
+		})
+	}
+}
+
+func TestPlainKeyValue(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	indexPrefix := keys.SystemSQLCodec.IndexPrefix(104, 1)
+	testData := []struct {
+		value  string
+		expKey roachpb.Key
+		expStr string
+		expErr string
+	}{
+		{"hex:f089", indexPrefix, "human:/Table/104/1", ``},
+		{"hex:0xf0 89", indexPrefix, "human:/Table/104/1", ``},
+		{"b64:8Ik=", indexPrefix, "human:/Table/104/1", ``},
+		{"human:/Table/104/1", indexPrefix, "human:/Table/104/1", ``},
+		{"raw:foo", roachpb.Key("foo"), "raw:foo", ``},
+		{"foo", roachpb.Key("foo"), "raw:foo", ``},
+		{"raw-hex:00ff", roachpb.Key("\x00\xff"), `raw:\x00\xff`, ``},
+		{`escaped:"a\x00"`, roachpb.Key("a\x00"), `raw:a\x00`, ``},
+		{"rangeID:42/hardstate", keys.RaftHardStateKey(42), "rangeID:42/hardstate", ``},
+		{"lock:human:/Table/104/1", nil, "", ``},
+		// The hex and b64 types take the bytes of the key, so an encoded
+		// MVCCKey is taken as is.
+		{"hex:f08900", roachpb.Key("\xf0\x89\x00"), "", ``},
+		{"human:/Table/104/1@5,0", nil, "",
+			`invalid key "human:/Table/104/1@5,0": this flag takes a key without a timestamp`},
+		{"hex:zz", nil, "", `invalid hex key "zz"`},
+		{"hex:f089@5,0", nil, "", `invalid hex key`},
+		{"foo:bar", nil, "", `unknown key type 'foo'`},
+	}
+	for _, td := range testData {
+		t.Run(td.value, func(t *testing.T) {
+			var key roachpb.Key
+			v := makePlainKeyValue(&key)
+			err := v.Set(td.value)
+			if td.expErr != "" {
+				require.ErrorContains(t, err, td.expErr)
+				return
+			}
+			require.NoError(t, err)
+			if td.expKey != nil {
+				require.Equal(t, td.expKey, key)
+			}
+			if td.expStr != "" {
+				require.Equal(t, td.expStr, v.String())
+			}
+
+			// The string of the key can be set back.
+			var key2 roachpb.Key
+			require.NoError(t, makePlainKeyValue(&key2).Set(v.String()))
+			require.Equal(t, key, key2)
+		})
+	}
+
+	// A key with a timestamp is rejected with a hint to remove it.
+	var key roachpb.Key
+	err := makePlainKeyValue(&key).Set("human:/Table/104/1@5,0")
+	require.Equal(t, []string{"remove the timestamp, i.e. use human:/Table/104/1"}, errors.GetAllHints(err))
+	require.Equal(t, "", makePlainKeyValue(&key).String())
+}
+
+func TestRangeIDListFlagValue(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testData := []struct {
+		values []string
+		exp    []roachpb.RangeID
+		expStr string
+		expErr string
+	}{
+		{[]string{"r42"}, []roachpb.RangeID{42}, "42", ``},
+		{[]string{"42"}, []roachpb.RangeID{42}, "42", ``},
+		{[]string{"r100-r103,42"}, []roachpb.RangeID{42, 100, 101, 102, 103}, "42,100-103", ``},
+		{[]string{"100-r102, r7"}, []roachpb.RangeID{7, 100, 101, 102}, "7,100-102", ``},
+		{[]string{"r5-6,r6,5"}, []roachpb.RangeID{5, 6}, "5-6", ``},
+		{[]string{"r3", "1-r2"}, []roachpb.RangeID{1, 2, 3}, "1-3", ``},
+		// The largest range IDs.
+		{[]string{"r9223372036854775806-r9223372036854775807"}, []roachpb.RangeID{math.MaxInt64 - 1, math.MaxInt64}, "9223372036854775806-9223372036854775807", ``},
+		{[]string{""}, nil, "", ``},
+		// Invalid values.
+		{[]string{"r0"}, nil, "", `invalid range ID "r0"`},
+		{[]string{"r42,x"}, nil, "", `invalid range ID "x"`},
+		{[]string{"r1,rr2"}, nil, "", `invalid range ID "rr2"`},
+		{[]string{"r1-n3"}, nil, "", `invalid range ID range "r1-n3": invalid range ID "n3"`},
+		{[]string{"r9-r3"}, nil, "", `invalid range ID range "r9-r3": the start 9 is greater than the end 3`},
+		{[]string{"r1-r100000"}, nil, "", `more than 65536 ranges`},
+		{[]string{"r1,,r2"}, nil, "", `empty range ID`},
+	}
+	for _, td := range testData {
+		t.Run(strings.Join(td.values, " "), func(t *testing.T) {
+			var ids []roachpb.RangeID
+			l := makeRangeIDListValue(&ids)
+			var err error
+			for _, v := range td.values {
+				if err = l.Set(v); err != nil {
+					break
+				}
+			}
+			if td.expErr != "" {
+				require.ErrorContains(t, err, td.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, td.exp, ids)
+			require.Equal(t, td.expStr, l.String())
+
+			// The string of the list can be set back.
+			var ids2 []roachpb.RangeID
+			require.NoError(t, makeRangeIDListValue(&ids2).Set(l.String()))
+			require.Equal(t, ids, ids2)
+		})
+	}
+}
+
+func TestBytesOrPercentageValueUnits(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	resolver := func(percent float64) (int64, error) {
+		return percentOf(4<<30, percent), nil
+	}
+	testData := []struct {
+		units       byteUnitPolicy
+		value       string
+		exp         int64
+		expWarnings []string
+		expErr      string
+		expHint     string
+	}{
+		// The SI units are powers of 1000 and the IEC units powers of 1024,
+		// whatever the policy.
+		{anyByteUnits, "32GB", 32e9, nil, ``, ``},
+		{anyByteUnits, "32GiB", 32 << 30, nil, ``, ``},
+		{anyByteUnits, "32G", 32e9, nil, ``, ``},
+		{anyByteUnits, "32Gi", 32 << 30, nil, ``, ``},
+		{warnSIByteUnits, "32GiB", 32 << 30, nil, ``, ``},
+		{warnSIByteUnits, "1024", 1024, nil, ``, ``},
+		{warnSIByteUnits, "1024B", 1024, nil, ``, ``},
+		{warnSIByteUnits, "32GB", 32e9, []string{
+			"32GB is 30 GiB as GB is a power of 1000; use 32GiB for a power of 1024",
+		}, ``, ``},
+		{warnSIByteUnits, "min(25%,512kb)+1gb", 1e9 + 512e3, []string{
+			"512kb is 500 KiB as kb is a power of 1000; use 512KiB for a power of 1024",
+			"1gb is 954 MiB as gb is a power of 1000; use 1GiB for a power of 1024",
+		}, ``, ``},
+		{iecByteUnitsOnly, "32GiB", 32 << 30, nil, ``, ``},
+		{iecByteUnitsOnly, "25%", 1 << 30, nil, ``, ``},
+		{iecByteUnitsOnly, "32GB", 0, nil,
+			`size "32GB" has an SI unit, a power of 1000; only IEC units are accepted`,
+			`use 32GiB for the IEC unit, a power of 1024`},
+		{iecByteUnitsOnly, "25%+1.5 M", 0, nil,
+			`invalid expression "25%\+1.5 M" at position 5: size "1.5 M" has an SI unit`,
+			`use 1.5MiB for the IEC unit, a power of 1024`},
+		{siByteUnitsOnly, "32GB", 32e9, nil, ``, ``},
+		{siByteUnitsOnly, "32Gi", 0, nil,
+			`size "32Gi" has an IEC unit, a power of 1024; only SI units are accepted`,
+			`use 32GB for the SI unit, a power of 1000`},
+		{siByteUnitsOnly, "2KiB", 0, nil, `has an IEC unit`, `use 2kB for the SI unit, a power of 1000`},
+	}
+	for _, td := range testData {
+		t.Run(fmt.Sprintf("%d/%s", td.units, td.value), func(t *testing.T) {
+			var v int64
+			b := makeBytesOrPercentageValue(&v, resolver, nil /* autoResolver */, defaultPercentBounds).
+				withByteUnits(td.units)
+			err := b.Set(td.value)
+			if td.expErr != "" {
+				require.Error(t, err)
+				require.Regexp(t, td.expErr, err.Error())
+				require.Contains(t, errors.GetAllHints(err), td.expHint)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, td.exp, v)
+			var warnings []string
+			for _, w := range b.unitWarnings() {
+				warnings = append(warnings, w.StripMarkers())
+			}
+			require.Equal(t, td.expWarnings, warnings)
+		})
+	}
+
+	t.Run("describe", func(t *testing.T) {
+		var v int64
+		b := makeBytesOrPercentageValue(&v, resolver, nil /* autoResolver */, defaultPercentBounds)
+		for _, tc := range []struct{ value, exp string }{
+			{"32GB", "32GB = 30 GiB (32000000000 bytes)"},
+			{"32GiB", "32GiB = 32 GiB (34359738368 bytes)"},
+			{"1GiB+1MB", "1GiB+1MB = 1.0 GiB (1074741824 bytes)"},
+		} {
+			require.NoError(t, b.Set(tc.value))
+			desc, ok := b.describeSize()
+			require.True(t, ok)
+			require.Equal(t, tc.exp, string(desc.Redact()))
+		}
+		// The percentages are described by describePercentages.
+		require.NoError(t, b.Set("25%"))
+		_, ok := b.describeSize()
+		require.False(t, ok)
+	})
+}
+
+func TestBytesOrPercentageValueExplicitlySet(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	resolver := func(percent float64) (int64, error) {
+		return percentOf(4<<30, percent), nil
+	}
+	autoResolver := func() (int64, error) { return 1 << 30, nil }
+	testData := []struct {
+		name       string
+		defaultVal string
+		// value is only given on the command line if given is set.
+		given       bool
+		value       string
+		exp         int64
+		expExplicit bool
+		expSet      bool
+		expErr      string
+	}{
+		// An unset flag leaves the value untouched, unless it has a default.
+		{"unset", "", false, "", 42, false, false, ``},
+		{"unset with a default", "128MiB", false, "", 128 << 20, false, false, ``},
+		// A flag set to its default value is explicitly set.
+		{"set to the default value", "128MiB", true, "128MiB", 128 << 20, true, true, ``},
+		{"set without a default", "", true, "128MiB", 128 << 20, true, true, ``},
+		{"set to a percentage", "128MiB", true, "25%", 1 << 30, true, true, ``},
+		{"set to auto", "128MiB", true, "auto", 1 << 30, true, true, ``},
+		// An empty value is an error rather than a way to leave the flag unset.
+		{"set empty", "128MiB", true, "", 0, false, false, `empty value`},
+		{"set blank", "", true, "  ", 0, false, false, `empty value`},
+	}
+	for _, td := range testData {
+		t.Run(td.name, func(t *testing.T) {
+			b := makeBytesOrPercentageValue(nil /* v */, nil /* percentResolver */, autoResolver, defaultPercentBounds).
+				withDefault(td.defaultVal)
+			if td.given {
+				err := b.Set(td.value)
+				if td.expErr != "" {
+					require.ErrorContains(t, err, td.expErr)
+					require.False(t, b.ExplicitlySet())
+					require.NotEmpty(t, errors.GetAllHints(err))
+					return
+				}
+				require.NoError(t, err)
+			}
+			// The flag is explicitly set as soon as it is parsed, even if it
+			// is only evaluated by Resolve.
+			require.Equal(t, td.expExplicit, b.ExplicitlySet())
+
+			v := int64(42)
+			require.NoError(t, b.Resolve(&v, resolver))
+			require.Equal(t, td.exp, v)
+			require.Equal(t, td.expExplicit, b.ExplicitlySet())
+			require.Equal(t, td.expSet, b.IsSet())
+		})
+	}
+}
+
+func TestKeyRedactionMarkers(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	indexPrefix := keys.SystemSQLCodec.IndexPrefix(104, 1)
+	tableEnd := keys.SystemSQLCodec.TablePrefix(105)
+	testData := []struct {
+		value  string
+		expKey roachpb.Key
+		expErr string
+	}{
+		// Markers around the payload and around the whole value.
+		{"hex:‹f08900›", indexPrefix, ``},
+		{"‹hex:f08900›", indexPrefix, ``},
+		{"human:‹/Table/104/1›", indexPrefix, ``},
+		{"‹human:/Table/104/1›", indexPrefix, ``},
+		{"‹foo›", roachpb.Key("foo"), ``},
+		// A copy can miss a marker.
+		{"human:‹/Table/104/1", indexPrefix, ``},
+		{"human:/Table/104/1›", indexPrefix, ``},
+		// The key was redacted out of the log.
+		{"‹×›", nil, `invalid key "‹×›": the key was redacted from the log`},
+		{"human:‹×›", nil, `invalid key "‹×›": the key was redacted from the log`},
+	}
+	for _, td := range testData {
+		t.Run(td.value, func(t *testing.T) {
+			var k mvccKey
+			err := k.Set(td.value)
+			var key roachpb.Key
+			plainErr := makePlainKeyValue(&key).Set(td.value)
+			if td.expErr != "" {
+				require.ErrorContains(t, err, td.expErr)
+				require.ErrorContains(t, plainErr, td.expErr)
+				require.NotEmpty(t, errors.GetAllHints(err))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, td.expKey, k.Key)
+			if !strings.Contains(td.value, "hex:") {
+				// The hex type of a plain key takes the bytes of the key
+				// rather than an encoded MVCCKey.
+				require.NoError(t, plainErr)
+				require.Equal(t, td.expKey, key)
+			}
+		})
+	}
+
+	t.Run("key range", func(t *testing.T) {
+		for _, value := range []string{
+			"human:‹/Table/104/1›,human:‹/Table/105›",
+			"‹human:/Table/104/1›,‹human:/Table/105›",
+			"‹human:/Table/104/1,human:/Table/105›",
+		} {
+			var start, end storage.MVCCKey
+			require.NoError(t, makeKeyRangeValue(&start, &end).Set(value), value)
+			require.Equal(t, indexPrefix, start.Key)
+			require.Equal(t, tableEnd, end.Key)
+		}
+		var start, end storage.MVCCKey
+		err := makeKeyRangeValue(&start, &end).Set("‹×›,human:/Table/105")
+		require.ErrorContains(t, err, `the key was redacted from the log`)
+		err = makeKeyRangeValue(&start, &end).Set("‹×›")
+		require.ErrorContains(t, err, `the key was redacted from the log`)
+	})
+}
+
+func TestFlagCompletions(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	type color int
+	colors := enumValues[color]{
+		names:   map[string]color{"red": 0, "green": 1, "blue": 2},
+		aliases: map[string]color{"lime": 1},
+	}
+	var c color
+	var wait nodeDecommissionWaitType
+	var checks nodeDecommissionCheckMode
+	var k mvccKey
+	var pk roachpb.Key
+	testData := []struct {
+		value      flagCompleter
+		toComplete string
+		exp        []string
+	}{
+		{makeEnumFlag(&c, "color", colors), "", []string{"blue", "green", "lime", "red"}},
+		{makeEnumFlag(&c, "color", colors), "l", []string{"lime"}},
+		{makeEnumFlag(&c, "color", colors), "x", nil},
+		{&wait, "", []string{"all", "live", "none"}},
+		{&checks, "dry-run,s", []string{"dry-run,skip", "dry-run,strict"}},
+		{&k, "h", []string{"hex:", "human:"}},
+		{&k, "RAW", []string{"raw:", "raw-hex:"}},
+		{&k, "rangeid", []string{"rangeID:"}},
+		{&k, "lock:hu", []string{"lock:human:"}},
+		{&k, "lock:", []string{"lock:b64:", "lock:base64:", "lock:escaped:", "lock:hex:", "lock:human:",
+			"lock:pretty:", "lock:rangeID:", "lock:raw:", "lock:raw-hex:"}},
+		{&k, "human:/Table", nil},
+		{makePlainKeyValue(&pk), "b", []string{"b64:", "base64:"}},
+	}
+	for _, td := range testData {
+		t.Run(fmt.Sprintf("%T/%s", td.value, td.toComplete), func(t *testing.T) {
+			res, _ := td.value.complete(td.toComplete)
+			require.Equal(t, td.exp, res)
+		})
+	}
+
+	// The suggested values are accepted by Set.
+	for _, name := range colors.completions("") {
+		require.NoError(t, makeEnumFlag(&c, "color", colors).Set(name))
+	}
+	for _, name := range nodeDecommissionChecks.completions("") {
+		require.NoError(t, checks.Set(name))
+	}
+	for _, prefix := range completeKeyType("") {
+		_, err := parseKeyType(strings.TrimSuffix(prefix, ":"))
+		require.True(t, err == nil || prefix == rawHexPrefix, prefix)
+	}
+
+	t.Run("registered", func(t *testing.T) {
+		root := &cobra.Command{Use: "root"}
+		sub := &cobra.Command{Use: "sub", Run: func(*cobra.Command, []string) {}}
+		root.AddCommand(sub)
+		sub.Flags().Var(makeEnumFlag(&c, "color", colors), "color", "")
+		sub.Flags().Var(&k, "from", "")
+		sub.Flags().String("other", "", "")
+		require.NoError(t, registerFlagCompletions(root))
+
+		complete := func(args ...string) string {
+			var buf strings.Builder
+			root.SetOut(&buf)
+			root.SetArgs(append([]string{cobra.ShellCompRequestCmd}, args...))
+			require.NoError(t, root.Execute())
+			return buf.String()
+		}
+		require.Equal(t, "green\n:4\n", complete("sub", "--color", "g"))
+		require.Equal(t, "hex:\nhuman:\n:6\n", complete("sub", "--from", "h"))
+	})
+}
+
+// TestFlagResolutions checks the resolutions of the flags of a node
+// configured with both percentages and absolute values, as they are logged
+// at startup.
+func TestFlagResolutions(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	// The test reads the logs back from the files.
+	defer log.ScopeWithoutShowLogs(t).Close(t)
+
+	defer func(saved map[string]resolvedFlag) { resolvedFlags = saved }(resolvedFlags)
+	resolvedFlags = map[string]resolvedFlag{}
+
+	memory := func() (redact.RedactableString, error) {
+		return redact.Sprintf("cgroup limit (%s)", humanizeutil.IBytes(4<<30)), nil
+	}
+	resolver := func(percent float64) (int64, error) {
+		return percentOf(4<<30, percent), nil
+	}
+	newValue := func() bytesOrPercentageValue {
+		return makeBytesOrPercentageValue(nil /* v */, nil /* percentResolver */, nil /* autoResolver */, defaultPercentBounds).
+			withCapacity(memory)
+	}
+	cache, sqlMem, tsdbMem := newValue(), newValue(), newValue()
+	tempStorage := newValue().withDefault("32MiB")
+	var drainWait time.Duration
+	drain := makeDurationOrPercentageValue(&drainWait, percentBounds{min: 1, max: 100})
+	registerResolvedFlag("cache", &cache)
+	registerResolvedFlag("max-sql-memory", &sqlMem)
+	registerResolvedFlag("max-tsdb-memory", &tsdbMem)
+	registerResolvedFlag("max-disk-temp-storage", &tempStorage)
+	registerResolvedFlag("drain-wait", &drain)
+
+	require.NoError(t, cache.Set("25%"))
+	require.NoError(t, sqlMem.Set("2GiB"))
+	require.NoError(t, drain.Set("50%"))
+	resolutions := func() []string {
+		var res []string
+		for _, r := range flagResolutions() {
+			res = append(res, r.String())
+		}
+		return res
+	}
+	// The percentages are only known once resolved.
+	require.Equal(t, []string{
+		"--cache: spec=25%, of=cgroup limit (4.0 GiB), unresolved",
+		"--drain-wait: spec=50%, unresolved",
+		"--max-disk-temp-storage: not set",
+		"--max-sql-memory: spec=2GiB, resolved=2.0 GiB (2147483648 bytes)",
+		"--max-tsdb-memory: not set",
+	}, resolutions())
+
+	var v int64
+	require.NoError(t, cache.Resolve(&v, resolver))
+	require.NoError(t, sqlMem.Resolve(&v, resolver))
+	require.NoError(t, tsdbMem.Resolve(&v, resolver))
+	require.NoError(t, tempStorage.Resolve(&v, resolver))
+	require.NoError(t, drain.Resolve(time.Minute))
+	exp := []string{
+		"--cache: spec=25%, of=cgroup limit (4.0 GiB), resolved=1.0 GiB (1073741824 bytes)",
+		"--drain-wait: spec=50%, of=1m0s, resolved=30s",
+		"--max-disk-temp-storage: spec=32MiB (default), resolved=32 MiB (33554432 bytes)",
+		"--max-sql-memory: spec=2GiB, resolved=2.0 GiB (2147483648 bytes)",
+		"--max-tsdb-memory: not set",
+	}
+	require.Equal(t, exp, resolutions())
+
+	// The flag values are safe, so nothing is redacted from the logs.
+	start := timeutil.Now()
+	logFlagResolutions(context.Background())
+	log.FlushFiles()
+	entries, err := log.FetchEntriesFromFiles(start.UnixNano(), timeutil.Now().UnixNano(), 100,
+		regexp.MustCompile("flag resolution: "), log.WithMarkedSensitiveData)
+	require.NoError(t, err)
+	var logged []string
+	for _, e := range entries {
+		logged = append(logged, strings.TrimPrefix(e.Message, "flag resolution: "))
+	}
+	// The entries are returned from the most recent one.
+	for i, j := 0, len(logged)-1; i < j; i, j = i+1, j-1 {
+		logged[i], logged[j] = logged[j], logged[i]
+	}
+	require.Equal(t, exp, logged)
+}
+
+// TestEnumFlagSuggestions checks that the errors of the enum flags suggest
+// the closest valid value when the value is misspelled, but not when it is
+// too far from any of them.
+func TestEnumFlagSuggestions(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testData := []struct {
+		value pflag.Value
+		input string
+		// expSuggestion is the suggested value, or "" if none is expected.
+		// The error is not checked if the input is valid.
+		expSuggestion string
+		valid         bool
+	}{
+		// Near misses.
+		{new(nodeDecommissionCheckMode), "enbled", "enabled", false},
+		{new(nodeDecommissionCheckMode), "constrains,capacity", "constraints", false},
+		{new(nodeDecommissionWaitType), "nome", "none", false},
+		{new(nodeDecommissionWaitType), "alll:30m", "all", false},
+		{makeEnumFlag(new(checksFormat), "checks-format", checksFormats), "tabel", "table", false},
+		{makeEnumFlag(new(checksFormat), "checks-format", checksFormats), "JSON", "json", false},
+		// Exact matches.
+		{new(nodeDecommissionCheckMode), "enabled", "", true},
+		{new(nodeDecommissionWaitType), "none", "", true},
+		{makeEnumFlag(new(checksFormat), "checks-format", checksFormats), "table", "", true},
+		// Too far from any value.
+		{new(nodeDecommissionCheckMode), "disk", "", false},
+		{new(nodeDecommissionCheckMode), "st", "", false},
+		{new(nodeDecommissionWaitType), "forever", "", false},
+		{makeEnumFlag(new(checksFormat), "checks-format", checksFormats), "yaml", "", false},
+		{makeEnumFlag(new(checksFormat), "checks-format", checksFormats), "", "", false},
+	}
+	for _, td := range testData {
+		t.Run(td.input, func(t *testing.T) {
+			err := td.value.Set(td.input)
+			if td.valid {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			// The possible values are listed in any case.
+			require.Contains(t, err.Error(), "(possible values: ")
+			if td.expSuggestion == "" {
+				require.NotContains(t, err.Error(), "did you mean")
+			} else {
+				require.Contains(t, err.Error(), "; did you mean "+td.expSuggestion+"? (possible values: ")
+			}
+		})
+	}
+}
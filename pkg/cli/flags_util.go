@@ -6,155 +6,581 @@
 package cli
 
 import (
+	"bytes"
+	"encoding/base64"
 	gohex "encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
 	"math"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/cli/cliflags"
 	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/server"
 	"github.com/cockroachdb/cockroach/pkg/server/status"
 	"github.com/cockroachdb/cockroach/pkg/storage"
 	"github.com/cockroachdb/cockroach/pkg/util"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/fuzzystrmatch"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/keysutil"
+	"github.com/cockroachdb/cockroach/pkg/util/netutil/addr"
 	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/errors/oserror"
 	"github.com/cockroachdb/pebble/vfs"
 	"github.com/cockroachdb/redact"
 	humanize "github.com/dustin/go-humanize"
+	"github.com/elastic/gosigar"
+	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
 
-type localityList []roachpb.LocalityAddress
+// localityList is the value of --locality-advertise-addr: a comma-separated
+// list of key=value@address entries. The key of an entry extends to the
+// first '=' and the address starts after the last '@', so that the tier
+// values may contain '=' and '@', e.g. for base64-encoded values. Neither the
+// keys nor the values may contain ','. The keys must not be empty, and a
+// tier must not be listed twice, as the server would only ever use one of
+// its addresses; a key may however be listed with different values.
+//
+// The addresses are host[:port], where IPv6 hosts must be enclosed in
+// brackets and the other hosts must be valid DNS names (see validateHost).
+// The port defaults to the advertised port of the server, as for
+// --advertise-addr. It is only known once all the flags are parsed, so it is
+// filled in by extraServerFlagInit, after which String renders the addresses
+// exactly as they are advertised.
+//
+// The flag can be repeated. As for the other slice flags, the first
+// occurrence replaces the default entries and the following ones add to
+// them, whether each occurrence has one entry or a comma-separated list of
+// them, and a tier must not be listed twice across occurrences either.
+type localityList struct {
+	addrs []roachpb.LocalityAddress
+	// changed is set by the first call to Set, so that the following calls
+	// add to its entries instead of replacing them.
+	changed bool
+}
 
 var _ pflag.Value = &localityList{}
+var _ pflag.SliceValue = &localityList{}
 
 // Type implements the pflag.Value interface.
 func (l *localityList) Type() string { return "localityList" }
 
-// String implements the pflag.Value interface.
+// String implements the pflag.Value interface. The result is accepted by
+// Set.
 func (l *localityList) String() string {
-	string := ""
-	for _, loc := range []roachpb.LocalityAddress(*l) {
-		string += loc.LocalityTier.Key + "=" + loc.LocalityTier.Value + "@" + loc.Address.String() + ","
-	}
-
-	return string
+	return strings.Join(l.GetSlice(), ",")
 }
 
-// Set implements the pflag.Value interface.
+// Set implements the pflag.Value interface. The first call replaces the
+// default entries, and the following ones add to the entries of the previous
+// occurrences of the flag.
 func (l *localityList) Set(value string) error {
-	*l = []roachpb.LocalityAddress{}
+	if l.changed {
+		return l.Append(value)
+	}
+	var res localityList
+	if err := res.Append(value); err != nil {
+		return err
+	}
+	l.addrs = res.addrs
+	l.changed = true
+	return nil
+}
 
+// Append implements the pflag.SliceValue interface. Like Set, it accepts a
+// comma-separated list of entries. If any of them is invalid, none is added.
+func (l *localityList) Append(value string) error {
 	values := strings.Split(value, ",")
 
+	seen := make(map[roachpb.Tier]struct{}, len(l.addrs)+len(values))
+	for _, loc := range l.addrs {
+		seen[loc.LocalityTier] = struct{}{}
+	}
+	added := make([]roachpb.LocalityAddress, 0, len(values))
 	for _, value := range values {
-		split := strings.Split(value, "@")
-		if len(split) != 2 {
-			return fmt.Errorf("invalid value for --locality-advertise-address: %s", l)
+		at := strings.LastIndexByte(value, '@')
+		if at < 0 {
+			return fmt.Errorf("invalid value for --locality-advertise-address: %s", value)
 		}
 
-		tierSplit := strings.Split(split[0], "=")
-		if len(tierSplit) != 2 {
-			return fmt.Errorf("invalid value for --locality-advertise-address: %s", l)
+		key, val, ok := strings.Cut(value[:at], "=")
+		if !ok {
+			return fmt.Errorf("invalid value for --locality-advertise-address: %s", value)
+		}
+
+		if key == "" {
+			return fmt.Errorf("invalid value for --locality-advertise-address: %s: empty locality key", value)
 		}
 
 		tier := roachpb.Tier{}
-		tier.Key = tierSplit[0]
-		tier.Value = tierSplit[1]
+		tier.Key = key
+		tier.Value = val
+		if _, ok := seen[tier]; ok {
+			return fmt.Errorf("invalid value for --locality-advertise-address: duplicate locality %s", tier)
+		}
+		seen[tier] = struct{}{}
 
 		locAddress := roachpb.LocalityAddress{}
 		locAddress.LocalityTier = tier
-		locAddress.Address = util.MakeUnresolvedAddr("tcp", split[1])
+		address, err := normalizeLocalityAddr(value[at+1:])
+		if err != nil {
+			return errors.Wrapf(err, "invalid value for --locality-advertise-address: %s", value)
+		}
+		locAddress.Address = util.MakeUnresolvedAddr("tcp", address)
 
-		*l = append(*l, locAddress)
+		added = append(added, locAddress)
 	}
+	l.addrs = append(l.addrs, added...)
 
 	return nil
 }
 
-// This file contains definitions for data types suitable for use by
-// the flag+pflag packages.
+// Replace implements the pflag.SliceValue interface.
+func (l *localityList) Replace(values []string) error {
+	var res localityList
+	for _, value := range values {
+		if err := res.Append(value); err != nil {
+			return err
+		}
+	}
+	l.addrs = res.addrs
+	return nil
+}
+
+// GetSlice implements the pflag.SliceValue interface. It returns the
+// entries in the form accepted by Append.
+func (l *localityList) GetSlice() []string {
+	res := make([]string, len(l.addrs))
+	for i, loc := range l.addrs {
+		res[i] = loc.LocalityTier.String() + "@" + loc.Address.String()
+	}
+	return res
+}
 
-type dumpMode int
+// normalizeLocalityAddr validates the address of a localityList entry and
+// returns it with its host enclosed in brackets if it is an IPv6 address, so
+// that it can be told apart from the port. The port is left out if a is
+// missing one.
+func normalizeLocalityAddr(a string) (string, error) {
+	host, port, err := addr.SplitHostPort(a, "" /* defaultPort */)
+	if err != nil {
+		return "", err
+	}
+	if host == "" {
+		return "", errors.Newf("missing host in address %q", a)
+	}
+	if err := validateHost(host); err != nil {
+		return "", errors.Wrapf(err, "invalid address %q", a)
+	}
+	if port == "" {
+		if strings.Contains(host, ":") {
+			return "[" + host + "]", nil
+		}
+		return host, nil
+	}
+	return net.JoinHostPort(host, port), nil
+}
 
+// maxHostnameLength and maxHostnameLabelLength are the maximum lengths of a
+// DNS name and of each of its dot-separated labels.
 const (
-	dumpBoth dumpMode = iota
-	dumpSchemaOnly
-	dumpDataOnly
+	maxHostnameLength      = 253
+	maxHostnameLabelLength = 63
 )
 
+// validateHost checks that host is an IP address, possibly with an IPv6
+// zone, or a syntactically valid DNS name, so that typos are reported when
+// the flags are parsed rather than when the address is dialed. The labels
+// of a name may contain letters, digits, '-' and '_', which is common in the
+// names of containers even though it is not valid in a hostname.
+func validateHost(host string) error {
+	if _, err := netip.ParseAddr(host); err == nil {
+		return nil
+	}
+	if strings.Contains(host, ":") {
+		return errors.Newf("invalid IPv6 address %q", host)
+	}
+	name := strings.TrimSuffix(host, ".")
+	if len(name) > maxHostnameLength {
+		return errors.Newf("host name %q is longer than %d characters", host, maxHostnameLength)
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" {
+			return errors.Newf("invalid host name %q: empty label", host)
+		}
+		if len(label) > maxHostnameLabelLength {
+			return errors.Newf("invalid host name %q: label %q is longer than %d characters",
+				host, label, maxHostnameLabelLength)
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return errors.Newf("invalid host name %q: label %q starts or ends with '-'", host, label)
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+				return errors.Newf("invalid host name %q: invalid character %q", host, c)
+			}
+		}
+	}
+	return nil
+}
+
+// This file contains definitions for data types suitable for use by
+// the flag+pflag packages.
+
+// flagCompleter is implemented by the flag values that suggest their values
+// to the shell completion; see registerFlagCompletions. The suggestions are
+// derived from the tables Set looks the values up in, so that they stay in
+// sync with the accepted values.
+type flagCompleter interface {
+	// complete returns the values that complete toComplete, the value typed
+	// so far.
+	complete(toComplete string) ([]string, cobra.ShellCompDirective)
+}
+
+// registerFlagCompletions registers the completion function of the flags of
+// cmd and its sub-commands whose values implement flagCompleter.
+func registerFlagCompletions(cmd *cobra.Command) error {
+	registered := make(map[*pflag.Flag]bool)
+	var register func(cmd *cobra.Command) error
+	register = func(cmd *cobra.Command) error {
+		var err error
+		cmd.LocalFlags().VisitAll(func(f *pflag.Flag) {
+			c, ok := f.Value.(flagCompleter)
+			if !ok || registered[f] || err != nil {
+				return
+			}
+			registered[f] = true
+			err = cmd.RegisterFlagCompletionFunc(f.Name,
+				func(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+					return c.complete(toComplete)
+				})
+		})
+		if err != nil {
+			return errors.Wrapf(err, "registering the completions of %s", cmd.CommandPath())
+		}
+		for _, sub := range cmd.Commands() {
+			if err := register(sub); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return register(cmd)
+}
+
+// completeListElem completes the last element of a comma-separated list, e.g.
+// the "sch" of "data,sch", with the names returned by complete.
+func completeListElem(
+	toComplete string, complete func(string) []string,
+) ([]string, cobra.ShellCompDirective) {
+	i := strings.LastIndexByte(toComplete, ',')
+	var res []string
+	for _, name := range complete(toComplete[i+1:]) {
+		res = append(res, toComplete[:i+1]+name)
+	}
+	return res, cobra.ShellCompDirectiveNoFileComp
+}
+
+// enumValue is the underlying type of the values of an enum flag.
+type enumValue interface {
+	~int | ~uint8
+}
+
+// enumValues names the values of an enum flag. Each value has a canonical
+// name, which is how it is rendered, and may have aliases, which are
+// accepted as well.
+type enumValues[T enumValue] struct {
+	names   map[string]T
+	aliases map[string]T
+}
+
+// lookup returns the value named s, by its canonical name or an alias.
+func (e enumValues[T]) lookup(s string) (T, bool) {
+	if v, ok := e.names[s]; ok {
+		return v, true
+	}
+	v, ok := e.aliases[s]
+	return v, ok
+}
+
+// format returns the canonical name of v. A value without a name is
+// rendered as a number.
+func (e enumValues[T]) format(v T) string {
+	for name, nv := range e.names {
+		if nv == v {
+			return name
+		}
+	}
+	return strconv.FormatInt(int64(v), 10)
+}
+
+// ordered returns the canonical names, ordered by value.
+func (e enumValues[T]) ordered() []string {
+	names := make([]string, 0, len(e.names))
+	for name := range e.names {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return e.names[names[i]] < e.names[names[j]] })
+	return names
+}
+
+// allNames returns the canonical names and the aliases, sorted.
+func (e enumValues[T]) allNames() []string {
+	names := make([]string, 0, len(e.names)+len(e.aliases))
+	for name := range e.names {
+		names = append(names, name)
+	}
+	for name := range e.aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// possibleValues lists the canonical names and the aliases, sorted.
+func (e enumValues[T]) possibleValues() string {
+	return strings.Join(e.allNames(), ", ")
+}
+
+// suggestion returns "; did you mean <name>?" for the canonical name or alias
+// closest to the unknown name s, or "" if none is close enough (see
+// closestName). The errors of the enum flags insert it after the invalid
+// value, before the possible values.
+func (e enumValues[T]) suggestion(s string) string {
+	if name := closestName(s, e.allNames()); name != "" {
+		return "; did you mean " + name + "?"
+	}
+	return ""
+}
+
+// completions returns the canonical names and the aliases that start with
+// prefix, sorted.
+func (e enumValues[T]) completions(prefix string) []string {
+	var res []string
+	for _, name := range e.allNames() {
+		if strings.HasPrefix(name, prefix) {
+			res = append(res, name)
+		}
+	}
+	return res
+}
+
+// enumFlag is the value of a flag that takes one of a set of named values.
+type enumFlag[T enumValue] struct {
+	val    *T
+	name   string
+	values enumValues[T]
+}
+
+// makeEnumFlag returns the value of the flag --name, which stores one of
+// values in val.
+func makeEnumFlag[T enumValue](val *T, name string, values enumValues[T]) *enumFlag[T] {
+	return &enumFlag[T]{val: val, name: name, values: values}
+}
+
 // Type implements the pflag.Value interface.
-func (m *dumpMode) Type() string { return "string" }
+func (f *enumFlag[T]) Type() string { return "string" }
 
 // String implements the pflag.Value interface.
-func (m *dumpMode) String() string {
-	switch *m {
-	case dumpBoth:
-		return "both"
-	case dumpSchemaOnly:
-		return "schema"
-	case dumpDataOnly:
-		return "data"
+func (f *enumFlag[T]) String() string {
+	if f.val == nil {
+		// pflag renders a zero value of the flag type to decide whether to
+		// print the default; the other values of this file handle it the
+		// same way.
+		return ""
 	}
-	return ""
+	return f.values.format(*f.val)
+}
+
+// complete implements the flagCompleter interface.
+func (f *enumFlag[T]) complete(toComplete string) ([]string, cobra.ShellCompDirective) {
+	return f.values.completions(toComplete), cobra.ShellCompDirectiveNoFileComp
 }
 
 // Set implements the pflag.Value interface.
-func (m *dumpMode) Set(s string) error {
-	switch s {
-	case "both":
-		*m = dumpBoth
-	case "schema":
-		*m = dumpSchemaOnly
-	case "data":
-		*m = dumpDataOnly
-	default:
-		return fmt.Errorf("invalid value for --dump-mode: %s", s)
+func (f *enumFlag[T]) Set(s string) error {
+	v, ok := f.values.lookup(s)
+	if !ok {
+		return fmt.Errorf("invalid value for --%s: %s%s (possible values: %s)",
+			f.name, s, f.values.suggestion(s), f.values.possibleValues())
 	}
+	*f.val = v
 	return nil
 }
 
+// mvccKey is the value of the flags that take an MVCC key, in the form
+// [<type>:]<key>[@<timestamp>]. The optional timestamp suffix, of the form
+// walltime[,logical] as printed by hlc.Timestamp.String, applies to the
+// raw, escaped, human, pretty and rangeID key types; the hex and b64 types
+// encode their own timestamp. The part after the last '@' is taken as the
+// timestamp, so a raw key containing '@' must escape it as \x40. A raw key
+// can also be given as plain hex bytes, as raw:raw-hex:<hex> or just
+// raw-hex:<hex>.
+//
+// The pretty type accepts the rendering of MVCCKey.String, e.g.
+// /Table/104/1/42/1712345678.000000001,0, so that the keys printed by the
+// debug commands can be pasted into the flags. It is like the human type,
+// except that a last segment containing a comma is taken as the timestamp.
+// The human type does not do so, as the last segment of a key could be a
+// column value; but the key part of MVCCKey.String is scanned the same way,
+// so only the keys supported by the human type can be pasted.
+//
+// The escaped type accepts keys with escaped bytes, as rendered by %q, with
+// or without the quotes. Unlike for the raw type, an '@' within the quotes is
+// part of the key.
+//
+// The lock type designates the lock-table key of a key given in any of the
+// other formats, e.g. lock:human:/Table/104/1/42. The lock-table keys are not
+// versioned, so they do not take a timestamp.
 type mvccKey storage.MVCCKey
 
 // Type implements the pflag.Value interface.
 func (k *mvccKey) Type() string { return "engine.MVCCKey" }
 
-// String implements the pflag.Value interface.
+// String implements the pflag.Value interface. The result is accepted by
+// Set; see formatKey. The empty key renders as an empty string.
 func (k *mvccKey) String() string {
-	return storage.MVCCKey(*k).String()
+	if len(k.Key) == 0 && k.Timestamp.IsEmpty() {
+		return ""
+	}
+	s := formatKey(k.Key)
+	if !k.Timestamp.IsEmpty() {
+		s += "@" + k.Timestamp.String()
+	}
+	return s
+}
+
+// describe renders the key in all the supported encodings, one per line,
+// so that users can check what a flag decoded to; see --print-key. Unlike
+// String, which renders the key in a single format for pflag, the result
+// is meant for humans.
+func (k *mvccKey) describe() string {
+	key := storage.MVCCKey(*k)
+	encoded := storage.EncodeMVCCKey(key)
+	rawHex := rawHexPrefix + gohex.EncodeToString(key.Key)
+	ts := "none"
+	if !key.Timestamp.IsEmpty() {
+		ts = key.Timestamp.String()
+		rawHex += "@" + ts
+	}
+	var buf strings.Builder
+	for _, row := range []struct{ name, value string }{
+		{"key", k.String()},
+		{"pretty", key.Key.String()},
+		{"hex", "hex:" + gohex.EncodeToString(encoded)},
+		{"base64", "b64:" + base64.StdEncoding.EncodeToString(encoded)},
+		{"raw key", rawHex},
+		{"timestamp", ts},
+	} {
+		fmt.Fprintf(&buf, "%-10s %s\n", row.name+":", row.value)
+	}
+	return buf.String()
+}
+
+// complete implements the flagCompleter interface. It suggests the key type
+// prefixes, e.g. human:, including those of the keys wrapped by the lock
+// type, e.g. lock:human:. The key itself is not completed.
+func (k *mvccKey) complete(toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completeKeyType(toComplete), cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeKeyType returns the key type prefixes that complete toComplete,
+// matched case-insensitively like parseKeyType does.
+func completeKeyType(toComplete string) []string {
+	lockPrefix := lock.String() + ":"
+	if len(toComplete) >= len(lockPrefix) && strings.EqualFold(toComplete[:len(lockPrefix)], lockPrefix) {
+		var res []string
+		for _, c := range completeKeyType(toComplete[len(lockPrefix):]) {
+			if c != lockPrefix {
+				// A lock-table key does not have a lock-table key.
+				res = append(res, toComplete[:len(lockPrefix)]+c)
+			}
+		}
+		return res
+	}
+	var res []string
+	for _, prefix := range append(keyTypePrefixes(), rawHexPrefix) {
+		if len(toComplete) <= len(prefix) && strings.EqualFold(toComplete, prefix[:len(toComplete)]) {
+			res = append(res, prefix)
+		}
+	}
+	return res
+}
+
+// keyTypePrefixes returns the prefixes of the key types accepted by
+// parseKeyType, e.g. human:, sorted.
+func keyTypePrefixes() []string {
+	names := keyTypeNames()
+	for i := range names {
+		names[i] += ":"
+	}
+	return names
 }
 
 // Set implements the pflag.Value interface.
 func (k *mvccKey) Set(value string) error {
-	var typ keyType
-	var keyStr string
-	i := strings.IndexByte(value, ':')
-	if i == -1 {
-		keyStr = value
-	} else {
+	typ, keyStr, err := splitKeyType(value)
+	if err != nil {
+		return err
+	}
+
+	if typ == lock {
+		return k.setLockTableKey(keyStr)
+	}
+
+	var ts hlc.Timestamp
+	at := strings.LastIndexByte(keyStr, '@')
+	if typ == escaped && at < strings.LastIndexAny(keyStr, "\"`") {
+		// The '@' is within the quoted key.
+		at = -1
+	}
+	if at >= 0 {
+		if typ == hex || typ == b64 {
+			return errors.Newf("a %s key encodes its own timestamp; it cannot have a @timestamp suffix", typ)
+		}
 		var err error
-		typ, err = parseKeyType(value[:i])
+		ts, err = hlc.ParseTimestamp(keyStr[at+1:])
 		if err != nil {
-			return err
+			return errors.Wrapf(err, "invalid timestamp suffix in key %q", value)
 		}
-		keyStr = value[i+1:]
+		keyStr = keyStr[:at]
 	}
 
 	switch typ {
 	case hex:
-		b, err := gohex.DecodeString(keyStr)
+		b, err := decodeHex(keyStr)
+		if err != nil {
+			return err
+		}
+		newK, err := decodeEncodedMVCCKey(b, "hex", gohex.EncodeToString)
+		if err != nil {
+			return err
+		}
+		*k = mvccKey(newK)
+	case b64:
+		b, err := decodeBase64(keyStr)
 		if err != nil {
 			return err
 		}
-		newK, err := storage.DecodeMVCCKey(b)
+		newK, err := decodeEncodedMVCCKey(b, "base64", base64.StdEncoding.EncodeToString)
 		if err != nil {
-			encoded := gohex.EncodeToString(storage.EncodeMVCCKey(storage.MakeMVCCMetadataKey(roachpb.Key(b))))
-			return errors.Wrapf(err, "perhaps this is just a hex-encoded key; you need an "+
-				"encoded MVCCKey (i.e. with a timestamp component); here's one with a zero timestamp: %s",
-				encoded)
+			return err
 		}
 		*k = mvccKey(newK)
 	case raw:
@@ -162,20 +588,44 @@ func (k *mvccKey) Set(value string) error {
 		if err != nil {
 			return err
 		}
-		*k = mvccKey(storage.MakeMVCCMetadataKey(roachpb.Key(unquoted)))
+		*k = mvccKey(storage.MVCCKey{Key: roachpb.Key(unquoted), Timestamp: ts})
+	case escaped:
+		key, err := decodeEscaped(keyStr)
+		if err != nil {
+			return err
+		}
+		*k = mvccKey(storage.MVCCKey{Key: key, Timestamp: ts})
 	case human:
-		scanner := keysutil.MakePrettyScanner(nil /* tableParser */, nil /* tenantParser */)
-		key, err := scanner.Scan(keyStr)
+		key, err := scanHumanKey(keyStr)
+		if err != nil {
+			return err
+		}
+		*k = mvccKey(storage.MVCCKey{Key: key, Timestamp: ts})
+	case prettyKey:
+		// The timestamp printed by MVCCKey.String always has a logical
+		// part, so a last segment with a comma cannot be a column value.
+		if i := strings.LastIndexByte(keyStr, '/'); i >= 0 && strings.Contains(keyStr[i:], ",") {
+			if at >= 0 {
+				return errors.Newf("invalid key %q: the timestamp cannot be given twice", value)
+			}
+			var err error
+			ts, err = hlc.ParseTimestamp(keyStr[i+1:])
+			if err != nil {
+				return errors.Wrapf(err, "invalid timestamp suffix in key %q", value)
+			}
+			keyStr = keyStr[:i]
+		}
+		key, err := scanHumanKey(keyStr)
 		if err != nil {
 			return err
 		}
-		*k = mvccKey(storage.MakeMVCCMetadataKey(key))
+		*k = mvccKey(storage.MVCCKey{Key: key, Timestamp: ts})
 	case rangeID:
-		fromID, err := parseRangeID(keyStr)
+		key, err := parseRangeIDKey(keyStr)
 		if err != nil {
 			return err
 		}
-		*k = mvccKey(storage.MakeMVCCMetadataKey(keys.MakeRangeIDPrefix(fromID)))
+		*k = mvccKey(storage.MVCCKey{Key: key, Timestamp: ts})
 	default:
 		return fmt.Errorf("unknown key type %s", typ)
 	}
@@ -183,244 +633,1633 @@ func (k *mvccKey) Set(value string) error {
 	return nil
 }
 
-// unquoteArg unquotes the provided argument using Go double-quoted
-// string literal rules.
-func unquoteArg(arg string) (string, error) {
-	s, err := strconv.Unquote(`"` + arg + `"`)
+// splitKeyType splits a key of the form [<type>:]<key> into its type, raw by
+// default, and the rest. The redaction markers of a key pasted from the logs
+// are stripped from both; see stripRedactionMarkers.
+func splitKeyType(value string) (keyType, string, error) {
+	value, err := stripRedactionMarkers(value)
+	if err != nil {
+		return 0, "", err
+	}
+	i := strings.IndexByte(value, ':')
+	if i == -1 || strings.HasPrefix(value, rawHexPrefix) {
+		// A raw-hex key is a raw key; see unquoteArg.
+		return raw, value, nil
+	}
+	typ, err := parseKeyType(value[:i])
+	if err != nil {
+		return 0, "", err
+	}
+	keyStr, err := stripRedactionMarkers(value[i+1:])
 	if err != nil {
-		return "", errors.Wrapf(err, "invalid argument %q", arg)
+		return 0, "", err
 	}
-	return s, nil
+	return typ, keyStr, nil
 }
 
-type keyType int
-
-//go:generate stringer -type=keyType
-const (
-	raw keyType = iota
-	human
-	rangeID
-	hex
-)
-
-func parseKeyType(value string) (keyType, error) {
-	for typ, i := range _keyTypes {
-		if strings.EqualFold(value, typ) {
-			return i, nil
-		}
+// stripRedactionMarkers strips the redaction markers around a key copied
+// from the logs, e.g. ‹/Table/104/1›. Either marker is stripped on its own,
+// as a copy can miss one, but only if the markers are around the whole value:
+// those of ‹a›,‹b› are around each key of a key range. A key that was
+// redacted out of the logs, i.e. the ‹×› placeholder, is an error.
+func stripRedactionMarkers(value string) (string, error) {
+	if strings.TrimSpace(value) == string(redact.RedactedMarker()) {
+		return "", errors.WithHint(
+			errors.Newf("invalid key %q: the key was redacted from the log it was copied from", value),
+			"find the key in an unredacted log, or in the output of the command that reported it")
+	}
+	start, end := string(redact.StartMarker()), string(redact.EndMarker())
+	if i := strings.LastIndex(value, start); i > 0 {
+		return value, nil
 	}
-	return 0, fmt.Errorf("unknown key type '%s'", value)
+	if i := strings.Index(value, end); i >= 0 && i != len(value)-len(end) {
+		return value, nil
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(value, start), end), nil
 }
 
-type nodeDecommissionWaitType int
+// plainKeyValue is the value of the flags that take a key without a
+// timestamp, a roachpb.Key rather than an MVCC key. It accepts the formats of
+// mvccKey, without the @timestamp suffix, except that the hex and b64 types
+// take the bytes of the key itself rather than an encoded MVCCKey, e.g.
+// hex:f089 for /Table/104/1.
+type plainKeyValue struct {
+	key *roachpb.Key
+}
 
-const (
-	nodeDecommissionWaitAll nodeDecommissionWaitType = iota
-	nodeDecommissionWaitNone
-)
+var _ pflag.Value = plainKeyValue{}
+
+// makePlainKeyValue creates a plainKeyValue that sets *key.
+func makePlainKeyValue(key *roachpb.Key) plainKeyValue {
+	return plainKeyValue{key: key}
+}
 
 // Type implements the pflag.Value interface.
-func (s *nodeDecommissionWaitType) Type() string { return "string" }
+func (k plainKeyValue) Type() string { return "roachpb.Key" }
 
-// String implements the pflag.Value interface.
-func (s *nodeDecommissionWaitType) String() string {
-	switch *s {
-	case nodeDecommissionWaitAll:
-		return "all"
-	case nodeDecommissionWaitNone:
-		return "none"
-	default:
-		panic("unexpected node decommission wait type (possible values: all, none)")
+// String implements the pflag.Value interface. The result is accepted by
+// Set; see formatKey. The empty key renders as an empty string.
+func (k plainKeyValue) String() string {
+	if k.key == nil || len(*k.key) == 0 {
+		return ""
 	}
+	return formatKey(*k.key)
+}
+
+// complete implements the flagCompleter interface; see mvccKey.complete.
+func (k plainKeyValue) complete(toComplete string) ([]string, cobra.ShellCompDirective) {
+	return completeKeyType(toComplete), cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
 }
 
 // Set implements the pflag.Value interface.
-func (s *nodeDecommissionWaitType) Set(value string) error {
-	switch value {
-	case "all":
-		*s = nodeDecommissionWaitAll
-	case "none":
-		*s = nodeDecommissionWaitNone
-	default:
-		return fmt.Errorf("invalid node decommission parameter: %s "+
-			"(possible values: all, none)", value)
+func (k plainKeyValue) Set(value string) error {
+	typ, keyStr, err := splitKeyType(value)
+	if err != nil {
+		return err
+	}
+	switch typ {
+	case hex, b64:
+		var b []byte
+		if typ == hex {
+			b, err = decodeHex(keyStr)
+		} else {
+			b, err = decodeBase64(keyStr)
+		}
+		if err != nil {
+			return errors.WithHint(err, "the hex and b64 formats of this flag take the bytes of "+
+				"the key, not an encoded MVCCKey as --from and --to do")
+		}
+		*k.key = b
+		return nil
+	}
+	var m mvccKey
+	if err := m.Set(value); err != nil {
+		return err
+	}
+	if !m.Timestamp.IsEmpty() {
+		return errors.WithHintf(
+			errors.Newf("invalid key %q: this flag takes a key without a timestamp", value),
+			"remove the timestamp, i.e. use %s", formatKey(m.Key))
 	}
+	*k.key = m.Key
 	return nil
 }
 
-type nodeDecommissionCheckMode int
-
-const (
-	nodeDecommissionChecksSkip nodeDecommissionCheckMode = iota
-	nodeDecommissionChecksEnabled
-	nodeDecommissionChecksStrict
-)
+// humanTenantKeyParse is a keys.KeyParserFunc for the part of a
+// human-readable key that follows /Tenant, of the form
+// /<tenant ID>[/Table/...]. The part that follows /Table is parsed by
+// humanTableKeyParse.
+func humanTenantKeyParse(input string) (remainder string, output roachpb.Key) {
+	seg, remainder := shiftKeySegment(input)
+	id, err := strconv.ParseUint(seg, 10, 64)
+	var tenantID roachpb.TenantID
+	if err == nil {
+		tenantID, err = roachpb.MakeTenantID(id)
+	}
+	if err != nil {
+		panic(&keys.ErrUglifyUnsupported{Wrapped: errors.Wrapf(err, "invalid tenant ID %q", seg)})
+	}
+	output = keys.MakeTenantPrefix(tenantID)
+	if strings.HasPrefix(remainder, "/Table/") {
+		var tableKey roachpb.Key
+		remainder, tableKey = humanTableKeyParse(remainder[len("/Table"):])
+		output = append(output, tableKey...)
+	}
+	return remainder, output
+}
 
-// Type implements the pflag.Value interface.
-func (s *nodeDecommissionCheckMode) Type() string { return "string" }
+// humanTableKeyParse is a keys.KeyParserFunc for the part of a human-readable
+// key that follows /Table, of the form /<table ID>[/<index ID>[/<value>...]].
+// The values of the index columns must be integers.
+func humanTableKeyParse(input string) (remainder string, output roachpb.Key) {
+	parseID := func(what, seg string) uint32 {
+		id, err := strconv.ParseUint(seg, 10, 32)
+		if err != nil {
+			panic(&keys.ErrUglifyUnsupported{Wrapped: errors.Wrapf(err, "invalid %s %q", what, seg)})
+		}
+		return uint32(id)
+	}
+	seg, input := shiftKeySegment(input)
+	output = keys.SystemSQLCodec.TablePrefix(parseID("table ID", seg))
+	if input == "" {
+		return "", output
+	}
+	seg, input = shiftKeySegment(input)
+	output = encoding.EncodeUvarintAscending(output, uint64(parseID("index ID", seg)))
+	for input != "" {
+		seg, input = shiftKeySegment(input)
+		v, err := strconv.ParseInt(seg, 10, 64)
+		if err != nil {
+			panic(&keys.ErrUglifyUnsupported{Wrapped: errors.Wrapf(err,
+				"invalid index column value %q; only integer values are supported", seg)})
+		}
+		output = encoding.EncodeVarintAscending(output, v)
+	}
+	return "", output
+}
 
-// String implements the pflag.Value interface.
-func (s *nodeDecommissionCheckMode) String() string {
-	switch *s {
-	case nodeDecommissionChecksSkip:
-		return "skip"
-	case nodeDecommissionChecksEnabled:
-		return "enabled"
-	case nodeDecommissionChecksStrict:
-		return "strict"
-	default:
-		panic("unexpected node decommission check mode (possible values: enabled, strict, skip)")
+// shiftKeySegment splits the first segment, i.e. the part up to the next
+// slash, off a human-readable key which starts with a slash.
+func shiftKeySegment(input string) (seg, remainder string) {
+	if !strings.HasPrefix(input, "/") {
+		panic(&keys.ErrUglifyUnsupported{Wrapped: errors.Newf("expected / at %q", input)})
 	}
+	seg, remainder = input[1:], ""
+	if i := strings.IndexByte(seg, '/'); i >= 0 {
+		seg, remainder = seg[:i], seg[i:]
+	}
+	return seg, remainder
 }
 
-// Set implements the pflag.Value interface.
-func (s *nodeDecommissionCheckMode) Set(value string) error {
-	switch value {
-	case "skip":
-		*s = nodeDecommissionChecksSkip
-	case "enabled":
-		*s = nodeDecommissionChecksEnabled
-	case "strict":
-		*s = nodeDecommissionChecksStrict
-	default:
-		return fmt.Errorf("invalid node decommission parameter: %s "+
-			"(possible values: enabled, strict, skip)", value)
+// setLockTableKey sets k to the lock-table key of the key designated by
+// payload, which is in any of the other formats, e.g. lock:human:/Table/104/1/42.
+func (k *mvccKey) setLockTableKey(payload string) error {
+	if i := strings.IndexByte(payload, ':'); i >= 0 {
+		if typ, err := parseKeyType(payload[:i]); err == nil && typ == lock {
+			return errors.Newf("invalid lock-table key %q: the locked key cannot be a lock-table key", payload)
+		}
+	}
+	var locked mvccKey
+	if err := locked.Set(payload); err != nil {
+		return errors.Wrap(err, "invalid locked key")
 	}
+	if !locked.Timestamp.IsEmpty() {
+		return errors.Newf("invalid lock-table key %q: the locked key cannot have a timestamp", payload)
+	}
+	key, _ := keys.LockTableSingleKey(locked.Key, nil /* buf */)
+	*k = mvccKey(storage.MakeMVCCMetadataKey(key))
 	return nil
 }
 
-// bytesOrPercentageValue is a flag that accepts an integer value, an integer
-// plus a unit (e.g. 32GB or 32GiB) or a percentage (e.g. 32%). In all these
-// cases, it transforms the string flag input into an int64 value.
-//
-// Since it accepts a percentage, instances need to be configured with
-// instructions on how to resolve a percentage to a number (i.e. the answer to
-// the question "a percentage of what?"). This is done by taking in a
-// percentResolverFunc. There are predefined ones: memoryPercentResolver and
-// diskPercentResolverFactory.
-//
-// bytesOrPercentageValue can be used in two ways:
-// 1. Upon flag parsing, it can write an int64 value through a pointer specified
-// by the caller.
-// 2. It can store the flag value as a string and only convert it to an int64 on
-// a subsequent Resolve() call. Input validation still happens at flag parsing
-// time.
-//
-// Option 2 is useful when percentages cannot be resolved at flag parsing time.
-// For example, we have flags that can be expressed as percentages of the
-// capacity of storage device. Which storage device is in question might only be
-// known once other flags are parsed (e.g. --max-disk-temp-storage=10% depends
-// on --store).
-type bytesOrPercentageValue struct {
-	bval *humanizeutil.BytesValue
-
-	origVal string
+// scanHumanKey scans a key of the human type.
+func scanHumanKey(s string) (roachpb.Key, error) {
+	// The scanner only accepts one custom parser; the tenant parser handles
+	// the tables of the secondary tenants itself.
+	scanner := keysutil.MakePrettyScanner(humanTableKeyParse, nil /* tenantParser */)
+	if strings.HasPrefix(s, "/Tenant") {
+		scanner = keysutil.MakePrettyScanner(nil /* tableParser */, humanTenantKeyParse)
+	}
+	return scanner.Scan(s)
+}
 
-	// percentResolver is used to turn a percent string into a value. See
-	// memoryPercentResolver() and diskPercentResolverFactory().
-	percentResolver percentResolverFunc
+// formatKey renders key in the most readable of the formats accepted by
+// mvccKey.Set that can express it: the range-ID local keys in the rangeID
+// format, the lock-table keys in the lock format, the keys whose pretty
+// form can be scanned back in the human format, and the other keys in the
+// raw format.
+func formatKey(key roachpb.Key) string {
+	if s, ok := formatRangeIDKey(key); ok {
+		return s
+	}
+	if bytes.HasPrefix(key, keys.LockTableSingleKeyStart) {
+		if locked, err := keys.DecodeLockTableSingleKey(key); err == nil {
+			return "lock:" + formatKey(locked)
+		}
+	}
+	if s := key.String(); !strings.Contains(s, "@") {
+		if scanned, err := scanHumanKey(s); err == nil && scanned.Equal(key) {
+			return "human:" + s
+		}
+	}
+	quoted := strconv.Quote(string(key))
+	return "raw:" + rawKeyReplacer.Replace(quoted[1:len(quoted)-1])
 }
 
-var _ redact.SafeFormatter = (*bytesOrPercentageValue)(nil)
+// rawKeyReplacer escapes the characters of a raw key that would be taken as
+// separators: the '@' of the timestamp suffix of mvccKey, and the ',' of
+// keyRangeValue.
+var rawKeyReplacer = strings.NewReplacer("@", `\x40`, ",", `\x2c`)
 
-type percentResolverFunc func(percent int) (int64, error)
+// rangeIDKeySuffixes lists the suffixes of the rangeID key type, i.e. the
+// range-ID local keys that can be designated as rangeID:<range ID>/<suffix>.
+// The raftlog suffix can be followed by /<log index> to designate an entry.
+var rangeIDKeySuffixes = []struct {
+	name string
+	key  func(roachpb.RangeID) roachpb.Key
+}{
+	{"appliedstate", keys.RangeAppliedStateKey},
+	{"forceflush", keys.RangeForceFlushKey},
+	{"gchint", keys.RangeGCHintKey},
+	{"gcthreshold", keys.RangeGCThresholdKey},
+	{"hardstate", keys.RaftHardStateKey},
+	{"lastreplicagc", keys.RangeLastReplicaGCTimestampKey},
+	{"lease", keys.RangeLeaseKey},
+	{"priorreadsummary", keys.RangePriorReadSummaryKey},
+	{"raftlog", keys.RaftLogPrefix},
+	{"replicaid", keys.RaftReplicaIDKey},
+	{"tombstone", keys.RangeTombstoneKey},
+	{"truncatedstate", keys.RaftTruncatedStateKey},
+	{"version", keys.RangeVersionKey},
+}
 
-// memoryPercentResolver turns a percent into the respective fraction of the
-// system's internal memory.
-func memoryPercentResolver(percent int) (int64, error) {
+// parseRangeIDKey parses a key of the rangeID type, of the form
+// <range ID>[/<suffix>], where the suffix is one of rangeIDKeySuffixes.
+// Without a suffix, the key is the range-ID prefix of the range.
+func parseRangeIDKey(s string) (roachpb.Key, error) {
+	idStr, suffix, hasSuffix := strings.Cut(s, "/")
+	id, err := parseRangeID(idStr)
+	if err != nil {
+		return nil, err
+	}
+	if !hasSuffix {
+		return keys.MakeRangeIDPrefix(id), nil
+	}
+	name, logIndex, hasLogIndex := strings.Cut(suffix, "/")
+	for _, sfx := range rangeIDKeySuffixes {
+		if !strings.EqualFold(name, sfx.name) {
+			continue
+		}
+		if !hasLogIndex {
+			return sfx.key(id), nil
+		}
+		if sfx.name != "raftlog" {
+			break
+		}
+		idx, err := strconv.ParseUint(logIndex, 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid raft log index %q", logIndex)
+		}
+		return keys.RaftLogKey(id, kvpb.RaftIndex(idx)), nil
+	}
+	names := make([]string, len(rangeIDKeySuffixes))
+	for i, sfx := range rangeIDKeySuffixes {
+		names[i] = sfx.name
+		if sfx.name == "raftlog" {
+			names[i] += "[/<log index>]"
+		}
+	}
+	return nil, errors.Newf("unknown range-ID key suffix %q; supported suffixes: %s",
+		suffix, strings.Join(names, ", "))
+}
+
+// formatRangeIDKey renders key in the rangeID format if it is one of the
+// keys that parseRangeIDKey can produce.
+func formatRangeIDKey(key roachpb.Key) (string, bool) {
+	if !bytes.HasPrefix(key, keys.LocalRangeIDPrefix) {
+		return "", false
+	}
+	rest, id, err := encoding.DecodeUvarintAscending(key[len(keys.LocalRangeIDPrefix):])
+	if err != nil || id == 0 {
+		return "", false
+	}
+	if len(rest) == 0 {
+		return fmt.Sprintf("rangeID:%d", id), true
+	}
+	for _, sfx := range rangeIDKeySuffixes {
+		prefix := sfx.key(roachpb.RangeID(id))
+		if bytes.Equal(key, prefix) {
+			return fmt.Sprintf("rangeID:%d/%s", id, sfx.name), true
+		}
+		if sfx.name == "raftlog" && bytes.HasPrefix(key, prefix) {
+			rest, idx, err := encoding.DecodeUint64Ascending(key[len(prefix):])
+			if err == nil && len(rest) == 0 {
+				return fmt.Sprintf("rangeID:%d/raftlog/%d", id, idx), true
+			}
+		}
+	}
+	return "", false
+}
+
+// decodeEncodedMVCCKey decodes the encoded MVCCKey b, which was given in the
+// named format. If b is not an encoded MVCCKey, the error shows the bytes
+// as a key and their encoding, with the given function, as an MVCCKey with
+// a zero timestamp, in case they are just a key.
+func decodeEncodedMVCCKey(
+	b []byte, format string, encode func([]byte) string,
+) (storage.MVCCKey, error) {
+	k, err := storage.DecodeMVCCKey(b)
+	if err != nil {
+		encoded := encode(storage.EncodeMVCCKey(storage.MakeMVCCMetadataKey(roachpb.Key(b))))
+		return storage.MVCCKey{}, errors.Wrapf(err, "perhaps this is just a %s-encoded key (%s); you need an "+
+			"encoded MVCCKey (i.e. with a timestamp component); here's one with a zero timestamp: %s",
+			format, roachpb.Key(b), encoded)
+	}
+	return k, nil
+}
+
+// decodeHex decodes s as hexadecimal, ignoring a 0x prefix and whitespace,
+// which are easily picked up when copying bytes from elsewhere.
+func decodeHex(s string) ([]byte, error) {
+	h := strings.Join(strings.Fields(s), "")
+	if len(h) >= 2 && h[0] == '0' && (h[1] == 'x' || h[1] == 'X') {
+		h = h[2:]
+	}
+	b, err := gohex.DecodeString(h)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid hex key %q", s)
+	}
+	return b, nil
+}
+
+// decodeBase64 decodes s, which may use either the standard or the URL-safe
+// base64 alphabet, with or without padding.
+func decodeBase64(s string) ([]byte, error) {
+	enc := base64.RawStdEncoding
+	if strings.ContainsAny(s, "-_") {
+		enc = base64.RawURLEncoding
+	}
+	b, err := enc.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid base64 key %q", s)
+	}
+	return b, nil
+}
+
+// decodeEscaped decodes a key rendered with escaped bytes, as by the %q verb
+// used by the key formatter for the keys it cannot pretty-print, e.g.
+// "\x89\xf7\x01". The surrounding double quotes or backquotes are optional.
+func decodeEscaped(s string) (roachpb.Key, error) {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '`') {
+		unquoted, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid escaped key %s", s)
+		}
+		return roachpb.Key(unquoted), nil
+	}
+	unquoted, err := unquoteArg(s)
+	if err != nil {
+		return nil, err
+	}
+	return roachpb.Key(unquoted), nil
+}
+
+// rawHexPrefix introduces an argument of unquoteArg given as plain hex
+// bytes, e.g. raw-hex:00ff22, for the keys that are awkward to escape.
+const rawHexPrefix = "raw-hex:"
+
+// unquoteArg unquotes the provided argument using Go double-quoted
+// string literal rules, except that a double quote does not need to be
+// escaped. An argument of the form raw-hex:<hex> is instead decoded as
+// plain hex bytes, with no escaping at all.
+func unquoteArg(arg string) (string, error) {
+	if h, ok := strings.CutPrefix(arg, rawHexPrefix); ok {
+		b, err := gohex.DecodeString(h)
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid argument %q", arg)
+		}
+		return string(b), nil
+	}
+	buf := make([]byte, 0, len(arg))
+	for s := arg; len(s) > 0; {
+		if s[0] == '"' {
+			buf = append(buf, s[0])
+			s = s[1:]
+			continue
+		}
+		c, multibyte, tail, err := strconv.UnquoteChar(s, '"')
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid argument %q: invalid escape sequence at offset %d",
+				arg, len(arg)-len(s))
+		}
+		if c < utf8.RuneSelf || !multibyte {
+			buf = append(buf, byte(c))
+		} else {
+			buf = utf8.AppendRune(buf, c)
+		}
+		s = tail
+	}
+	return string(buf), nil
+}
+
+type keyType int
+
+//go:generate stringer -type=keyType -linecomment
+const (
+	raw keyType = iota
+	human
+	rangeID
+	hex
+	b64
+	lock
+	prettyKey // pretty
+	escaped
+)
+
+// parseKeyType returns the key type named by value. The name is matched
+// case-insensitively and may be abbreviated to any unambiguous prefix.
+func parseKeyType(value string) (keyType, error) {
+	names := keyTypeNames()
+	var matches []string
+	for _, name := range names {
+		if strings.EqualFold(value, name) {
+			return keyTypeByName(name), nil
+		}
+		if value != "" && len(value) < len(name) && strings.EqualFold(value, name[:len(value)]) {
+			matches = append(matches, name)
+		}
+	}
+	if len(matches) > 0 {
+		typ := keyTypeByName(matches[0])
+		ambiguous := false
+		for _, name := range matches[1:] {
+			ambiguous = ambiguous || keyTypeByName(name) != typ
+		}
+		if !ambiguous {
+			return typ, nil
+		}
+		return 0, errors.Newf("ambiguous key type '%s': could be %s",
+			value, strings.Join(matches, ", "))
+	}
+	if s := closestName(value, names); s != "" {
+		return 0, errors.Newf("unknown key type '%s'; did you mean '%s'? (valid types: %s)",
+			value, s, strings.Join(names, ", "))
+	}
+	return 0, errors.Newf("unknown key type '%s' (valid types: %s)",
+		value, strings.Join(names, ", "))
+}
+
+// keyTypeAliases lists the alternative names of key types, in addition to
+// the names generated by stringer.
+var keyTypeAliases = map[string]keyType{
+	"base64": b64,
+}
+
+// keyTypeNames returns the sorted names accepted by parseKeyType.
+func keyTypeNames() []string {
+	names := make([]string, 0, len(_keyTypes)+len(keyTypeAliases))
+	for name := range _keyTypes {
+		names = append(names, name)
+	}
+	for name := range keyTypeAliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func keyTypeByName(name string) keyType {
+	if typ, ok := keyTypeAliases[name]; ok {
+		return typ
+	}
+	return _keyTypes[name]
+}
+
+// maxTypoDistance is the largest edit distance between an unknown name, e.g.
+// of a key type or of the value of an enum flag, and a valid one for the
+// latter to be suggested.
+const maxTypoDistance = 2
+
+// closestName returns the name closest to value by case-insensitive edit
+// distance, or "" if none is close enough. The distance must also be smaller
+// than the length of value, so that very short inputs do not match
+// arbitrary names. Ties go to the first of names.
+func closestName(value string, names []string) string {
+	best, bestDist := "", maxTypoDistance+1
+	for _, name := range names {
+		d := fuzzystrmatch.LevenshteinDistance(strings.ToLower(value), strings.ToLower(name))
+		if d < bestDist && d < len(value) {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+// keyRangeValue is the value of the flags that take a range of keys, in the
+// form <start>,<end>, where both keys are in any of the formats accepted by
+// mvccKey, e.g. human:/Table/104,human:/Table/105. Either key can be omitted
+// to leave that side of the range open, e.g. ,human:/Table/105. The start
+// key must sort before the end key.
+//
+// The keys and their timestamps can contain commas, so the value is split at
+// the comma where both sides are valid keys. If there are several such
+// commas, e.g. a,b,c, the key types must be given and the commas within the
+// raw keys escaped as \x2c, as String does.
+//
+// The keys are written to the same fields as a pair of mvccKey flags, so
+// that the commands taking --from and --to can adopt it alongside them. An
+// open side is written as storage.NilKey, the default of those fields.
+type keyRangeValue struct {
+	start, end *storage.MVCCKey
+}
+
+// makeKeyRangeValue returns a keyRangeValue that writes the keys of the range
+// to start and end.
+func makeKeyRangeValue(start, end *storage.MVCCKey) *keyRangeValue {
+	return &keyRangeValue{start: start, end: end}
+}
+
+// Type implements the pflag.Value interface.
+func (r *keyRangeValue) Type() string { return "<start>,<end>" }
+
+// String implements the pflag.Value interface. The result is accepted by
+// Set.
+func (r *keyRangeValue) String() string {
+	if r.start == nil {
+		return ""
+	}
+	return (*mvccKey)(r.start).String() + "," + (*mvccKey)(r.end).String()
+}
+
+// Set implements the pflag.Value interface. The redaction markers of a key
+// range pasted from the logs, around the range or around each key, are
+// stripped; see stripRedactionMarkers.
+func (r *keyRangeValue) Set(value string) error {
+	value, err := stripRedactionMarkers(value)
+	if err != nil {
+		return err
+	}
+	var start, end storage.MVCCKey
+	var startErr, endErr error
+	found := false
+	for i := 0; i < len(value); i++ {
+		if value[i] != ',' {
+			continue
+		}
+		s, err := parseRangeBoundKey(value[:i])
+		if err != nil {
+			startErr, endErr = errors.Wrapf(err, "invalid start key %q", value[:i]), nil
+			continue
+		}
+		e, err := parseRangeBoundKey(value[i+1:])
+		if err != nil {
+			startErr, endErr = nil, errors.Wrapf(err, "invalid end key %q", value[i+1:])
+			continue
+		}
+		if found {
+			return errors.Newf("ambiguous key range %q: it can be split at several commas; "+
+				"give the types of the keys, e.g. raw:, and escape the commas within raw keys as \\x2c", value)
+		}
+		start, end, found = s, e, true
+	}
+	if !found {
+		if startErr == nil && endErr == nil {
+			return errors.Newf("invalid key range %q: expected <start>,<end>", value)
+		}
+		// The error is about the split at the last comma, i.e. the longest
+		// start key.
+		return errors.WithHint(errors.CombineErrors(startErr, endErr),
+			"a key range is <start>,<end>; either key can be omitted")
+	}
+	if len(start.Key) > 0 && len(end.Key) > 0 && !start.Less(end) {
+		return errors.Newf("invalid key range %q: the start key %s does not sort before the end key %s",
+			value, (*mvccKey)(&start), (*mvccKey)(&end))
+	}
+	*r.start, *r.end = start, end
+	return nil
+}
+
+// parseRangeBoundKey parses a side of a keyRangeValue. An empty side is
+// storage.NilKey.
+func parseRangeBoundKey(s string) (storage.MVCCKey, error) {
+	if s == "" {
+		return storage.NilKey, nil
+	}
+	var k mvccKey
+	if err := k.Set(s); err != nil {
+		return storage.MVCCKey{}, err
+	}
+	return storage.MVCCKey(k), nil
+}
+
+// idListValue is the value of the flags that take a set of IDs, as a
+// comma-separated list of IDs and inclusive ranges of IDs, e.g. 1-3,7,9-12.
+// The IDs are kept sorted and deduplicated.
+//
+// It implements pflag.SliceValue: the flag can be repeated, in which case
+// the IDs of all the occurrences are accumulated, the first occurrence
+// replacing the default.
+type idListValue[T ~int32 | ~int64] struct {
+	ids     *[]T
+	changed bool
+
+	// parseID parses a single ID. what names the IDs in the errors, e.g.
+	// "node ID", and plural the things they identify, e.g. "nodes".
+	parseID func(string) (T, error)
+	what    string
+	plural  string
+}
+
+// nodeIDListValue is an idListValue of node IDs.
+type nodeIDListValue = idListValue[roachpb.NodeID]
+
+// rangeIDListValue is an idListValue of range IDs. The IDs can have the r
+// prefix of the logs, e.g. r100-r110,r42.
+type rangeIDListValue = idListValue[roachpb.RangeID]
+
+var _ pflag.SliceValue = (*nodeIDListValue)(nil)
+var _ pflag.SliceValue = (*rangeIDListValue)(nil)
+
+// maxIDRangeSize is the largest number of IDs in a range of an idListValue,
+// so that a typo does not expand to billions of IDs.
+const maxIDRangeSize = 1 << 16
+
+// makeNodeIDListValue returns a nodeIDListValue that writes the IDs to ids.
+func makeNodeIDListValue(ids *[]roachpb.NodeID) *nodeIDListValue {
+	return &nodeIDListValue{ids: ids, parseID: parseNodeID, what: "node ID", plural: "nodes"}
+}
+
+// makeRangeIDListValue returns a rangeIDListValue that writes the IDs to
+// ids.
+func makeRangeIDListValue(ids *[]roachpb.RangeID) *rangeIDListValue {
+	return &rangeIDListValue{
+		ids: ids, parseID: parseRangeIDListElem, what: "range ID", plural: "ranges",
+	}
+}
+
+// Type implements the pflag.Value interface.
+func (l *idListValue[T]) Type() string { return "a-b,c,d-e,..." }
+
+// String implements the pflag.Value interface. The consecutive IDs are
+// rendered as ranges, e.g. 1-3,7,9-12.
+func (l *idListValue[T]) String() string {
+	return strings.Join(l.GetSlice(), ",")
+}
+
+// Set implements the pflag.Value interface.
+func (l *idListValue[T]) Set(value string) error {
+	var elems []string
+	if value != "" {
+		elems = strings.Split(value, ",")
+	}
+	if !l.changed {
+		return l.Replace(elems)
+	}
+	for _, elem := range elems {
+		if err := l.Append(elem); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Append implements the pflag.SliceValue interface. value is an ID or a
+// range of IDs.
+func (l *idListValue[T]) Append(value string) error {
+	ids, err := l.parseIDRange(value)
+	if err != nil {
+		return err
+	}
+	*l.ids = mergeIDs(*l.ids, ids)
+	l.changed = true
+	return nil
+}
+
+// Replace implements the pflag.SliceValue interface. Each of values is an ID
+// or a range of IDs.
+func (l *idListValue[T]) Replace(values []string) error {
+	var res []T
+	for _, v := range values {
+		ids, err := l.parseIDRange(v)
+		if err != nil {
+			return err
+		}
+		res = mergeIDs(res, ids)
+	}
+	*l.ids = res
+	l.changed = true
+	return nil
+}
+
+// GetSlice implements the pflag.SliceValue interface. It returns the IDs and
+// the ranges of consecutive IDs.
+func (l *idListValue[T]) GetSlice() []string {
+	if l.ids == nil {
+		return nil
+	}
+	var res []string
+	ids := *l.ids
+	for i := 0; i < len(ids); {
+		j := i
+		for j+1 < len(ids) && ids[j+1] == ids[j]+1 {
+			j++
+		}
+		if j == i {
+			res = append(res, strconv.FormatInt(int64(ids[i]), 10))
+		} else {
+			res = append(res, fmt.Sprintf("%d-%d", ids[i], ids[j]))
+		}
+		i = j + 1
+	}
+	return res
+}
+
+// parseIDRange parses an ID, or an inclusive range of IDs of the form
+// <start>-<end>, into the sorted IDs. Surrounding spaces are ignored.
+func (l *idListValue[T]) parseIDRange(s string) ([]T, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, errors.Newf("empty %s", l.what)
+	}
+	if s[0] == '-' {
+		return nil, errors.Newf("invalid %s %q: %ss are positive", l.what, s, l.what)
+	}
+	startStr, endStr, isRange := strings.Cut(s, "-")
+	start, err := l.parseID(startStr)
+	if err != nil {
+		return nil, err
+	}
+	if !isRange {
+		return []T{start}, nil
+	}
+	end, err := l.parseID(endStr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %s range %q", l.what, s)
+	}
+	if end < start {
+		return nil, errors.Newf("invalid %s range %q: the start %d is greater than the end %d",
+			l.what, s, start, end)
+	}
+	if int64(end)-int64(start) >= maxIDRangeSize {
+		return nil, errors.Newf("invalid %s range %q: more than %d %s",
+			l.what, s, maxIDRangeSize, l.plural)
+	}
+	// Loop on the count rather than on the ID, which would wrap around past
+	// end if end is the largest ID.
+	n := int64(end - start)
+	ids := make([]T, 0, n+1)
+	for i := int64(0); i <= n; i++ {
+		ids = append(ids, start+T(i))
+	}
+	return ids, nil
+}
+
+// parseNodeID parses a positive node ID.
+func parseNodeID(s string) (roachpb.NodeID, error) {
+	if s == "" {
+		return 0, errors.New("empty node ID")
+	}
+	id, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid node ID %q", s)
+	}
+	if id <= 0 {
+		return 0, errors.Newf("invalid node ID %q: node IDs are positive", s)
+	}
+	return roachpb.NodeID(id), nil
+}
+
+// parseRangeIDListElem parses a positive range ID of a rangeIDListValue,
+// with or without the r prefix; see parseRangeID.
+func parseRangeIDListElem(s string) (roachpb.RangeID, error) {
+	if s == "" {
+		return 0, errors.New("empty range ID")
+	}
+	id, err := parseRangeID(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid range ID %q", s)
+	}
+	return id, nil
+}
+
+// mergeIDs merges the sorted IDs b into the sorted and deduplicated IDs a.
+func mergeIDs[T ~int32 | ~int64](a, b []T) []T {
+	res := make([]T, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		var id T
+		if j == len(b) || (i < len(a) && a[i] <= b[j]) {
+			id, i = a[i], i+1
+		} else {
+			id, j = b[j], j+1
+		}
+		if len(res) == 0 || res[len(res)-1] != id {
+			res = append(res, id)
+		}
+	}
+	return res
+}
+
+// nodeDecommissionWaitType is the value of --wait for node decommission, of
+// the form <mode>[:<timeout>]. The timeout, if non-zero, bounds the time the
+// decommission waits for the replicas to move off the target nodes.
+type nodeDecommissionWaitType struct {
+	mode    nodeDecommissionWaitMode
+	timeout time.Duration
+}
+
+type nodeDecommissionWaitMode int
+
+const (
+	nodeDecommissionWaitAll nodeDecommissionWaitMode = iota
+	nodeDecommissionWaitNone
+	// nodeDecommissionWaitLive is like nodeDecommissionWaitAll, except that
+	// the replicas of the ranges that are unavailable, because a quorum of
+	// their replicas is on non-live nodes, are not waited for.
+	nodeDecommissionWaitLive
+)
+
+// nodeDecommissionWaitModes names the node decommission wait modes.
+var nodeDecommissionWaitModes = enumValues[nodeDecommissionWaitMode]{
+	names: map[string]nodeDecommissionWaitMode{
+		"all":  nodeDecommissionWaitAll,
+		"none": nodeDecommissionWaitNone,
+		"live": nodeDecommissionWaitLive,
+	},
+}
+
+// Type implements the pflag.Value interface.
+func (s *nodeDecommissionWaitType) Type() string { return "string" }
+
+// String implements the pflag.Value interface.
+func (s *nodeDecommissionWaitType) String() string {
+	mode := nodeDecommissionWaitModes.format(s.mode)
+	if s.timeout > 0 {
+		return mode + ":" + s.timeout.String()
+	}
+	return mode
+}
+
+// complete implements the flagCompleter interface. The modes are suggested
+// without a timeout.
+func (s *nodeDecommissionWaitType) complete(
+	toComplete string,
+) ([]string, cobra.ShellCompDirective) {
+	return nodeDecommissionWaitModes.completions(toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// Set implements the pflag.Value interface.
+func (s *nodeDecommissionWaitType) Set(value string) error {
+	var res nodeDecommissionWaitType
+	mode, timeout, hasTimeout := strings.Cut(value, ":")
+	var ok bool
+	if res.mode, ok = nodeDecommissionWaitModes.lookup(mode); !ok {
+		return fmt.Errorf("invalid node decommission parameter: %s%s "+
+			"(possible values: %s; all and live accept a :<timeout> suffix, e.g. all:30m)",
+			value, nodeDecommissionWaitModes.suggestion(mode), nodeDecommissionWaitModes.possibleValues())
+	}
+	if hasTimeout {
+		if res.mode == nodeDecommissionWaitNone {
+			return fmt.Errorf("invalid node decommission parameter: %s: "+
+				"none does not wait, so it does not accept a timeout", value)
+		}
+		d, err := time.ParseDuration(timeout)
+		if err != nil {
+			return errors.Wrapf(err, "invalid node decommission parameter: %s", value)
+		}
+		if d < 0 {
+			return fmt.Errorf("invalid node decommission parameter: %s: "+
+				"the timeout cannot be negative", value)
+		}
+		res.timeout = d
+	}
+	*s = res
+	return nil
+}
+
+// nodeDecommissionCheckMode is the value of --checks for node decommission: a
+// set of readiness checks, plus whether to stop after evaluating them.
+type nodeDecommissionCheckMode uint8
+
+const (
+	nodeDecommissionCheckConstraints nodeDecommissionCheckMode = 1 << iota
+	nodeDecommissionCheckCapacity
+	nodeDecommissionCheckAction
+	nodeDecommissionCheckReplaceOnly
+	// nodeDecommissionCheckDryRun reports the ranges blocking the
+	// decommission, but does not start it.
+	nodeDecommissionCheckDryRun
+)
+
+// The predefined sets of checks.
+const (
+	nodeDecommissionChecksSkip    nodeDecommissionCheckMode = 0
+	nodeDecommissionChecksEnabled nodeDecommissionCheckMode = nodeDecommissionCheckConstraints |
+		nodeDecommissionCheckCapacity | nodeDecommissionCheckAction
+	nodeDecommissionChecksStrict nodeDecommissionCheckMode = nodeDecommissionChecksEnabled |
+		nodeDecommissionCheckReplaceOnly
+	nodeDecommissionChecksDryRun nodeDecommissionCheckMode = nodeDecommissionChecksEnabled |
+		nodeDecommissionCheckDryRun
+)
+
+// nodeDecommissionChecksSkippable are the checks that the server evaluates
+// unless their names are listed in DecommissionPreCheckRequest.SkipChecks.
+const nodeDecommissionChecksSkippable = nodeDecommissionCheckConstraints |
+	nodeDecommissionCheckCapacity | nodeDecommissionCheckAction
+
+// nodeDecommissionChecks names the individual checks, rendered in the order
+// of their values, and the predefined sets of checks.
+var nodeDecommissionChecks = enumValues[nodeDecommissionCheckMode]{
+	names: map[string]nodeDecommissionCheckMode{
+		"constraints":  nodeDecommissionCheckConstraints,
+		"capacity":     nodeDecommissionCheckCapacity,
+		"action":       nodeDecommissionCheckAction,
+		"replace-only": nodeDecommissionCheckReplaceOnly,
+		"dry-run":      nodeDecommissionCheckDryRun,
+	},
+	aliases: map[string]nodeDecommissionCheckMode{
+		"skip":    nodeDecommissionChecksSkip,
+		"enabled": nodeDecommissionChecksEnabled,
+		"strict":  nodeDecommissionChecksStrict,
+	},
+}
+
+// Type implements the pflag.Value interface.
+func (s *nodeDecommissionCheckMode) Type() string { return "string" }
+
+// String implements the pflag.Value interface.
+func (s *nodeDecommissionCheckMode) String() string {
+	checks := *s &^ nodeDecommissionCheckDryRun
+	if *s == nodeDecommissionChecksDryRun {
+		return "dry-run"
+	}
+	var names []string
+	switch checks {
+	case nodeDecommissionChecksSkip:
+		names = append(names, "skip")
+	case nodeDecommissionChecksEnabled:
+		names = append(names, "enabled")
+	case nodeDecommissionChecksStrict:
+		names = append(names, "strict")
+	default:
+		for _, name := range nodeDecommissionChecks.ordered() {
+			if c := nodeDecommissionChecks.names[name]; checks&c != 0 {
+				names = append(names, name)
+				checks &^= c
+			}
+		}
+		if checks != 0 {
+			names = append(names, nodeDecommissionChecks.format(checks))
+		}
+	}
+	if *s&nodeDecommissionCheckDryRun != 0 {
+		names = append(names, "dry-run")
+	}
+	return strings.Join(names, ",")
+}
+
+// complete implements the flagCompleter interface.
+func (s *nodeDecommissionCheckMode) complete(
+	toComplete string,
+) ([]string, cobra.ShellCompDirective) {
+	return completeListElem(toComplete, nodeDecommissionChecks.completions)
+}
+
+// Set implements the pflag.Value interface.
+func (s *nodeDecommissionCheckMode) Set(value string) error {
+	var res nodeDecommissionCheckMode
+	names := strings.Split(value, ",")
+	for _, name := range names {
+		check, ok := nodeDecommissionChecks.lookup(name)
+		if !ok {
+			return fmt.Errorf("invalid node decommission parameter: %s%s "+
+				"(possible values: %s, or a comma-separated list of them)",
+				value, nodeDecommissionChecks.suggestion(name), nodeDecommissionChecks.possibleValues())
+		}
+		if name == "skip" && len(names) > 1 {
+			return fmt.Errorf("invalid node decommission parameter: %s: "+
+				"skip cannot be combined with other checks", value)
+		}
+		res |= check
+	}
+	if res == nodeDecommissionCheckDryRun {
+		// A dry run alone evaluates the default checks.
+		res = nodeDecommissionChecksDryRun
+	}
+	*s = res
+	return nil
+}
+
+// bytesOrPercentageValue is a flag that accepts an integer value, an integer
+// plus a unit (e.g. 32GB or 32GiB), a percentage (e.g. 32%) or an expression
+// combining them (e.g. min(25%,16GiB) or 25%+1GiB; see bytesExpr). In all
+// these cases, it transforms the string flag input into an int64 value.
+//
+// Some flags also accept "auto", in which case the value is computed by a
+// per-flag heuristic, the autoResolverFunc, when Resolve is called.
+//
+// Since it accepts a percentage, instances need to be configured with
+// instructions on how to resolve a percentage to a number (i.e. the answer to
+// the question "a percentage of what?"). This is done by taking in a
+// percentResolverFunc. There are predefined ones: memoryPercentResolver (or
+// memoryAndSwapPercentResolverFactory, see withSwap) and
+// diskPercentResolverFactory.
+//
+// bytesOrPercentageValue can be used in two ways:
+// 1. Upon flag parsing, it can write an int64 value through a pointer specified
+// by the caller.
+// 2. It can store the flag value as a string and only convert it to an int64 on
+// a subsequent Resolve() call. Input validation still happens at flag parsing
+// time.
+//
+// Option 2 is useful when percentages cannot be resolved at flag parsing time.
+// For example, we have flags that can be expressed as percentages of the
+// capacity of storage device. Which storage device is in question might only be
+// known once other flags are parsed (e.g. --max-disk-temp-storage=10% depends
+// on --store).
+type bytesOrPercentageValue struct {
+	bval *humanizeutil.BytesValue
+
+	origVal string
+
+	// percentResolver is used to turn a percent string into a value. See
+	// memoryPercentResolver() and diskPercentResolverFactory().
+	percentResolver percentResolverFunc
+
+	// autoResolver computes the value of the flag when it is set to "auto".
+	// It is nil if the flag does not accept "auto".
+	autoResolver autoResolverFunc
+
+	// bounds are the percentages accepted by the flag.
+	bounds percentBounds
+
+	// sizeBounds are the sizes the flag can resolve to, and flagName the name
+	// of the flag in the errors. See withSizeBounds().
+	sizeBounds sizeBounds
+	flagName   string
+
+	// expr is the parsed origVal. It is nil if origVal is "auto".
+	expr bytesExpr
+
+	// countsSwap is set if the percentages are of the memory plus the swap
+	// space. See withSwap().
+	countsSwap bool
+
+	// explicitlySet is set once Set has succeeded, i.e. when the flag was
+	// given on the command line, even if to its default value. See
+	// ExplicitlySet().
+	explicitlySet bool
+
+	// defaultVal is the value Resolve applies when the flag was not set, and
+	// isDefault is set once it has. See withDefault().
+	defaultVal string
+	isDefault  bool
+
+	// units is the policy on the units of the sizes, and siSizes the sizes
+	// given with SI units when the policy warns about them. See
+	// withByteUnits().
+	units   byteUnitPolicy
+	siSizes []string
+
+	// absVal is the size the flag resolved to, once bval is set.
+	absVal int64
+
+	// capacity describes what the percentages and "auto" are resolved
+	// against, e.g. "cgroup limit (4.0 GiB)", for the logs. See
+	// withCapacity().
+	capacity func() (redact.RedactableString, error)
+}
+
+var _ redact.SafeFormatter = (*bytesOrPercentageValue)(nil)
+
+type percentResolverFunc func(percent float64) (int64, error)
+
+// sizeBounds is the inclusive range of the sizes a bytesOrPercentageValue can
+// resolve to. A zero max means that there is no maximum.
+type sizeBounds struct {
+	min, max int64
+}
+
+// minCacheSize and minTempStorageSize are the smallest --cache and
+// --max-disk-temp-storage. Smaller values cause failures at run time rather
+// than when the flags are parsed.
+const (
+	minCacheSize       = 64 << 20 // 64 MiB
+	minTempStorageSize = 64 << 20 // 64 MiB
+)
+
+// autoResolverFunc computes the value of a bytesOrPercentageValue set to
+// "auto". It is only called by Resolve, once all the flags have been parsed,
+// so that it can depend on other flags (e.g. the number of stores).
+type autoResolverFunc func() (int64, error)
+
+// autoBytesValue is the value of a bytesOrPercentageValue that is computed by
+// its autoResolverFunc.
+const autoBytesValue = "auto"
+
+// percentBounds is the inclusive range of the percentages accepted by a
+// bytesOrPercentageValue.
+type percentBounds struct {
+	min, max float64
+}
+
+// defaultPercentBounds are the bounds of most flags: a percentage of the
+// memory or of a disk that leaves room for everything else.
+var defaultPercentBounds = percentBounds{min: 1, max: 99}
+
+func (b percentBounds) String() string {
+	return fmt.Sprintf("%s%% - %s%%", formatPercent(b.min), formatPercent(b.max))
+}
+
+func formatPercent(percent float64) string {
+	return strconv.FormatFloat(percent, 'f', -1, 64)
+}
+
+// memoryPercentResolver turns a percent into the respective fraction of the
+// system's internal memory.
+func memoryPercentResolver(percent float64) (int64, error) {
 	sizeBytes, _, err := status.GetTotalMemoryWithoutLogging()
 	if err != nil {
 		return 0, err
 	}
-	return (sizeBytes * int64(percent)) / 100, nil
+	return percentOf(sizeBytes, percent), nil
+}
+
+// memoryCapacityDescription describes the memory the percentages are resolved
+// against by memoryPercentResolver, e.g. "cgroup limit (4.0 GiB)".
+func memoryCapacityDescription(
+	source status.MemorySource, totalMemory int64,
+) redact.RedactableString {
+	return redact.Sprintf("%s (%s)", source, humanizeutil.IBytes(totalMemory))
+}
+
+// memoryAndSwapPercentResolverFactory produces a percentResolverFunc that
+// turns a percent into the respective fraction of the memory, as read by
+// readMemory, plus the swap space, as read by readSwap. It is the resolver of
+// the memory flags that opt in to counting the swap space with withSwap.
+// When the size of the swap space cannot be read, only the memory is counted;
+// see getMemoryAndSwap.
+func memoryAndSwapPercentResolverFactory(
+	readMemory func() (int64, status.MemorySource, string, error), readSwap func() (int64, error),
+) percentResolverFunc {
+	return func(percent float64) (int64, error) {
+		total, _, _, err := getMemoryAndSwap(readMemory, readSwap)
+		if err != nil {
+			return 0, err
+		}
+		return percentOf(total, percent), nil
+	}
+}
+
+// getMemoryAndSwap returns the memory plus the swap space, along with a
+// description of the components counted for the logs, e.g. "cgroup limit
+// (4.0 GiB) + swap (2.0 GiB)". If the size of the swap space cannot be read,
+// e.g. on platforms that do not report it, only the memory is counted and a
+// warning is returned instead of an error.
+func getMemoryAndSwap(
+	readMemory func() (int64, status.MemorySource, string, error), readSwap func() (int64, error),
+) (total int64, desc redact.RedactableString, warning string, err error) {
+	memory, source, _, err := readMemory()
+	if err != nil {
+		return 0, "", "", err
+	}
+	desc = memoryCapacityDescription(source, memory)
+	swap, err := readSwap()
+	if err != nil {
+		return memory, redact.Sprintf("%s, swap not counted", desc),
+			fmt.Sprintf("the size of the swap space is unavailable, counting the memory only: %v", err), nil
+	}
+	return memory + swap, redact.Sprintf("%s + swap (%s)", desc, humanizeutil.IBytes(swap)), "", nil
+}
+
+// systemSwap returns the size of the swap space of the system.
+func systemSwap() (int64, error) {
+	swap := gosigar.Swap{}
+	if err := swap.Get(); err != nil {
+		return 0, err
+	}
+	if swap.Total > math.MaxInt64 {
+		return 0, fmt.Errorf("unsupported swap size %s", humanize.IBytes(swap.Total))
+	}
+	return int64(swap.Total), nil
 }
 
+// diskCapacityKind is the capacity of a storage device that the percentages
+// are resolved against.
+type diskCapacityKind int
+
+const (
+	// diskTotalCapacity is the size of the device.
+	diskTotalCapacity diskCapacityKind = iota
+	// diskAvailableCapacity is the space available on the device when the
+	// percentages are resolved.
+	diskAvailableCapacity
+)
+
 // diskPercentResolverFactory takes in a path and produces a percentResolverFunc
-// bound to the respective storage device.
+// bound to the respective storage device, resolving the percentages against
+// the total or the available capacity of the device. It also returns a
+// description of that capacity for the logs, e.g. "available (120 GiB of 500
+// GiB total)".
 //
-// An error is returned if dir does not exist.
-func diskPercentResolverFactory(dir string) (percentResolverFunc, error) {
-	du, err := vfs.Default.GetDiskUsage(dir)
+// dir does not need to exist yet: the device is found through its nearest
+// existing ancestor; see nearestExistingDir.
+func diskPercentResolverFactory(
+	fs vfs.FS, dir string, kind diskCapacityKind,
+) (percentResolverFunc, redact.RedactableString, error) {
+	existingDir, err := nearestExistingDir(fs, dir)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+	du, err := fs.GetDiskUsage(existingDir)
+	if err != nil {
+		return nil, "", err
 	}
 	if du.TotalBytes > math.MaxInt64 {
-		return nil, fmt.Errorf("unsupported disk size %s, max supported size is %s",
+		return nil, "", fmt.Errorf("unsupported disk size %s, max supported size is %s",
 			humanize.IBytes(du.TotalBytes), humanizeutil.IBytes(math.MaxInt64))
 	}
 	deviceCapacity := int64(du.TotalBytes)
+	desc := redact.Sprintf("total (%s)", humanizeutil.IBytes(deviceCapacity))
+	if kind == diskAvailableCapacity {
+		deviceCapacity = int64(du.AvailBytes)
+		desc = redact.Sprintf("available (%s of %s total)",
+			humanizeutil.IBytes(deviceCapacity), humanizeutil.IBytes(int64(du.TotalBytes)))
+	}
+
+	return func(percent float64) (int64, error) {
+		return percentOf(deviceCapacity, percent), nil
+	}, desc, nil
+}
+
+// cacheSizeAutoResolver is the autoResolverFunc of --cache.
+func cacheSizeAutoResolver() (int64, error) {
+	totalMemory, _, err := status.GetTotalMemoryWithoutLogging()
+	if err != nil {
+		return 0, err
+	}
+	return autoCacheSize(totalMemory, len(serverCfg.Stores.Specs)), nil
+}
+
+// sqlMemoryAutoResolver is the autoResolverFunc of --max-sql-memory.
+func sqlMemoryAutoResolver() (int64, error) {
+	totalMemory, _, err := status.GetTotalMemoryWithoutLogging()
+	if err != nil {
+		return 0, err
+	}
+	return autoSQLMemorySize(totalMemory, len(serverCfg.Stores.Specs)), nil
+}
+
+// autoCacheSize is a quarter of the memory, but at least the default cache
+// size for every store, up to half of the memory.
+func autoCacheSize(totalMemory int64, numStores int) int64 {
+	size := max(percentOf(totalMemory, 25), int64(numStores)*server.DefaultCacheSize)
+	return min(size, percentOf(totalMemory, 50))
+}
+
+// minAutoSQLMemorySize is the smallest --max-sql-memory=auto.
+const minAutoSQLMemorySize = 256 << 20 // 256 MiB
+
+// autoSQLMemorySize is a quarter of the memory, reduced when the automatic
+// cache size is larger than a quarter of the memory so that the two together
+// use at most half of it.
+func autoSQLMemorySize(totalMemory int64, numStores int) int64 {
+	size := min(percentOf(totalMemory, 25), percentOf(totalMemory, 50)-autoCacheSize(totalMemory, numStores))
+	return max(size, minAutoSQLMemorySize)
+}
+
+// storePercentResolverFactory produces the percentResolverFunc of a flag
+// whose percentages are relative to the storage of a store, e.g.
+// --max-disk-temp-storage, along with a description of that storage for the
+// logs. The percentages of an on-disk store are of its device (see
+// diskPercentResolverFactory) and those of an in-memory store are of its
+// size. flagName is used in the errors.
+func storePercentResolverFactory(
+	fs vfs.FS, spec base.StoreSpec, kind diskCapacityKind, flagName string,
+) (percentResolverFunc, redact.RedactableString, error) {
+	if !spec.InMemory {
+		return diskPercentResolverFactory(fs, spec.Path, kind)
+	}
+	size := spec.Size.Capacity
+	if spec.Size.Percent > 0 {
+		totalMemory, _, err := status.GetTotalMemoryWithoutLogging()
+		if err != nil {
+			return nil, "", err
+		}
+		size = percentOf(totalMemory, spec.Size.Percent)
+	}
+	if size == 0 {
+		// The store can use as much memory as it needs, so there is nothing to
+		// compute the percentages of.
+		return func(float64) (int64, error) {
+			return 0, errors.Newf("--%s cannot be a percentage of the in-memory store, "+
+				"which has no size; specify a size in --%s or an absolute --%s",
+				flagName, cliflags.Store.Name, flagName)
+		}, "", nil
+	}
+	return func(percent float64) (int64, error) {
+		return percentOf(size, percent), nil
+	}, redact.Sprintf("in-memory store (%s)", humanizeutil.IBytes(size)), nil
+}
+
+// nearestExistingDir returns dir if it exists, and otherwise its nearest
+// existing ancestor, which is on the storage device dir will be created on. If
+// fs supports symlinks (see readlinkFS), the symlinks in dir are resolved
+// first, even those to directories that do not exist yet.
+func nearestExistingDir(fs vfs.FS, dir string) (string, error) {
+	cur := dir
+	if rfs, ok := fs.(readlinkFS); ok {
+		cur = resolveSymlinks(rfs, dir, maxSymlinks)
+	}
+	for {
+		_, err := fs.Stat(cur)
+		if err == nil {
+			return cur, nil
+		}
+		if !oserror.IsNotExist(err) {
+			return "", err
+		}
+		parent := fs.PathDir(cur)
+		if parent == cur {
+			return "", errors.Wrapf(err, "no ancestor of %s exists", dir)
+		}
+		cur = parent
+	}
+}
+
+// readlinkFS is a vfs.FS that supports symlinks, which vfs.FS has no method
+// for. The filesystems that do not implement it, e.g. the in-memory ones, are
+// assumed to have no symlinks.
+type readlinkFS interface {
+	vfs.FS
+	// Readlink returns the target of the symlink name, and an error if name
+	// is not a symlink.
+	Readlink(name string) (string, error)
+}
+
+// osFS is the vfs.FS of the OS along with its symlinks. Pass osFS{vfs.Default}
+// rather than vfs.Default to the functions that resolve symlinks.
+type osFS struct {
+	vfs.FS
+}
+
+var _ readlinkFS = osFS{}
+
+// Readlink implements the readlinkFS interface.
+func (osFS) Readlink(name string) (string, error) {
+	return os.Readlink(name)
+}
+
+// maxSymlinks is the maximum number of symlinks followed by resolveSymlinks,
+// so that it terminates on symlink loops.
+const maxSymlinks = 40
+
+// resolveSymlinks resolves the symlinks in path, component by component.
+// Unlike filepath.EvalSymlinks, it also resolves the symlinks in the existing
+// part of a path that does not fully exist, and follows the dangling symlinks,
+// up to depth of them.
+func resolveSymlinks(fs readlinkFS, path string, depth int) string {
+	parent := fs.PathDir(path)
+	if parent == path {
+		return path
+	}
+	path = fs.PathJoin(resolveSymlinks(fs, parent, depth), fs.PathBase(path))
+	target, err := fs.Readlink(path)
+	if err != nil || depth == 0 {
+		// path is not a symlink, or there are too many of them.
+		return path
+	}
+	if !filepath.IsAbs(target) {
+		target = fs.PathJoin(fs.PathDir(path), target)
+	}
+	return resolveSymlinks(fs, target, depth-1)
+}
 
-	return func(percent int) (int64, error) {
-		return (deviceCapacity * int64(percent)) / 100, nil
-	}, nil
+// percentOf returns percent% of total, rounded down. The fractional part of
+// the percentage is only applied at this point, and the integer percentages
+// are computed with integer arithmetic.
+func percentOf(total int64, percent float64) int64 {
+	if whole := math.Trunc(percent); whole == percent {
+		return total * int64(whole) / 100
+	}
+	return int64(math.Floor(float64(total) * percent / 100))
 }
 
 // makeBytesOrPercentageValue creates a bytesOrPercentageValue.
 //
 // v and percentResolver can be nil (either they're both specified or they're
 // both nil). If they're nil, then Resolve() has to be called later to get the
-// passed-in value.
+// passed-in value. autoResolver is nil if the flag does not accept "auto";
+// otherwise, Resolve() has to be called later too. The percentages outside of
+// bounds are rejected; most flags use defaultPercentBounds.
 //
 // When using this function, be sure to define the flag Value in a
 // context struct (in context.go) and place the call to
 // makeBytesOrPercentageValue() in one of the context init
 // functions. Do not use global-scope variables.
 func makeBytesOrPercentageValue(
-	v *int64, percentResolver func(percent int) (int64, error),
+	v *int64,
+	percentResolver percentResolverFunc,
+	autoResolver autoResolverFunc,
+	bounds percentBounds,
 ) bytesOrPercentageValue {
 	return bytesOrPercentageValue{
 		bval:            humanizeutil.NewBytesValue(v),
 		percentResolver: percentResolver,
+		autoResolver:    autoResolver,
+		bounds:          bounds,
 	}
 }
 
 var fractionRE = regexp.MustCompile(`^0?\.[0-9]+$`)
 
-// Set implements the pflags.Flag interface.
+// withSizeBounds returns b, rejecting the values that resolve to a size outside
+// of bounds. flagName is the name of the flag in the errors.
+func (b bytesOrPercentageValue) withSizeBounds(
+	flagName string, bounds sizeBounds,
+) bytesOrPercentageValue {
+	b.flagName = flagName
+	b.sizeBounds = bounds
+	return b
+}
+
+// withSwap returns b, resolving its percentages against the memory plus the
+// swap space rather than against the memory only. It is meant for the flags
+// whose percentResolver is memoryPercentResolver, and is opted in to per flag,
+// e.g. with --max-sql-memory-include-swap, as only some operators size their
+// budgets against the swap space, e.g. on VMs whose memory is overcommitted.
+func (b bytesOrPercentageValue) withSwap() bytesOrPercentageValue {
+	b.percentResolver = memoryAndSwapPercentResolverFactory(status.GetTotalMemoryWithSource, systemSwap)
+	b.countsSwap = true
+	b.capacity = func() (redact.RedactableString, error) {
+		_, desc, _, err := getMemoryAndSwap(status.GetTotalMemoryWithSource, systemSwap)
+		return desc, err
+	}
+	return b
+}
+
+// withCapacity returns b, with a description of what its percentages and
+// "auto" are resolved against, e.g. memoryCapacity, which is logged along
+// with the value the flag resolved to; see resolution().
+func (b bytesOrPercentageValue) withCapacity(
+	capacity func() (redact.RedactableString, error),
+) bytesOrPercentageValue {
+	b.capacity = capacity
+	return b
+}
+
+// memoryCapacity describes the memory the percentages are resolved against
+// by memoryPercentResolver; see memoryCapacityDescription.
+func memoryCapacity() (redact.RedactableString, error) {
+	totalMemory, source, _, err := status.GetTotalMemoryWithSource()
+	if err != nil {
+		return "", err
+	}
+	return memoryCapacityDescription(source, totalMemory), nil
+}
+
+// withDefault returns b, with a default value that Resolve applies when the
+// flag was not set. The default is a size, a percentage or any other value
+// accepted by the flag, and goes through the same parsing, resolution and
+// bounds checks as a value given on the command line. The flag is still not
+// considered set, see IsSet().
+func (b bytesOrPercentageValue) withDefault(defaultVal string) bytesOrPercentageValue {
+	b.defaultVal = defaultVal
+	return b
+}
+
+// withByteUnits returns b, applying units to the units of its sizes: the SI
+// units, e.g. GB, which are powers of 1000, can be rejected, or reported by
+// unitWarnings() so that the operators who meant GiB are warned.
+func (b bytesOrPercentageValue) withByteUnits(units byteUnitPolicy) bytesOrPercentageValue {
+	b.units = units
+	return b
+}
+
+// Set implements the pflags.Flag interface. The value is parsed and its
+// percentages validated even if it cannot be evaluated before Resolve is
+// called. An empty value, e.g. from the expansion of an unset environment
+// variable, is an error rather than a way to leave the flag unset.
 func (b *bytesOrPercentageValue) Set(s string) error {
+	if strings.TrimSpace(s) == "" {
+		return errEmptyBytesValue
+	}
+	b.isDefault = false
+	if err := b.set(s); err != nil {
+		return err
+	}
+	b.explicitlySet = true
+	return nil
+}
+
+var errEmptyBytesValue = errors.WithHint(
+	errors.New("empty value; specify a size, a percentage or an expression"),
+	"an empty value can come from an unset environment variable; "+
+		"omit the flag to use its default")
+
+func (b *bytesOrPercentageValue) set(s string) error {
 	b.origVal = s
-	if strings.HasSuffix(s, "%") || fractionRE.MatchString(s) {
-		multiplier := 100.0
-		if s[len(s)-1] == '%' {
-			// We have a percentage.
-			multiplier = 1.0
-			s = s[:len(s)-1]
-		}
-		// The user can express .123 or 0.123. Parse as float.
-		frac, err := strconv.ParseFloat(s, 32)
-		if err != nil {
-			return err
+	b.siSizes = nil
+	if s == autoBytesValue {
+		b.expr = nil
+		if b.autoResolver == nil && b.percentResolver != nil {
+			// The flag is not going to be resolved later.
+			return errAutoNotSupported
 		}
-		percent := int(frac * multiplier)
-		if percent < 1 || percent > 99 {
-			return fmt.Errorf("percentage %d%% out of range 1%% - 99%%", percent)
+		// The value is computed by Resolve.
+		return nil
+	}
+	expr, siSizes, err := parseBytesExpr(s, b.bounds, b.units)
+	if err != nil {
+		return err
+	}
+	b.expr = expr
+	b.siSizes = siSizes
+	if expr.hasPercent() && b.percentResolver == nil {
+		// percentResolver not set means that this flag is not yet supposed to set
+		// any value.
+		return nil
+	}
+	absVal, err := expr.eval(b.percentResolver)
+	if err != nil {
+		return err
+	}
+	if err := b.checkSizeBounds(absVal); err != nil {
+		return err
+	}
+	b.absVal = absVal
+	return b.bval.Set(strconv.FormatInt(absVal, 10))
+}
+
+// checkSizeBounds returns an error if absVal, the resolved value of the flag,
+// is outside of its size bounds.
+func (b *bytesOrPercentageValue) checkSizeBounds(absVal int64) error {
+	bounds := b.sizeBounds
+	if absVal >= bounds.min && (bounds.max == 0 || absVal <= bounds.max) {
+		return nil
+	}
+	spec := fmt.Sprintf("--%s=%s", b.flagName, b.origVal)
+	if b.isDefault {
+		spec = "the default " + spec
+	}
+	if _, ok := b.expr.(bytesLit); ok {
+		if absVal < bounds.min {
+			return errors.Newf("%s is below the minimum of %s", spec, humanizeutil.IBytes(bounds.min))
 		}
+		return errors.Newf("%s is above the maximum of %s", spec, humanizeutil.IBytes(bounds.max))
+	}
+	// The value depends on the machine, so the error mentions what it resolved
+	// to.
+	if absVal < bounds.min {
+		return errors.Newf("%s resolves to %s, below the minimum of %s; use --%s=%s or more",
+			spec, humanizeutil.IBytes(absVal), humanizeutil.IBytes(bounds.min),
+			b.flagName, strings.ReplaceAll(string(humanizeutil.IBytes(bounds.min)), " ", ""))
+	}
+	return errors.Newf("%s resolves to %s, above the maximum of %s",
+		spec, humanizeutil.IBytes(absVal), humanizeutil.IBytes(bounds.max))
+}
 
-		if b.percentResolver == nil {
-			// percentResolver not set means that this flag is not yet supposed to set
-			// any value.
+// Resolve can be called to get the flag's value (if any):
+//   - if the flag was set (see ExplicitlySet()), its value is evaluated,
+//     resolving its percentages with percentResolver or computing "auto", and
+//     written to *v. This is so even if the flag was set to its default value.
+//   - if the flag was not set and has a default (see withDefault()), the
+//     default is evaluated the same way and written to *v.
+//   - otherwise, *v is left untouched, so that the caller's own default or
+//     heuristic applies.
+func (b *bytesOrPercentageValue) Resolve(v *int64, percentResolver percentResolverFunc) error {
+	if !b.explicitlySet {
+		// The flag was not passed on the command line.
+		if b.defaultVal == "" {
 			return nil
 		}
-
-		absVal, err := b.percentResolver(percent)
+		b.origVal = b.defaultVal
+		b.isDefault = true
+	}
+	b.percentResolver = percentResolver
+	b.bval = humanizeutil.NewBytesValue(v)
+	if b.isAuto() {
+		if b.autoResolver == nil {
+			return errAutoNotSupported
+		}
+		absVal, err := b.autoResolver()
 		if err != nil {
+			return errors.Wrap(err, "resolving auto")
+		}
+		if err := b.checkSizeBounds(absVal); err != nil {
 			return err
 		}
-		s = fmt.Sprint(absVal)
+		b.absVal = absVal
+		return b.bval.Set(strconv.FormatInt(absVal, 10))
 	}
-	return b.bval.Set(s)
+	return b.set(b.origVal)
 }
 
-// Resolve can be called to get the flag's value (if any). If the flag had been
-// previously set, *v will be written.
-func (b *bytesOrPercentageValue) Resolve(v *int64, percentResolver percentResolverFunc) error {
-	// The flag was not passed on the command line.
-	if b.origVal == "" {
-		return nil
-	}
-	b.percentResolver = percentResolver
-	b.bval = humanizeutil.NewBytesValue(v)
-	return b.Set(b.origVal)
+var errAutoNotSupported = errors.Newf(
+	"%q is not supported by this flag; specify a size or a percentage", autoBytesValue)
+
+func (b *bytesOrPercentageValue) isAuto() bool {
+	return b.origVal == autoBytesValue
 }
 
 // Type implements the pflag.Value interface.
@@ -433,12 +2272,436 @@ func (b *bytesOrPercentageValue) String() string {
 	return redact.StringWithoutMarkers(b)
 }
 
-// SafeFormat implements the redact.SafeFormatter interface.
+// SafeFormat implements the redact.SafeFormatter interface. A percentage or
+// an expression is rendered as given, followed by the resolved size once it is
+// known, e.g. 25% (8.0 GiB). The flag values are not sensitive, so they are
+// marked as safe.
 func (b *bytesOrPercentageValue) SafeFormat(p redact.SafePrinter, _ rune) {
-	p.Print(b.bval)
+	if b.isAuto() {
+		p.Print(redact.SafeString(autoBytesValue))
+		if b.bval.IsSet() {
+			p.Printf(" (resolved: %s)", b.bval)
+		}
+		return
+	}
+	if _, ok := b.expr.(bytesLit); ok || b.expr == nil {
+		p.Print(b.bval)
+		return
+	}
+	p.Print(redact.SafeString(b.origVal))
+	if b.bval.IsSet() {
+		p.Printf(" (%s)", b.bval)
+	}
+}
+
+// describePercentages describes how the percentages of the flag were resolved
+// against the capacity described by of, e.g. "25% of cgroup limit (4.0 GiB) =
+// 1.0 GiB". It returns false if the flag has no percentage or is not resolved
+// yet.
+func (b *bytesOrPercentageValue) describePercentages(
+	of redact.RedactableString,
+) (redact.RedactableString, bool) {
+	if b.expr == nil || !b.expr.hasPercent() || !b.bval.IsSet() {
+		return "", false
+	}
+	if percent, ok := b.expr.(percentLit); ok {
+		return redact.Sprintf("%s%% of %s = %s",
+			redact.SafeString(formatPercent(float64(percent))), of, b.bval), true
+	}
+	return redact.Sprintf("%s with percentages of %s = %s",
+		redact.SafeString(b.origVal), of, b.bval), true
+}
+
+// describeSize describes the size the flag resolved to in IEC units and in
+// bytes, so that it is unambiguous whatever the units of the value, e.g.
+// "32GB = 29.8 GiB (32000000000 bytes)". It returns false if the flag has a
+// percentage (see describePercentages) or is not resolved yet.
+func (b *bytesOrPercentageValue) describeSize() (redact.RedactableString, bool) {
+	if b.expr == nil || b.expr.hasPercent() || !b.bval.IsSet() {
+		return "", false
+	}
+	v, err := b.expr.eval(nil /* resolver */)
+	if err != nil {
+		return "", false
+	}
+	return redact.Sprintf("%s = %s (%d bytes)", redact.SafeString(b.origVal), b.bval, redact.SafeInt(v)), true
 }
 
-// IsSet returns true iff Set has successfully been called.
+// unitWarnings returns a warning for each size of the flag given with an SI
+// unit, when the flag warns about them (see withByteUnits()), e.g. "32GB is
+// 29.8 GiB as GB is a power of 1000; use 32GiB for a power of 1024".
+func (b *bytesOrPercentageValue) unitWarnings() []redact.RedactableString {
+	var res []redact.RedactableString
+	for _, size := range b.siSizes {
+		v, err := humanizeutil.ParseBytes(size)
+		if err != nil {
+			continue
+		}
+		_, unit := splitSize(size)
+		res = append(res, redact.Sprintf("%s is %s as %s is a power of 1000; use %s for a power of 1024",
+			redact.SafeString(size), humanizeutil.IBytes(v), redact.SafeString(unit),
+			redact.SafeString(withSizeUnits(size, iecByteUnits))))
+	}
+	return res
+}
+
+// IsSet returns true iff the flag has a value given on the command line: a
+// flag set to "auto" is set even before it is resolved, but a flag deferring
+// its percentages to Resolve is only set once they are resolved. A flag
+// resolved to its default is not set. Use ExplicitlySet to know whether the
+// flag was given on the command line.
 func (b *bytesOrPercentageValue) IsSet() bool {
-	return b.bval.IsSet()
+	return !b.isDefault && (b.bval.IsSet() || b.isAuto())
+}
+
+// ExplicitlySet returns true iff the flag was given on the command line, i.e.
+// Set succeeded, whatever the value and whether or not it is resolved yet. A
+// flag explicitly set to its default value is explicitly set, while a flag
+// resolved to its default (see withDefault()) is not.
+func (b *bytesOrPercentageValue) ExplicitlySet() bool {
+	return b.explicitlySet
+}
+
+// resolution implements the resolvedFlag interface. The capacity is only
+// described for the values that depend on it, i.e. the percentages and
+// "auto".
+func (b *bytesOrPercentageValue) resolution() flagResolution {
+	r := flagResolution{spec: b.origVal, isDefault: b.isDefault}
+	if b.origVal == "" {
+		return r
+	}
+	if (b.isAuto() || (b.expr != nil && b.expr.hasPercent())) && b.capacity != nil {
+		if of, err := b.capacity(); err == nil {
+			r.of = of
+		}
+	}
+	if b.bval.IsSet() {
+		r.resolved = redact.Sprintf("%s (%d bytes)", b.bval, redact.SafeInt(b.absVal))
+	}
+	return r
+}
+
+// resolvedFlag is a flag value that is resolved once all the flags are
+// parsed, possibly against the capacity of the machine, e.g. a
+// bytesOrPercentageValue. The resolution of the registered ones is logged at
+// startup, so that what they resolved to can be found in a single place; see
+// registerResolvedFlag.
+type resolvedFlag interface {
+	// resolution describes the value of the flag as given, what it was
+	// resolved against and what it resolved to.
+	resolution() flagResolution
+}
+
+// resolvedFlags are the registered resolvedFlags, by flag name.
+var resolvedFlags = map[string]resolvedFlag{}
+
+// registerResolvedFlag registers the value of --name, so that its resolution
+// is logged by logFlagResolutions. It is called where the value is created,
+// and replaces the value registered for the same flag before, e.g. by a
+// previous initCLIDefaults.
+func registerResolvedFlag(name string, value resolvedFlag) {
+	resolvedFlags[name] = value
+}
+
+// flagResolutions returns the resolutions of the registered flags, ordered
+// by flag name.
+func flagResolutions() []flagResolution {
+	names := make([]string, 0, len(resolvedFlags))
+	for name := range resolvedFlags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	res := make([]flagResolution, 0, len(names))
+	for _, name := range names {
+		r := resolvedFlags[name].resolution()
+		r.name = name
+		res = append(res, r)
+	}
+	return res
+}
+
+// flagResolution describes how a resolvedFlag was resolved, e.g.
+// "--cache: spec=25%, of=cgroup limit (4.0 GiB), resolved=1.0 GiB
+// (1073741824 bytes)".
+type flagResolution struct {
+	name string
+	// spec is the value of the flag as given, or its default. isDefault is
+	// set in the latter case.
+	spec      string
+	isDefault bool
+	// of describes what the value was resolved against, if it depends on it.
+	of redact.RedactableString
+	// resolved is the value the flag resolved to, if it is resolved.
+	resolved redact.RedactableString
+}
+
+var _ redact.SafeFormatter = flagResolution{}
+
+// SafeFormat implements the redact.SafeFormatter interface. The flag values
+// are not sensitive, so they are marked as safe.
+func (r flagResolution) SafeFormat(p redact.SafePrinter, _ rune) {
+	p.Printf("--%s: ", redact.SafeString(r.name))
+	if r.spec == "" {
+		p.SafeString("not set")
+		return
+	}
+	p.Printf("spec=%s", redact.SafeString(r.spec))
+	if r.isDefault {
+		p.SafeString(" (default)")
+	}
+	if r.of != "" {
+		p.Printf(", of=%s", r.of)
+	}
+	if r.resolved == "" {
+		p.SafeString(", unresolved")
+		return
+	}
+	p.Printf(", resolved=%s", r.resolved)
+}
+
+// String implements the fmt.Stringer interface.
+func (r flagResolution) String() string {
+	return redact.StringWithoutMarkers(r)
+}
+
+// durationOrPercentageValue is a flag that accepts a duration (e.g. 30s) or a
+// percentage (e.g. 50%) of some other duration, e.g. a drain wait expressed as
+// a percentage of the shutdown grace period.
+//
+// The duration a percentage is taken of is usually only known once all the
+// flags have been parsed, so a percentage is validated when the flag is
+// parsed but only converted to a duration by a subsequent Resolve() call. A
+// duration is written when the flag is parsed.
+type durationOrPercentageValue struct {
+	d *time.Duration
+
+	origVal string
+
+	// isPercent is true if the flag was set to a percentage, which is then
+	// percent. resolved is true once Resolve has written the duration it
+	// resolves to, and base is then the duration it is a percentage of.
+	isPercent bool
+	percent   float64
+	resolved  bool
+	base      time.Duration
+
+	// bounds are the percentages accepted by the flag.
+	bounds percentBounds
+}
+
+var _ redact.SafeFormatter = (*durationOrPercentageValue)(nil)
+
+// makeDurationOrPercentageValue creates a durationOrPercentageValue that
+// writes its value to v. The percentages outside of bounds are rejected.
+//
+// As with makeBytesOrPercentageValue, define the flag Value in a context
+// struct (in context.go) and place the call in one of the context init
+// functions.
+func makeDurationOrPercentageValue(
+	v *time.Duration, bounds percentBounds,
+) durationOrPercentageValue {
+	return durationOrPercentageValue{d: v, bounds: bounds}
+}
+
+// Set implements the pflag.Value interface.
+func (d *durationOrPercentageValue) Set(s string) error {
+	if strings.HasSuffix(s, "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+		if err != nil {
+			return err
+		}
+		if !(percent >= d.bounds.min && percent <= d.bounds.max) {
+			return errors.Newf("percentage %s out of range %s", s, d.bounds)
+		}
+		// The duration is written by Resolve.
+		d.origVal, d.isPercent, d.percent, d.resolved = s, true, percent, false
+		return nil
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	if v < 0 {
+		return errors.Newf("duration %s cannot be negative", s)
+	}
+	*d.d = v
+	d.origVal, d.isPercent, d.percent, d.resolved = s, false, 0, false
+	return nil
+}
+
+// Resolve writes the value of the flag, if it was set to a percentage, as
+// that percentage of base. It does nothing if the flag was set to a duration
+// or not set.
+func (d *durationOrPercentageValue) Resolve(base time.Duration) error {
+	if !d.isPercent {
+		return nil
+	}
+	if base < 0 {
+		return errors.Newf("cannot resolve %s of a negative duration %s", d.origVal, base)
+	}
+	*d.d = time.Duration(percentOf(int64(base), d.percent))
+	d.resolved, d.base = true, base
+	return nil
+}
+
+// Type implements the pflag.Value interface.
+func (d *durationOrPercentageValue) Type() string {
+	return "duration"
+}
+
+// String implements the pflag.Value interface.
+func (d *durationOrPercentageValue) String() string {
+	return redact.StringWithoutMarkers(d)
+}
+
+// SafeFormat implements the redact.SafeFormatter interface. A percentage is
+// rendered as given, followed by the duration it resolves to once it is
+// known, e.g. 50% (30s). The flag values are not sensitive, so they are
+// marked as safe.
+func (d *durationOrPercentageValue) SafeFormat(p redact.SafePrinter, _ rune) {
+	if !d.isPercent {
+		if d.d == nil {
+			return
+		}
+		p.Print(redact.SafeString(d.d.String()))
+		return
+	}
+	p.Print(redact.SafeString(d.origVal))
+	if d.resolved {
+		p.Printf(" (%s)", redact.SafeString(d.d.String()))
+	}
+}
+
+// IsSet returns true iff Set has successfully been called. A flag set to a
+// percentage is set even before it is resolved.
+func (d *durationOrPercentageValue) IsSet() bool {
+	return d.origVal != ""
+}
+
+// resolution implements the resolvedFlag interface.
+func (d *durationOrPercentageValue) resolution() flagResolution {
+	r := flagResolution{spec: d.origVal}
+	switch {
+	case d.origVal == "":
+	case !d.isPercent:
+		r.resolved = redact.Sprintf("%s", redact.SafeString(d.d.String()))
+	case d.resolved:
+		r.of = redact.Sprintf("%s", redact.SafeString(d.base.String()))
+		r.resolved = redact.Sprintf("%s", redact.SafeString(d.d.String()))
+	}
+	return r
+}
+
+// jsonOrFileValue is the value of the flags that take a JSON payload. Like
+// with curl, a value starting with @ is the path of a file containing the
+// payload, and - reads the payload from the standard input; any other value
+// is the payload itself. The payload is validated when the flag is parsed.
+type jsonOrFileValue struct {
+	payload *string
+
+	// source is the value of the flag as given, e.g. @options.json.
+	source string
+
+	// stdin is read for the value -.
+	stdin io.Reader
+}
+
+// maxJSONPayloadSize is the largest payload read from a file or the standard
+// input by a jsonOrFileValue, so that a wrong path, e.g. of a device, does
+// not fill the memory.
+const maxJSONPayloadSize = 1 << 20 // 1 MiB
+
+// makeJSONOrFileValue returns a jsonOrFileValue that writes the payload to
+// payload.
+func makeJSONOrFileValue(payload *string) *jsonOrFileValue {
+	return &jsonOrFileValue{payload: payload, stdin: os.Stdin}
+}
+
+// Type implements the pflag.Value interface.
+func (v *jsonOrFileValue) Type() string { return "<json>|@<file>|-" }
+
+// String implements the pflag.Value interface. A payload read from a file or
+// the standard input is rendered as its source, e.g. @options.json.
+func (v *jsonOrFileValue) String() string {
+	if v.source != "" || v.payload == nil {
+		return v.source
+	}
+	return *v.payload
+}
+
+// Set implements the pflag.Value interface. The empty value clears the
+// payload.
+func (v *jsonOrFileValue) Set(s string) error {
+	var payload []byte
+	var source string
+	switch {
+	case s == "":
+	case s == "-":
+		b, err := readJSONPayload(v.stdin, "the standard input")
+		if err != nil {
+			return err
+		}
+		payload, source = b, s
+	case strings.HasPrefix(s, "@"):
+		path := s[1:]
+		if path == "" {
+			return errors.New("missing file name after @")
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return errors.Wrap(err, "cannot read the JSON payload")
+		}
+		defer f.Close()
+		b, err := readJSONPayload(f, path)
+		if err != nil {
+			return err
+		}
+		payload, source = b, s
+	default:
+		payload = []byte(s)
+	}
+	if len(payload) > 0 {
+		if err := validateJSON(payload, source); err != nil {
+			return err
+		}
+	}
+	*v.payload, v.source = string(payload), source
+	return nil
+}
+
+// readJSONPayload reads the payload of a jsonOrFileValue from r, which is
+// named by what in the errors.
+func readJSONPayload(r io.Reader, what string) ([]byte, error) {
+	b, err := io.ReadAll(io.LimitReader(r, maxJSONPayloadSize+1))
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read the JSON payload from %s", what)
+	}
+	if len(b) > maxJSONPayloadSize {
+		return nil, errors.Newf("the JSON payload in %s is larger than the maximum of %s",
+			what, humanizeutil.IBytes(maxJSONPayloadSize))
+	}
+	return b, nil
+}
+
+// validateJSON returns an error, with the line and column of the syntax
+// error, if payload is not valid JSON. source is the value of the flag the
+// payload was read from, if any.
+func validateJSON(payload []byte, source string) error {
+	err := json.Unmarshal(payload, new(json.RawMessage))
+	if err == nil {
+		return nil
+	}
+	in := ""
+	if source != "" {
+		in = " in " + source
+	}
+	var serr *json.SyntaxError
+	if !errors.As(err, &serr) {
+		return errors.Wrapf(err, "invalid JSON%s", in)
+	}
+	// The offset is past the erroneous character.
+	prefix := payload[:max(serr.Offset-1, 0)]
+	line := bytes.Count(prefix, []byte("\n")) + 1
+	col := len(prefix) - bytes.LastIndexByte(prefix, '\n')
+	return errors.Wrapf(err, "invalid JSON%s at line %d, column %d", in, line, col)
 }
@@ -110,16 +110,16 @@ start_test "Check that a ?? in a function call context prints help about that fu
 send "select count(??\r"
 eexpect "Function: "
 eexpect "count"
+eexpect "Aggregate overloads"
 eexpect "number of selected elements"
-eexpect "Signature"
 eexpect "See also"
 eexpect root@
 
 send "select count(??\t"
 eexpect "Function: "
 eexpect "count"
+eexpect "Aggregate overloads"
 eexpect "number of selected elements"
-eexpect "Signature"
 eexpect "See also"
 eexpect "select count(??"
 send "\010\010"
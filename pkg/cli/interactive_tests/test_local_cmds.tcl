@@ -244,7 +244,7 @@ end_test
 start_test "Check that \\hf with a valid function name prints that functions help."
 send "\\hf version\r"
 eexpect "Category:"
-eexpect "Signature"
+eexpect "Ordinary overloads"
 eexpect root@
 end_test
 
@@ -17,6 +17,10 @@ func _() {
 	_ = x[human-1]
 	_ = x[rangeID-2]
 	_ = x[hex-3]
+	_ = x[b64-4]
+	_ = x[lock-5]
+	_ = x[prettyKey-6]
+	_ = x[escaped-7]
 }
 
 func (i keyType) String() string {
@@ -29,6 +33,14 @@ func (i keyType) String() string {
 		return "rangeID"
 	case hex:
 		return "hex"
+	case b64:
+		return "b64"
+	case lock:
+		return "lock"
+	case prettyKey:
+		return "pretty"
+	case escaped:
+		return "escaped"
 	default:
 		return "keyType(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
@@ -39,4 +51,8 @@ var _keyTypes = map[string]keyType{
 	"human":   1,
 	"rangeID": 2,
 	"hex":     3,
+	"b64":     4,
+	"lock":    5,
+	"pretty":  6,
+	"escaped": 7,
 }
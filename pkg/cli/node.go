@@ -7,24 +7,30 @@ package cli
 
 import (
 	"context"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
 	"os"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/cockroachdb/cockroach/pkg/cli/clierror"
 	"github.com/cockroachdb/cockroach/pkg/cli/clierrorplus"
 	"github.com/cockroachdb/cockroach/pkg/cli/cliflags"
 	"github.com/cockroachdb/cockroach/pkg/cli/clisqlclient"
 	"github.com/cockroachdb/cockroach/pkg/cli/clisqlexec"
+	"github.com/cockroachdb/cockroach/pkg/cli/exit"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/liveness/livenesspb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/catconstants"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/retry"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/errors"
 	"github.com/cockroachdb/redact"
 	"github.com/spf13/cobra"
@@ -313,19 +319,22 @@ var decommissionNodeCmd = &cobra.Command{
 	Short: "decommissions the node(s)",
 	Long: `
 Marks the nodes with the supplied IDs as decommissioning.
-This will cause leases and replicas to be removed from these nodes.`,
+This will cause leases and replicas to be removed from these nodes.
+The IDs can be given as comma-separated lists and ranges, e.g. 1-3,7.`,
 	Args: cobra.MinimumNArgs(0),
 	RunE: clierrorplus.MaybeDecorateError(runDecommissionNode),
 }
 
+// parseNodeIDs parses the node IDs given as arguments. Each argument is
+// parsed like a nodeIDListValue, so that it can be a list of node IDs and
+// ranges of node IDs, e.g. 1-3,7.
 func parseNodeIDs(strNodeIDs []string) ([]roachpb.NodeID, error) {
-	nodeIDs := make([]roachpb.NodeID, 0, len(strNodeIDs))
+	var nodeIDs []roachpb.NodeID
+	l := makeNodeIDListValue(&nodeIDs)
 	for _, str := range strNodeIDs {
-		i, err := strconv.ParseInt(str, 10, 32)
-		if err != nil {
-			return nil, errors.Wrapf(err, "unable to parse %s", str)
+		if err := l.Set(str); err != nil {
+			return nil, err
 		}
-		nodeIDs = append(nodeIDs, roachpb.NodeID(i))
 	}
 	return nodeIDs, nil
 }
@@ -499,7 +508,17 @@ func runDecommissionNodeImpl(
 		var err error
 		preCheckReq = &serverpb.DecommissionPreCheckRequest{
 			NodeIDs:         nodeIDs,
-			StrictReadiness: checks == nodeDecommissionChecksStrict,
+			StrictReadiness: checks&nodeDecommissionCheckReplaceOnly != 0,
+		}
+		for _, name := range nodeDecommissionChecks.ordered() {
+			if c := nodeDecommissionChecks.names[name]; c&nodeDecommissionChecksSkippable != 0 && checks&c == 0 {
+				preCheckReq.SkipChecks = append(preCheckReq.SkipChecks, name)
+			}
+		}
+		if checks&nodeDecommissionCheckDryRun != 0 {
+			// Report each blocking range, rather than only the counts of the
+			// errors.
+			preCheckReq.NumReplicaReport = dryRunBlockingRangesToReport
 		}
 		preCheckResp, err = c.DecommissionPreCheck(ctx, preCheckReq)
 		if err != nil {
@@ -508,11 +527,24 @@ func runDecommissionNodeImpl(
 		}
 	}
 
+	// With --checks-format=json or csv, only the blocking ranges are written
+	// to stdout, so that they can be consumed by other programs. The status
+	// and readiness tables are written to stderr instead.
+	format := nodeCtx.nodeDecommissionChecksFormat
+	statusOut := io.Writer(os.Stdout)
+	if format != checksFormatTable && preCheckResp != nil {
+		statusOut = stderr
+		if err := printDecommissionBlockingRangeRecords(os.Stdout, format, preCheckResp); err != nil {
+			return err
+		}
+	}
+
 	// On a dry run, we simply run checks (as above), and print the decommission
 	// status.
-	if dryRun || !decommissionPreCheckReady(preCheckResp) {
+	if dryRun || checks&nodeDecommissionCheckDryRun != 0 || !decommissionPreCheckReady(preCheckResp) {
 		req := &serverpb.DecommissionStatusRequest{
-			NodeIDs: nodeIDs,
+			NodeIDs:                  nodeIDs,
+			ExcludeUnavailableRanges: wait.mode == nodeDecommissionWaitLive,
 		}
 		resp, err := c.DecommissionStatus(ctx, req)
 		if err != nil {
@@ -521,21 +553,39 @@ func runDecommissionNodeImpl(
 		}
 		fmt.Fprintln(stderr)
 
-		err = printDecommissionStatusAndReadiness(*resp, preCheckResp)
+		err = printDecommissionStatusAndReadiness(statusOut, *resp, preCheckResp)
+		if err == nil {
+			printDecommissionSkippedReplicas(*resp)
+		}
 		if err == nil && !decommissionPreCheckReady(preCheckResp) {
-			printDecommissionBlockingErrorSummary(preCheckResp, preCheckBlockingRangeErrsToReport)
+			switch {
+			case format != checksFormatTable:
+				// The blocking ranges were written above.
+			case checks&nodeDecommissionCheckDryRun != 0:
+				printDecommissionBlockingRanges(preCheckResp)
+			default:
+				printDecommissionBlockingErrorSummary(preCheckResp, preCheckBlockingRangeErrsToReport)
+			}
 			fmt.Fprintln(stderr)
 			err = errors.New("Cannot decommission nodes.")
 		}
 		return err
 	}
 
+	var deadline time.Time
+	if wait.timeout > 0 {
+		deadline = timeutil.Now().Add(wait.timeout)
+	}
 	prevResponse := serverpb.DecommissionStatusResponse{}
 	for r := retry.StartWithCtx(ctx, opts); r.Next(); {
 		req := &serverpb.DecommissionRequest{
 			NodeIDs:          nodeIDs,
 			TargetMembership: livenesspb.MembershipStatus_DECOMMISSIONING,
 			NumReplicaReport: int32(numReplicaReport),
+			// With --wait=live, the replicas of unavailable ranges are not
+			// counted, so that the wait ends once only such replicas remain
+			// on the nodes.
+			ExcludeUnavailableRanges: wait.mode == nodeDecommissionWaitLive,
 		}
 		resp, err := c.Decommission(ctx, req)
 		if err != nil {
@@ -549,9 +599,10 @@ func runDecommissionNodeImpl(
 
 		if !reflect.DeepEqual(&prevResponse, resp) {
 			fmt.Fprintln(stderr)
-			if err = printDecommissionStatusAndReadiness(*resp, preCheckResp); err != nil {
+			if err = printDecommissionStatusAndReadiness(statusOut, *resp, preCheckResp); err != nil {
 				return err
 			}
+			printDecommissionSkippedReplicas(*resp)
 			prevResponse = *resp
 
 			// The decommissioning status changed. Set `sameStatusCount` back to zero.
@@ -582,14 +633,36 @@ func runDecommissionNodeImpl(
 		}
 
 		anyActive := false
-		var replicaCount int64
+		var replicaCount, skippedReplicaCount int64
 		statusByNodeID := map[roachpb.NodeID]serverpb.DecommissionStatusResponse_Status{}
 		for _, status := range resp.Status {
 			anyActive = anyActive || status.Membership.Active()
 			replicaCount += status.ReplicaCount
+			skippedReplicaCount += status.SkippedReplicaCount
 			statusByNodeID[status.NodeID] = status
 		}
 
+		if !anyActive && replicaCount == 0 && skippedReplicaCount > 0 {
+			// With --wait=live, only the replicas of unavailable ranges remain
+			// on the nodes. Those ranges still need to be repaired, so the
+			// nodes are left decommissioning rather than marked as
+			// decommissioned.
+			req := &serverpb.DecommissionStatusRequest{
+				NodeIDs:                  nodeIDs,
+				NumReplicaReport:         skippedRangesToReport,
+				ExcludeUnavailableRanges: true,
+			}
+			resp, err := c.DecommissionStatus(ctx, req)
+			if err != nil {
+				fmt.Fprintln(stderr)
+				return errors.Wrap(err, "while trying to check decommission status")
+			}
+			fmt.Fprintln(stderr)
+			printDecommissionSkippedRanges(*resp)
+			return errors.Newf("%d replicas of unavailable ranges remain on the target nodes; "+
+				"the nodes are left decommissioning", skippedReplicaCount)
+		}
+
 		if !anyActive && replicaCount == 0 {
 			// We now drain the nodes in order to close all SQL connections.
 			// Note: iteration is not necessary here since there are no remaining leases
@@ -647,18 +720,26 @@ func runDecommissionNodeImpl(
 				return errors.Wrap(err, "while trying to mark as decommissioned")
 			}
 
-			fmt.Fprintln(os.Stdout, "\nNo more data reported on target nodes. "+
+			fmt.Fprintln(statusOut, "\nNo more data reported on target nodes. "+
 				"Please verify cluster health before removing the nodes.")
 			return nil
 		}
 
-		if wait == nodeDecommissionWaitNone {
+		if wait.mode == nodeDecommissionWaitNone {
 			// The intent behind --wait=none is for it to be used when polling
 			// manually from an external system. We'll only mark nodes as
 			// fully decommissioned once the replica count hits zero and they're
 			// all marked as decommissioning.
 			return nil
 		}
+		if !deadline.IsZero() && timeutil.Now().After(deadline) {
+			fmt.Fprintln(stderr)
+			printDecommissionRemainingReplicas(*resp)
+			return clierror.NewError(
+				errors.Newf("timed out after %s waiting for %d replicas to move off the target nodes",
+					wait.timeout, replicaCount),
+				exit.DecommissionTimedOut())
+		}
 		if replicaCount < minReplicaCount {
 			minReplicaCount = replicaCount
 			r.Reset()
@@ -730,16 +811,19 @@ var recommissionNodeCmd = &cobra.Command{
 	Long: `
 For the nodes with the supplied IDs, resets the decommissioning states,
 signaling the affected nodes to participate in the cluster again.
+The IDs can be given as comma-separated lists and ranges, e.g. 1-3,7.
 	`,
 	Args: cobra.MinimumNArgs(0),
 	RunE: clierrorplus.MaybeDecorateError(runRecommissionNode),
 }
 
 func printDecommissionStatusAndReadiness(
-	statusResp serverpb.DecommissionStatusResponse, checkResp *serverpb.DecommissionPreCheckResponse,
+	w io.Writer,
+	statusResp serverpb.DecommissionStatusResponse,
+	checkResp *serverpb.DecommissionPreCheckResponse,
 ) error {
 	if checkResp == nil {
-		return printDecommissionStatus(statusResp)
+		return printDecommissionStatus(w, statusResp)
 	}
 
 	reportByNodeID := make(map[roachpb.NodeID]serverpb.DecommissionPreCheckResponse_NodeCheckResult)
@@ -747,15 +831,15 @@ func printDecommissionStatusAndReadiness(
 		reportByNodeID[nodeCheckResult.NodeID] = nodeCheckResult
 	}
 
-	return sqlExecCtx.PrintQueryOutput(os.Stdout, stderr, append(decommissionNodesColumnHeaders, decommissionNodesCheckAddlColumnHeaders...),
+	return sqlExecCtx.PrintQueryOutput(w, stderr, append(decommissionNodesColumnHeaders, decommissionNodesCheckAddlColumnHeaders...),
 		clisqlexec.NewRowSliceIter(
 			decommissionStatusAndReadinessValueToRows(statusResp.Status, reportByNodeID),
 			decommissionStatusAndReadinessAlignment(),
 		))
 }
 
-func printDecommissionStatus(resp serverpb.DecommissionStatusResponse) error {
-	return sqlExecCtx.PrintQueryOutput(os.Stdout, stderr, decommissionNodesColumnHeaders,
+func printDecommissionStatus(w io.Writer, resp serverpb.DecommissionStatusResponse) error {
+	return sqlExecCtx.PrintQueryOutput(w, stderr, decommissionNodesColumnHeaders,
 		clisqlexec.NewRowSliceIter(decommissionResponseValueToRows(resp.Status), decommissionStatusAlignment()))
 }
 
@@ -774,6 +858,47 @@ func printDecommissionReplicas(resp serverpb.DecommissionStatusResponse) {
 	}
 }
 
+// printDecommissionRemainingReplicas reports the replicas left on the target
+// nodes when the decommission times out.
+func printDecommissionRemainingReplicas(resp serverpb.DecommissionStatusResponse) {
+	for _, nodeStatus := range resp.Status {
+		fmt.Fprintf(stderr, "n%d: %d replicas remaining\n", nodeStatus.NodeID, nodeStatus.ReplicaCount)
+	}
+}
+
+// printDecommissionSkippedReplicas reports the replicas that are not waited
+// for with --wait=live.
+func printDecommissionSkippedReplicas(resp serverpb.DecommissionStatusResponse) {
+	for _, nodeStatus := range resp.Status {
+		if nodeStatus.SkippedReplicaCount == 0 {
+			continue
+		}
+		fmt.Fprintf(stderr,
+			"n%d: skipped %d replicas of ranges that are unavailable due to non-live nodes\n",
+			nodeStatus.NodeID,
+			nodeStatus.SkippedReplicaCount,
+		)
+	}
+}
+
+// printDecommissionSkippedRanges reports the unavailable ranges that still
+// have replicas on the target nodes with --wait=live.
+func printDecommissionSkippedRanges(resp serverpb.DecommissionStatusResponse) {
+	for _, nodeStatus := range resp.Status {
+		for _, replica := range nodeStatus.SkippedReplicas {
+			fmt.Fprintf(stderr,
+				"n%d still has replica id %d for unavailable range r%d\n",
+				nodeStatus.NodeID,
+				replica.ReplicaID,
+				replica.RangeID,
+			)
+		}
+		if n := nodeStatus.SkippedReplicaCount - int64(len(nodeStatus.SkippedReplicas)); n > 0 {
+			fmt.Fprintf(stderr, "n%d: ...and %d more\n", nodeStatus.NodeID, n)
+		}
+	}
+}
+
 func printDecommissionBlockingErrorSummary(
 	resp *serverpb.DecommissionPreCheckResponse, reportLimit int,
 ) {
@@ -805,6 +930,145 @@ func printDecommissionBlockingErrorSummary(
 	}
 }
 
+// dryRunBlockingRangesToReport is the maximum number of blocking ranges
+// reported with --checks=dry-run.
+const dryRunBlockingRangesToReport = 100
+
+// skippedRangesToReport is the maximum number of unavailable ranges reported
+// for each node when they are all that prevents a decommission with
+// --wait=live from completing.
+const skippedRangesToReport = 20
+
+// printDecommissionBlockingRanges reports each range blocking the
+// decommission, with the action the allocator determined for it.
+func printDecommissionBlockingRanges(resp *serverpb.DecommissionPreCheckResponse) {
+	fmt.Fprintln(stderr, "\nranges blocking decommission detected")
+	reported := 0
+	for _, nodeCheckResult := range resp.CheckedNodes {
+		for _, rangeCheckResult := range nodeCheckResult.CheckedRanges {
+			fmt.Fprintf(stderr,
+				"n%d: r%d (%s): %s\n",
+				nodeCheckResult.NodeID,
+				rangeCheckResult.RangeID,
+				rangeCheckResult.Action,
+				rangeCheckResult.Error,
+			)
+			reported++
+		}
+	}
+	if reported >= dryRunBlockingRangesToReport {
+		fmt.Fprintf(stderr, "...more blocking ranges may exist.\n")
+	}
+}
+
+// checksFormat is the value of --checks-format: how the ranges blocking a
+// node decommission are reported.
+type checksFormat int
+
+const (
+	// checksFormatTable reports the blocking ranges as text on stderr, after
+	// the table of the readiness of the nodes.
+	checksFormatTable checksFormat = iota
+	// checksFormatJSON writes the blocking ranges to stdout as a JSON array
+	// of decommissionBlockingRange.
+	checksFormatJSON
+	// checksFormatCSV writes the blocking ranges to stdout as CSV, with a
+	// header row.
+	checksFormatCSV
+)
+
+// checksFormats names the values of --checks-format.
+var checksFormats = enumValues[checksFormat]{
+	names: map[string]checksFormat{
+		"table": checksFormatTable,
+		"json":  checksFormatJSON,
+		"csv":   checksFormatCSV,
+	},
+}
+
+// decommissionBlockingRange is the record of a range blocking the
+// decommission of a node, as written with --checks-format=json or csv.
+type decommissionBlockingRange struct {
+	NodeID  roachpb.NodeID  `json:"node_id"`
+	RangeID roachpb.RangeID `json:"range_id"`
+	Action  string          `json:"action"`
+	// BlockingConstraints are the constraints that no store able to take a
+	// new replica of the range matched, if any.
+	BlockingConstraints string `json:"blocking_constraints"`
+	// CandidateStores are the stores found to take a new replica of the
+	// range, if any.
+	CandidateStores []roachpb.StoreID `json:"candidate_stores"`
+	Error           string            `json:"error"`
+}
+
+// decommissionBlockingRangeColumns are the columns written with
+// --checks-format=csv, in the order of the fields of
+// decommissionBlockingRange.
+var decommissionBlockingRangeColumns = []string{
+	"node_id", "range_id", "action", "blocking_constraints", "candidate_stores", "error",
+}
+
+// decommissionBlockingRanges returns the records of the ranges blocking the
+// decommission of the checked nodes, in the order of the response.
+func decommissionBlockingRanges(
+	resp *serverpb.DecommissionPreCheckResponse,
+) []decommissionBlockingRange {
+	records := []decommissionBlockingRange{}
+	for _, nodeCheckResult := range resp.CheckedNodes {
+		for _, rangeCheckResult := range nodeCheckResult.CheckedRanges {
+			records = append(records, decommissionBlockingRange{
+				NodeID:              nodeCheckResult.NodeID,
+				RangeID:             rangeCheckResult.RangeID,
+				Action:              rangeCheckResult.Action,
+				BlockingConstraints: rangeCheckResult.BlockingConstraints,
+				CandidateStores:     append([]roachpb.StoreID{}, rangeCheckResult.CandidateStoreIDs...),
+				Error:               rangeCheckResult.Error,
+			})
+		}
+	}
+	return records
+}
+
+// printDecommissionBlockingRangeRecords writes the records of the ranges
+// blocking the decommission to w, in the given format. The records are
+// written even if there are none, so that the output can always be parsed.
+func printDecommissionBlockingRangeRecords(
+	w io.Writer, format checksFormat, resp *serverpb.DecommissionPreCheckResponse,
+) error {
+	records := decommissionBlockingRanges(resp)
+	switch format {
+	case checksFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case checksFormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(decommissionBlockingRangeColumns); err != nil {
+			return err
+		}
+		for _, r := range records {
+			stores := make([]string, len(r.CandidateStores))
+			for i, storeID := range r.CandidateStores {
+				stores[i] = strconv.FormatInt(int64(storeID), 10)
+			}
+			if err := cw.Write([]string{
+				strconv.FormatInt(int64(r.NodeID), 10),
+				strconv.FormatInt(int64(r.RangeID), 10),
+				r.Action,
+				r.BlockingConstraints,
+				strings.Join(stores, " "),
+				r.Error,
+			}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return errors.AssertionFailedf("unexpected checks format: %s", checksFormats.format(format))
+	}
+}
+
 // decommissionPreCheckReady checks if, given a valid response, there are any
 // nodes shown to not be ready for decommission.
 func decommissionPreCheckReady(resp *serverpb.DecommissionPreCheckResponse) bool {
@@ -881,7 +1145,7 @@ func runRecommissionNode(cmd *cobra.Command, args []string) error {
 		}
 		return err
 	}
-	return printDecommissionStatus(*resp)
+	return printDecommissionStatus(os.Stdout, *resp)
 }
 
 var drainNodeCmd = &cobra.Command{
@@ -9,6 +9,9 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"reflect"
 	"regexp"
 	"strconv"
@@ -17,12 +20,19 @@ import (
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/build"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/liveness/livenesspb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
+	"github.com/cockroachdb/cockroach/pkg/testutils/datapathutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/skip"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/datadriven"
 	"github.com/cockroachdb/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
 )
 
 func Example_node() {
@@ -300,3 +310,303 @@ func extractFields(line string) ([]string, error) {
 	}
 	return r, nil
 }
+
+// fakeDecommissionAdminClient is a serverpb.AdminClient implementing the RPCs
+// used by runDecommissionNodeImpl. It records the requests it receives.
+type fakeDecommissionAdminClient struct {
+	serverpb.AdminClient
+	readiness serverpb.DecommissionPreCheckResponse_NodeReadiness
+	// skippedRangeIDs are the unavailable ranges that have a replica on each
+	// node once the nodes are decommissioning, with --wait=live.
+	skippedRangeIDs  []roachpb.RangeID
+	preCheckReqs     []*serverpb.DecommissionPreCheckRequest
+	statusReqs       []*serverpb.DecommissionStatusRequest
+	decommissionReqs []*serverpb.DecommissionRequest
+}
+
+// skippedStatus returns the status of a decommissioning node on which only
+// the replicas of the skipped ranges remain.
+func (c *fakeDecommissionAdminClient) skippedStatus(
+	nodeID roachpb.NodeID, numReplicaReport int32,
+) serverpb.DecommissionStatusResponse_Status {
+	status := serverpb.DecommissionStatusResponse_Status{
+		NodeID:              nodeID,
+		Membership:          livenesspb.MembershipStatus_DECOMMISSIONING,
+		SkippedReplicaCount: int64(len(c.skippedRangeIDs)),
+	}
+	for _, rangeID := range c.skippedRangeIDs {
+		if len(status.SkippedReplicas) < int(numReplicaReport) {
+			status.SkippedReplicas = append(status.SkippedReplicas,
+				&serverpb.DecommissionStatusResponse_Replica{ReplicaID: 1, RangeID: rangeID})
+		}
+	}
+	return status
+}
+
+func (c *fakeDecommissionAdminClient) DecommissionPreCheck(
+	_ context.Context, req *serverpb.DecommissionPreCheckRequest, _ ...grpc.CallOption,
+) (*serverpb.DecommissionPreCheckResponse, error) {
+	c.preCheckReqs = append(c.preCheckReqs, req)
+	var resp serverpb.DecommissionPreCheckResponse
+	for _, nodeID := range req.NodeIDs {
+		result := serverpb.DecommissionPreCheckResponse_NodeCheckResult{
+			NodeID:                nodeID,
+			DecommissionReadiness: c.readiness,
+			ReplicaCount:          1,
+		}
+		if c.readiness == serverpb.DecommissionPreCheckResponse_ALLOCATION_ERRORS {
+			result.CheckedRanges = []serverpb.DecommissionPreCheckResponse_RangeCheckResult{{
+				RangeID: 7,
+				Action:  "replace decommissioning voter",
+				Error:   "0 of 1 live stores are able to take a new replica for the range",
+			}}
+		}
+		resp.CheckedNodes = append(resp.CheckedNodes, result)
+	}
+	return &resp, nil
+}
+
+func (c *fakeDecommissionAdminClient) DecommissionStatus(
+	_ context.Context, req *serverpb.DecommissionStatusRequest, _ ...grpc.CallOption,
+) (*serverpb.DecommissionStatusResponse, error) {
+	c.statusReqs = append(c.statusReqs, req)
+	var resp serverpb.DecommissionStatusResponse
+	for _, nodeID := range req.NodeIDs {
+		if len(c.skippedRangeIDs) > 0 && req.ExcludeUnavailableRanges {
+			resp.Status = append(resp.Status, c.skippedStatus(nodeID, req.NumReplicaReport))
+			continue
+		}
+		resp.Status = append(resp.Status, serverpb.DecommissionStatusResponse_Status{
+			NodeID:       nodeID,
+			IsLive:       true,
+			ReplicaCount: 1,
+		})
+	}
+	return &resp, nil
+}
+
+func (c *fakeDecommissionAdminClient) Decommission(
+	_ context.Context, req *serverpb.DecommissionRequest, _ ...grpc.CallOption,
+) (*serverpb.DecommissionStatusResponse, error) {
+	c.decommissionReqs = append(c.decommissionReqs, req)
+	var resp serverpb.DecommissionStatusResponse
+	if len(c.skippedRangeIDs) > 0 && req.ExcludeUnavailableRanges {
+		for _, nodeID := range req.NodeIDs {
+			resp.Status = append(resp.Status, c.skippedStatus(nodeID, req.NumReplicaReport))
+		}
+	}
+	return &resp, nil
+}
+
+// TestDecommissionChecksDryRun checks that --checks=dry-run evaluates the
+// decommission readiness without changing the membership of the nodes.
+func TestDecommissionChecksDryRun(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	nodeIDs := []roachpb.NodeID{2, 3}
+	for _, readiness := range []serverpb.DecommissionPreCheckResponse_NodeReadiness{
+		serverpb.DecommissionPreCheckResponse_READY,
+		serverpb.DecommissionPreCheckResponse_ALLOCATION_ERRORS,
+	} {
+		t.Run(readiness.String(), func(t *testing.T) {
+			c := &fakeDecommissionAdminClient{readiness: readiness}
+			err := runDecommissionNodeImpl(ctx, c,
+				nodeDecommissionWaitType{mode: nodeDecommissionWaitAll}, nodeDecommissionChecksDryRun,
+				false /* dryRun */, nodeIDs, 1 /* localNodeID */)
+			if readiness == serverpb.DecommissionPreCheckResponse_READY {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, "Cannot decommission nodes.")
+			}
+
+			require.Len(t, c.preCheckReqs, 1)
+			require.False(t, c.preCheckReqs[0].StrictReadiness)
+			require.Equal(t, int32(dryRunBlockingRangesToReport), c.preCheckReqs[0].NumReplicaReport)
+			require.Empty(t, c.decommissionReqs)
+		})
+	}
+}
+
+// TestDecommissionChecksFormatOutput checks that with --checks-format=json,
+// only the records of the blocking ranges are written to stdout, including
+// when the nodes are ready and get decommissioned.
+func TestDecommissionChecksFormatOutput(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	defer initCLIDefaults()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	for _, readiness := range []serverpb.DecommissionPreCheckResponse_NodeReadiness{
+		serverpb.DecommissionPreCheckResponse_READY,
+		serverpb.DecommissionPreCheckResponse_ALLOCATION_ERRORS,
+	} {
+		t.Run(readiness.String(), func(t *testing.T) {
+			nodeCtx.nodeDecommissionChecksFormat = checksFormatJSON
+			stdoutFile, err := os.Create(filepath.Join(dir, readiness.String()+".stdout"))
+			require.NoError(t, err)
+			defer stdoutFile.Close()
+			stderrFile, err := os.Create(filepath.Join(dir, readiness.String()+".stderr"))
+			require.NoError(t, err)
+			defer stderrFile.Close()
+			defer func(prevStdout, prevStderr *os.File) {
+				os.Stdout, stderr = prevStdout, prevStderr
+			}(os.Stdout, stderr)
+			os.Stdout, stderr = stdoutFile, stderrFile
+
+			c := &fakeDecommissionAdminClient{readiness: readiness}
+			err = runDecommissionNodeImpl(ctx, c,
+				nodeDecommissionWaitType{mode: nodeDecommissionWaitAll}, nodeDecommissionChecksEnabled,
+				false /* dryRun */, []roachpb.NodeID{2}, 1 /* localNodeID */)
+
+			stdoutBytes, readErr := os.ReadFile(stdoutFile.Name())
+			require.NoError(t, readErr)
+			var records []decommissionBlockingRange
+			require.NoError(t, json.Unmarshal(stdoutBytes, &records), "stdout: %s", stdoutBytes)
+			stderrBytes, readErr := os.ReadFile(stderrFile.Name())
+			require.NoError(t, readErr)
+
+			if readiness == serverpb.DecommissionPreCheckResponse_READY {
+				require.NoError(t, err)
+				require.Empty(t, records)
+				// The nodes are marked as decommissioning, then as
+				// decommissioned.
+				require.Len(t, c.decommissionReqs, 2)
+				require.Contains(t, string(stderrBytes), "No more data reported on target nodes.")
+			} else {
+				require.EqualError(t, err, "Cannot decommission nodes.")
+				require.Len(t, records, 1)
+				require.Equal(t, roachpb.RangeID(7), records[0].RangeID)
+				require.Empty(t, c.decommissionReqs)
+				require.Contains(t, string(stderrBytes), "readiness")
+			}
+		})
+	}
+}
+
+// TestDecommissionChecksSkipped checks that the checks left out of --checks
+// are skipped by the server.
+func TestDecommissionChecksSkipped(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	testData := []struct {
+		checks        nodeDecommissionCheckMode
+		expSkipped    []string
+		expStrictness bool
+	}{
+		{nodeDecommissionChecksEnabled, nil, false},
+		{nodeDecommissionChecksStrict, nil, true},
+		{nodeDecommissionCheckConstraints | nodeDecommissionCheckAction, []string{"capacity"}, false},
+		{nodeDecommissionCheckReplaceOnly, []string{"constraints", "capacity", "action"}, true},
+	}
+	for _, td := range testData {
+		t.Run(td.checks.String(), func(t *testing.T) {
+			c := &fakeDecommissionAdminClient{readiness: serverpb.DecommissionPreCheckResponse_READY}
+			require.NoError(t, runDecommissionNodeImpl(ctx, c,
+				nodeDecommissionWaitType{mode: nodeDecommissionWaitNone}, td.checks,
+				true /* dryRun */, []roachpb.NodeID{2}, 1 /* localNodeID */))
+			require.Len(t, c.preCheckReqs, 1)
+			require.Equal(t, td.expSkipped, c.preCheckReqs[0].SkipChecks)
+			require.Equal(t, td.expStrictness, c.preCheckReqs[0].StrictReadiness)
+			require.Empty(t, c.decommissionReqs)
+		})
+	}
+}
+
+// TestDecommissionWaitLiveSkippedRanges checks that with --wait=live, nodes
+// on which only the replicas of unavailable ranges remain are left
+// decommissioning, and that the command fails.
+func TestDecommissionWaitLiveSkippedRanges(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	c := &fakeDecommissionAdminClient{skippedRangeIDs: []roachpb.RangeID{7, 12}}
+	err := runDecommissionNodeImpl(ctx, c,
+		nodeDecommissionWaitType{mode: nodeDecommissionWaitLive}, nodeDecommissionChecksSkip,
+		false /* dryRun */, []roachpb.NodeID{2, 3}, 1 /* localNodeID */)
+	require.EqualError(t, err, "4 replicas of unavailable ranges remain on the target nodes; "+
+		"the nodes are left decommissioning")
+
+	require.NotEmpty(t, c.decommissionReqs)
+	for _, req := range c.decommissionReqs {
+		require.Equal(t, livenesspb.MembershipStatus_DECOMMISSIONING, req.TargetMembership)
+		require.True(t, req.ExcludeUnavailableRanges)
+	}
+	require.Len(t, c.statusReqs, 1)
+	require.Equal(t, int32(skippedRangesToReport), c.statusReqs[0].NumReplicaReport)
+	require.True(t, c.statusReqs[0].ExcludeUnavailableRanges)
+}
+
+// TestDecommissionBlockingRangeRecords checks the records of the ranges
+// blocking a decommission written with --checks-format=json and csv against
+// a golden file, for a fabricated pre-check report.
+func TestDecommissionBlockingRangeRecords(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	r12 := serverpb.DecommissionPreCheckResponse_RangeCheckResult{
+		RangeID:           12,
+		Action:            "add voter",
+		CandidateStoreIDs: []roachpb.StoreID{4},
+		Error:             "range r12 needs repair beyond replacing/removing the decommissioning replica: add voter",
+	}
+	blocked := &serverpb.DecommissionPreCheckResponse{
+		CheckedNodes: []serverpb.DecommissionPreCheckResponse_NodeCheckResult{{
+			NodeID:                2,
+			DecommissionReadiness: serverpb.DecommissionPreCheckResponse_ALLOCATION_ERRORS,
+			ReplicaCount:          10,
+			CheckedRanges: []serverpb.DecommissionPreCheckResponse_RangeCheckResult{{
+				RangeID:             7,
+				Action:              "replace decommissioning voter",
+				BlockingConstraints: "constraints [{+region=us-east1}]; voter_constraints []",
+				Error: "0 of 2 live stores are able to take a new replica for the range " +
+					"(1 already has a voter, 0 already have a non-voter); " +
+					"replicas must match constraints [{+region=us-east1}]; " +
+					"voting replicas must match voter_constraints []",
+			}, r12},
+		}, {
+			NodeID:                3,
+			DecommissionReadiness: serverpb.DecommissionPreCheckResponse_ALLOCATION_ERRORS,
+			ReplicaCount:          5,
+			CheckedRanges:         []serverpb.DecommissionPreCheckResponse_RangeCheckResult{r12},
+		}},
+	}
+	ready := &serverpb.DecommissionPreCheckResponse{
+		CheckedNodes: []serverpb.DecommissionPreCheckResponse_NodeCheckResult{{
+			NodeID:                2,
+			DecommissionReadiness: serverpb.DecommissionPreCheckResponse_READY,
+			ReplicaCount:          10,
+		}},
+	}
+
+	// Using datadriven allows TESTFLAGS=-rewrite.
+	datadriven.RunTest(t, datapathutils.TestDataPath(t, "decommission", "blocking_ranges"),
+		func(t *testing.T, td *datadriven.TestData) string {
+			if td.Cmd != "records" {
+				t.Fatalf("unknown command: %s", td.Cmd)
+			}
+			var name string
+			td.ScanArgs(t, "format", &name)
+			format, ok := checksFormats.lookup(name)
+			require.True(t, ok, "unknown format: %s", name)
+			resp := blocked
+			if td.HasArg("ready") {
+				resp = ready
+			}
+
+			var buf bytes.Buffer
+			require.NoError(t, printDecommissionBlockingRangeRecords(&buf, format, resp))
+			if format == checksFormatJSON {
+				// The JSON must decode back to the records.
+				var records []decommissionBlockingRange
+				require.NoError(t, json.Unmarshal(buf.Bytes(), &records))
+				require.Equal(t, decommissionBlockingRanges(resp), records)
+			}
+			return buf.String()
+		})
+}
@@ -85,6 +85,10 @@ func doDrain(
 				minWait += wait
 			}
 		}
+		// A percentage given with --drain-wait is one of minWait.
+		if err := drainCtx.drainWaitValue.Resolve(minWait); err != nil {
+			return err
+		}
 		if minWait > drainCtx.drainWait {
 			fmt.Fprintf(stderr, "warning: --drain-wait is %s, but the server.shutdown.{drain,query,jobs,connection,lease_transfer}_wait "+
 				"cluster settings require a value of at least %s; using the larger value\n",
@@ -94,6 +98,10 @@ func doDrain(
 		return nil
 	}); err != nil {
 		fmt.Fprintf(stderr, "warning: could not check drain related cluster settings: %v\n", err)
+		if drainCtx.drainWaitValue.isPercent && !drainCtx.drainWaitValue.resolved {
+			fmt.Fprintf(stderr, "warning: cannot resolve --drain-wait=%s without the cluster settings; using %s\n",
+				drainCtx.drainWaitValue.origVal, drainCtx.drainWait)
+		}
 	}
 
 	err = timeutil.RunWithTimeout(ctx, "drain", drainCtx.drainWait, func(ctx context.Context) (err error) {
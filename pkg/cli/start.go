@@ -16,6 +16,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"runtime/debug"
+	"strconv"
 	"strings"
 	"time"
 
@@ -192,41 +193,20 @@ func initTempStorageConfig(
 	// target, if any. If we can't find one, we use the first StoreSpec in the
 	// list.
 	//
-	// While we look, we also clean up any abandoned temporary directories. We
-	// don't know which store spec was used previously—and it may change if
-	// encryption gets enabled after the fact—so we check each store.
-	specIdxDisk := -1
-	specIdxEncrypted := -1
-	for i, spec := range stores.Specs {
+	// We also clean up any abandoned temporary directories. We don't know
+	// which store spec was used previously—and it may change if encryption
+	// gets enabled after the fact—so we check each store.
+	for _, spec := range stores.Specs {
 		if spec.InMemory {
 			continue
 		}
-		if spec.IsEncrypted() && specIdxEncrypted == -1 {
-			// TODO(jackson): One store's EncryptionOptions may say to encrypt
-			// with a real key, while another store's say to use key=plain.
-			// This provides no guarantee that we'll use the encrypted one's.
-			specIdxEncrypted = i
-		}
-		if specIdxDisk == -1 {
-			specIdxDisk = i
-		}
 		recordPath := filepath.Join(spec.Path, server.TempDirsRecordFilename)
 		if err := fs.CleanupTempDirs(recordPath); err != nil {
 			return base.TempStorageConfig{}, errors.Wrap(err,
 				"could not cleanup temporary directories from record file")
 		}
 	}
-
-	// Use first store by default. This might be an in-memory store.
-	specIdx := 0
-	if specIdxEncrypted >= 0 {
-		// Prefer an encrypted store.
-		specIdx = specIdxEncrypted
-	} else if specIdxDisk >= 0 {
-		// Prefer a non-encrypted on-disk store.
-		specIdx = specIdxDisk
-	}
-	useStore := stores.Specs[specIdx]
+	useStore := tempStorageStore(stores)
 
 	var recordPath string
 	if !useStore.InMemory {
@@ -235,37 +215,39 @@ func initTempStorageConfig(
 
 	// The temp store size can depend on the location of the first regular store
 	// (if it's expressed as a percentage), so we resolve that flag here.
-	var tempStorePercentageResolver percentResolverFunc
 	if !useStore.InMemory {
 		dir := useStore.Path
-		// Create the store dir, if it doesn't exist. The dir is required to exist
-		// by diskPercentResolverFactory.
+		// Create the store dir, if it doesn't exist, as the temp directory is
+		// created in it by default.
 		if err := os.MkdirAll(dir, 0755); err != nil {
 			return base.TempStorageConfig{}, errors.Wrapf(err, "failed to create dir for first store: %s", dir)
 		}
-		var err error
-		tempStorePercentageResolver, err = diskPercentResolverFactory(dir)
-		if err != nil {
-			return base.TempStorageConfig{}, errors.Wrapf(err, "failed to create resolver for: %s", dir)
-		}
-	} else {
-		tempStorePercentageResolver = memoryPercentResolver
 	}
+	// The temp storage competes with the stores for the space left on the
+	// device, so the percentages are of the available space.
+	tempStorePercentageResolver, tempStoreCapacity, err := storePercentResolverFactory(
+		osFS{FS: vfs.Default}, useStore, diskAvailableCapacity, cliflags.SQLTempStorage.Name)
+	if err != nil {
+		return base.TempStorageConfig{}, errors.Wrapf(err, "failed to create resolver for: %s", useStore)
+	}
+	// The default temp storage size is different when the temp
+	// storage is in memory (which occurs when no temp directory
+	// is specified and the first store is in memory).
+	defaultTempStorageMaxSizeBytes := int64(base.DefaultTempStorageMaxSizeBytes)
+	if startCtx.tempDir == "" && useStore.InMemory {
+		defaultTempStorageMaxSizeBytes = base.DefaultInMemTempStorageMaxSizeBytes
+	}
+	startCtx.diskTempStorageSizeValue = startCtx.diskTempStorageSizeValue.withDefault(
+		strconv.FormatInt(defaultTempStorageMaxSizeBytes, 10)).
+		withCapacity(func() (redact.RedactableString, error) { return tempStoreCapacity, nil })
 	var tempStorageMaxSizeBytes int64
 	if err := startCtx.diskTempStorageSizeValue.Resolve(
 		&tempStorageMaxSizeBytes, tempStorePercentageResolver,
 	); err != nil {
 		return base.TempStorageConfig{}, err
 	}
-	if !startCtx.diskTempStorageSizeValue.IsSet() {
-		// The default temp storage size is different when the temp
-		// storage is in memory (which occurs when no temp directory
-		// is specified and the first store is in memory).
-		if startCtx.tempDir == "" && useStore.InMemory {
-			tempStorageMaxSizeBytes = base.DefaultInMemTempStorageMaxSizeBytes
-		} else {
-			tempStorageMaxSizeBytes = base.DefaultTempStorageMaxSizeBytes
-		}
+	if desc, ok := startCtx.diskTempStorageSizeValue.describePercentages(tempStoreCapacity); ok {
+		log.Ops.Infof(ctx, "--%s: %s", redact.SafeString(cliflags.SQLTempStorage.Name), desc)
 	}
 
 	// Initialize a base.TempStorageConfig based on first store's spec and
@@ -505,6 +487,14 @@ func runStartInternal(
 			if err := tryReadLocalityFileFlag(fs); err != nil {
 				return roachpb.TenantID{}, roachpb.Locality{}, err
 			}
+			if err := validateLocalityAdvertiseAddrs(
+				serverCfg.Locality, serverCfg.LocalityAddresses,
+			); err != nil {
+				if startCtx.strictLocalityAdvertiseAddr {
+					return roachpb.TenantID{}, roachpb.Locality{}, err
+				}
+				log.Ops.Warningf(ctx, "%v", err)
+			}
 			return tenantID, serverCfg.Locality, nil
 		}
 	}
@@ -514,7 +504,7 @@ func runStartInternal(
 
 	// Set the soft memory limit on the Go runtime.
 	if err = func() error {
-		if startCtx.goMemLimitValue.IsSet() {
+		if startCtx.goMemLimitValue.ExplicitlySet() {
 			if goMemLimit < 0 {
 				return errors.New("--max-go-memory must be non-negative")
 			} else if goMemLimit > 0 && goMemLimit < defaultGoMemLimitMinValue {
@@ -617,6 +607,9 @@ func runStartInternal(
 	// environment variables, which are reported too, have been read and
 	// registered.
 	reportConfiguration(ctx)
+	// The flags resolved against the machine are all resolved now that the
+	// temp storage is.
+	logFlagResolutions(ctx)
 
 	// ReadyFn will be called when the server has started listening on
 	// its network sockets, but perhaps before it has done bootstrapping
@@ -1317,6 +1310,14 @@ func hintServerCmdFlags(ctx context.Context, cmd *cobra.Command) {
 				"- for local-only servers:  --listen-addr=localhost:36257 --sql-addr=localhost:26257\n"+
 				"- for multi-node clusters: --listen-addr=:36257 --sql-addr=:26257 --advertise-addr=<host/IP addr>\n", host)
 	}
+
+	// With --strict-locality-advertise-addr, extraServerFlagInit has
+	// already refused the mismatched tiers.
+	if !startCtx.strictLocalityAdvertiseAddr {
+		if err := validateLocalityAdvertiseAddrs(serverCfg.Locality, serverCfg.LocalityAddresses); err != nil {
+			log.Ops.Shoutf(ctx, severity.WARNING, "%v\nHINT: %s", err, errors.FlattenHints(err))
+		}
+	}
 }
 
 func clientFlagsRPC() string {
@@ -1347,7 +1348,7 @@ func reportConfiguration(ctx context.Context) {
 
 func maybeWarnMemorySizes(ctx context.Context) {
 	// Is the cache configuration OK?
-	if !startCtx.cacheSizeValue.IsSet() {
+	if !startCtx.cacheSizeValue.ExplicitlySet() {
 		var buf redact.StringBuilder
 		buf.Printf("Using the default setting for --cache (%s).\n", &startCtx.cacheSizeValue)
 		buf.Printf("  A significantly larger value is usually needed for good performance.\n")
@@ -1375,6 +1376,94 @@ func maybeWarnMemorySizes(ctx context.Context) {
 	}
 }
 
+// tempStorageStore returns the store that holds the temp storage. If
+// encryption at rest is enabled in any fashion, we'll want temp storage to be
+// encrypted too, so this is the first encrypted store, if any. Otherwise, it is
+// the first on-disk store, or the first store if they are all in memory.
+func tempStorageStore(stores base.StoreSpecList) base.StoreSpec {
+	specIdxDisk := -1
+	specIdxEncrypted := -1
+	for i, spec := range stores.Specs {
+		if spec.InMemory {
+			continue
+		}
+		if spec.IsEncrypted() && specIdxEncrypted == -1 {
+			// TODO(jackson): One store's EncryptionOptions may say to encrypt
+			// with a real key, while another store's say to use key=plain.
+			// This provides no guarantee that we'll use the encrypted one's.
+			specIdxEncrypted = i
+		}
+		if specIdxDisk == -1 {
+			specIdxDisk = i
+		}
+	}
+
+	// Use first store by default. This might be an in-memory store.
+	specIdx := 0
+	if specIdxEncrypted >= 0 {
+		// Prefer an encrypted store.
+		specIdx = specIdxEncrypted
+	} else if specIdxDisk >= 0 {
+		// Prefer a non-encrypted on-disk store.
+		specIdx = specIdxDisk
+	}
+	return stores.Specs[specIdx]
+}
+
+// reportMemoryPercentages logs what the memory flags given as percentages
+// resolved to, and whether they are percentages of the physical memory or of
+// the cgroup memory limit, and of the swap space for the flags that count it.
+// The flags given as sizes are logged in IEC units, along with a warning for
+// the sizes given with SI units.
+func reportMemoryPercentages(ctx context.Context) {
+	totalMemory, source, _, err := status.GetTotalMemoryWithSource()
+	if err != nil {
+		return
+	}
+	memoryDesc := memoryCapacityDescription(source, totalMemory)
+	for _, f := range []struct {
+		name  string
+		value *bytesOrPercentageValue
+	}{
+		{cliflags.Cache.Name, &startCtx.cacheSizeValue},
+		{cliflags.SQLMem.Name, &startCtx.sqlSizeValue},
+		{cliflags.TSDBMem.Name, &startCtx.tsdbSizeValue},
+		{cliflags.GoMemLimit.Name, &startCtx.goMemLimitValue},
+	} {
+		for _, warning := range f.value.unitWarnings() {
+			log.Ops.Warningf(ctx, "--%s: %s", redact.SafeString(f.name), warning)
+		}
+		if desc, ok := f.value.describeSize(); ok {
+			log.Ops.Infof(ctx, "--%s: %s", redact.SafeString(f.name), desc)
+			continue
+		}
+		of := memoryDesc
+		if f.value.countsSwap {
+			_, swapDesc, warning, err := getMemoryAndSwap(status.GetTotalMemoryWithSource, systemSwap)
+			if err != nil {
+				continue
+			}
+			if warning != "" {
+				log.Ops.Warningf(ctx, "--%s: %s", redact.SafeString(f.name), warning)
+			}
+			of = swapDesc
+		}
+		if desc, ok := f.value.describePercentages(of); ok {
+			log.Ops.Infof(ctx, "--%s: %s", redact.SafeString(f.name), desc)
+		}
+	}
+}
+
+// logFlagResolutions logs how each flag registered with registerResolvedFlag
+// was resolved, one line per flag, e.g. "--cache: spec=25%, of=cgroup limit
+// (4.0 GiB), resolved=1.0 GiB (1073741824 bytes)", so that what the flags
+// resolved to on a node can be found in a single place.
+func logFlagResolutions(ctx context.Context) {
+	for _, r := range flagResolutions() {
+		log.Ops.Infof(ctx, "flag resolution: %s", r)
+	}
+}
+
 func exitIfDiskFull(fs vfs.FS, specs []base.StoreSpec) error {
 	var cause error
 	var ballastPaths []string
@@ -1469,6 +1558,7 @@ func setupAndInitializeLoggingAndProfiling(
 		}
 	}
 
+	reportMemoryPercentages(ctx)
 	maybeWarnMemorySizes(ctx)
 
 	// We log build information to stdout (for the short summary), but also
@@ -52,7 +52,7 @@ var debugTimeSeriesDumpOpts = struct {
 }{
 	format:       tsDumpText,
 	from:         timestampValue{},
-	to:           timestampValue(timeutil.Now().Add(24 * time.Hour)),
+	to:           timestampValue{WallTime: timeutil.Now().Add(24 * time.Hour).UnixNano()},
 	clusterLabel: "",
 	yaml:         "/tmp/tsdump.yaml",
 }
@@ -130,7 +130,7 @@ will then convert it to the --format requested in the current invocation.
 				w = makeOpenMetricsWriter(os.Stdout)
 			}
 		default:
-			return errors.Newf("unknown output format: %v", debugTimeSeriesDumpOpts.format)
+			return errors.Newf("unknown output format: %s", tsDumpFormats.format(debugTimeSeriesDumpOpts.format))
 		}
 
 		var recv func() (*tspb.TimeSeriesData, error)
@@ -148,8 +148,8 @@ will then convert it to the --format requested in the current invocation.
 				return err
 			}
 			req := &tspb.DumpRequest{
-				StartNanos: time.Time(debugTimeSeriesDumpOpts.from).UnixNano(),
-				EndNanos:   time.Time(debugTimeSeriesDumpOpts.to).UnixNano(),
+				StartNanos: debugTimeSeriesDumpOpts.from.WallTime,
+				EndNanos:   debugTimeSeriesDumpOpts.to.WallTime,
 				Names:      names,
 				Resolutions: []tspb.TimeSeriesResolution{
 					tspb.TimeSeriesResolution_RESOLUTION_30M, tspb.TimeSeriesResolution_RESOLUTION_10S,
@@ -608,54 +608,16 @@ const (
 	tsDumpDatadogInit
 )
 
-// Type implements the pflag.Value interface.
-func (m *tsDumpFormat) Type() string { return "string" }
-
-// String implements the pflag.Value interface.
-func (m *tsDumpFormat) String() string {
-	switch *m {
-	case tsDumpCSV:
-		return "csv"
-	case tsDumpTSV:
-		return "tsv"
-	case tsDumpText:
-		return "text"
-	case tsDumpRaw:
-		return "raw"
-	case tsDumpOpenMetrics:
-		return "openmetrics"
-	case tsDumpJSON:
-		return "json"
-	case tsDumpDatadog:
-		return "datadog"
-	case tsDumpDatadogInit:
-		return "datadoginit"
-	}
-	return ""
-}
-
-// Set implements the pflag.Value interface.
-func (m *tsDumpFormat) Set(s string) error {
-	switch s {
-	case "text":
-		*m = tsDumpText
-	case "csv":
-		*m = tsDumpCSV
-	case "tsv":
-		*m = tsDumpTSV
-	case "raw":
-		*m = tsDumpRaw
-	case "openmetrics":
-		*m = tsDumpOpenMetrics
-	case "json":
-		*m = tsDumpJSON
-	case "datadog":
-		*m = tsDumpDatadog
-	case "datadoginit":
-		*m = tsDumpDatadogInit
-
-	default:
-		return fmt.Errorf("invalid value for --format: %s", s)
-	}
-	return nil
+// tsDumpFormats names the values of --format.
+var tsDumpFormats = enumValues[tsDumpFormat]{
+	names: map[string]tsDumpFormat{
+		"text":        tsDumpText,
+		"csv":         tsDumpCSV,
+		"tsv":         tsDumpTSV,
+		"raw":         tsDumpRaw,
+		"openmetrics": tsDumpOpenMetrics,
+		"json":        tsDumpJSON,
+		"datadog":     tsDumpDatadog,
+		"datadoginit": tsDumpDatadogInit,
+	},
 }
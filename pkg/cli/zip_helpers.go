@@ -12,11 +12,13 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/errors"
@@ -271,13 +273,13 @@ func (fs *fileSelection) isIncluded(filename string, ctime, mtime time.Time) boo
 		return false
 	}
 	// Then its mtime must not be before the selected "from" time.
-	if mtime.Before(time.Time(fs.startTimestamp)) {
+	if mtime.Before(fs.startTimestamp.goTime()) {
 		return false
 	}
 	// And the selected "until" time must not be before the ctime.
 	// Note: the inverted call is because `Before` uses strict
 	// inequality.
-	if (*time.Time)(&fs.endTimestamp).Before(ctime) {
+	if fs.endTimestamp.goTime().Before(ctime) {
 		return false
 	}
 	return true
@@ -520,39 +522,103 @@ func (z *zipReporter) result(err error) error {
 	return z.fail(err)
 }
 
-// timestampValue is a wrapper around time.Time which supports the
-// pflag.Value interface and can be initialized from a command line flag.
-// It recognizes the following input formats:
+// timestampValue is a timestamp which supports the pflag.Value interface and
+// can be initialized from a command line flag. It recognizes the following
+// input formats:
 //
-//	YYYY-MM-DD
-//	YYYY-MM-DD HH:MM
-//	YYYY-MM-DD HH:MM:SS
-type timestampValue time.Time
+//	YYYY-MM-DD [HH:MM[:SS]]  in UTC; a date alone is midnight UTC
+//	2024-04-05T12:30:00Z     RFC3339, with optional fractional seconds; UTC
+//	                         if the time zone is omitted
+//	-1h30m                   an offset from the current time
+//	1712345678.000000001,0   an HLC timestamp, as printed by hlc.Timestamp
+//
+// The offsets are resolved against the current time when the flag is parsed.
+type timestampValue hlc.Timestamp
+
+// timestampFormats describes the formats accepted by timestampValue in
+// errors.
+const timestampFormats = "use a date and time in UTC, e.g. 2024-04-05 12:30:00, " +
+	"an RFC3339 timestamp, e.g. 2024-04-05T12:30:00Z, " +
+	"an offset from the current time, e.g. -1h30m, " +
+	"or an HLC timestamp, e.g. 1712345678.000000001,0"
+
+// hlcTimestampRE matches the timestamps printed by hlc.Timestamp, which
+// hlc.ParseTimestamp parses. The fractional part is checked separately, so
+// that the error says why it is invalid.
+var hlcTimestampRE = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?(,[0-9]+)?$`)
+
+// timestampLayouts are the layouts of the timestamps that are neither
+// offsets nor HLC timestamps, tried in order. The layouts without a time
+// zone are in UTC.
+var timestampLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04",
+	"2006-01-02 15:04:05",
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+}
 
 // Type implements the pflag.Value interface.
 func (t *timestampValue) Type() string {
-	return "YYYY-MM-DD [HH:MM[:SS]]"
+	return "timestamp"
 }
 
+// String implements the pflag.Value interface. A timestamp without a logical
+// component is rendered as a date and time in UTC, and otherwise as an HLC
+// timestamp. Both are accepted by Set.
 func (t *timestampValue) String() string {
-	return (*time.Time)(t).Format("2006-01-02 15:04:05")
+	if t.Logical != 0 {
+		return hlc.Timestamp(*t).String()
+	}
+	return t.goTime().UTC().Format("2006-01-02 15:04:05.999999999")
 }
 
 // Set implements the pflag.Value interface.
 func (t *timestampValue) Set(v string) error {
-	v = strings.TrimSpace(v)
-	var tm time.Time
-	var err error
-	if len(v) <= len("YYYY-MM-DD") {
-		tm, err = time.ParseInLocation("2006-01-02", v, time.UTC)
-	} else if len(v) <= len("YYYY-MM-DD HH:MM") {
-		tm, err = time.ParseInLocation("2006-01-02 15:04", v, time.UTC)
-	} else {
-		tm, err = time.ParseInLocation("2006-01-02 15:04:05", v, time.UTC)
-	}
+	ts, err := parseTimestamp(v, timeutil.Now())
 	if err != nil {
 		return err
 	}
-	*t = timestampValue(tm)
+	*t = timestampValue(ts)
 	return nil
 }
+
+// goTime returns the wall time of t. The zero timestampValue is the zero
+// time.Time, which the users of the flags take to mean no bound.
+func (t timestampValue) goTime() time.Time {
+	if hlc.Timestamp(t).IsEmpty() {
+		return time.Time{}
+	}
+	return timeutil.Unix(0, t.WallTime)
+}
+
+// parseTimestamp parses s in one of the formats of timestampValue, resolving
+// the offsets against now.
+func parseTimestamp(s string, now time.Time) (hlc.Timestamp, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.HasPrefix(s, "-"):
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return hlc.Timestamp{}, errors.Wrapf(err, "invalid timestamp %q", s)
+		}
+		return hlc.Timestamp{WallTime: now.Add(d).UnixNano()}, nil
+	case hlcTimestampRE.MatchString(s):
+		if _, nanos, ok := strings.Cut(strings.Split(s, ",")[0], "."); ok && len(nanos) != 9 {
+			return hlc.Timestamp{}, errors.Newf(
+				"invalid timestamp %q: the fractional part of an HLC timestamp "+
+					"must be 9 digits of nanoseconds, e.g. 1712345678.000000001,0", s)
+		}
+		ts, err := hlc.ParseTimestamp(s)
+		if err != nil {
+			return hlc.Timestamp{}, errors.Wrapf(err, "invalid timestamp %q", s)
+		}
+		return ts, nil
+	}
+	for _, layout := range timestampLayouts {
+		if tm, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return hlc.Timestamp{WallTime: tm.UnixNano()}, nil
+		}
+	}
+	return hlc.Timestamp{}, errors.Newf("invalid timestamp %q; %s", s, timestampFormats)
+}
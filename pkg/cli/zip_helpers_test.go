@@ -11,6 +11,7 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 )
 
 func TestFileSelection(t *testing.T) {
@@ -140,17 +141,35 @@ func TestTimestampFlag(t *testing.T) {
 		in  string
 		exp string
 	}{
-		{"", `parsing time "" as "2006-01-02": cannot parse "" as "2006"`},
+		{"", `invalid timestamp ""; ` + timestampFormats},
 		{"2011-01-02", "2011-01-02 00:00:00"},
 		{"  2011-01-02", "2011-01-02 00:00:00"},
 		{"2011-01-02  ", "2011-01-02 00:00:00"},
-		{"2011-01-02 03", `parsing time "2011-01-02 03" as "2006-01-02 15:04": cannot parse "" as ":"`},
+		{"2011-01-02 03", `invalid timestamp "2011-01-02 03"; ` + timestampFormats},
 		{"2011-01-02 03:04", "2011-01-02 03:04:00"},
 		{"  2011-01-02 03:04", "2011-01-02 03:04:00"},
 		{"2011-01-02 03:04 ", "2011-01-02 03:04:00"},
 		{"2011-01-02 03:04:06", "2011-01-02 03:04:06"},
 		{"  2011-01-02 03:04:06", "2011-01-02 03:04:06"},
 		{"2011-01-02 03:04:06 ", "2011-01-02 03:04:06"},
+		// RFC3339, with or without fractional seconds and time zone.
+		{"2011-01-02T03:04:06Z", "2011-01-02 03:04:06"},
+		{"2011-01-02T03:04:06.123456789Z", "2011-01-02 03:04:06.123456789"},
+		{"2011-01-02T03:04:06-07:00", "2011-01-02 10:04:06"},
+		{"2011-01-02T03:04:06.5+01:00", "2011-01-02 02:04:06.5"},
+		{"2011-01-02T03:04:06", "2011-01-02 03:04:06"},
+		{"2011-01-02T03:04:06.25", "2011-01-02 03:04:06.25"},
+		// HLC timestamps. Those with a logical component are rendered as such.
+		{"1293937446.000000000,0", "2011-01-02 03:04:06"},
+		{"1293937446.000000001", "2011-01-02 03:04:06.000000001"},
+		{"1293937446", "2011-01-02 03:04:06"},
+		{"1293937446.000000001,3", "1293937446.000000001,3"},
+		{"1293937446.5,0", `invalid timestamp "1293937446.5,0": the fractional part of an HLC timestamp must be 9 digits of nanoseconds, e.g. 1712345678.000000001,0`},
+		// Partial dates are rejected.
+		{"2011-01", `invalid timestamp "2011-01"; ` + timestampFormats},
+		{"2011-1-2", `invalid timestamp "2011-1-2"; ` + timestampFormats},
+		{"2011-01-02T03", `invalid timestamp "2011-01-02T03"; ` + timestampFormats},
+		{"yesterday", `invalid timestamp "yesterday"; ` + timestampFormats},
 	}
 
 	for _, tc := range testCases {
@@ -165,3 +184,47 @@ func TestTimestampFlag(t *testing.T) {
 		}
 	}
 }
+
+func TestParseTimestamp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	nyc, err := timeutil.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		in  string
+		now time.Time
+		exp time.Time
+		err string
+	}{
+		{"-1h30m", time.Date(2024, 4, 5, 12, 0, 0, 0, time.UTC), time.Date(2024, 4, 5, 10, 30, 0, 0, time.UTC), ``},
+		{"-0s", time.Date(2024, 4, 5, 12, 0, 0, 0, time.UTC), time.Date(2024, 4, 5, 12, 0, 0, 0, time.UTC), ``},
+		{"-1.5s", time.Date(2024, 4, 5, 12, 0, 0, 0, time.UTC), time.Date(2024, 4, 5, 11, 59, 58, 5e8, time.UTC), ``},
+		// The offsets are absolute durations: across the switch to daylight
+		// saving time, 24 hours before noon is 11am the day before in local
+		// time, and 1pm across the switch back.
+		{"-24h", time.Date(2024, 3, 10, 12, 0, 0, 0, nyc), time.Date(2024, 3, 9, 11, 0, 0, 0, nyc), ``},
+		{"-24h", time.Date(2024, 11, 3, 12, 0, 0, 0, nyc), time.Date(2024, 11, 2, 13, 0, 0, 0, nyc), ``},
+		{"-1h", time.Date(2024, 3, 10, 3, 30, 0, 0, nyc), time.Date(2024, 3, 10, 1, 30, 0, 0, nyc), ``},
+		{"-1d", time.Time{}, time.Time{}, `invalid timestamp "-1d": time: unknown unit "d" in duration "-1d"`},
+		{"--1h", time.Time{}, time.Time{}, `invalid timestamp "--1h": time: invalid duration "--1h"`},
+	}
+
+	for _, tc := range testCases {
+		ts, err := parseTimestamp(tc.in, tc.now)
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("%s: expected error %q, got %v", tc.in, tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.in, err)
+			continue
+		}
+		if actual := timeutil.Unix(0, ts.WallTime); !actual.Equal(tc.exp) || ts.Logical != 0 {
+			t.Errorf("%s from %s: expected %s, got %s", tc.in, tc.now, tc.exp, ts)
+		}
+	}
+}
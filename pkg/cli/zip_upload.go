@@ -434,7 +434,7 @@ func processLogFile(
 	)
 
 	stream, err := newFileLogStream(
-		file, time.Time(debugZipUploadOpts.from), time.Time(debugZipUploadOpts.to),
+		file, debugZipUploadOpts.from.goTime(), debugZipUploadOpts.to.goTime(),
 		inputEditMode, debugZipUploadOpts.logFormat,
 	)
 	if err != nil {
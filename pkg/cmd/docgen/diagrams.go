@@ -21,6 +21,7 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/cmd/docgen/extract"
 	"github.com/cockroachdb/cockroach/pkg/util/envutil"
+	"github.com/cockroachdb/cockroach/pkg/util/flagutil"
 	"github.com/cockroachdb/errors"
 	"github.com/spf13/cobra"
 )
@@ -30,10 +31,16 @@ func init() {
 
 	// Global vars.
 	var (
-		filter      string
+		filter      = regexp.MustCompile(".*")
 		invertMatch bool
 	)
 
+	// matchesFilter returns whether the statement name is selected by
+	// --filter and --invert-match. An empty filter matches everything.
+	matchesFilter := func(name string) bool {
+		return (filter == nil || filter.MatchString(name)) != invertMatch
+	}
+
 	write := func(name string, data []byte) {
 		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
 			log.Fatal(err)
@@ -63,9 +70,7 @@ func init() {
 				return bytes.NewReader(bnf)
 			}
 
-			filterRE := regexp.MustCompile(filter)
-
-			if filterRE.MatchString(topStmt) != invertMatch {
+			if matchesFilter(topStmt) {
 				name := topStmt
 				if !quiet {
 					fmt.Println("processing", name)
@@ -82,7 +87,7 @@ func init() {
 				log.Fatal(err)
 			}
 			for _, s := range stmtSpecs {
-				if filterRE.MatchString(s.name) == invertMatch {
+				if !matchesFilter(s.name) {
 					continue
 				}
 				if !quiet {
@@ -157,7 +162,6 @@ func init() {
 				}
 			}
 
-			filterRE := regexp.MustCompile(filter)
 			stripRE := regexp.MustCompile("\n(\n| )+")
 
 			matches, err := filepath.Glob(filepath.Join(bnfDir, "*.bnf"))
@@ -181,7 +185,7 @@ func init() {
 			sem := make(chan struct{}, maxWorkers) // max number of concurrent workers
 			for _, m := range matches {
 				name := strings.TrimSuffix(filepath.Base(m), ".bnf")
-				if filterRE.MatchString(name) == invertMatch {
+				if !matchesFilter(name) {
 					continue
 				}
 				wg.Add(1)
@@ -260,7 +264,7 @@ func init() {
 		Short: "Generate diagrams.",
 	}
 
-	diagramCmd.PersistentFlags().StringVar(&filter, "filter", ".*", "Filter statement names (regular expression)")
+	diagramCmd.PersistentFlags().Var(flagutil.Regexp(&filter), "filter", "Filter statement names (regular expression; empty to match everything)")
 	diagramCmd.PersistentFlags().BoolVar(&invertMatch, "invert-match", false, "Generate everything that doesn't match the filter")
 
 	diagramCmd.AddCommand(cmdBNF, cmdSVG)
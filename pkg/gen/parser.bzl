@@ -2,9 +2,12 @@
 
 PARSER_SRCS = [
     "//pkg/sql/lexbase:keywords.go",
+    "//pkg/sql/lexbase:lookahead_keywords.go",
     "//pkg/sql/lexbase:reserved_keywords.go",
     "//pkg/sql/lexbase:tokens.go",
+    "//pkg/sql/parser:help_grammar.go",
     "//pkg/sql/parser:help_messages.go",
+    "//pkg/sql/parser:help_topics.go",
     "//pkg/sql/parser:helpmap_test.go",
     "//pkg/sql/parser:sql.go",
     "//pkg/sql/sem/tree:createtypevariety_string.go",
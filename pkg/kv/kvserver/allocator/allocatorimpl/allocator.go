@@ -389,6 +389,21 @@ type allocatorError struct {
 
 var _ errors.SafeFormatter = &allocatorError{}
 
+// errStoresThrottled marks the errors returned when the stores that could
+// take a replica are throttled.
+var errStoresThrottled = errors.New("matching stores are throttled")
+
+// IsCapacityError returns whether err was returned because the stores that
+// could take a replica are throttled or have full disks, as opposed to no
+// store satisfying the constraints of the range.
+func IsCapacityError(err error) bool {
+	if errors.Is(err, errStoresThrottled) {
+		return true
+	}
+	var ae *allocatorError
+	return errors.As(err, &ae) && ae.fullStores > 0
+}
+
 func (ae *allocatorError) Error() string {
 	return redact.Sprint(ae).StripMarkers()
 }
@@ -435,30 +450,45 @@ func (ae *allocatorError) SafeFormatError(p errors.Printer) (next error) {
 
 	var b redact.StringBuilder
 	b.Print(baseMsg)
-	b.Printf("; replicas must match constraints [")
-	for i := range ae.constraints {
-		if i > 0 {
-			b.SafeRune(' ')
-		}
-		b.SafeRune('{')
-		b.Print(ae.constraints[i])
-		b.SafeRune('}')
-	}
-	b.SafeRune(']')
+	b.Printf("; replicas must match constraints ")
+	printConstraints(&b, ae.constraints)
+	b.Printf("; voting replicas must match voter_constraints ")
+	printConstraints(&b, ae.voterConstraints)
 
-	b.Printf("; voting replicas must match voter_constraints [")
-	for i := range ae.voterConstraints {
+	p.Print(b)
+	return nil
+}
+
+// printConstraints prints the constraints as a list of conjunctions, e.g.
+// "[{+region=us-east1} {+region=us-west1}]".
+func printConstraints(b *redact.StringBuilder, constraints []roachpb.ConstraintsConjunction) {
+	b.SafeRune('[')
+	for i := range constraints {
 		if i > 0 {
 			b.SafeRune(' ')
 		}
 		b.SafeRune('{')
-		b.Print(ae.voterConstraints[i].String())
+		b.Print(constraints[i].String())
 		b.SafeRune('}')
 	}
 	b.SafeRune(']')
+}
 
-	p.Print(b)
-	return nil
+// BlockingConstraints returns the constraints and voter constraints that the
+// stores able to take a new replica had to match, if err was returned because
+// no such store was found. It returns false if err was returned for another
+// reason or if the range has no constraints.
+func BlockingConstraints(err error) (string, bool) {
+	var ae *allocatorError
+	if !errors.As(err, &ae) || (len(ae.constraints) == 0 && len(ae.voterConstraints) == 0) {
+		return "", false
+	}
+	var b redact.StringBuilder
+	b.Printf("constraints ")
+	printConstraints(&b, ae.constraints)
+	b.Printf("; voter_constraints ")
+	printConstraints(&b, ae.voterConstraints)
+	return b.RedactableString().StripMarkers(), true
 }
 
 func (*allocatorError) AllocationErrorMarker() {}
@@ -1276,9 +1306,9 @@ func (a *Allocator) AllocateTarget(
 	// When there are throttled stores that do match, we shouldn't send
 	// the replica to purgatory.
 	if len(throttled) > 0 {
-		return roachpb.ReplicationTarget{}, "", errors.Errorf(
+		return roachpb.ReplicationTarget{}, "", errors.Mark(errors.Errorf(
 			"%d matching stores are currently throttled: %v", len(throttled), throttled,
-		)
+		), errStoresThrottled)
 	}
 
 	// Count the number of live stores which have full disks, to be included in
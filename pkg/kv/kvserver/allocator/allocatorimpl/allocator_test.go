@@ -8249,6 +8249,36 @@ func TestAllocatorError(t *testing.T) {
 	}
 }
 
+// TestBlockingConstraints checks that the constraints are extracted from the
+// allocator errors that have some, even when they are wrapped.
+func TestBlockingConstraints(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	constraint := []roachpb.ConstraintsConjunction{
+		{Constraints: []roachpb.Constraint{{Value: "one", Type: roachpb.Constraint_REQUIRED}}},
+	}
+
+	testCases := []struct {
+		err      error
+		expected string
+		ok       bool
+	}{
+		{errors.New("boom"), "", false},
+		{&allocatorError{existingVoterCount: 1, aliveStores: 1}, "", false},
+		{&allocatorError{constraints: constraint, existingVoterCount: 1, aliveStores: 1},
+			"constraints [{+one}]; voter_constraints []", true},
+		{errors.Wrap(&allocatorError{voterConstraints: constraint, aliveStores: 1}, "avoid up-replicating"),
+			"constraints []; voter_constraints [{+one}]", true},
+	}
+
+	for i, testCase := range testCases {
+		constraints, ok := BlockingConstraints(testCase.err)
+		assert.Equalf(t, testCase.ok, ok, "test case: %d", i)
+		assert.Equalf(t, testCase.expected, constraints, "test case: %d", i)
+	}
+}
+
 func TestFilterBehindReplicas(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
@@ -28,6 +28,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/kvcoord"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/allocator/allocatorimpl"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/liveness"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/liveness/livenesspb"
@@ -37,6 +38,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/security/username"
 	"github.com/cockroachdb/cockroach/pkg/server/apiconstants"
 	"github.com/cockroachdb/cockroach/pkg/server/authserver"
+	"github.com/cockroachdb/cockroach/pkg/server/decommissioning"
 	"github.com/cockroachdb/cockroach/pkg/server/privchecker"
 	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
 	"github.com/cockroachdb/cockroach/pkg/server/srverrors"
@@ -2693,7 +2695,19 @@ func (s *systemAdminServer) DecommissionPreCheck(
 		}
 	}
 
-	results, err := s.server.DecommissionPreCheck(ctx, nodesToCheck, req.StrictReadiness, collectTraces, int(req.NumReplicaReport))
+	checks := decommissioning.DefaultChecks
+	if req.StrictReadiness {
+		checks |= decommissioning.CheckReplaceOnly
+	}
+	for _, name := range req.SkipChecks {
+		check, ok := decommissioning.SkippableChecks[name]
+		if !ok {
+			return nil, grpcstatus.Errorf(codes.InvalidArgument, "unknown decommission check %q", name)
+		}
+		checks &^= check
+	}
+
+	results, err := s.server.decommissionPreCheck(ctx, nodesToCheck, checks, collectTraces, int(req.NumReplicaReport))
 	if err != nil {
 		return nil, err
 	}
@@ -2709,6 +2723,12 @@ func (s *systemAdminServer) DecommissionPreCheck(
 			Events:  recordedSpansToTraceEvents(rangeWithErr.TracingSpans),
 			Error:   rangeWithErr.Err.Error(),
 		}
+		if constraints, ok := allocatorimpl.BlockingConstraints(rangeWithErr.Err); ok {
+			rangeCheckResult.BlockingConstraints = constraints
+		}
+		if rangeWithErr.Target.StoreID != 0 {
+			rangeCheckResult.CandidateStoreIDs = []roachpb.StoreID{rangeWithErr.Target.StoreID}
+		}
 
 		for _, nID := range nodesToCheck {
 			if rangeWithErr.Desc.Replicas().HasReplicaOnNode(nID) {
@@ -2794,12 +2814,34 @@ func (s *systemAdminServer) decommissionStatusHelper(
 		return false
 	}
 
+	// We use ScanNodeVitalityFromKV to avoid races in which the caller has
+	// just made an update to a liveness record but has not received this
+	// update in its local liveness instance yet. Doing a consistent read
+	// here avoids such issues.
+	//
+	// For an example, see:
+	//
+	// https://github.com/cockroachdb/cockroach/issues/73636
+	vitalityMap, err := s.nodeLiveness.ScanNodeVitalityFromKV(ctx)
+	if err != nil {
+		return nil, err
+	}
+	isLiveReplica := func(r roachpb.ReplicaDescriptor) bool {
+		l, ok := vitalityMap[r.NodeID]
+		return ok && l.IsLive(livenesspb.DecommissionCheck)
+	}
+
 	// Compute the replica counts for the target nodes only. This map doubles as
-	// a lookup table to check whether we care about a given node.
-	var replicaCounts map[roachpb.NodeID]int64
+	// a lookup table to check whether we care about a given node. The replicas
+	// of unavailable ranges are counted and reported separately if the request
+	// excludes them.
+	var replicaCounts, skippedReplicaCounts map[roachpb.NodeID]int64
+	var skippedReplicas map[roachpb.NodeID][]*serverpb.DecommissionStatusResponse_Replica
 	if err := s.db.Txn(ctx, func(ctx context.Context, txn *kv.Txn) error {
 		const pageSize = 10000
 		replicaCounts = make(map[roachpb.NodeID]int64)
+		skippedReplicaCounts = make(map[roachpb.NodeID]int64)
+		skippedReplicas = make(map[roachpb.NodeID][]*serverpb.DecommissionStatusResponse_Replica)
 		for _, nodeID := range nodeIDs {
 			replicaCounts[nodeID] = 0
 		}
@@ -2810,6 +2852,22 @@ func (s *systemAdminServer) decommissionStatusHelper(
 					if err := row.ValueProto(&rangeDesc); err != nil {
 						return errors.Wrapf(err, "%s: unable to unmarshal range descriptor", row.Key)
 					}
+					if req.ExcludeUnavailableRanges && !rangeDesc.Replicas().CanMakeProgress(isLiveReplica) {
+						for _, r := range rangeDesc.Replicas().Descriptors() {
+							if _, ok := replicaCounts[r.NodeID]; ok {
+								skippedReplicaCounts[r.NodeID]++
+								if len(skippedReplicas[r.NodeID]) < int(numReplicaReport) {
+									skippedReplicas[r.NodeID] = append(skippedReplicas[r.NodeID],
+										&serverpb.DecommissionStatusResponse_Replica{
+											ReplicaID: r.ReplicaID,
+											RangeID:   rangeDesc.RangeID,
+										},
+									)
+								}
+							}
+						}
+						continue
+					}
 					for _, r := range rangeDesc.Replicas().Descriptors() {
 						if numReplicaReport > 0 {
 							if len(replicasToReport[r.NodeID]) < int(numReplicaReport) {
@@ -2841,30 +2899,19 @@ func (s *systemAdminServer) decommissionStatusHelper(
 	}
 
 	var res serverpb.DecommissionStatusResponse
-	// We use ScanNodeVitalityFromKV to avoid races in which the caller has
-	// just made an update to a liveness record but has not received this
-	// update in its local liveness instance yet. Doing a consistent read
-	// here avoids such issues.
-	//
-	// For an example, see:
-	//
-	// https://github.com/cockroachdb/cockroach/issues/73636
-	vitalityMap, err := s.nodeLiveness.ScanNodeVitalityFromKV(ctx)
-	if err != nil {
-		return nil, err
-	}
-
 	for nodeID := range replicaCounts {
 		l, ok := vitalityMap[nodeID]
 		if !ok {
 			return nil, errors.Newf("unable to get liveness for %d", nodeID)
 		}
 		nodeResp := serverpb.DecommissionStatusResponse_Status{
-			NodeID:           nodeID,
-			ReplicaCount:     replicaCounts[nodeID],
-			Membership:       l.MembershipStatus(),
-			Draining:         l.IsDraining(),
-			ReportedReplicas: replicasToReport[nodeID],
+			NodeID:              nodeID,
+			ReplicaCount:        replicaCounts[nodeID],
+			Membership:          l.MembershipStatus(),
+			Draining:            l.IsDraining(),
+			ReportedReplicas:    replicasToReport[nodeID],
+			SkippedReplicaCount: skippedReplicaCounts[nodeID],
+			SkippedReplicas:     skippedReplicas[nodeID],
 		}
 		if l.IsLive(livenesspb.DecommissionCheck) {
 			nodeResp.IsLive = true
@@ -2907,7 +2954,11 @@ func (s *systemAdminServer) Decommission(
 		return &serverpb.DecommissionStatusResponse{}, nil
 	}
 
-	return s.DecommissionStatus(ctx, &serverpb.DecommissionStatusRequest{NodeIDs: nodeIDs, NumReplicaReport: req.NumReplicaReport})
+	return s.DecommissionStatus(ctx, &serverpb.DecommissionStatusRequest{
+		NodeIDs:                  nodeIDs,
+		NumReplicaReport:         req.NumReplicaReport,
+		ExcludeUnavailableRanges: req.ExcludeUnavailableRanges,
+	})
 }
 
 // DataDistribution returns a count of replicas on each node for each table.
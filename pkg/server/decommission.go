@@ -143,6 +143,22 @@ func (s *topLevelServer) DecommissionPreCheck(
 	strictReadiness bool,
 	collectTraces bool,
 	maxErrors int,
+) (decommissioning.PreCheckResult, error) {
+	checks := decommissioning.DefaultChecks
+	if strictReadiness {
+		checks |= decommissioning.CheckReplaceOnly
+	}
+	return s.decommissionPreCheck(ctx, nodeIDs, checks, collectTraces, maxErrors)
+}
+
+// decommissionPreCheck is like DecommissionPreCheck, but only evaluates the
+// given checks.
+func (s *topLevelServer) decommissionPreCheck(
+	ctx context.Context,
+	nodeIDs []roachpb.NodeID,
+	checks decommissioning.Checks,
+	collectTraces bool,
+	maxErrors int,
 ) (decommissioning.PreCheckResult, error) {
 	// Ensure that if collectTraces is enabled, that a maxErrors >0 is set in
 	// order to avoid unlimited memory usage.
@@ -237,12 +253,12 @@ func (s *topLevelServer) DecommissionPreCheck(
 				continue
 			}
 
-			action, _, recording, rErr := evalStore.AllocatorCheckRange(ctx, &desc, collectTraces, overrideStorePool)
+			action, target, recording, rErr := evalStore.AllocatorCheckRange(ctx, &desc, collectTraces, overrideStorePool)
 			rangesChecked += 1
 			actionCounts[action.String()] += 1
 
-			if passed, checkResult := evaluateRangeCheckResult(strictReadiness, collectTraces,
-				&desc, action, recording, rErr,
+			if passed, checkResult := evaluateRangeCheckResult(checks, collectTraces,
+				&desc, action, target, recording, rErr,
 			); !passed {
 				rangeErrors = append(rangeErrors, checkResult)
 			}
@@ -264,19 +280,21 @@ func (s *topLevelServer) DecommissionPreCheck(
 }
 
 // evaluateRangeCheckResult returns true or false if the range has passed
-// decommissioning checks (based on if we are testing strict readiness or not),
-// as well as the encapsulated range check result with errors defined as needed.
+// the given decommissioning checks, as well as the encapsulated range check
+// result with errors defined as needed.
 func evaluateRangeCheckResult(
-	strictReadiness bool,
+	checks decommissioning.Checks,
 	collectTraces bool,
 	desc *roachpb.RangeDescriptor,
 	action allocatorimpl.AllocatorAction,
+	target roachpb.ReplicationTarget,
 	recording tracingpb.Recording,
 	rErr error,
 ) (passed bool, _ decommissioning.RangeCheckResult) {
 	checkResult := decommissioning.RangeCheckResult{
 		Desc:   *desc,
 		Action: action.String(),
+		Target: target,
 		Err:    rErr,
 	}
 
@@ -285,19 +303,27 @@ func evaluateRangeCheckResult(
 	}
 
 	if rErr != nil {
-		return false, checkResult
+		check := decommissioning.CheckConstraints
+		if allocatorimpl.IsCapacityError(rErr) {
+			check = decommissioning.CheckCapacity
+		}
+		if checks&check != 0 {
+			return false, checkResult
+		}
+		checkResult.Err = nil
 	}
 
-	if action == allocatorimpl.AllocatorRangeUnavailable ||
-		action == allocatorimpl.AllocatorNoop ||
-		action == allocatorimpl.AllocatorConsiderRebalance {
+	if checks&decommissioning.CheckAction != 0 &&
+		(action == allocatorimpl.AllocatorRangeUnavailable ||
+			action == allocatorimpl.AllocatorNoop ||
+			action == allocatorimpl.AllocatorConsiderRebalance) {
 		checkResult.Err = errors.Errorf("range r%d requires unexpected allocation action: %s",
 			desc.RangeID, action,
 		)
 		return false, checkResult
 	}
 
-	if strictReadiness && !(action.Replace() || action.Remove()) {
+	if checks&decommissioning.CheckReplaceOnly != 0 && !(action.Replace() || action.Remove()) {
 		checkResult.Err = errors.Errorf(
 			"range r%d needs repair beyond replacing/removing the decommissioning replica: %s",
 			desc.RangeID, action,
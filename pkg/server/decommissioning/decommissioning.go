@@ -14,8 +14,11 @@ import (
 // and target for a single range that has an extant replica on a node targeted
 // for decommission.
 type RangeCheckResult struct {
-	Desc         roachpb.RangeDescriptor
-	Action       string
+	Desc   roachpb.RangeDescriptor
+	Action string
+	// Target is the store found by the allocator to take a new replica of the
+	// range, if any.
+	Target       roachpb.ReplicationTarget
 	TracingSpans tracingpb.Recording
 	Err          error
 }
@@ -28,3 +31,34 @@ type PreCheckResult struct {
 	ActionCounts   map[string]int
 	RangesNotReady []RangeCheckResult
 }
+
+// Checks is a set of the checks evaluated for the ranges that have a replica
+// on a node targeted for decommission.
+type Checks uint8
+
+const (
+	// CheckConstraints checks that stores satisfying the constraints of the
+	// range can take the replicas to move.
+	CheckConstraints Checks = 1 << iota
+	// CheckCapacity checks that the stores that could take the replicas to
+	// move are neither throttled nor full.
+	CheckCapacity
+	// CheckAction checks that the range needs an allocation action carried
+	// out by the decommission, i.e. that it is available and needs more than
+	// a rebalance.
+	CheckAction
+	// CheckReplaceOnly checks that the range needs only the replacement or
+	// removal of the replicas to move. It is the strict readiness check.
+	CheckReplaceOnly
+)
+
+// DefaultChecks are the checks evaluated unless the readiness is strict.
+const DefaultChecks = CheckConstraints | CheckCapacity | CheckAction
+
+// SkippableChecks maps the names of the checks that can be skipped with
+// DecommissionPreCheckRequest.SkipChecks to the checks.
+var SkippableChecks = map[string]Checks{
+	"constraints": CheckConstraints,
+	"capacity":    CheckCapacity,
+	"action":      CheckAction,
+}
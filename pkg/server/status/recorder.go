@@ -851,47 +851,92 @@ func GetTotalMemory(ctx context.Context) (int64, error) {
 // GetTotalMemoryWithoutLogging is the same as GetTotalMemory, but returns any warning
 // as a string instead of logging it.
 func GetTotalMemoryWithoutLogging() (int64, string, error) {
-	totalMem, err := func() (int64, error) {
-		mem := gosigar.Mem{}
-		if err := mem.Get(); err != nil {
-			return 0, err
-		}
-		if mem.Total > math.MaxInt64 {
-			return 0, fmt.Errorf("inferred memory size %s exceeds maximum supported memory size %s",
-				humanize.IBytes(mem.Total), humanize.Bytes(math.MaxInt64))
-		}
-		return int64(mem.Total), nil
-	}()
+	memory, _, warning, err := GetTotalMemoryWithSource()
+	return memory, warning, err
+}
+
+// MemorySource is where the total memory returned by GetTotalMemoryWithSource
+// comes from.
+type MemorySource int
+
+const (
+	// PhysicalMemory is the memory of the system.
+	PhysicalMemory MemorySource = iota
+	// CgroupMemoryLimit is the memory limit of the cgroup of the process.
+	CgroupMemoryLimit
+)
+
+// SafeValue implements the redact.SafeValue interface.
+func (MemorySource) SafeValue() {}
+
+func (s MemorySource) String() string {
+	if s == CgroupMemoryLimit {
+		return "cgroup limit"
+	}
+	return "physical memory"
+}
+
+// GetTotalMemoryWithSource is the same as GetTotalMemoryWithoutLogging, but
+// also returns whether the memory is the cgroups available memory or the total
+// system memory.
+func GetTotalMemoryWithSource() (int64, MemorySource, string, error) {
+	var cgroupMemoryLimit func() (int64, string, error)
+	if runtime.GOOS == "linux" {
+		cgroupMemoryLimit = cgroups.GetMemoryLimit
+	}
+	return getTotalMemory(systemMemory, cgroupMemoryLimit)
+}
+
+func systemMemory() (int64, error) {
+	mem := gosigar.Mem{}
+	if err := mem.Get(); err != nil {
+		return 0, err
+	}
+	if mem.Total > math.MaxInt64 {
+		return 0, fmt.Errorf("inferred memory size %s exceeds maximum supported memory size %s",
+			humanize.IBytes(mem.Total), humanize.Bytes(math.MaxInt64))
+	}
+	return int64(mem.Total), nil
+}
+
+// getTotalMemory returns the cgroups available memory if it is set and smaller
+// than the system memory, and the system memory otherwise. readCgroupLimit is
+// nil if the system has no cgroups.
+func getTotalMemory(
+	readSystemMemory func() (int64, error), readCgroupLimit func() (int64, string, error),
+) (int64, MemorySource, string, error) {
+	totalMem, err := readSystemMemory()
 	if err != nil {
-		return 0, "", err
+		return 0, PhysicalMemory, "", err
 	}
-	checkTotal := func(x int64, warning string) (int64, string, error) {
+	checkTotal := func(x int64, source MemorySource, warning string) (int64, MemorySource, string, error) {
 		if x <= 0 {
 			// https://github.com/elastic/gosigar/issues/72
-			return 0, warning, fmt.Errorf("inferred memory size %d is suspicious, considering invalid", x)
+			return 0, source, warning, fmt.Errorf("inferred memory size %d is suspicious, considering invalid", x)
 		}
-		return x, warning, nil
+		return x, source, warning, nil
 	}
-	if runtime.GOOS != "linux" {
-		return checkTotal(totalMem, "")
+	if readCgroupLimit == nil {
+		return checkTotal(totalMem, PhysicalMemory, "")
 	}
-	cgAvlMem, warning, err := cgroups.GetMemoryLimit()
+	cgAvlMem, warning, err := readCgroupLimit()
 	if err != nil {
-		return checkTotal(totalMem,
+		return checkTotal(totalMem, PhysicalMemory,
 			fmt.Sprintf("available memory from cgroups is unsupported, using system memory %s instead: %v",
 				humanizeutil.IBytes(totalMem), err))
 	}
 	// Let's special case unlimited memory from cgroups to get a more accurate error message.
-	// When memory limit isn't set, cgroups returns 2^63-1 rounded to page size multiple (i.e., 4096).
-	if cgAvlMem == 0x7FFFFFFFFFFFF000 {
-		return checkTotal(totalMem,
+	// When memory limit isn't set, cgroups returns 2^63-1 rounded to page size multiple (i.e., 4096),
+	// or 2^63-1 for "max" in cgroups v2.
+	if cgAvlMem == 0x7FFFFFFFFFFFF000 || cgAvlMem == math.MaxInt64 {
+		return checkTotal(totalMem, PhysicalMemory,
 			fmt.Sprintf("available memory from cgroups (%s) is unlimited ('systemd' without MemoryMax?), using system memory %s instead: %s",
 				humanize.IBytes(uint64(cgAvlMem)), humanizeutil.IBytes(totalMem), warning))
 	}
 	if cgAvlMem == 0 || (totalMem > 0 && cgAvlMem > totalMem) {
-		return checkTotal(totalMem,
+		return checkTotal(totalMem, PhysicalMemory,
 			fmt.Sprintf("available memory from cgroups (%s) is unsupported, using system memory %s instead: %s",
 				humanize.IBytes(uint64(cgAvlMem)), humanizeutil.IBytes(totalMem), warning))
 	}
-	return checkTotal(cgAvlMem, "")
+	return checkTotal(cgAvlMem, CgroupMemoryLimit, "")
 }
@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"math"
 	"math/rand"
 	"os"
 	"reflect"
@@ -733,6 +734,46 @@ func TestMetricsRecorder(t *testing.T) {
 	recorder.mu.RUnlock()
 }
 
+func TestGetTotalMemory(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const physical = 64 << 30
+	readSystemMemory := func() (int64, error) { return physical, nil }
+	cgroupLimit := func(limit int64, err error) func() (int64, string, error) {
+		return func() (int64, string, error) { return limit, "", err }
+	}
+	testCases := []struct {
+		name            string
+		readCgroupLimit func() (int64, string, error)
+		expMemory       int64
+		expSource       MemorySource
+		expWarning      string
+	}{
+		{"no cgroups", nil, physical, PhysicalMemory, ""},
+		{"limited", cgroupLimit(4<<30, nil), 4 << 30, CgroupMemoryLimit, ""},
+		{"max", cgroupLimit(math.MaxInt64, nil), physical, PhysicalMemory, "is unlimited"},
+		{"unlimited", cgroupLimit(0x7FFFFFFFFFFFF000, nil), physical, PhysicalMemory, "is unlimited"},
+		{"above physical memory", cgroupLimit(128<<30, nil), physical, PhysicalMemory, "is unsupported"},
+		{"no memory controller", cgroupLimit(0, nil), physical, PhysicalMemory, "is unsupported"},
+		{"absent", cgroupLimit(0, &os.PathError{
+			Op: "open", Path: "/sys/fs/cgroup/memory.max", Err: os.ErrNotExist,
+		}), physical, PhysicalMemory, "file does not exist"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			memory, source, warning, err := getTotalMemory(readSystemMemory, tc.readCgroupLimit)
+			require.NoError(t, err)
+			require.Equal(t, tc.expMemory, memory)
+			require.Equal(t, tc.expSource, source)
+			if tc.expWarning == "" {
+				require.Empty(t, warning)
+			} else {
+				require.Contains(t, warning, tc.expWarning)
+			}
+		})
+	}
+}
+
 func BenchmarkExtractValueAllocs(b *testing.B) {
 	// Create a dummy histogram.
 	h := metric.NewHistogram(metric.HistogramOptions{
@@ -837,10 +837,11 @@ func (ex *connExecutor) execStmtInOpenState(
 				// "PREPARE ... AS",
 				// TODO(radu): it would be nice if the parser would figure out this
 				// string and store it in tree.Prepare.
-				SQL:             tree.AsStringWithFlags(s.Statement, tree.FmtParsable),
-				AST:             s.Statement,
-				NumPlaceholders: stmt.NumPlaceholders,
-				NumAnnotations:  stmt.NumAnnotations,
+				SQL:              tree.AsStringWithFlags(s.Statement, tree.FmtParsable),
+				AST:              s.Statement,
+				NumPlaceholders:  stmt.NumPlaceholders,
+				UsedPlaceholders: stmt.UsedPlaceholders,
+				NumAnnotations:   stmt.NumAnnotations,
 			},
 			ex.server.cfg.GenerateID(),
 			tree.FmtFlags(queryFormattingForFingerprintsMask.Get(&ex.server.cfg.Settings.SV)),
@@ -1862,10 +1863,11 @@ func (ex *connExecutor) execStmtInOpenStateWithPausablePortal(
 				// "PREPARE ... AS",
 				// TODO(radu): it would be nice if the parser would figure out this
 				// string and store it in tree.Prepare.
-				SQL:             tree.AsStringWithFlags(s.Statement, tree.FmtParsable),
-				AST:             s.Statement,
-				NumPlaceholders: vars.stmt.NumPlaceholders,
-				NumAnnotations:  vars.stmt.NumAnnotations,
+				SQL:              tree.AsStringWithFlags(s.Statement, tree.FmtParsable),
+				AST:              s.Statement,
+				NumPlaceholders:  vars.stmt.NumPlaceholders,
+				UsedPlaceholders: vars.stmt.UsedPlaceholders,
+				NumAnnotations:   vars.stmt.NumAnnotations,
 			},
 			ex.server.cfg.GenerateID(),
 			tree.FmtFlags(queryFormattingForFingerprintsMask.Get(&ex.server.cfg.Settings.SV)),
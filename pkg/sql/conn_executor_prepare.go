@@ -9,6 +9,7 @@ import (
 	"context"
 
 	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgwirebase"
@@ -414,9 +415,10 @@ func (ex *connExecutor) execBind(
 	qargs := make(tree.QueryArguments, numQArgs)
 	if bindCmd.internalArgs != nil {
 		if len(bindCmd.internalArgs) != int(numQArgs) {
-			return retErr(
+			return retErr(withMissingArgContext(
 				pgwirebase.NewProtocolViolationErrorf(
-					"expected %d arguments, got %d", numQArgs, len(bindCmd.internalArgs)))
+					"expected %d arguments, got %d", numQArgs, len(bindCmd.internalArgs)),
+				ps, len(bindCmd.internalArgs)))
 		}
 		for i, datum := range bindCmd.internalArgs {
 			t := ps.InferredTypes[i]
@@ -453,9 +455,10 @@ func (ex *connExecutor) execBind(
 		}
 
 		if len(bindCmd.Args) != int(numQArgs) {
-			return retErr(
+			return retErr(withMissingArgContext(
 				pgwirebase.NewProtocolViolationErrorf(
-					"bind message supplies %d parameters, but requires %d", len(bindCmd.Args), numQArgs))
+					"bind message supplies %d parameters, but requires %d", len(bindCmd.Args), numQArgs),
+				ps, len(bindCmd.Args)))
 		}
 
 		resolve := func(ctx context.Context, txn *kv.Txn) (err error) {
@@ -548,6 +551,16 @@ func (ex *connExecutor) execBind(
 	return nil, nil
 }
 
+// withMissingArgContext annotates an error about the number of arguments
+// bound to a prepared statement with the first occurrence of the first
+// placeholder that is left without an argument, if any.
+func withMissingArgContext(err error, ps *PreparedStatement, numArgs int) error {
+	if numArgs >= len(ps.InferredTypes) {
+		return err
+	}
+	return parser.WithPlaceholderContext(err, ps.Statement, tree.PlaceholderIdx(numArgs))
+}
+
 // addPortal creates a new PreparedPortal on the connExecutor.
 //
 // It is illegal to call this when a portal with that name already exists (even
@@ -32,31 +32,16 @@ func IsHexDigit(ch int) bool {
 		(ch >= 'A' && ch <= 'F')
 }
 
-// reservedOrLookaheadKeywords are the reserved keywords plus those keywords for
-// which we need one token of lookahead extra to determine their token type.
+// reservedOrLookaheadKeywords are the reserved keywords plus
+// LookaheadKeywords, which is generated from the lookahead rules of the
+// parser.
 var reservedOrLookaheadKeywords = make(map[string]struct{})
 
 func init() {
 	for s := range reservedKeywords {
 		reservedOrLookaheadKeywords[s] = struct{}{}
 	}
-	for _, s := range []string{
-		"between",
-		"ilike",
-		"in",
-		"like",
-		"of",
-		"ordinality",
-		"similar",
-		"time",
-		"generated",
-		"reset",
-		"role",
-		"user",
-		"on",
-		"tenant",
-		"set",
-	} {
+	for _, s := range LookaheadKeywords {
 		reservedOrLookaheadKeywords[s] = struct{}{}
 	}
 }
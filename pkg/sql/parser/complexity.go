@@ -0,0 +1,71 @@
+// Copyright 2026 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package parser
+
+import "github.com/cockroachdb/cockroach/pkg/sql/parser/statements"
+
+// complexityParen describes an open parenthesis, for measureComplexity.
+type complexityParen struct {
+	// subquery is set if the parenthesis opens a subquery.
+	subquery bool
+	// inList is set if the parenthesis opens the list of an IN operator, in
+	// which case n is the number of elements seen so far.
+	inList bool
+	n      int
+}
+
+// measureComplexity computes the complexity of the statement with the given
+// tokens, once the statement has parsed. Only the number of tokens is exact:
+// the joins, the subquery depth and the IN list lengths are heuristics,
+// estimated from the tokens rather than from the syntax tree, so e.g. the
+// tables joined with commas in "FROM a, b" do not count as a join. See
+// statements.Complexity for the heuristics.
+func measureComplexity(tokens []sqlSymType) statements.Complexity {
+	c := statements.Complexity{Tokens: len(tokens)}
+	var buf [16]complexityParen
+	parens := buf[:0]
+	depth := 0
+	for i := range tokens {
+		switch tokens[i].id {
+		case JOIN:
+			c.Joins++
+		case '(':
+			var p complexityParen
+			if i+1 < len(tokens) {
+				switch tokens[i+1].id {
+				case SELECT, VALUES, WITH, TABLE:
+					p.subquery = true
+					depth++
+					c.SubqueryDepth = max(c.SubqueryDepth, depth)
+				}
+			}
+			if !p.subquery && i > 0 && tokens[i-1].id == IN {
+				p.inList = true
+				if i+1 < len(tokens) && tokens[i+1].id != ')' {
+					p.n = 1
+				}
+			}
+			parens = append(parens, p)
+		case ',':
+			if len(parens) > 0 && parens[len(parens)-1].inList {
+				parens[len(parens)-1].n++
+			}
+		case ')':
+			if len(parens) == 0 {
+				break
+			}
+			p := parens[len(parens)-1]
+			parens = parens[:len(parens)-1]
+			if p.subquery {
+				depth--
+			}
+			if p.inList {
+				c.MaxInListLen = max(c.MaxInListLen, p.n)
+			}
+		}
+	}
+	return c
+}
@@ -0,0 +1,175 @@
+// Copyright 2026 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package parser
+
+import "github.com/cockroachdb/errors"
+
+// dialectHint describes syntax from another SQL dialect that CockroachDB
+// does not support, and the hint to attach to the syntax errors it causes.
+type dialectHint struct {
+	// match returns true if the syntax error reported at tokens[pos] is
+	// caused by the pattern. pos may be len(tokens) if the error was
+	// reported at the end of the input.
+	match func(tokens []sqlSymType, pos int) bool
+	// hint names the CockroachDB equivalent of the pattern.
+	hint string
+}
+
+// dialectHints is the list of patterns recognized when a statement fails to
+// parse. The first matching pattern determines the hint.
+var dialectHints = []dialectHint{
+	// MySQL.
+	{
+		match: identIs("auto_increment"),
+		hint:  "use SERIAL or GENERATED BY DEFAULT AS IDENTITY instead of AUTO_INCREMENT",
+	},
+	{
+		// ENGINE = follows the closing parenthesis of CREATE [TEMP] TABLE.
+		match: func(tokens []sqlSymType, pos int) bool {
+			if !identIs("engine")(tokens, pos) || !tokenIs('=')(tokens, pos+1) ||
+				!tokenIs(')')(tokens, pos-1) || !tokenIs(CREATE)(tokens, 0) {
+				return false
+			}
+			for i := 1; i < pos; i++ {
+				if tokenIs(TABLE)(tokens, i) {
+					return true
+				}
+			}
+			return false
+		},
+		hint: "CockroachDB does not use storage engines; remove the ENGINE table option",
+	},
+	{
+		match: func(tokens []sqlSymType, pos int) bool {
+			return identIs("unsigned")(tokens, pos) && afterNumericType(tokens, pos)
+		},
+		hint: "UNSIGNED is not supported; use a CHECK (col >= 0) constraint instead",
+	},
+	{
+		// LIMIT x, y; only a single-token x is recognized.
+		match: func(tokens []sqlSymType, pos int) bool {
+			return tokenIs(',')(tokens, pos) && pos >= 2 && tokens[pos-2].id == LIMIT
+		},
+		hint: "use LIMIT y OFFSET x instead of LIMIT x, y",
+	},
+
+	// Oracle and SQL Server.
+	{
+		match: typeModifiersOf("varchar2", "nvarchar2"),
+		hint:  "use STRING or VARCHAR(n) instead of VARCHAR2(n)",
+	},
+	{
+		match: typeModifiersOf("number"),
+		hint:  "use DECIMAL(p, s) instead of NUMBER(p, s)",
+	},
+	{
+		match: typeModifiersOf("nvarchar"),
+		hint:  "use STRING instead of NVARCHAR(MAX), or VARCHAR(n) instead of NVARCHAR(n)",
+	},
+	{
+		// [name], reported either at the bracket or at the name.
+		match: func(tokens []sqlSymType, pos int) bool {
+			return (tokenIs('[')(tokens, pos) && tokenIs(IDENT)(tokens, pos+1) && tokenIs(']')(tokens, pos+2)) ||
+				(tokenIs('[')(tokens, pos-1) && tokenIs(IDENT)(tokens, pos) && tokenIs(']')(tokens, pos+1))
+		},
+		hint: "use double quotes instead of square brackets to quote identifiers",
+	},
+	{
+		// SELECT TOP n, reported at n since "top" is a valid select target.
+		match: func(tokens []sqlSymType, pos int) bool {
+			return tokenIs(SELECT)(tokens, pos-2) && identIs("top")(tokens, pos-1)
+		},
+		hint: "use LIMIT n instead of SELECT TOP n",
+	},
+	{
+		// CONNECT BY, reported at or before CONNECT, or at BY if CONNECT was
+		// taken as a table alias.
+		match: func(tokens []sqlSymType, pos int) bool {
+			for i := max(pos-1, 0); i+1 < len(tokens); i++ {
+				if identIs("connect")(tokens, i) && tokenIs(BY)(tokens, i+1) {
+					return true
+				}
+			}
+			return false
+		},
+		hint: "use a recursive common table expression (WITH RECURSIVE) instead of CONNECT BY",
+	},
+}
+
+// tokenIs returns a match function for dialectHint that matches if the
+// syntax error is reported at a token with the given id.
+func tokenIs(id int32) func([]sqlSymType, int) bool {
+	return func(tokens []sqlSymType, pos int) bool {
+		return pos >= 0 && pos < len(tokens) && tokens[pos].id == id
+	}
+}
+
+// identIs returns a match function for dialectHint that matches if the
+// syntax error is reported at an identifier with the given (lowercase)
+// name.
+func identIs(name string) func([]sqlSymType, int) bool {
+	return func(tokens []sqlSymType, pos int) bool {
+		return pos >= 0 && pos < len(tokens) && tokens[pos].id == IDENT && tokens[pos].str == name
+	}
+}
+
+// afterNumericType returns true if the token at pos follows a numeric type
+// name, possibly with type modifiers, as in "INT UNSIGNED" or "DECIMAL(10,
+// 2) UNSIGNED".
+func afterNumericType(tokens []sqlSymType, pos int) bool {
+	pos--
+	if tokenIs(')')(tokens, pos) {
+		// Skip the type modifiers.
+		for pos >= 0 && tokens[pos].id != '(' {
+			pos--
+		}
+		pos--
+	}
+	if pos < 0 {
+		return false
+	}
+	switch tokens[pos].id {
+	case SMALLINT, INT, INTEGER, BIGINT, DEC, DECIMAL, NUMERIC, REAL, FLOAT, PRECISION:
+		return true
+	case IDENT:
+		// The other integer types are identifiers, e.g. INT8 or MySQL's
+		// TINYINT.
+		switch tokens[pos].str {
+		case "tinyint", "mediumint", "int1", "int2", "int3", "int4", "int8":
+			return true
+		}
+	}
+	return false
+}
+
+// typeModifiersOf returns a match function for dialectHint that matches if
+// the syntax error is reported at an opening parenthesis following one of
+// the given (lowercase) type names, as in "VARCHAR2(10)". Without the
+// parenthesis, the type names parse as references to user-defined types.
+func typeModifiersOf(typeNames ...string) func([]sqlSymType, int) bool {
+	return func(tokens []sqlSymType, pos int) bool {
+		if !tokenIs('(')(tokens, pos) {
+			return false
+		}
+		for _, name := range typeNames {
+			if identIs(name)(tokens, pos-1) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// addDialectHint attaches a hint to the last error if the syntax error was
+// caused by one of the patterns in dialectHints.
+func (l *lexer) addDialectHint() {
+	for _, h := range dialectHints {
+		if h.match(l.tokens, l.lastPos) {
+			l.lastError = errors.WithHint(l.lastError, h.hint)
+			return
+		}
+	}
+}
@@ -14,6 +14,8 @@ package fuzz
 
 import (
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	// See above comment about why this is imported.
 	_ "github.com/cockroachdb/cockroach/pkg/sql/sem/builtins"
 )
@@ -25,3 +27,17 @@ func FuzzParse(data []byte) int {
 	}
 	return 1
 }
+
+// FuzzParseCanonicalErrors parses the input with canonical errors enabled,
+// so that the errors can be compared against those returned by PostgreSQL.
+// It panics on internal errors.
+func FuzzParseCanonicalErrors(data []byte) int {
+	_, err := parser.ParseWithOptions(string(data), parser.ParseOptions{CanonicalErrors: true})
+	if err != nil {
+		if pgerror.GetPGCode(err) == pgcode.Internal {
+			panic(parser.CanonicalError(err))
+		}
+		return 0
+	}
+	return 1
+}
@@ -11,6 +11,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"reflect"
 	"sort"
 	"strings"
 	"text/tabwriter"
@@ -31,6 +32,10 @@ type HelpMessage struct {
 	Command string
 	// Function is set if the message is about a built-in function.
 	Function string
+	// FunctionDetail, if set, is used instead of Text when the help
+	// token directly follows the function name in a call, as in
+	// "count(??". It describes the overloads grouped by class.
+	FunctionDetail string
 
 	// HelpMessageBody contains the details of the message.
 	HelpMessageBody
@@ -40,6 +45,108 @@ type HelpMessage struct {
 // recognized by the CLI code.
 const helpHintPrefix = "help:"
 
+// MessageCatalog translates the help texts and hints produced by the
+// parser. Messages are identified by stable keys, so that translations do
+// not affect the identification of errors, e.g. by telemetry.
+type MessageCatalog interface {
+	// Lookup returns the translation of the message with the given key. If
+	// ok is false, the English message is used.
+	Lookup(key string) (msg string, ok bool)
+}
+
+// The keys of the messages that can be translated by a MessageCatalog. The
+// messages of keys ending with "f" are format strings, whose translation
+// must use the same verbs.
+const (
+	// The labels of the fields of help messages.
+	MsgHelpCommand     = "help.command"
+	MsgHelpFunction    = "help.function"
+	MsgHelpDescription = "help.description"
+	MsgHelpCategory    = "help.category"
+	MsgHelpSyntax      = "help.syntax"
+	MsgHelpGrammar     = "help.grammar"
+	MsgHelpSeeAlso     = "help.see-also"
+	// MsgHelpDescriptionPrefix is followed by the command or function name
+	// to form the key of the short description of a help message.
+	MsgHelpDescriptionPrefix = "help.description."
+	// MsgHelpTextPrefix is followed by the command name to form the key of
+	// the syntax summary of a help message.
+	MsgHelpTextPrefix = "help.text."
+	// MsgTryHelpf and MsgTryHelpFunctionf are the hints of syntax errors in
+	// statements and function calls that have a help message.
+	MsgTryHelpf         = "hint.try-help"
+	MsgTryHelpFunctionf = "hint.try-help-function"
+	// MsgPurposelyUnimplementedPrefix is followed by the feature name to form
+	// the key of the hint of an error for purposely unimplemented syntax.
+	MsgPurposelyUnimplementedPrefix = "hint.purposely-unimplemented."
+)
+
+// messageCatalog is the catalog registered with SetMessageCatalog, if any.
+var messageCatalog MessageCatalog
+
+// SetMessageCatalog registers the catalog used to translate help texts and
+// hints. It must be called before any statement is parsed, e.g. from an
+// init function. A nil catalog restores the English messages.
+func SetMessageCatalog(c MessageCatalog) {
+	messageCatalog = c
+}
+
+// translate returns the translation of the message with the given key, or
+// the English message msg if there is none.
+func translate(key, msg string) string {
+	if messageCatalog != nil {
+		if t, ok := messageCatalog.Lookup(key); ok {
+			return t
+		}
+	}
+	return msg
+}
+
+// HelpResponse is the error returned by the parser when the input contains
+// a help token (??). It carries the help message, so that callers can
+// recognize help responses without inspecting the error text. Use
+// GetHelpResponse to retrieve it.
+type HelpResponse struct {
+	cause error
+	// Msg is the help message requested by the input.
+	Msg HelpMessage
+}
+
+var _ error = (*HelpResponse)(nil)
+var _ fmt.Formatter = (*HelpResponse)(nil)
+var _ errors.SafeFormatter = (*HelpResponse)(nil)
+
+func (h *HelpResponse) Error() string { return h.cause.Error() }
+func (h *HelpResponse) Cause() error  { return h.cause }
+func (h *HelpResponse) Unwrap() error { return h.cause }
+
+func (h *HelpResponse) Format(s fmt.State, verb rune) { errors.FormatError(h, s, verb) }
+
+func (h *HelpResponse) SafeFormatError(p errors.Printer) (next error) {
+	return h.cause
+}
+
+// GetHelpResponse returns the help message carried by err, if err is the
+// response to a help request.
+func GetHelpResponse(err error) (HelpMessage, bool) {
+	var h *HelpResponse
+	if errors.As(err, &h) {
+		return h.Msg, true
+	}
+	return HelpMessage{}, false
+}
+
+// withHelpResponse marks err as the response to a help request.
+//
+// The message is also attached as a hint prefixed with helpHintPrefix, and
+// callers are expected to prefix err with specialHelpErrorPrefix, for CLI
+// shells that recognize help responses by their text. Both can be removed
+// once all supported CLI shells use the "help" field reported by SHOW
+// SYNTAX instead.
+func withHelpResponse(err error, msg HelpMessage) error {
+	return &HelpResponse{cause: errors.WithHint(err, msg.String()), Msg: msg}
+}
+
 // String implements the fmt.String interface.
 func (h *HelpMessage) String() string {
 	var buf bytes.Buffer
@@ -48,26 +155,40 @@ func (h *HelpMessage) String() string {
 	return buf.String()
 }
 
-// Format prints out details about the message onto the specified output stream.
+// Format prints out details about the message onto the specified output
+// stream. The labels, the short description and the syntax summary are
+// translated by the registered MessageCatalog, if any.
 func (h *HelpMessage) Format(w io.Writer) {
+	field := func(key, label, value string) {
+		fmt.Fprintf(w, "%-13s%s\n", translate(key, label)+":", value)
+	}
+	name := h.Command
+	if name == "" {
+		name = h.Function
+	}
 	if h.Command != "" {
-		fmt.Fprintf(w, "Command:     %s\n", h.Command)
+		field(MsgHelpCommand, "Command", h.Command)
 	}
 	if h.Function != "" {
-		fmt.Fprintf(w, "Function:    %s\n", h.Function)
+		field(MsgHelpFunction, "Function", h.Function)
 	}
 	if h.ShortDescription != "" {
-		fmt.Fprintf(w, "Description: %s\n", h.ShortDescription)
+		field(MsgHelpDescription, "Description", translate(MsgHelpDescriptionPrefix+name, h.ShortDescription))
 	}
 	if h.Category != "" {
-		fmt.Fprintf(w, "Category:    %s\n", h.Category)
+		field(MsgHelpCategory, "Category", h.Category)
 	}
+	text := h.Text
 	if h.Command != "" {
-		fmt.Fprintln(w, "Syntax:")
+		fmt.Fprintf(w, "%s:\n", translate(MsgHelpSyntax, "Syntax"))
+		text = translate(MsgHelpTextPrefix+h.Command, text)
+	}
+	fmt.Fprintln(w, strings.TrimSpace(text))
+	if h.Grammar != "" {
+		fmt.Fprintf(w, "\n%s:\n%s\n", translate(MsgHelpGrammar, "Grammar"), strings.TrimRight(h.Grammar, "\n"))
 	}
-	fmt.Fprintln(w, strings.TrimSpace(h.Text))
 	if h.SeeAlso != "" {
-		fmt.Fprintf(w, "\nSee also:\n  %s\n", h.SeeAlso)
+		fmt.Fprintf(w, "\n%s:\n  %s\n", translate(MsgHelpSeeAlso, "See also"), h.SeeAlso)
 	}
 }
 
@@ -78,7 +199,7 @@ func helpWith(sqllex sqlLexer, helpText string) int {
 	scan := sqllex.(*lexer)
 	if helpText == "" {
 		scan.lastError = pgerror.WithCandidateCode(errors.New("help upon syntax error"), pgcode.Syntax)
-		scan.populateHelpMsg("help:\n" + AllHelp)
+		scan.populateHelpMsg(HelpMessage{HelpMessageBody: HelpMessageBody{Text: AllHelp}})
 		return 1
 	}
 	msg := HelpMessage{Command: helpText, HelpMessageBody: HelpMessages[helpText]}
@@ -108,6 +229,8 @@ func helpWithFunction(sqllex sqlLexer, f tree.ResolvableFunctionReference) int {
 		},
 	}
 
+	msg.FunctionDetail = functionHelpDetail(d.Name, d.Overloads)
+
 	var buf bytes.Buffer
 	w := tabwriter.NewWriter(&buf, 0, 0, 1, ' ', 0)
 
@@ -136,6 +259,116 @@ func helpWithFunction(sqllex sqlLexer, f tree.ResolvableFunctionReference) int {
 	return 1
 }
 
+// helpWidth is the width to which the descriptions in function help
+// messages are wrapped, so that they render well in the CLI pager.
+const helpWidth = 78
+
+// functionClassHeaders is the heading used for each class of overloads in
+// function help messages. The classes are listed in this order.
+var functionClassHeaders = []struct {
+	class  tree.FunctionClass
+	header string
+}{
+	{tree.NormalClass, "Ordinary overloads:"},
+	{tree.AggregateClass, "Aggregate overloads (also usable as window functions):"},
+	{tree.WindowClass, "Window overloads:"},
+	{tree.GeneratorClass, "Set-returning overloads:"},
+	{tree.SQLClass, "SQL overloads:"},
+}
+
+// functionHelpDetail describes the overloads of a built-in function,
+// grouped by class. Within a class, consecutive overloads that share a
+// description, volatility and NULL behavior are listed together under a
+// one-line summary of the description.
+func functionHelpDetail(name string, overloads []tree.Overload) string {
+	var buf strings.Builder
+	for _, c := range functionClassHeaders {
+		var last *tree.Overload
+		for i := range overloads {
+			b := &overloads[i]
+			if b.Class != c.class {
+				continue
+			}
+			if last == nil {
+				if buf.Len() > 0 {
+					buf.WriteString("\n")
+				}
+				fmt.Fprintf(&buf, "%s\n", c.header)
+			}
+			if last == nil || b.Info != last.Info || b.Volatility != last.Volatility ||
+				b.CalledOnNullInput != last.CalledOnNullInput {
+				buf.WriteString("\n")
+				if desc := firstSentence(b.Info); desc != "" {
+					writeWrapped(&buf, desc, "  ", helpWidth)
+				}
+				fmt.Fprintf(&buf, "  %s\n\n", overloadProperties(b))
+			}
+			last = b
+			simplifyRet := b.Class == tree.GeneratorClass
+			fmt.Fprintf(&buf, "    %s%s\n", name, b.Signature(simplifyRet))
+		}
+	}
+	return buf.String()
+}
+
+// overloadProperties describes the volatility and the NULL behavior of an
+// overload.
+func overloadProperties(b *tree.Overload) string {
+	props := "Volatility: " + b.Volatility.String()
+	switch {
+	case b.Class == tree.WindowClass:
+		// The NULL behavior of window functions is specific to each function
+		// and is described in its documentation.
+	case b.CalledOnNullInput:
+		props += "; called on NULL input"
+	case b.Class == tree.AggregateClass:
+		props += "; NULL inputs are skipped"
+	default:
+		props += "; returns NULL on NULL input"
+	}
+	return props
+}
+
+// firstSentence returns the first sentence of the first paragraph of a
+// function description, with its whitespace collapsed.
+func firstSentence(info string) string {
+	if i := strings.Index(info, "\n\n"); i >= 0 {
+		info = info[:i]
+	}
+	info = strings.Join(strings.Fields(info), " ")
+	if i := strings.Index(info, ". "); i >= 0 {
+		info = info[:i+1]
+	}
+	return info
+}
+
+// writeWrapped writes the words of s to buf, prefixing each line with
+// indent and wrapping lines so that they do not exceed width, unless a
+// single word is longer than that.
+func writeWrapped(buf *strings.Builder, s, indent string, width int) {
+	lineLen := 0
+	for _, word := range strings.Fields(s) {
+		switch {
+		case lineLen == 0:
+		case lineLen+1+len(word) > width:
+			buf.WriteString("\n")
+			lineLen = 0
+		default:
+			buf.WriteByte(' ')
+			lineLen++
+		}
+		if lineLen == 0 {
+			buf.WriteString(indent)
+			lineLen = len(indent)
+		}
+		buf.WriteString(word)
+		lineLen += len(word)
+	}
+	if lineLen > 0 {
+		buf.WriteString("\n")
+	}
+}
+
 func helpWithFunctionByName(sqllex sqlLexer, s string) int {
 	un := &tree.UnresolvedName{NumParts: 1, Parts: tree.NameParts{s}}
 	return helpWithFunction(sqllex, tree.ResolvableFunctionReference{FunctionReference: un})
@@ -159,7 +392,19 @@ type HelpMessageBody struct {
 	Category         string
 	ShortDescription string
 	Text             string
-	SeeAlso          string
+	// Grammar is an excerpt of the grammar of the statement, generated from
+	// sql.y. See GrammarEBNF.
+	Grammar string
+	SeeAlso string
+}
+
+// GrammarEBNF returns an excerpt of the grammar of the statement with the
+// given help key, e.g. "ALTER TABLE", in a simplified EBNF: the rule of the
+// statement and the rules it refers to directly. Long rules are elided
+// with "...". It returns an empty string if the statement has no help
+// message.
+func GrammarEBNF(stmt string) string {
+	return helpGrammars[stmt]
 }
 
 // HelpMessages is the registry of all help messages, keyed by the
@@ -188,6 +433,9 @@ var HelpMessages = func(h map[string]HelpMessageBody) map[string]HelpMessageBody
 		m.ShortDescription = strings.TrimSpace(m.ShortDescription)
 		m.Text = strings.TrimSpace(m.Text)
 		m.SeeAlso = strings.TrimSpace(m.SeeAlso)
+		if g := helpGrammars[k]; g != "" {
+			m.Grammar = g + "\nSee the full grammar at " + docs.URL("sql-grammar.html")
+		}
 
 		// If the description contains <source>, append the <source> help.
 		if strings.Contains(m.Text, "<source>") && k != "<SOURCE>" {
@@ -209,6 +457,81 @@ var HelpMessages = func(h map[string]HelpMessageBody) map[string]HelpMessageBody
 	return h
 }(helpMessages)
 
+// HelpTopicForTag returns the key in HelpMessages of the help message named
+// by the longest leading words of the given statement tag, as returned by
+// tree.Statement.StatementTag, e.g. SHOW CREATE for SHOW CREATE ALL TABLES.
+// HelpForStatement also maps the statements whose tag does not name a help
+// message, e.g. SPLIT, to the message of their family.
+func HelpTopicForTag(tag string) (string, bool) {
+	for words := tag; words != ""; {
+		if _, ok := HelpMessages[words]; ok {
+			return words, true
+		}
+		i := strings.LastIndexByte(words, ' ')
+		if i < 0 {
+			break
+		}
+		words = words[:i]
+	}
+	return "", false
+}
+
+// HelpForStatement returns the help message for the given statement, e.g.
+// to show contextual help for a statement typed by a user.
+//
+// The candidate messages are those of the documented statements of the
+// grammar that construct the AST node of the statement (see
+// statementHelpTopics, generated from sql.y). The statement tag selects
+// among them: the candidate sharing the most words with the tag is chosen,
+// e.g. ALTER VIEW for a RenameTable tagged ALTER MATERIALIZED VIEW. If no
+// candidate shares a word with the tag, the message named by the tag is
+// preferred, and otherwise the first candidate, e.g. ALTER TABLE for SPLIT.
+func HelpForStatement(stmt tree.Statement) (HelpMessage, bool) {
+	tag := stmt.StatementTag()
+	candidates := statementHelpTopics[nodeName(stmt)]
+	topic, best := "", 0
+	for _, c := range candidates {
+		if n := sharedWords(c, tag); n > best {
+			topic, best = c, n
+		}
+	}
+	if topic == "" {
+		var ok bool
+		if topic, ok = HelpTopicForTag(tag); !ok {
+			if len(candidates) == 0 {
+				return HelpMessage{}, false
+			}
+			topic = candidates[0]
+		}
+	}
+	return HelpMessage{Command: topic, HelpMessageBody: HelpMessages[topic]}, true
+}
+
+// nodeName returns the name of the type of an AST node, e.g. Split for a
+// *tree.Split.
+func nodeName(stmt tree.Statement) string {
+	t := reflect.TypeOf(stmt)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// sharedWords returns the number of words of topic that appear in tag.
+func sharedWords(topic, tag string) int {
+	tagWords := strings.Fields(tag)
+	n := 0
+	for _, w := range strings.Fields(topic) {
+		for _, tw := range tagWords {
+			if w == tw {
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
+
 // AllHelp contains an overview of all statements with help messages.
 // For example, displayed in the CLI shell with \h without additional parameters.
 var AllHelp = func(h map[string]HelpMessageBody) string {
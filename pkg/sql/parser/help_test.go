@@ -6,11 +6,15 @@
 package parser
 
 import (
+	"context"
 	"regexp"
 	"strings"
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/testutils/datapathutils"
+	"github.com/cockroachdb/datadriven"
+	"github.com/cockroachdb/errors"
 )
 
 func TestHelpMessagesDefined(t *testing.T) {
@@ -301,6 +305,13 @@ func TestContextualHelp(t *testing.T) {
 
 		{`CLOSE ??`, `CLOSE`},
 
+		{`COMMENT ON ??`, `COMMENT ON`},
+		{`COMMENT ON TABLE t ??`, `COMMENT ON`},
+		{`COMMENT ON TABLE t IS 'x' ??`, `COMMENT ON`},
+
+		{`COPY ??`, `COPY`},
+		{`COPY t ??`, `COPY`},
+
 		{`FETCH ??`, `FETCH`},
 		{`FETCH 1 ??`, `FETCH`},
 
@@ -558,6 +569,9 @@ func TestContextualHelp(t *testing.T) {
 
 		{`USE ??`, `USE`},
 
+		{`UNLISTEN ??`, `UNLISTEN`},
+		{`UNLISTEN c ??`, `UNLISTEN`},
+
 		{`RESET blah ??`, `RESET`},
 		{`RESET SESSION ??`, `RESET`},
 		{`RESET CLUSTER SETTING ??`, `RESET CLUSTER SETTING`},
@@ -652,6 +666,11 @@ func TestContextualHelp(t *testing.T) {
 			if !strings.HasPrefix(err.Error(), "help token in input") {
 				t.Fatal(err)
 			}
+			if resp, ok := GetHelpResponse(err); !ok {
+				t.Fatalf("expected a help response, got %v", err)
+			} else if resp.Command != test.key {
+				t.Errorf("expected help for %q, got %q", test.key, resp.Command)
+			}
 			pgerr := pgerror.Flatten(err)
 			help := pgerr.Hint
 			msg := HelpMessage{Command: test.key, HelpMessageBody: HelpMessages[test.key]}
@@ -663,6 +682,223 @@ func TestContextualHelp(t *testing.T) {
 	}
 }
 
+// TestHelpResponse checks that help requests, and only help requests, are
+// reported with a HelpResponse, including by SHOW SYNTAX.
+func TestHelpResponse(t *testing.T) {
+	testData := []struct {
+		input string
+		// key is the expected help message, or empty if the input is a
+		// syntax error rather than a help request.
+		key  string
+		hint string
+	}{
+		{input: `ALTER TABLE blah RENAME TO ??`, key: `ALTER TABLE`},
+		// The shortcut taken by SHOW SYNTAX for a known prefix.
+		{input: `ALTER DEFAULT PRIVILEGES ??`, key: `ALTER DEFAULT PRIVILEGES`},
+		// A syntax error in a rule with contextual help.
+		{
+			input: `ALTER DEFAULT PRIVILEGES GRANT ALL ON PROCEDURES TO foo`,
+			hint:  `try \h ALTER DEFAULT PRIVILEGES`,
+		},
+		// A "??" inside a literal or a comment is not a help request.
+		{input: `ALTER TABLE blah RENAME TO '??'`, hint: `try \h ALTER TABLE`},
+		{input: `ALTER TABLE blah RENAME TO $$??$$`, hint: `try \h ALTER TABLE`},
+		{input: `ALTER TABLE blah RENAME TO -- ??`, hint: `try \h ALTER TABLE`},
+		{input: `ALTER TABLE blah RENAME TO /* ?? */`, hint: `try \h ALTER TABLE`},
+		// A syntax error before the help token takes precedence.
+		{
+			input: `ALTER DEFAULT PRIVILEGES GRANT ALL ON PROCEDURES ??`,
+			hint:  `try \h ALTER DEFAULT PRIVILEGES`,
+		},
+	}
+	for _, test := range testData {
+		t.Run(test.input, func(t *testing.T) {
+			_, err := Parse(test.input)
+			if err == nil {
+				t.Fatalf("parser didn't trigger error")
+			}
+			resp, isHelp := GetHelpResponse(err)
+			var fields []string
+			var help, hint string
+			RunShowSyntax(context.Background(), test.input,
+				func(_ context.Context, field, msg string) {
+					fields = append(fields, field)
+					switch field {
+					case "help":
+						help = msg
+					case "hint":
+						hint = msg
+					}
+				}, nil /* reportErr */)
+			if len(fields) == 0 || fields[0] != "error" {
+				t.Errorf("expected the error to be reported first, got %v", fields)
+			}
+
+			if test.key == "" {
+				if isHelp {
+					t.Errorf("unexpected help response for syntax error %v", err)
+				}
+				if help != "" {
+					t.Errorf("unexpected help field for syntax error:\n%s", help)
+				}
+				if hint != test.hint {
+					t.Errorf("expected hint %q, got %q", test.hint, hint)
+				}
+				return
+			}
+
+			if !isHelp {
+				t.Fatalf("expected a help response, got %v", err)
+			}
+			msg := HelpMessage{Command: test.key, HelpMessageBody: HelpMessages[test.key]}
+			if resp != msg {
+				t.Errorf("expected help response:\n%+v\ngot:\n%+v", msg, resp)
+			}
+			// The legacy text payload is still present.
+			if !strings.HasPrefix(err.Error(), specialHelpErrorPrefix) {
+				t.Errorf("expected error to start with %q, got %v", specialHelpErrorPrefix, err)
+			}
+			if hint != msg.String() {
+				t.Errorf("unexpected hint: got:\n%s\nexpected:\n%s", hint, msg.String())
+			}
+			var expected strings.Builder
+			msg.Format(&expected)
+			if help != expected.String() {
+				t.Errorf("unexpected help field: got:\n%s\nexpected:\n%s", help, expected.String())
+			}
+		})
+	}
+}
+
+// TestHelpTokenInLiterals checks that a "??" inside a literal, a quoted
+// identifier or a comment does not prevent a statement from being parsed.
+func TestHelpTokenInLiterals(t *testing.T) {
+	for _, input := range []string{
+		`SELECT '??'`,
+		`SELECT e'??'`,
+		`SELECT $$??$$, $a$??$a$`,
+		`SELECT "??" FROM t`,
+		`SELECT 1 -- ??`,
+		`SELECT 1 /* ?? */`,
+		`ALTER TABLE blah RENAME TO "??"`,
+	} {
+		t.Run(input, func(t *testing.T) {
+			if _, err := Parse(input); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var fields []string
+			RunShowSyntax(context.Background(), input,
+				func(_ context.Context, field, msg string) {
+					fields = append(fields, field)
+				}, nil /* reportErr */)
+			if len(fields) != 1 || fields[0] != "sql" {
+				t.Errorf("expected the statement to be reported, got %v", fields)
+			}
+		})
+	}
+}
+
+// TestHelpResponseRequiresHelpToken checks that the lexer refuses to turn
+// an error into a help response unless the last token is a help token.
+func TestHelpResponseRequiresHelpToken(t *testing.T) {
+	testData := []struct {
+		sql     string
+		lastPos int
+		help    bool
+	}{
+		{`SELECT ??`, 1, true},
+		{`SELECT '??'`, 1, false},
+		{`SELECT 1 -- ??`, 1, false},
+	}
+	for _, d := range testData {
+		t.Run(d.sql, func(t *testing.T) {
+			s := makeSQLScanner(d.sql)
+			var tokens []sqlSymType
+			for {
+				var lval sqlSymType
+				s.Scan(&lval)
+				if lval.id == 0 {
+					break
+				}
+				tokens = append(tokens, lval)
+			}
+			var l lexer
+			l.init(d.sql, tokens, ParseOptions{})
+			l.lastPos = d.lastPos
+			l.lastError = errors.New("syntax error")
+			l.populateHelpMsg(HelpMessage{Command: "SELECT", HelpMessageBody: HelpMessages["SELECT"]})
+			if d.help {
+				if !strings.HasPrefix(l.lastError.Error(), specialHelpErrorPrefix) {
+					t.Errorf("expected a help response, got %v", l.lastError)
+				}
+				return
+			}
+			if !errors.HasAssertionFailure(l.lastError) {
+				t.Errorf("expected an assertion failure, got %v", l.lastError)
+			}
+		})
+	}
+}
+
+// testCatalog is a pseudo-localized MessageCatalog.
+type testCatalog map[string]string
+
+func (c testCatalog) Lookup(key string) (string, bool) {
+	msg, ok := c[key]
+	return msg, ok
+}
+
+func TestMessageCatalog(t *testing.T) {
+	SetMessageCatalog(testCatalog{
+		MsgHelpCommand:                           "Çömmänd",
+		MsgHelpSyntax:                            "Šÿñtäx",
+		MsgHelpDescriptionPrefix + "ALTER TABLE": "[çhäñgé thé déƒîñîtîöñ öƒ ä täblé]",
+		MsgTryHelpf:                              `[trÿ \h %s]`,
+		MsgPurposelyUnimplementedPrefix + "reindex table": "[ÇöçkröäçhÐB döéš ñöt réqüîré réîñðéxîñg.]",
+	})
+	defer SetMessageCatalog(nil)
+
+	// Help messages use the translations, and fall back to English.
+	_, err := Parse(`ALTER TABLE blah RENAME TO ??`)
+	msg, ok := GetHelpResponse(err)
+	if !ok {
+		t.Fatalf("expected a help response, got %v", err)
+	}
+	help := msg.String()
+	for _, expected := range []string{
+		"Çömmänd:     ALTER TABLE\n",
+		"Description: [çhäñgé thé déƒîñîtîöñ öƒ ä täblé]\n",
+		"Category:    DDL\n",
+		"Šÿñtäx:\n",
+	} {
+		if !strings.Contains(help, expected) {
+			t.Errorf("expected %q in help message:\n%s", expected, help)
+		}
+	}
+
+	// The hints of syntax errors are translated.
+	_, err = Parse(`ALTER TABLE blah RENAME TO 'blih'`)
+	if hints := errors.GetAllHints(err); len(hints) != 1 || hints[0] != `[trÿ \h ALTER TABLE]` {
+		t.Errorf("unexpected hints %q", hints)
+	}
+
+	// The hints of unimplemented errors are translated, but not the message.
+	_, err = Parse(`REINDEX TABLE t`)
+	if !strings.Contains(err.Error(), "unimplemented: this syntax") {
+		t.Errorf("unexpected error %v", err)
+	}
+	if hints := errors.GetAllHints(err); len(hints) != 1 || hints[0] != "[ÇöçkröäçhÐB döéš ñöt réqüîré réîñðéxîñg.]" {
+		t.Errorf("unexpected hints %q", hints)
+	}
+
+	// Without a catalog, the messages are in English.
+	SetMessageCatalog(nil)
+	_, err = Parse(`ALTER TABLE blah RENAME TO 'blih'`)
+	if hints := errors.GetAllHints(err); len(hints) != 1 || hints[0] != `try \h ALTER TABLE` {
+		t.Errorf("unexpected hints %q", hints)
+	}
+}
+
 func TestHelpKeys(t *testing.T) {
 	// This test checks that if a help key is a valid prefix for '?',
 	// then it is also present in the rendered help message.  It also
@@ -706,3 +942,86 @@ func TestNoEmptySyntaxSectionInHelpTexts(t *testing.T) {
 }
 
 var emptySyntaxRe = regexp.MustCompile(`(?s)Syntax:\s*(See also:|$)`)
+
+// TestHelpGrammar checks that every help message includes an excerpt of
+// the grammar of its statement.
+func TestHelpGrammar(t *testing.T) {
+	for key, body := range HelpMessages {
+		grammar := GrammarEBNF(key)
+		if grammar == "" {
+			t.Errorf("no grammar generated for %q", key)
+			continue
+		}
+		if !strings.HasPrefix(body.Grammar, grammar) {
+			t.Errorf("help message for %q does not include its grammar:\n%s", key, body.Grammar)
+		}
+		msg := HelpMessage{Command: key, HelpMessageBody: body}
+		if !strings.Contains(msg.String(), "\nGrammar:\n"+grammar) {
+			t.Errorf("help message for %q does not render its grammar:\n%s", key, msg.String())
+		}
+	}
+	if GrammarEBNF("NOT A STATEMENT") != "" {
+		t.Errorf("unexpected grammar for an unknown statement")
+	}
+}
+
+// TestHelpForStatement checks that the statements map to the help message of
+// their family when they do not have one of their own.
+func TestHelpForStatement(t *testing.T) {
+	testData := []struct {
+		sql   string
+		topic string
+	}{
+		{`SELECT 1`, `SELECT`},
+		{`ALTER TABLE t ADD COLUMN x INT8`, `ALTER TABLE`},
+		{`ALTER TABLE t SPLIT AT VALUES (1)`, `ALTER TABLE`},
+		{`ALTER MATERIALIZED VIEW v RENAME TO w`, `ALTER VIEW`},
+		{`SHOW CREATE ALL TABLES`, `SHOW CREATE`},
+		{`SHOW INDEXES FROM DATABASE d`, `SHOW INDEXES`},
+		{`PAUSE JOBS FOR SCHEDULES SELECT 1`, `PAUSE JOBS`},
+		{`SET application_name = 'x'`, `SET SESSION`},
+		{`COMMENT ON TABLE t IS 'x'`, `COMMENT ON`},
+		{`COPY t FROM STDIN`, `COPY`},
+		{`UPSERT INTO t VALUES (1)`, `UPSERT`},
+		{`DROP PROCEDURE p`, `DROP PROCEDURE`},
+		{`ALTER TABLE t CONFIGURE ZONE USING num_replicas = 3`, `ALTER TABLE`},
+		{`UNLISTEN c`, `UNLISTEN`},
+	}
+	for _, d := range testData {
+		t.Run(d.sql, func(t *testing.T) {
+			stmt, err := ParseOne(d.sql)
+			if err != nil {
+				t.Fatal(err)
+			}
+			msg, ok := HelpForStatement(stmt.AST)
+			if !ok {
+				t.Fatalf("no help for tag %q", stmt.AST.StatementTag())
+			}
+			if msg.Command != d.topic {
+				t.Errorf("tag %q: expected help for %q, got %q", stmt.AST.StatementTag(), d.topic, msg.Command)
+			}
+			if msg.HelpMessageBody != HelpMessages[d.topic] {
+				t.Errorf("tag %q: unexpected help message body", stmt.AST.StatementTag())
+			}
+		})
+	}
+
+	// Every statement of the parser tests has a help message.
+	datadriven.Walk(t, datapathutils.TestDataPath(t), func(t *testing.T, path string) {
+		datadriven.RunTest(t, path, func(t *testing.T, d *datadriven.TestData) string {
+			if d.Cmd != "parse" && d.Cmd != "parse-no-verify" {
+				return d.Expected
+			}
+			stmts, err := Parse(d.Input)
+			if err != nil {
+				return d.Expected
+			}
+			for _, stmt := range stmts {
+				if _, ok := HelpForStatement(stmt.AST); !ok {
+					t.Errorf("%s: no help for tag %q", d.Pos, stmt.AST.StatementTag())
+				}
+			}
+			return d.Expected
+		})
+	})
+}
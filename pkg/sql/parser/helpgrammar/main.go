@@ -0,0 +1,430 @@
+// Copyright 2026 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+// helpgrammar generates sql/parser/help_grammar.go from sql.y.
+//
+// For each statement documented with a %Help marker (see the parser's
+// README.md), it renders the grammar rule that follows the marker, and the
+// rules that it refers to directly, in a simplified EBNF. The excerpts are
+// embedded in the help messages, so that the syntax shown by \h cannot
+// drift from the grammar.
+//
+// With -topics, it generates sql/parser/help_topics.go instead, which maps
+// the AST nodes constructed by the grammar to the help messages of the
+// statements that construct them. See statementTopics.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/rsg/yacc"
+)
+
+const (
+	// maxRules is the maximum number of rules in an excerpt, including the
+	// rule of the statement itself.
+	maxRules = 6
+	// maxAlternatives is the maximum number of alternatives rendered for a
+	// single rule.
+	maxAlternatives = 8
+	// maxLineLen is the length beyond which an alternative is elided.
+	maxLineLen = 72
+	// elided marks the parts of the grammar left out of an excerpt.
+	elided = "..."
+)
+
+var (
+	helpRE   = regexp.MustCompile(`^// %Help:\s*(.*)$`)
+	ruleRE   = regexp.MustCompile(`^([a-z_0-9]+):`)
+	extendRE = regexp.MustCompile(`^\|?\s*([a-z_0-9]+)\s*// EXTEND WITH HELP:\s*(.*)$`)
+	// stmtTypeRE matches the declarations of the rules that produce
+	// statements, or the parts of statements that the rules using them
+	// complete, e.g. set_zone_config.
+	stmtTypeRE = regexp.MustCompile(`^%type\s*<(tree\.Statements?|tree\.SelectStatement|\*tree\.[A-Za-z0-9]+)>\s*(.*)$`)
+	nodeRE     = regexp.MustCompile(`&tree\.([A-Z][A-Za-z0-9]*)\{`)
+)
+
+var (
+	topics    = flag.Bool("topics", false, "generate help_topics.go instead of help_grammar.go")
+	lookahead = flag.String("lookahead", "", "path to the parser's lookahead.go, to spell the tokens produced by the lexer as their keyword")
+)
+
+func main() {
+	flag.Parse()
+	src, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		log.Fatal(err)
+	}
+	rules, err := helpRules(src)
+	if err != nil {
+		log.Fatal(err)
+	}
+	t, err := yacc.Parse("sql.y", string(src))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *topics {
+		writeTopics(statementTopics(src, t, rules))
+		return
+	}
+	prods := documentedProductions(t)
+	tokens, err := lookaheadTokens(*lookahead)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	keys := make([]string, 0, len(rules))
+	for k := range rules {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	buf.WriteString(`// Code generated by helpgrammar. DO NOT EDIT.
+// GENERATED FILE DO NOT EDIT
+
+package parser
+
+var helpGrammars = map[string]string{
+`)
+	for _, k := range keys {
+		fmt.Fprintf(&buf, "%q: %q,\n", k, excerpt(prods, tokens, rules[k]))
+	}
+	buf.WriteString("}\n")
+	writeSource(buf.Bytes())
+}
+
+// writeTopics generates help_topics.go.
+func writeTopics(topics map[string][]string) {
+	nodes := make([]string, 0, len(topics))
+	for n := range topics {
+		nodes = append(nodes, n)
+	}
+	sort.Strings(nodes)
+
+	var buf bytes.Buffer
+	buf.WriteString(`// Code generated by helpgrammar. DO NOT EDIT.
+// GENERATED FILE DO NOT EDIT
+
+package parser
+
+var statementHelpTopics = map[string][]string{
+`)
+	for _, n := range nodes {
+		fmt.Fprintf(&buf, "%q: {", n)
+		for i, k := range topics[n] {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			fmt.Fprintf(&buf, "%q", k)
+		}
+		buf.WriteString("},\n")
+	}
+	buf.WriteString("}\n")
+	writeSource(buf.Bytes())
+}
+
+// writeSource formats the generated code and writes it to stdout.
+func writeSource(src []byte) {
+	out, err := format.Source(src)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := os.Stdout.Write(out); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// helpRules returns the name of the grammar rule documented by each %Help
+// marker, i.e. the rule defined on the first line that follows the help
+// text.
+func helpRules(src []byte) (map[string]string, error) {
+	rules := make(map[string]string)
+	key := ""
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := helpRE.FindStringSubmatch(line); m != nil {
+			// The key is followed by an optional " - description".
+			key, _, _ = strings.Cut(m[1], "-")
+			key = strings.TrimSpace(key)
+			continue
+		}
+		if key == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+		if m := ruleRE.FindStringSubmatch(line); m != nil {
+			rules[key] = m[1]
+		}
+		key = ""
+	}
+	return rules, scanner.Err()
+}
+
+// statementTopics returns, for each AST node constructed by the rules that
+// produce statements, the help keys of the documented statements that can
+// construct it, in the order in which the statements appear in the grammar.
+//
+// A rule is documented by the %Help marker that precedes it or, failing
+// that, by the EXTEND WITH HELP annotation of the alternative that refers
+// to it. The other rules take the help keys of the documented rules that
+// reach them without going through another documented rule.
+func statementTopics(src []byte, t *yacc.Tree, rules map[string]string) map[string][]string {
+	documented := make(map[string]string)
+	stmtRules := make(map[string]bool)
+	for _, line := range strings.Split(string(src), "\n") {
+		if m := extendRE.FindStringSubmatch(line); m != nil {
+			documented[m[1]] = strings.TrimSpace(m[2])
+		}
+		if m := stmtTypeRE.FindStringSubmatch(line); m != nil {
+			for _, name := range strings.Fields(m[2]) {
+				stmtRules[name] = true
+			}
+		}
+	}
+	for k, rule := range rules {
+		documented[rule] = k
+	}
+	prods := make(map[string]*yacc.ProductionNode)
+	for _, p := range t.Productions {
+		prods[p.Name] = p
+	}
+
+	// order is the position in the grammar of the first rule documented by
+	// each help key.
+	order := make(map[string]yacc.Pos)
+	for rule, k := range documented {
+		p := prods[rule]
+		if p == nil {
+			continue
+		}
+		if pos, ok := order[k]; !ok || p.Pos < pos {
+			order[k] = p.Pos
+		}
+	}
+
+	reachedBy := make(map[string]map[string]bool)
+	for rule, k := range documented {
+		seen := make(map[string]bool)
+		var visit func(name string)
+		visit = func(name string) {
+			if seen[name] || prods[name] == nil {
+				return
+			}
+			seen[name] = true
+			if reachedBy[name] == nil {
+				reachedBy[name] = make(map[string]bool)
+			}
+			reachedBy[name][k] = true
+			for _, e := range prods[name].Expressions {
+				for _, item := range e.Items {
+					if _, ok := documented[item.Value]; !ok && stmtRules[item.Value] {
+						visit(item.Value)
+					}
+				}
+			}
+		}
+		visit(rule)
+	}
+
+	keysByNode := make(map[string]map[string]bool)
+	for name, p := range prods {
+		if !stmtRules[name] {
+			continue
+		}
+		keys := reachedBy[name]
+		if k, ok := documented[name]; ok {
+			keys = map[string]bool{k: true}
+		}
+		for _, e := range p.Expressions {
+			for _, m := range nodeRE.FindAllStringSubmatch(e.Command, -1) {
+				if keysByNode[m[1]] == nil {
+					keysByNode[m[1]] = make(map[string]bool)
+				}
+				for k := range keys {
+					keysByNode[m[1]][k] = true
+				}
+			}
+		}
+	}
+
+	topics := make(map[string][]string)
+	for n, keys := range keysByNode {
+		if len(keys) == 0 {
+			continue
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Slice(sorted, func(i, j int) bool {
+			if order[sorted[i]] != order[sorted[j]] {
+				return order[sorted[i]] < order[sorted[j]]
+			}
+			return sorted[i] < sorted[j]
+		})
+		topics[n] = sorted
+	}
+	return topics
+}
+
+// documentedProductions returns the alternatives of each rule of the
+// grammar, leaving out the ones that are not meant to be documented: the
+// error recovery and help rules, and the unimplemented syntax. The
+// conventions are the same as for the SQL diagrams generated by docgen.
+func documentedProductions(t *yacc.Tree) map[string][][]yacc.Item {
+	prods := make(map[string][][]yacc.Item)
+	for _, p := range t.Productions {
+		var impl [][]yacc.Item
+	exprs:
+		for _, e := range p.Expressions {
+			if strings.Contains(e.Command, "unimplemented") && !strings.Contains(e.Command, "FORCE DOC") {
+				continue
+			}
+			if strings.Contains(e.Command, "SKIP DOC") || strings.Contains(e.Command, "helpWith") {
+				continue
+			}
+			for _, item := range e.Items {
+				if item.Value == "error" || item.Value == "HELPTOKEN" {
+					continue exprs
+				}
+			}
+			impl = append(impl, e.Items)
+		}
+		prods[p.Name] = impl
+	}
+	return prods
+}
+
+// lookaheadTokens reads the lookahead rules of the lexer in the given file,
+// and returns the keyword of each token that the rules produce, e.g. INDEX
+// for INDEX_BEFORE_PAREN. The rules that keep their token are skipped.
+func lookaheadTokens(path string) (map[string]string, error) {
+	if path == "" {
+		return nil, fmt.Errorf("-lookahead is required")
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), path, nil /* src */, 0 /* mode */)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make(map[string]string)
+	ast.Inspect(f, func(n ast.Node) bool {
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok {
+			return true
+		}
+		var tok, repl string
+		for _, elt := range lit.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+			key, ok1 := kv.Key.(*ast.Ident)
+			val, ok2 := kv.Value.(*ast.Ident)
+			if !ok1 || !ok2 {
+				continue
+			}
+			switch key.Name {
+			case "token":
+				tok = val.Name
+			case "replacement":
+				repl = val.Name
+			}
+		}
+		if tok != "" && repl != "" && tok != repl {
+			tokens[repl] = tok
+		}
+		return true
+	})
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("no lookahead rules found in %s", path)
+	}
+	return tokens, nil
+}
+
+// excerpt renders the given rule and the rules it refers to directly.
+func excerpt(prods map[string][][]yacc.Item, tokens map[string]string, rule string) string {
+	names := []string{rule}
+	seen := map[string]bool{rule: true}
+	for _, items := range prods[rule] {
+		for _, item := range items {
+			if item.Typ == yacc.TypToken && !seen[item.Value] && len(prods[item.Value]) > 0 {
+				seen[item.Value] = true
+				names = append(names, item.Value)
+			}
+		}
+	}
+	var buf strings.Builder
+	for i, name := range names {
+		if i == maxRules {
+			buf.WriteString(elided + "\n")
+			break
+		}
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		renderRule(&buf, name, prods[name], tokens)
+	}
+	return buf.String()
+}
+
+// renderRule renders the alternatives of a rule, eliding the alternatives
+// beyond maxAlternatives and the end of the alternatives longer than
+// maxLineLen.
+func renderRule(
+	buf *strings.Builder, name string, alts [][]yacc.Item, tokens map[string]string,
+) {
+	fmt.Fprintf(buf, "%s ::=\n", name)
+	for i, items := range alts {
+		sep := "  "
+		if i > 0 {
+			sep = "| "
+		}
+		if i == maxAlternatives {
+			fmt.Fprintf(buf, "  %s%s\n", sep, elided)
+			return
+		}
+		fmt.Fprintf(buf, "  %s%s\n", sep, renderItems(items, tokens))
+	}
+}
+
+// renderItems renders the items of an alternative. The tokens produced by
+// the lookahead in the lexer, e.g. WITH_LA or INDEX_BEFORE_PAREN, are spelled
+// as their keyword, which tokens maps them to.
+func renderItems(items []yacc.Item, tokens map[string]string) string {
+	if len(items) == 0 {
+		return "/* empty */"
+	}
+	var buf strings.Builder
+	for i, item := range items {
+		if i > 0 {
+			buf.WriteString(" ")
+		}
+		if buf.Len()+len(item.Value) > maxLineLen {
+			buf.WriteString(elided)
+			break
+		}
+		if kw, ok := tokens[item.Value]; ok {
+			buf.WriteString(kw)
+		} else {
+			buf.WriteString(item.Value)
+		}
+	}
+	return buf.String()
+}
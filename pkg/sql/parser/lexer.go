@@ -8,13 +8,19 @@ package parser
 import (
 	"bytes"
 	"fmt"
+	"regexp"
 	"strings"
+	"unicode"
 
+	"github.com/cockroachdb/cockroach/pkg/sql/lexbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser/statements"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/scanner"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	unimp "github.com/cockroachdb/cockroach/pkg/util/errorutil/unimplemented"
+	"github.com/cockroachdb/cockroach/pkg/util/intsets"
 	"github.com/cockroachdb/errors"
 )
 
@@ -23,31 +29,203 @@ type lexer struct {
 	// tokens contains tokens generated by the scanner.
 	tokens []sqlSymType
 
-	// The type that should be used when an INT or SERIAL is encountered.
+	// The type that should be used when an INT is encountered.
 	nakedIntType *types.T
+	// The type that should be used when a SERIAL is encountered.
+	nakedSerialType *types.T
+	// canonicalErrors is set when errors should be reported in canonical
+	// form. See ParseOptions.CanonicalErrors.
+	canonicalErrors bool
 
 	// lastPos is the position into the tokens slice of the last
 	// token returned by Lex().
 	lastPos int
+	// tokenBase is the index of the first token of the statement in the
+	// token stream of the input. See statements.TokenRange.
+	tokenBase int
 
 	stmt tree.Statement
+	// tokenRange is the range of the tokens of stmt, recorded by SetStmt.
+	tokenRange statements.TokenRange
 	// numPlaceholders is 1 + the highest placeholder index encountered.
 	numPlaceholders int
-	numAnnotations  tree.AnnotationIdx
+	// usedPlaceholders is the set of the indexes of the placeholders
+	// encountered.
+	usedPlaceholders intsets.Fast
+	numAnnotations   tree.AnnotationIdx
+	// placeholderTypeHints records the casts of placeholders.
+	placeholderTypeHints map[tree.PlaceholderIdx]statements.PlaceholderTypeHint
+	// placeholderPositions records the position of the first occurrence of
+	// each placeholder.
+	placeholderPositions map[tree.PlaceholderIdx]int32
+	// annotationOverflow is set if the annotation indexes were exhausted.
+	annotationOverflow bool
+	// collectNames is set if the referenced names are to be collected in
+	// referencedNames. See ParseOptions.CollectNames.
+	collectNames    bool
+	referencedNames []statements.ReferencedName
+	// asOfSystemTimePos is the position of the first AS OF SYSTEM TIME
+	// clause, or -1 if there is none.
+	asOfSystemTimePos int32
 
 	lastError error
 }
 
-func (l *lexer) init(sql string, tokens []sqlSymType, nakedIntType *types.T) {
+func (l *lexer) init(sql string, tokens []sqlSymType, opts ParseOptions) {
 	l.in = sql
 	l.tokens = tokens
 	l.lastPos = -1
+	l.tokenBase = opts.tokenBase
 	l.stmt = nil
+	l.tokenRange = statements.TokenRange{}
 	l.numPlaceholders = 0
-	l.numAnnotations = 0
+	l.usedPlaceholders = intsets.Fast{}
+	l.placeholderTypeHints = nil
+	l.placeholderPositions = nil
+	if s := opts.scratch; s != nil {
+		// The maps are taken from the scratch, so that they are not shared
+		// by the statements of a multi-statement input.
+		l.placeholderTypeHints, s.placeholderTypeHints = s.placeholderTypeHints, nil
+		l.placeholderPositions, s.placeholderPositions = s.placeholderPositions, nil
+		clear(l.placeholderTypeHints)
+		clear(l.placeholderPositions)
+	}
+	l.numAnnotations = opts.AnnotationBase
+	l.annotationOverflow = false
 	l.lastError = nil
+	l.asOfSystemTimePos = -1
+	l.collectNames = opts.CollectNames
+	l.referencedNames = nil
+
+	l.nakedIntType = opts.nakedIntType()
+	l.nakedSerialType = opts.SerialMode.serialType(l.nakedIntType)
+	l.canonicalErrors = opts.CanonicalErrors
+}
+
+// LexTokensForTesting runs the lookahead logic of the lexer over the given
+// tokens, bypassing the scanner, and returns the resulting token IDs. This
+// allows tests and fuzzers to construct exact token adjacencies that are
+// fiddly to produce from SQL text.
+func LexTokensForTesting(tokens []TokenString) []int32 {
+	symTokens := make([]sqlSymType, len(tokens))
+	for i, t := range tokens {
+		symTokens[i] = sqlSymType{id: t.TokenID, pos: int32(i), str: t.Str}
+	}
+	var l lexer
+	l.init("" /* sql */, symTokens, ParseOptions{})
+	defer l.cleanup()
+	res := make([]int32, 0, len(tokens))
+	for {
+		var lval sqlSymType
+		id := l.Lex(&lval)
+		if id == 0 {
+			return res
+		}
+		res = append(res, int32(id))
+	}
+}
 
-	l.nakedIntType = nakedIntType
+// TokenDescription describes a token received by the grammar. See
+// DescribeTokens.
+type TokenDescription struct {
+	// ID is the id of the token as received by the grammar.
+	ID int32
+	// Name is the name of the token id, e.g. IDENT or INDEX_BEFORE_PAREN.
+	Name string
+	// Text is the token as spelled in the input.
+	Text string
+	// Pos is the byte offset of the token in the input.
+	Pos int
+	// Rewritten is set if the lookahead logic of the lexer changed the id
+	// of the token produced by the scanner.
+	Rewritten bool
+}
+
+// DescribeTokens runs the scanner over the given SQL, then replays the
+// lookahead logic of the lexer over the tokens of each statement and
+// describes the tokens that the grammar receives. This is meant for
+// debugging the lookahead rules; the result is not affected by the
+// grammar, so it is returned even for invalid statements. The description
+// stops at the first lexical error.
+func DescribeTokens(sql string) []TokenDescription {
+	var p Parser
+	p.scanner.Init(sql)
+	defer p.scanner.Cleanup()
+	var res []TokenDescription
+	for {
+		stmtStart := stmtStartPos(sql, p.scanner.Pos())
+		stmt, tokens, done := p.scanOneStmt()
+		res = appendTokenDescriptions(res, stmt, stmtStart, tokens)
+		if done {
+			return res
+		}
+	}
+}
+
+// stmtStartPos returns the position of the first token of the next
+// statement in sql, skipping semicolons like scanOneStmt does.
+func stmtStartPos(sql string, pos int) int {
+	s := makeSQLScanner(sql[pos:])
+	for {
+		var lval sqlSymType
+		s.Scan(&lval)
+		if lval.id != ';' {
+			return pos + int(lval.pos)
+		}
+	}
+}
+
+// appendTokenDescriptions appends the description of the tokens of the
+// statement stmt, which starts at position stmtStart of the input.
+func appendTokenDescriptions(
+	res []TokenDescription, stmt string, stmtStart int, tokens []sqlSymType,
+) []TokenDescription {
+	scanned := make([]int32, len(tokens))
+	for i := range tokens {
+		scanned[i] = tokens[i].id
+	}
+	var l lexer
+	l.init(stmt, tokens, ParseOptions{})
+	defer l.cleanup()
+	for i := range scanned {
+		var lval sqlSymType
+		id := int32(l.Lex(&lval))
+		res = append(res, TokenDescription{
+			ID:        id,
+			Name:      tokenName(id),
+			Text:      tokenSpelling(stmt, lval.pos),
+			Pos:       stmtStart + int(lval.pos),
+			Rewritten: id != scanned[i],
+		})
+		if id == ERROR {
+			break
+		}
+	}
+	return res
+}
+
+// tokenSpelling returns the text of the token starting at position pos in
+// sql.
+func tokenSpelling(sql string, pos int32) string {
+	s := makeSQLScanner(sql[pos:])
+	var lval sqlSymType
+	s.Scan(&lval)
+	return sql[pos : int(pos)+s.Pos()]
+}
+
+// fixedTokenLexer is a sqlLexer that always returns the same token.
+type fixedTokenLexer int32
+
+func (l fixedTokenLexer) Lex(*sqlSymType) int { return int(l) }
+func (fixedTokenLexer) Error(string)          {}
+
+// tokenName returns the name of the given token id in the grammar.
+func tokenName(id int32) string {
+	if id == 0 {
+		return "EOF"
+	}
+	_, tok := sqllex1(fixedTokenLexer(id), &sqlSymType{})
+	return sqlTokname(tok)
 }
 
 // cleanup is used to avoid holding on to memory unnecessarily (for the cases
@@ -55,6 +233,9 @@ func (l *lexer) init(sql string, tokens []sqlSymType, nakedIntType *types.T) {
 func (l *lexer) cleanup() {
 	l.tokens = nil
 	l.stmt = nil
+	l.placeholderTypeHints = nil
+	l.placeholderPositions = nil
+	l.referencedNames = nil
 	l.lastError = nil
 }
 
@@ -64,8 +245,8 @@ func (l *lexer) Lex(lval *sqlSymType) int {
 	// The core lexing takes place in the scanner. Here we do a small bit of post
 	// processing of the lexical tokens so that the grammar only requires
 	// one-token lookahead despite SQL requiring multi-token lookahead in some
-	// cases. These special cases are handled below and the returned tokens are
-	// adjusted to reflect the lookahead (LA) that occurred.
+	// cases. These special cases are described by lookaheadRules, and the
+	// returned tokens are adjusted to reflect the lookahead (LA) that occurred.
 	if l.lastPos >= len(l.tokens) {
 		lval.id = 0
 		lval.pos = int32(len(l.in))
@@ -74,237 +255,11 @@ func (l *lexer) Lex(lval *sqlSymType) int {
 	}
 	*lval = l.tokens[l.lastPos]
 
-	switch lval.id {
-	case NOTHING:
-		// Introducing the "RETURNING NOTHING" syntax in CockroachDB
-		// was a terrible idea, given that it is not even used any more!
-		// We should really deprecate it and remove this special case.
-		if l.lastPos > 0 && l.tokens[l.lastPos-1].id == RETURNING {
-			lval.id = NOTHING_AFTER_RETURNING
-		}
-	case INDEX:
-		// The following complex logic is a consternation, really.
-		//
-		// It flows from a profoundly mistaken decision to allow the INDEX
-		// keyword inside the column definition list of CREATE, a place
-		// where PostgreSQL did not allow it, for a very good reason:
-		// applications legitimately want to name columns with the name
-		// "index".
-		//
-		// After this mistaken decision was first made, the INDEX keyword
-		// was also allowed in CockroachDB in another place where it is
-		// partially ambiguous with other identifiers: ORDER BY
-		// (`ORDER BY INDEX foo@bar`, ambiguous with `ORDER BY index`).
-		//
-		// Sadly it took a very long time before we realized this mistake,
-		// and by that time these uses of INDEX have become legitimate
-		// CockroachDB features.
-		//
-		// We are thus left with the need to disambiguate between:
-		//
-		// CREATE TABLE t(index a) -- column name "index", column type "a"
-		// CREATE TABLE t(index (a)) -- keyword INDEX, column name "a"
-		// CREATE TABLE t(index a (b)) -- keyword INDEX, index name "a", column name "b"
-		//
-		// Thankfully, a coldef for a column named "index" and an index
-		// specification differ unambiguously, *given sufficient
-		// lookaheaed*: an index specification always has an open '('
-		// after INDEX, with or without an identifier in-between. A column
-		// definition never has this.
-		//
-		// Likewise, between:
-		//
-		// ORDER BY index
-		// ORDER BY index a@idx
-		// ORDER BY index a.b@idx
-		// ORDER BY index a.b.c@idx
-		//
-		// We can unambiguously distinguish by the presence of the '@' sign
-		// with a maximum of 6 token lookahead.
-		//
-		var pprevID, prevID int32
-		if l.lastPos > 0 {
-			prevID = l.tokens[l.lastPos-1].id
-		}
-		if l.lastPos > 1 {
-			pprevID = l.tokens[l.lastPos-2].id
-		}
-		var nextID, secondID int32
-		if l.lastPos+1 < len(l.tokens) {
-			nextID = l.tokens[l.lastPos+1].id
-		}
-		if l.lastPos+2 < len(l.tokens) {
-			secondID = l.tokens[l.lastPos+2].id
-		}
-		afterCommaOrParen := prevID == ',' || prevID == '('
-		afterCommaOrOPTIONS := prevID == ',' || prevID == OPTIONS
-		afterCommaOrParenThenINVERTED := prevID == INVERTED && (pprevID == ',' || pprevID == '(')
-		afterCommaOrParenThenVECTOR := prevID == VECTOR && (pprevID == ',' || pprevID == '(')
-		followedByParen := nextID == '('
-		followedByNonPunctThenParen := nextID > 255 /* non-punctuation */ && secondID == '('
-		if //
-		// CREATE ... (INDEX (
-		// CREATE ... (x INT, y INT, INDEX (
-		(afterCommaOrParen && followedByParen) ||
-			// SCRUB ... WITH OPTIONS INDEX (...
-			// SCRUB ... WITH OPTIONS a, INDEX (...
-			(afterCommaOrOPTIONS && followedByParen) ||
-			// CREATE ... (INVERTED INDEX (
-			// CREATE ... (x INT, y INT, INVERTED INDEX (
-			(afterCommaOrParenThenINVERTED && followedByParen) ||
-			// CREATE ... (VECTOR INDEX (
-			// CREATE ... (x INT, y INT, VECTOR INDEX (
-			(afterCommaOrParenThenVECTOR && followedByParen) {
-			lval.id = INDEX_BEFORE_PAREN
-			break
-		}
-		if //
-		// CREATE ... (INDEX abc (
-		// CREATE ... (x INT, y INT, INDEX abc (
-		(afterCommaOrParen && followedByNonPunctThenParen) ||
-			// CREATE ... (INVERTED INDEX abc (
-			// CREATE ... (x INT, y INT, INVERTED INDEX abc (
-			(afterCommaOrParenThenINVERTED && followedByNonPunctThenParen) ||
-			// CREATE ... (VECTOR INDEX abc (
-			// CREATE ... (x INT, y INT, VECTOR INDEX abc (
-			(afterCommaOrParenThenVECTOR && followedByNonPunctThenParen) {
-			lval.id = INDEX_BEFORE_NAME_THEN_PAREN
-			break
-		}
-		// The rules above all require that the INDEX keyword be
-		// followed ultimately by an open parenthesis, with no '@'
-		// in-between. The rule below is strictly exclusive with this
-		// situation.
-		afterCommaOrOrderBy := prevID == ',' || (prevID == BY && pprevID == ORDER)
-		if afterCommaOrOrderBy {
-			// SORT BY INDEX <objname> @
-			// SORT BY a, b, INDEX <objname> @
-			atSignAfterObjectName := false
-			// An object name has one of the following forms:
-			//    name
-			//    name.name
-			//    name.name.name
-			// So it is between 1 and 5 tokens in length.
-			for i := l.lastPos + 1; i < len(l.tokens) && i < l.lastPos+7; i++ {
-				curToken := l.tokens[i].id
-				// An object name can only contain keyword/identifiers, and
-				// the punctuation '.'.
-				if curToken < 255 /* not ident/keyword */ && curToken != '.' && curToken != '@' {
-					// Definitely not object name.
-					break
-				}
-				if curToken == '@' {
-					if i == l.lastPos+1 {
-						/* The '@' cannot follow the INDEX keyword directly. */
-						break
-					}
-					atSignAfterObjectName = true
-					break
-				}
-			}
-			if atSignAfterObjectName {
-				lval.id = INDEX_AFTER_ORDER_BY_BEFORE_AT
-			}
-		}
-
-	case NOT, WITH, AS, GENERATED, NULLS, RESET, ROLE, USER, ON, TENANT, CLUSTER, SET:
-		nextToken := sqlSymType{}
-		if l.lastPos+1 < len(l.tokens) {
-			nextToken = l.tokens[l.lastPos+1]
-		}
-		secondToken := sqlSymType{}
-		if l.lastPos+2 < len(l.tokens) {
-			secondToken = l.tokens[l.lastPos+2]
-		}
-		thirdToken := sqlSymType{}
-		if l.lastPos+3 < len(l.tokens) {
-			thirdToken = l.tokens[l.lastPos+3]
-		}
-
-		// If you update these cases, update lex.lookaheadKeywords.
-		switch lval.id {
-		case AS:
-			switch nextToken.id {
-			case OF:
-				switch secondToken.id {
-				case SYSTEM:
-					lval.id = AS_LA
-				}
-			}
-		case NOT:
-			switch nextToken.id {
-			case BETWEEN, IN, LIKE, ILIKE, SIMILAR:
-				lval.id = NOT_LA
-			}
-		case GENERATED:
-			switch nextToken.id {
-			case ALWAYS:
-				lval.id = GENERATED_ALWAYS
-			case BY:
-				lval.id = GENERATED_BY_DEFAULT
-			}
-
-		case WITH:
-			switch nextToken.id {
-			case TIME, ORDINALITY, BUCKET_COUNT:
-				lval.id = WITH_LA
-			}
-		case NULLS:
-			switch nextToken.id {
-			case FIRST, LAST:
-				lval.id = NULLS_LA
-			}
-		case RESET:
-			switch nextToken.id {
-			case ALL:
-				lval.id = RESET_ALL
-			}
-		case ROLE:
-			switch nextToken.id {
-			case ALL:
-				lval.id = ROLE_ALL
-			}
-		case USER:
-			switch nextToken.id {
-			case ALL:
-				lval.id = USER_ALL
-			}
-		case ON:
-			switch nextToken.id {
-			case DELETE:
-				lval.id = ON_LA
-			case UPDATE:
-				switch secondToken.id {
-				case NO, RESTRICT, CASCADE, SET:
-					lval.id = ON_LA
-				}
-			}
-		case TENANT:
-			switch nextToken.id {
-			case ALL:
-				lval.id = TENANT_ALL
-			}
-		case CLUSTER:
-			switch nextToken.id {
-			case ALL:
-				lval.id = CLUSTER_ALL
-			}
-		case SET:
-			switch nextToken.id {
-			case TRACING:
-				// Do not use the lookahead rule for `SET tracing.custom ...`
-				if secondToken.str != "." {
-					lval.id = SET_TRACING
-				}
-			case SESSION:
-				switch secondToken.id {
-				case TRACING:
-					// Do not use the lookahead rule for `SET SESSION tracing.custom ...`
-					if thirdToken.str != "." {
-						lval.id = SET_TRACING
-					}
-				}
-			}
+	if r := findLookaheadRule(l.tokens, l.lastPos); r != nil {
+		if r.errorMsg != "" {
+			l.setTokenError(l.lastPos+r.errorOffset, r.errorMsg)
+		} else {
+			lval.id = r.replacement
 		}
 	}
 
@@ -326,8 +281,22 @@ func (l *lexer) lastToken() sqlSymType {
 	return l.tokens[l.lastPos]
 }
 
-// NewAnnotation returns a new annotation index.
+// setTokenError replaces the token at the given position with an ERROR
+// token carrying the given message, so that the parser reports it as a
+// lexical error when it reaches that token.
+func (l *lexer) setTokenError(pos int, msg string) {
+	l.tokens[pos].id = ERROR
+	l.tokens[pos].str = msg
+}
+
+// NewAnnotation returns a new annotation index. The indexes do not wrap
+// around: once they are exhausted, NoAnnotation is returned and the parse
+// fails after the statement is constructed.
 func (l *lexer) NewAnnotation() tree.AnnotationIdx {
+	if l.numAnnotations == tree.MaxAnnotations {
+		l.annotationOverflow = true
+		return tree.NoAnnotation
+	}
 	l.numAnnotations++
 	return l.numAnnotations
 }
@@ -335,61 +304,146 @@ func (l *lexer) NewAnnotation() tree.AnnotationIdx {
 // SetStmt is called from the parser when the statement is constructed.
 func (l *lexer) SetStmt(stmt tree.Statement) {
 	l.stmt = stmt
+	// The statement starts at the first token, and lastPos may point past
+	// its end if the parser has read the end of the input.
+	l.tokenRange = statements.TokenRange{
+		First: l.tokenBase,
+		Last:  l.tokenBase + min(l.lastPos, len(l.tokens)-1),
+	}
 }
 
 // UpdateNumPlaceholders is called from the parser when a placeholder is constructed.
-func (l *lexer) UpdateNumPlaceholders(p *tree.Placeholder) {
+// pos is the position of the placeholder token.
+func (l *lexer) UpdateNumPlaceholders(p *tree.Placeholder, pos int32) {
 	if n := int(p.Idx) + 1; l.numPlaceholders < n {
 		l.numPlaceholders = n
 	}
+	l.usedPlaceholders.Add(int(p.Idx))
+	if _, ok := l.placeholderPositions[p.Idx]; !ok {
+		if l.placeholderPositions == nil {
+			l.placeholderPositions = make(map[tree.PlaceholderIdx]int32)
+		}
+		l.placeholderPositions[p.Idx] = pos
+	}
+}
+
+// SetAsOfSystemTime is called from the parser when an AS OF SYSTEM TIME
+// clause is constructed. pos is the position of the clause.
+func (l *lexer) SetAsOfSystemTime(pos int32) {
+	if l.asOfSystemTimePos < 0 || pos < l.asOfSystemTimePos {
+		l.asOfSystemTimePos = pos
+	}
+}
+
+// AddReferencedName is called from the parser when a name is constructed in
+// a position that references a table, a sequence or a function. pos is the
+// position of the first token of the name. The name is only recorded when
+// parsing with ParseOptions.CollectNames.
+func (l *lexer) AddReferencedName(
+	kind statements.ReferencedNameKind, name tree.NodeFormatter, pos int32,
+) {
+	if !l.collectNames {
+		return
+	}
+	l.referencedNames = append(l.referencedNames, statements.ReferencedName{
+		Kind: kind,
+		Name: tree.AsString(name),
+		Pos:  pos,
+	})
+}
+
+// UpdatePlaceholderTypeHints is called from the parser when a cast is
+// constructed. If the cast applies directly to a placeholder, it records the
+// target type as a hint for the type of the placeholder.
+func (l *lexer) UpdatePlaceholderTypeHints(cast *tree.CastExpr) {
+	p, ok := cast.Expr.(*tree.Placeholder)
+	if !ok {
+		return
+	}
+	hint := statements.PlaceholderTypeHint{
+		TypeName: cast.Type.SQLString(),
+		Pos:      l.placeholderPos(p),
+	}
+	prev, ok := l.placeholderTypeHints[p.Idx]
+	if !ok {
+		if l.placeholderTypeHints == nil {
+			l.placeholderTypeHints = make(map[tree.PlaceholderIdx]statements.PlaceholderTypeHint)
+		}
+		l.placeholderTypeHints[p.Idx] = hint
+		return
+	}
+	if hint.TypeName == prev.TypeName {
+		return
+	}
+	for _, c := range prev.Conflicts {
+		if hint.TypeName == c.TypeName {
+			return
+		}
+	}
+	prev.Conflicts = append(prev.Conflicts, hint)
+	l.placeholderTypeHints[p.Idx] = prev
+}
+
+// placeholderPos returns the position of the token of the given placeholder,
+// which is among the tokens consumed so far.
+func (l *lexer) placeholderPos(p *tree.Placeholder) int32 {
+	for i := min(l.lastPos, len(l.tokens)-1); i >= 0; i-- {
+		if l.tokens[i].id == PLACEHOLDER && l.tokens[i].union.val == p {
+			return l.tokens[i].pos
+		}
+	}
+	return 0
 }
 
 // PurposelyUnimplemented wraps Error, setting lastUnimplementedError.
 func (l *lexer) PurposelyUnimplemented(feature string, reason string) {
 	// We purposely do not use unimp here, as it appends hints to suggest that
 	// the error may be actively tracked as a bug.
-	l.lastError = errors.WithHint(
+	// The telemetry key is normalized, as the feature names are free-form.
+	u := &tree.UnsupportedError{FeatureName: feature, Purposely: true}
+	u.Err = errors.WithHint(
 		errors.WithTelemetry(
 			pgerror.Newf(pgcode.Syntax, "unimplemented: this syntax"),
-			fmt.Sprintf("sql.purposely_unimplemented.%s", feature),
+			u.TelemetryKey(),
 		),
-		reason,
+		translate(MsgPurposelyUnimplementedPrefix+feature, reason),
 	)
-	l.populateErrorDetails()
-	l.lastError = &tree.UnsupportedError{
-		Err:         l.lastError,
-		FeatureName: feature,
-	}
+	l.setUnsupported(u)
 }
 
 // UnimplementedWithIssue wraps Error, setting lastUnimplementedError.
 func (l *lexer) UnimplementedWithIssue(issue int) {
-	l.lastError = unimp.NewWithIssue(issue, "this syntax")
-	l.populateErrorDetails()
-	l.lastError = &tree.UnsupportedError{
-		Err:         l.lastError,
+	l.setUnsupported(&tree.UnsupportedError{
+		Err:         unimp.NewWithIssue(issue, "this syntax"),
 		FeatureName: fmt.Sprintf("https://github.com/cockroachdb/cockroach/issues/%d", issue),
-	}
+		IssueNumber: issue,
+	})
 }
 
 // UnimplementedWithIssueDetail wraps Error, setting lastUnimplementedError.
 func (l *lexer) UnimplementedWithIssueDetail(issue int, detail string) {
-	l.lastError = unimp.NewWithIssueDetail(issue, detail, "this syntax")
-	l.populateErrorDetails()
-	l.lastError = &tree.UnsupportedError{
-		Err:         l.lastError,
+	l.setUnsupported(&tree.UnsupportedError{
+		Err:         unimp.NewWithIssueDetail(issue, detail, "this syntax"),
 		FeatureName: detail,
-	}
+		IssueNumber: issue,
+		Detail:      detail,
+	})
 }
 
 // Unimplemented wraps Error, setting lastUnimplementedError.
 func (l *lexer) Unimplemented(feature string) {
-	l.lastError = unimp.New(feature, "this syntax")
-	l.populateErrorDetails()
-	l.lastError = &tree.UnsupportedError{
-		Err:         l.lastError,
+	l.setUnsupported(&tree.UnsupportedError{
+		Err:         unimp.New(feature, "this syntax"),
 		FeatureName: feature,
-	}
+	})
+}
+
+// setUnsupported registers an error for unsupported syntax. The error is
+// decorated with the position of the syntax like any other error, and can be
+// retrieved from the result with errors.As.
+func (l *lexer) setUnsupported(err *tree.UnsupportedError) {
+	l.lastError = err
+	l.populateErrorDetails()
 }
 
 // setErr is called from parsing action rules to register an error observed
@@ -415,19 +469,50 @@ func (l *lexer) Error(e string) {
 	e = strings.TrimPrefix(e, "syntax error: ") // we'll add it again below.
 	l.lastError = pgerror.WithCandidateCode(errors.Newf("%s", e), pgcode.Syntax)
 	l.populateErrorDetails()
+	l.addDialectHint()
+	l.addQuotedAllHint()
+}
+
+// addQuotedAllHint attaches a hint to the last error if the syntax error was
+// reported at or just after "TENANT ALL" or "VIRTUAL CLUSTER ALL". There,
+// ALL always refers to every virtual cluster, so a virtual cluster that is
+// named "all" must be quoted. Help requests are left alone.
+func (l *lexer) addQuotedAllHint() {
+	if l.lastToken().id == HELPTOKEN {
+		return
+	}
+	for i := max(l.lastPos-2, 0); i <= l.lastPos && i+1 < len(l.tokens); i++ {
+		if l.tokens[i+1].id != ALL {
+			continue
+		}
+		if l.tokens[i].id == TENANT || (l.tokens[i].id == CLUSTER && i > 0 && l.tokens[i-1].id == VIRTUAL) {
+			l.lastError = errors.WithHint(l.lastError,
+				`ALL refers to all virtual clusters; use double quotes to refer to a virtual cluster named "all"`)
+			return
+		}
+	}
 }
 
 // PopulateErrorDetails properly wraps the "last error" field in the lexer.
+// For contextual errors, precedingText is rendered in the message before the
+// offending token; see lexer.errorContext.
 func PopulateErrorDetails(
-	tokID int32, lastTokStr string, lastTokPos int32, lastErr error, lIn string,
+	tokID int32, lastTokStr string, precedingText string, lastTokPos int32, lastErr error, lIn string,
 ) error {
 	var retErr error
 
+	width := 1
 	if tokID == ERROR {
 		// This is a tokenizer (lexical) error: the scanner
 		// will have stored the error message in the string field.
 		err := pgerror.WithCandidateCode(errors.Newf("lexical error: %s", lastTokStr), pgcode.Syntax)
 		retErr = errors.WithSecondaryError(err, lastErr)
+		if int(lastTokPos) < len(lIn) && lIn[lastTokPos] == '`' {
+			// Point at the whole would-be identifier, if it is terminated.
+			var hint string
+			hint, width = backtickHint(lIn, lastTokPos)
+			retErr = errors.WithHint(retErr, hint)
+		}
 	} else {
 		// This is a contextual error. Print the provided error message
 		// and the error context.
@@ -436,30 +521,230 @@ func PopulateErrorDetails(
 			// parser encounters a parsing error.
 			lastErr = errors.Wrap(lastErr, "syntax error")
 		}
-		retErr = errors.Wrapf(lastErr, "at or near \"%s\"", lastTokStr)
+		retErr = errors.Wrapf(lastErr, "at or near \"%s%s\"", precedingText, lastTokStr)
 	}
 
-	// Find the end of the line containing the last token.
-	i := strings.IndexByte(lIn[lastTokPos:], '\n')
+	return errors.WithDetail(retErr, sourceContext(lIn, lastTokPos, width))
+}
+
+// WithPlaceholderContext annotates err with the first occurrence of the
+// placeholder with the given index in the statement, in the same format as
+// the details of syntax errors. err is returned unchanged if the statement
+// does not contain the placeholder.
+func WithPlaceholderContext(
+	err error, stmt statements.Statement[tree.Statement], idx tree.PlaceholderIdx,
+) error {
+	pos, ok := stmt.PlaceholderPositions[idx]
+	if !ok {
+		return err
+	}
+	width := 1
+	for int(pos)+width < len(stmt.SQL) && lexbase.IsDigit(int(stmt.SQL[int(pos)+width])) {
+		width++
+	}
+	return errors.WithDetail(err, sourceContext(stmt.SQL, pos, width))
+}
+
+// backtickHint suggests a replacement for the backtick-quoted identifier
+// at position pos of sql. It also returns the width of the identifier,
+// including the backticks, or 1 if there is no closing backtick.
+func backtickHint(sql string, pos int32) (hint string, width int) {
+	ident, ok := scanner.BacktickQuotedIdent(sql, int(pos))
+	if !ok {
+		return "use double quotes instead of backticks to quote identifiers", 1
+	}
+	return fmt.Sprintf("use double quotes instead of backticks to quote identifiers, as in %s",
+		lexbase.EscapeSQLIdent(ident)), len(ident) + 2
+}
+
+// sourceContext returns the input SQL up to and including the line
+// containing the given position, followed by carets underlining the width
+// bytes starting at the position.
+func sourceContext(lIn string, pos int32, width int) string {
+	// Find the end of the line containing the position.
+	i := strings.IndexByte(lIn[pos:], '\n')
 	if i == -1 {
 		i = len(lIn)
 	} else {
-		i += int(lastTokPos)
+		i += int(pos)
 	}
-	// Find the beginning of the line containing the last token. Note that
+	// Find the beginning of the line containing the position. Note that
 	// LastIndexByte returns -1 if '\n' could not be found.
-	j := strings.LastIndexByte(lIn[:lastTokPos], '\n') + 1
-	// Output everything up to and including the line containing the last token.
+	j := strings.LastIndexByte(lIn[:pos], '\n') + 1
+	// Output everything up to and including the line containing the position.
 	var buf bytes.Buffer
 	fmt.Fprintf(&buf, "source SQL:\n%s\n", lIn[:i])
-	// Output a caret indicating where the last token starts.
-	fmt.Fprintf(&buf, "%s^", strings.Repeat(" ", int(lastTokPos)-j))
-	return errors.WithDetail(retErr, buf.String())
+	// Output carets indicating the position.
+	fmt.Fprintf(&buf, "%s%s", strings.Repeat(" ", int(pos)-j), strings.Repeat("^", width))
+	return buf.String()
 }
 
 func (l *lexer) populateErrorDetails() {
 	lastTok := l.lastToken()
-	l.lastError = PopulateErrorDetails(lastTok.id, lastTok.str, lastTok.pos, l.lastError, l.in)
+	if l.canonicalErrors {
+		l.lastError = populateCanonicalErrorDetails(lastTok.id, lastTok.str, l.lastError)
+		return
+	}
+	precedingText, tokText := l.errorContext(l.lastPos), lastTok.str
+	if lastTok.id != ERROR && l.lastPos >= 0 {
+		// Mention the offending token as written, rather than its normalized
+		// form. The end of the input is only mentioned when no token precedes
+		// it.
+		tokText = ""
+		if l.lastPos < len(l.tokens) {
+			start, end := l.tokenSpan(l.lastPos)
+			tokText = l.in[start:end]
+		} else if precedingText == "" {
+			tokText = lastTok.str
+		}
+	}
+	l.lastError = PopulateErrorDetails(
+		lastTok.id, tokText, precedingText, lastTok.pos, l.lastError, l.in,
+	)
+	if tokErr, ok := lastTok.union.val.(error); ok && lastTok.id == ERROR {
+		// The scanner keeps the error of an invalid token, e.g. a placeholder
+		// out of range, so that its hints reach the client.
+		for _, h := range errors.GetAllHints(tokErr) {
+			l.lastError = errors.WithHint(l.lastError, h)
+		}
+	}
+}
+
+const (
+	// errorContextTokens is the maximum number of tokens preceding the
+	// offending token that are mentioned in a syntax error message.
+	errorContextTokens = 3
+	// maxErrorContextLen caps the length of the preceding tokens mentioned in
+	// a syntax error message.
+	maxErrorContextLen = 32
+)
+
+// errorContext renders the tokens preceding the token at position pos, so
+// that a syntax error mentions e.g. "... GROUP BY (" instead of just "(".
+// At most errorContextTokens tokens are included, as written in the input
+// and with their original spacing collapsed to a single space. Literals are
+// kept as written: the message argument is redactable, so they are hidden
+// along with the rest of the input when the error is redacted. The result
+// is prefixed with "... " if earlier tokens were omitted.
+func (l *lexer) errorContext(pos int) string {
+	pos = min(pos, len(l.tokens))
+	start, length := pos, 0
+	var parts [errorContextTokens]string
+	for start > 0 && pos-start < errorContextTokens {
+		text := l.tokenText(start - 1)
+		if length+len(text) > maxErrorContextLen {
+			break
+		}
+		length += len(text)
+		start--
+		parts[pos-start-1] = text
+	}
+	if start == pos {
+		return ""
+	}
+	var buf strings.Builder
+	if start > 0 {
+		buf.WriteString("... ")
+	}
+	for i := pos - start - 1; i >= 0; i-- {
+		buf.WriteString(parts[i])
+	}
+	if pos == len(l.tokens) {
+		// Nothing follows the context at the end of the input.
+		return strings.TrimRightFunc(buf.String(), unicode.IsSpace)
+	}
+	return buf.String()
+}
+
+// tokenText returns the text of the token at position i as it appears in the
+// input, followed by a space if the input separates it from the next token
+// with whitespace or comments.
+func (l *lexer) tokenText(i int) string {
+	start, end := l.tokenSpan(i)
+	next := len(l.in)
+	if i+1 < len(l.tokens) {
+		next = int(l.tokens[i+1].pos)
+	}
+	if end < next {
+		return l.in[start:end] + " "
+	}
+	return l.in[start:end]
+}
+
+// tokenSpan returns the bounds of the token at position i in the input. The
+// token is scanned again, since only its start position is recorded.
+func (l *lexer) tokenSpan(i int) (start, end int) {
+	start = int(l.tokens[i].pos)
+	next := len(l.in)
+	if i+1 < len(l.tokens) {
+		next = max(int(l.tokens[i+1].pos), start)
+	}
+	s := makeSQLScanner(l.in[start:])
+	var lval sqlSymType
+	s.Scan(&lval)
+	end = min(start+s.Pos(), next)
+	// The scanner looks past the closing quote of a string for a
+	// continuation on the next line.
+	for end > start+1 && unicode.IsSpace(rune(l.in[end-1])) {
+		end--
+	}
+	return start, end
+}
+
+// atOrNearRE matches the mention of the offending input in lexical error
+// messages produced by the scanner.
+var atOrNearRE = regexp.MustCompile(` at or near "(?:[^"\\]|\\.)*"`)
+
+// backtickIdentRE matches the lexical error for a backtick-quoted
+// identifier, which names the identifier.
+var backtickIdentRE = regexp.MustCompile("^backtick-quoted identifier .* is not supported$")
+
+// populateCanonicalErrorDetails is like PopulateErrorDetails, but produces
+// the canonical form of the error described in ParseOptions.CanonicalErrors.
+func populateCanonicalErrorDetails(tokID int32, lastTokStr string, lastErr error) error {
+	if tokID == ERROR {
+		msg := atOrNearRE.ReplaceAllString(lastTokStr, "")
+		msg = backtickIdentRE.ReplaceAllString(msg, "backtick-quoted identifiers are not supported")
+		err := pgerror.WithCandidateCode(errors.Newf("lexical error: %s", msg), pgcode.Syntax)
+		return errors.WithSecondaryError(err, lastErr)
+	}
+	if !strings.Contains(lastErr.Error(), "syntax error") {
+		lastErr = errors.Wrap(lastErr, "syntax error")
+	}
+	return errors.Wrapf(lastErr, "at or near %s", tokenClass(tokID, lastTokStr))
+}
+
+// tokenClass describes the class of a token, for use in canonical errors.
+func tokenClass(id int32, str string) string {
+	switch id {
+	case 0:
+		return "EOF"
+	case IDENT:
+		return "identifier"
+	case SCONST, BCONST, BITCONST:
+		return "string literal"
+	case ICONST, FCONST:
+		return "numeric literal"
+	case PLACEHOLDER:
+		return "placeholder"
+	}
+	if _, ok := lexbase.KeywordsCategories[str]; ok {
+		return "keyword"
+	}
+	return "operator"
+}
+
+// CanonicalError renders an error in a canonical form suitable for
+// deduplicating and comparing errors, e.g. when fuzzing the parser against
+// PostgreSQL: the pgcode followed by the message, without details or hints.
+// Errors returned by parses using ParseOptions.CanonicalErrors additionally
+// do not mention the position or the text of the offending input.
+func CanonicalError(err error) string {
+	if err == nil {
+		return ""
+	}
+	pgErr := pgerror.Flatten(err)
+	return fmt.Sprintf("%s: %s", pgErr.Code, pgErr.Message)
 }
 
 // SetHelp marks the "last error" field in the lexer to become a
@@ -468,28 +753,60 @@ func (l *lexer) populateErrorDetails() {
 // encountered was HELPTOKEN -- other cases are just syntax errors,
 // and in that case we do not want the help text to overwrite the
 // lastError field, which was set earlier to contain details about the
-// syntax error.
+// syntax error. Note that the scanner only produces HELPTOKEN for a "??"
+// outside of literals, quoted identifiers and comments, so a "??" inside
+// those never results in a help request.
 func (l *lexer) SetHelp(msg HelpMessage) {
 	if l.lastError == nil {
 		l.lastError = pgerror.WithCandidateCode(errors.New("help request"), pgcode.Syntax)
 	}
 
 	if lastTok := l.lastToken(); lastTok.id == HELPTOKEN {
-		l.populateHelpMsg(msg.String())
+		if msg.FunctionDetail != "" && l.helpFollowsFunctionCall() {
+			msg.Text = msg.FunctionDetail
+		}
+		l.populateHelpMsg(msg)
 	} else {
 		if msg.Command != "" {
-			l.lastError = errors.WithHintf(l.lastError, `try \h %s`, msg.Command)
+			l.lastError = errors.WithHintf(l.lastError, translate(MsgTryHelpf, `try \h %s`), msg.Command)
 		} else {
-			l.lastError = errors.WithHintf(l.lastError, `try \hf %s`, msg.Function)
+			l.lastError = errors.WithHintf(l.lastError, translate(MsgTryHelpFunctionf, `try \hf %s`), msg.Function)
 		}
 	}
 }
 
+// helpFollowsFunctionCall returns true if the last token, which must be a
+// help token, directly follows a function name and an opening
+// parenthesis, as in "count(??".
+func (l *lexer) helpFollowsFunctionCall() bool {
+	if l.lastPos < 2 || l.lastPos >= len(l.tokens) {
+		return false
+	}
+	if l.tokens[l.lastPos-1].id != '(' {
+		return false
+	}
+	name := l.tokens[l.lastPos-2]
+	if name.id == IDENT {
+		return true
+	}
+	_, isKeyword := lexbase.KeywordsCategories[name.str]
+	return isKeyword
+}
+
 // specialHelpErrorPrefix is a special prefix that must be present at
 // the start of an error message to be considered a valid help
-// response payload by the CLI shell.
+// response payload by CLI shells that predate HelpResponse.
 const specialHelpErrorPrefix = "help token in input"
 
-func (l *lexer) populateHelpMsg(msg string) {
-	l.lastError = errors.WithHint(errors.Wrap(l.lastError, specialHelpErrorPrefix), msg)
+// populateHelpMsg turns the last error into a help response. The last token
+// must be a help token: a help response for any other input would hide the
+// syntax error from the client.
+func (l *lexer) populateHelpMsg(msg HelpMessage) {
+	if lastTok := l.lastToken(); lastTok.id != HELPTOKEN {
+		l.lastError = errors.NewAssertionErrorWithWrappedErrf(
+			l.lastError, "help response requested at token %q, which is not a help token", lastTok.str,
+		)
+		return
+	}
+	l.lastError = withHelpResponse(errors.Wrap(l.lastError, specialHelpErrorPrefix), msg)
 }
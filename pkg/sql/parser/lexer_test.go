@@ -6,8 +6,14 @@
 package parser
 
 import (
+	"os"
 	"reflect"
+	"regexp"
+	"strings"
 	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/lexbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 )
 
 func TestLexer(t *testing.T) {
@@ -23,6 +29,26 @@ func TestLexer(t *testing.T) {
 		{`NOT SIMILAR`, []int{NOT_LA, SIMILAR}},
 		{`AS OF SYSTEM TIME`, []int{AS_LA, OF, SYSTEM, TIME}},
 		{`AS OF`, []int{AS, OF}},
+		{`AS /* a */ OF SYSTEM TIME`, []int{AS_LA, OF, SYSTEM, TIME}},
+		{`AS OF /* follower read */ SYSTEM TIME`, []int{AS_LA, OF, SYSTEM, TIME}},
+		{`AS OF SYSTEM -- comment
+TIME`, []int{AS_LA, OF, SYSTEM, TIME}},
+		{"AS\nOF\n\tSYSTEM\nTIME", []int{AS_LA, OF, SYSTEM, TIME}},
+		{`AS OF SYSTEM`, []int{AS, OF, ERROR}},
+		{`AS OF TIME`, []int{AS, OF, ERROR}},
+		// The *_ALL rewrites only apply to the unquoted keyword; a quoted
+		// "all" is scanned as an identifier.
+		{`RESET ALL`, []int{RESET_ALL, ALL}},
+		{`RESET "all"`, []int{RESET, IDENT}},
+		{`RESET "ALL"`, []int{RESET, IDENT}},
+		{`ROLE ALL`, []int{ROLE_ALL, ALL}},
+		{`ROLE "all"`, []int{ROLE, IDENT}},
+		{`USER ALL`, []int{USER_ALL, ALL}},
+		{`USER "all"`, []int{USER, IDENT}},
+		{`TENANT ALL`, []int{TENANT_ALL, ALL}},
+		{`TENANT "all"`, []int{TENANT, IDENT}},
+		{`CLUSTER ALL`, []int{CLUSTER_ALL, ALL}},
+		{`CLUSTER "all"`, []int{CLUSTER, IDENT}},
 	}
 	for i, d := range testData {
 		s := makeSQLScanner(d.sql)
@@ -36,7 +62,7 @@ func TestLexer(t *testing.T) {
 			scanTokens = append(scanTokens, lval)
 		}
 		var l lexer
-		l.init(d.sql, scanTokens, defaultNakedIntType)
+		l.init(d.sql, scanTokens, ParseOptions{})
 		var lexTokens []int
 		for {
 			var lval sqlSymType
@@ -53,3 +79,325 @@ func TestLexer(t *testing.T) {
 	}
 
 }
+
+// TestLexLookaheadTokens exercises the lookahead rules in Lex using prebuilt
+// token sequences, bypassing the scanner.
+func TestLexLookaheadTokens(t *testing.T) {
+	// tok builds a token; punctuation tokens get their character as string,
+	// which some lookahead rules inspect.
+	tok := func(id int32) TokenString {
+		if id < 256 {
+			return TokenString{TokenID: id, Str: string(rune(id))}
+		}
+		return TokenString{TokenID: id}
+	}
+	const ident = IDENT
+	testData := []struct {
+		name     string
+		in       []int32
+		expected []int32
+	}{
+		{"nothing", []int32{NOTHING}, []int32{NOTHING}},
+		{"returning nothing", []int32{RETURNING, NOTHING}, []int32{RETURNING, NOTHING_AFTER_RETURNING}},
+
+		{"index alone", []int32{INDEX}, []int32{INDEX}},
+		{"paren index at end", []int32{'(', INDEX}, []int32{'(', INDEX}},
+		{"paren index paren", []int32{'(', INDEX, '('}, []int32{'(', INDEX_BEFORE_PAREN, '('}},
+		{"comma index paren", []int32{',', INDEX, '('}, []int32{',', INDEX_BEFORE_PAREN, '('}},
+		{"options index paren", []int32{OPTIONS, INDEX, '('}, []int32{OPTIONS, INDEX_BEFORE_PAREN, '('}},
+		{"inverted index paren", []int32{'(', INVERTED, INDEX, '('}, []int32{'(', INVERTED, INDEX_BEFORE_PAREN, '('}},
+		{"vector index paren", []int32{',', VECTOR, INDEX, '('}, []int32{',', VECTOR, INDEX_BEFORE_PAREN, '('}},
+		{"inverted index at start", []int32{INVERTED, INDEX, '('}, []int32{INVERTED, INDEX, '('}},
+		{"paren index name paren", []int32{'(', INDEX, ident, '('}, []int32{'(', INDEX_BEFORE_NAME_THEN_PAREN, ident, '('}},
+		{"paren index name at end", []int32{'(', INDEX, ident}, []int32{'(', INDEX, ident}},
+		{"inverted index name paren", []int32{',', INVERTED, INDEX, ident, '('}, []int32{',', INVERTED, INDEX_BEFORE_NAME_THEN_PAREN, ident, '('}},
+		{"vector index name paren", []int32{'(', VECTOR, INDEX, ident, '('}, []int32{'(', VECTOR, INDEX_BEFORE_NAME_THEN_PAREN, ident, '('}},
+		{"paren index punct paren", []int32{'(', INDEX, '.', '('}, []int32{'(', INDEX, '.', '('}},
+		{"order by index at", []int32{ORDER, BY, INDEX, ident, '@', ident}, []int32{ORDER, BY, INDEX_AFTER_ORDER_BY_BEFORE_AT, ident, '@', ident}},
+		{"order by index qualified at", []int32{ORDER, BY, INDEX, ident, '.', ident, '.', ident, '@', ident}, []int32{ORDER, BY, INDEX_AFTER_ORDER_BY_BEFORE_AT, ident, '.', ident, '.', ident, '@', ident}},
+		{"comma index at", []int32{',', INDEX, ident, '@', ident}, []int32{',', INDEX_AFTER_ORDER_BY_BEFORE_AT, ident, '@', ident}},
+		{"order by index directly at", []int32{ORDER, BY, INDEX, '@', ident}, []int32{ORDER, BY, INDEX, '@', ident}},
+		{"order by index at end", []int32{ORDER, BY, INDEX}, []int32{ORDER, BY, INDEX}},
+		{"order by index no at", []int32{ORDER, BY, INDEX, ident}, []int32{ORDER, BY, INDEX, ident}},
+		{"order by index at too far", []int32{ORDER, BY, INDEX, ident, '.', ident, '.', ident, '.', ident, '@'}, []int32{ORDER, BY, INDEX, ident, '.', ident, '.', ident, '.', ident, '@'}},
+		{"by index at", []int32{BY, INDEX, ident, '@'}, []int32{BY, INDEX, ident, '@'}},
+
+		{"as of system time", []int32{AS, OF, SYSTEM, TIME}, []int32{AS_LA, OF, SYSTEM, TIME}},
+		{"as of system at end", []int32{AS, OF, SYSTEM}, []int32{AS, OF, ERROR}},
+		{"as of time", []int32{AS, OF, TIME}, []int32{AS, OF, ERROR}},
+		{"as of system help", []int32{AS, OF, SYSTEM, HELPTOKEN}, []int32{AS_LA, OF, SYSTEM, HELPTOKEN}},
+		{"as of at end", []int32{AS, OF}, []int32{AS, OF}},
+		{"as at end", []int32{AS}, []int32{AS}},
+
+		{"not between", []int32{NOT, BETWEEN}, []int32{NOT_LA, BETWEEN}},
+		{"not in", []int32{NOT, IN}, []int32{NOT_LA, IN}},
+		{"not like", []int32{NOT, LIKE}, []int32{NOT_LA, LIKE}},
+		{"not ilike", []int32{NOT, ILIKE}, []int32{NOT_LA, ILIKE}},
+		{"not similar", []int32{NOT, SIMILAR}, []int32{NOT_LA, SIMILAR}},
+		{"not null", []int32{NOT, NULL}, []int32{NOT, NULL}},
+		{"not at end", []int32{NOT}, []int32{NOT}},
+
+		{"generated always as", []int32{GENERATED, ALWAYS, AS}, []int32{GENERATED_ALWAYS, ALWAYS, AS}},
+		{"generated always help", []int32{GENERATED, ALWAYS, HELPTOKEN}, []int32{GENERATED_ALWAYS, ALWAYS, HELPTOKEN}},
+		{"set generated always", []int32{SET, GENERATED, ALWAYS}, []int32{SET, GENERATED_ALWAYS, ALWAYS}},
+		{"generated always alias", []int32{GENERATED, ALWAYS, FROM}, []int32{GENERATED, ALWAYS, FROM}},
+		{"generated always at end", []int32{GENERATED, ALWAYS}, []int32{GENERATED, ALWAYS}},
+		{"generated by default", []int32{GENERATED, BY, DEFAULT}, []int32{GENERATED_BY_DEFAULT, BY, DEFAULT}},
+		{"generated by alias", []int32{GENERATED, BY, FROM}, []int32{GENERATED, BY, FROM}},
+		{"generated by at end", []int32{GENERATED, BY}, []int32{GENERATED, BY}},
+		{"generated at end", []int32{GENERATED}, []int32{GENERATED}},
+
+		{"with time", []int32{WITH, TIME}, []int32{WITH_LA, TIME}},
+		{"with ordinality", []int32{WITH, ORDINALITY}, []int32{WITH_LA, ORDINALITY}},
+		{"with bucket_count", []int32{WITH, BUCKET_COUNT}, []int32{WITH_LA, BUCKET_COUNT}},
+		{"with ident", []int32{WITH, ident}, []int32{WITH, ident}},
+		{"with cte ordinality", []int32{WITH, ORDINALITY, AS, '('}, []int32{WITH, ORDINALITY, AS, '('}},
+		{"with cte time cols", []int32{WITH, TIME, '(', ident}, []int32{WITH, TIME, '(', ident}},
+		{"with cte not materialized", []int32{WITH, BUCKET_COUNT, AS, NOT, MATERIALIZED}, []int32{WITH, BUCKET_COUNT, AS, NOT, MATERIALIZED}},
+		{"with cte materialized", []int32{WITH, ORDINALITY, AS, MATERIALIZED, '(', SELECT, ident}, []int32{WITH, ORDINALITY, AS, MATERIALIZED, '(', SELECT, ident}},
+		{"with cte materialized empty select", []int32{WITH, ORDINALITY, AS, MATERIALIZED, '(', SELECT, ')'}, []int32{WITH, ORDINALITY, AS, MATERIALIZED, '(', SELECT, ')'}},
+		{"with ordinality alias materialized cols", []int32{WITH, ORDINALITY, AS, MATERIALIZED, '(', ident, ','}, []int32{WITH_LA, ORDINALITY, AS, MATERIALIZED, '(', ident, ','}},
+		{"with ordinality alias", []int32{WITH, ORDINALITY, AS, ident, '('}, []int32{WITH_LA, ORDINALITY, AS, ident, '('}},
+		{"with ordinality alias materialized", []int32{WITH, ORDINALITY, AS, MATERIALIZED}, []int32{WITH_LA, ORDINALITY, AS, MATERIALIZED}},
+		{"with time zone", []int32{WITH, TIME, ZONE}, []int32{WITH_LA, TIME, ZONE}},
+
+		{"nulls first", []int32{NULLS, FIRST}, []int32{NULLS_LA, FIRST}},
+		{"nulls last", []int32{NULLS, LAST}, []int32{NULLS_LA, LAST}},
+		{"nulls at end", []int32{NULLS}, []int32{NULLS}},
+		{"name nulls last", []int32{ident, NULLS, LAST}, []int32{ident, NULLS_LA, LAST}},
+		{"paren nulls last", []int32{')', NULLS, LAST}, []int32{')', NULLS_LA, LAST}},
+		{"nulls nulls first", []int32{NULLS, NULLS, FIRST}, []int32{NULLS, NULLS_LA, FIRST}},
+		{"by nulls first", []int32{BY, NULLS, FIRST}, []int32{BY, NULLS, FIRST}},
+		{"comma nulls first", []int32{',', NULLS, FIRST}, []int32{',', NULLS, FIRST}},
+		{"select nulls first", []int32{SELECT, NULLS, FIRST}, []int32{SELECT, NULLS, FIRST}},
+
+		{"reset all", []int32{RESET, ALL}, []int32{RESET_ALL, ALL}},
+		{"role all", []int32{ROLE, ALL}, []int32{ROLE_ALL, ALL}},
+		{"user all", []int32{USER, ALL}, []int32{USER_ALL, ALL}},
+		{"tenant all", []int32{TENANT, ALL}, []int32{TENANT_ALL, ALL}},
+		{"cluster all", []int32{CLUSTER, ALL}, []int32{CLUSTER_ALL, ALL}},
+		{"reset ident", []int32{RESET, ident}, []int32{RESET, ident}},
+
+		// Foreign key actions; see the ON rules in lookaheadRules.
+		{"on delete", []int32{ON, DELETE}, []int32{ON_LA, DELETE}},
+		{"on delete no action", []int32{ON, DELETE, NO, ACTION}, []int32{ON_LA, DELETE, NO, ACTION}},
+		{"on delete set null", []int32{ON, DELETE, SET, NULL}, []int32{ON_LA, DELETE, SET, NULL}},
+		{"on update no action", []int32{ON, UPDATE, NO, ACTION}, []int32{ON_LA, UPDATE, NO, ACTION}},
+		{"on update restrict", []int32{ON, UPDATE, RESTRICT}, []int32{ON_LA, UPDATE, RESTRICT}},
+		{"on update restrict comma", []int32{ON, UPDATE, RESTRICT, ','}, []int32{ON_LA, UPDATE, RESTRICT, ','}},
+		{"on update cascade", []int32{ON, UPDATE, CASCADE}, []int32{ON_LA, UPDATE, CASCADE}},
+		{"on update cascade paren", []int32{ON, UPDATE, CASCADE, ')'}, []int32{ON_LA, UPDATE, CASCADE, ')'}},
+		{"on update set null", []int32{ON, UPDATE, SET, NULL}, []int32{ON_LA, UPDATE, SET, NULL}},
+		{"on update set default", []int32{ON, UPDATE, SET, DEFAULT}, []int32{ON_LA, UPDATE, SET, DEFAULT}},
+		{"on delete on update", []int32{ON, DELETE, CASCADE, ON, UPDATE, SET, NULL}, []int32{ON_LA, DELETE, CASCADE, ON_LA, UPDATE, SET, NULL}},
+		{"on update on delete", []int32{ON, UPDATE, NO, ACTION, ON, DELETE, SET, DEFAULT}, []int32{ON_LA, UPDATE, NO, ACTION, ON_LA, DELETE, SET, DEFAULT}},
+		{"set null on update", []int32{SET, NULL, ON, UPDATE, CASCADE}, []int32{SET, NULL, ON_LA, UPDATE, CASCADE}},
+		{"set default on update", []int32{SET, DEFAULT, ON, UPDATE, RESTRICT}, []int32{SET, DEFAULT, ON_LA, UPDATE, RESTRICT}},
+		// Column ON UPDATE expressions.
+		{"on update ident", []int32{ON, UPDATE, ident}, []int32{ON, UPDATE, ident}},
+		{"on update default", []int32{ON, UPDATE, DEFAULT}, []int32{ON, UPDATE, DEFAULT}},
+		{"on update null", []int32{ON, UPDATE, NULL}, []int32{ON, UPDATE, NULL}},
+		{"on update paren", []int32{ON, UPDATE, '('}, []int32{ON, UPDATE, '('}},
+		{"on update set ident", []int32{ON, UPDATE, SET, '+', ident}, []int32{ON, UPDATE, SET, '+', ident}},
+		{"on update set dot", []int32{ON, UPDATE, SET, '.', ident}, []int32{ON, UPDATE, SET, '.', ident}},
+		{"on update set at end", []int32{ON, UPDATE, SET}, []int32{ON, UPDATE, SET}},
+		{"on update no ident", []int32{ON, UPDATE, NO, '+', ident}, []int32{ON, UPDATE, NO, '+', ident}},
+		{"on update no at end", []int32{ON, UPDATE, NO}, []int32{ON, UPDATE, NO}},
+		{"on update help", []int32{ON, UPDATE, HELPTOKEN}, []int32{ON, UPDATE, HELPTOKEN}},
+		{"on update at end", []int32{ON, UPDATE}, []int32{ON, UPDATE}},
+		{"alter column set on update cascade", []int32{ALTER, COLUMN, ident, SET, ON, UPDATE, CASCADE}, []int32{ALTER, COLUMN, ident, SET, ON, UPDATE, CASCADE}},
+		{"alter set on update set null", []int32{ALTER, ident, SET, ON, UPDATE, SET, NULL}, []int32{ALTER, ident, SET, ON, UPDATE, SET, NULL}},
+		// A referenced table named set.
+		{"references set on delete", []int32{REFERENCES, SET, ON, DELETE, CASCADE}, []int32{REFERENCES, SET, ON_LA, DELETE, CASCADE}},
+		{"references set on update", []int32{REFERENCES, SET, ON, UPDATE, CASCADE}, []int32{REFERENCES, SET, ON_LA, UPDATE, CASCADE}},
+		{"type alter references set on update", []int32{ALTER, REFERENCES, SET, ON, UPDATE, NO, ACTION}, []int32{ALTER, REFERENCES, SET, ON_LA, UPDATE, NO, ACTION}},
+		{"references schema set on update", []int32{REFERENCES, ident, '.', SET, ON, UPDATE, SET, NULL}, []int32{REFERENCES, ident, '.', SET, ON_LA, UPDATE, SET, NULL}},
+		// Other uses of ON.
+		{"on ident", []int32{ON, ident}, []int32{ON, ident}},
+		{"on at end", []int32{ON}, []int32{ON}},
+
+		{"set tracing", []int32{SET, TRACING}, []int32{SET_TRACING, TRACING}},
+		{"set tracing ident", []int32{SET, TRACING, '=', ident}, []int32{SET_TRACING, TRACING, '=', ident}},
+		{"set tracing dot", []int32{SET, TRACING, '.', ident}, []int32{SET, TRACING, '.', ident}},
+		{"set session tracing", []int32{SET, SESSION, TRACING}, []int32{SET_TRACING, SESSION, TRACING}},
+		{"set session tracing dot", []int32{SET, SESSION, TRACING, '.', ident}, []int32{SET, SESSION, TRACING, '.', ident}},
+		{"set session at end", []int32{SET, SESSION}, []int32{SET, SESSION}},
+		{"set at end", []int32{SET}, []int32{SET}},
+	}
+	covered := make(map[string]bool)
+	for _, d := range testData {
+		t.Run(d.name, func(t *testing.T) {
+			tokens := make([]TokenString, len(d.in))
+			symTokens := make([]sqlSymType, len(d.in))
+			for i, id := range d.in {
+				tokens[i] = tok(id)
+				symTokens[i] = sqlSymType{id: id, str: tokens[i].Str}
+			}
+			res := LexTokensForTesting(tokens)
+			if !reflect.DeepEqual(d.expected, res) {
+				t.Errorf("expected %d, but found %d", d.expected, res)
+			}
+			for pos := range symTokens {
+				if r := findLookaheadRule(symTokens, pos); r != nil {
+					covered[r.name] = true
+				}
+			}
+		})
+	}
+
+	// Every lookahead rule must be exercised by a test case above.
+	t.Run("coverage", func(t *testing.T) {
+		for _, r := range lookaheadRules {
+			if !covered[r.name] {
+				t.Errorf("no test case for lookahead rule %q", r.name)
+			}
+		}
+	})
+}
+
+// TestLookaheadRules checks that the lookahead rules are well-formed.
+func TestLookaheadRules(t *testing.T) {
+	names := make(map[string]bool)
+	for _, r := range lookaheadRules {
+		if names[r.name] {
+			t.Errorf("duplicate lookahead rule name %q", r.name)
+		}
+		names[r.name] = true
+		if (r.replacement == 0) == (r.errorMsg == "") {
+			t.Errorf("lookahead rule %q must have either a replacement or an error", r.name)
+		}
+		if r.errorMsg != "" && (r.errorOffset < 1 || r.errorOffset > len(r.after)) {
+			t.Errorf("lookahead rule %q reports an error outside of its pattern", r.name)
+		}
+	}
+}
+
+// TestLookaheadTable checks that lookaheadRulesByToken returns the rules of
+// each token, in the order of lookaheadRules.
+func TestLookaheadTable(t *testing.T) {
+	maxToken := int32(0)
+	for _, r := range lookaheadRules {
+		maxToken = max(maxToken, r.token)
+	}
+	for id := int32(0); id <= maxToken+1; id++ {
+		var expected []string
+		for _, r := range lookaheadRules {
+			if r.token == id {
+				expected = append(expected, r.name)
+			}
+		}
+		var found []string
+		for _, r := range lookaheadRulesByToken.rulesFor(id) {
+			found = append(found, r.name)
+		}
+		if !reflect.DeepEqual(expected, found) {
+			t.Errorf("token %d: expected rules %q, found %q", id, expected, found)
+		}
+	}
+}
+
+// TestLookaheadKeywords checks that the keywords that must be quoted
+// because of the lookahead rules match lexbase.LookaheadKeywords, which is
+// used when formatting identifiers. The latter is generated from the rules
+// by lookahead_keywords.awk, so this checks that the script found all of
+// them.
+func TestLookaheadKeywords(t *testing.T) {
+	fromRules := make(map[string]struct{})
+	for _, r := range lookaheadRules {
+		for _, k := range r.quote {
+			if _, ok := lexbase.KeywordsCategories[k]; !ok {
+				t.Errorf("lookahead rule %q quotes %q, which is not a keyword", r.name, k)
+			}
+			fromRules[k] = struct{}{}
+		}
+	}
+	fromLexbase := make(map[string]struct{})
+	for _, k := range lexbase.LookaheadKeywords {
+		fromLexbase[k] = struct{}{}
+	}
+	if !reflect.DeepEqual(fromRules, fromLexbase) {
+		t.Errorf("lexbase.LookaheadKeywords is out of date:\nexpected %v\nfound %v", fromRules, fromLexbase)
+	}
+}
+
+// TestLexerErrorContext verifies the rendering of the tokens preceding the
+// offending token in syntax error messages.
+func TestLexerErrorContext(t *testing.T) {
+	testData := []struct {
+		sql      string
+		pos      int
+		expected string
+	}{
+		{`SELECT (`, 0, ``},
+		{`SELECT (`, 1, `SELECT `},
+		{`SELECT (1`, 3, `SELECT (1`},
+		{`SELECT 1 $1`, 2, `SELECT 1 `},
+		{`SELECT a, b FROM t GROUP BY (`, 8, `... t GROUP BY `},
+		{`SELECT 'secret', x'ff', 1.5 FROM`, 6, `... x'ff', 1.5 `},
+		{"SELECT 'a' AS \"a\"\n\"b\"", 4, `... 'a' AS "a" `},
+		{"SELECT 'a'\n'b'", 2, "SELECT 'a'\n'b'"},
+		{`SELECT a/* c */+ b`, 3, `SELECT a + `},
+		{"SELECT a::INT8--c\n)", 4, `... a::INT8 `},
+		{`SELECT "a  b" (`, 2, `SELECT "a  b" `},
+		{`SELECT a_very_long_column_name_exceeding_the_cap (`, 2, ``},
+	}
+	for _, d := range testData {
+		t.Run(d.sql, func(t *testing.T) {
+			s := makeSQLScanner(d.sql)
+			var tokens []sqlSymType
+			for {
+				var lval sqlSymType
+				s.Scan(&lval)
+				if lval.id == 0 {
+					break
+				}
+				tokens = append(tokens, lval)
+			}
+			var l lexer
+			l.init(d.sql, tokens, ParseOptions{})
+			if actual := l.errorContext(d.pos); actual != d.expected {
+				t.Errorf("expected %q, but found %q", d.expected, actual)
+			}
+		})
+	}
+}
+
+// TestPurposelyUnimplementedTelemetryKeys checks that the features that the
+// grammar reports as purposely unimplemented are counted separately: their
+// names must normalize to distinct telemetry keys.
+func TestPurposelyUnimplementedTelemetryKeys(t *testing.T) {
+	src, err := os.ReadFile("sql.y")
+	if err != nil {
+		t.Fatal(err)
+	}
+	re := regexp.MustCompile(`purposelyUnimplemented\(sqllex, "([^"]*)"`)
+	matches := re.FindAllStringSubmatch(string(src), -1)
+	if len(matches) == 0 {
+		t.Fatal("no purposely unimplemented features found in sql.y")
+	}
+	features := make(map[string]string)
+	for _, m := range matches {
+		feature := m[1]
+		key := (&tree.UnsupportedError{FeatureName: feature, Purposely: true}).TelemetryKey()
+		if !regexp.MustCompile(`^sql\.purposely_unimplemented\.[a-z0-9_]+$`).MatchString(key) {
+			t.Errorf("feature %q: invalid telemetry key %q", feature, key)
+		}
+		if prev, ok := features[key]; ok && prev != feature {
+			t.Errorf("features %q and %q have the same telemetry key %q", prev, feature, key)
+		}
+		features[key] = feature
+	}
+
+	for _, d := range []struct {
+		feature, key string
+	}{
+		{"reindex table", "sql.purposely_unimplemented.reindex_table"},
+		{"  Foo/bar!! baz ", "sql.purposely_unimplemented.foo_bar_baz"},
+		{strings.Repeat("x", 100), "sql.purposely_unimplemented." + strings.Repeat("x", 64)},
+	} {
+		key := (&tree.UnsupportedError{FeatureName: d.feature, Purposely: true}).TelemetryKey()
+		if key != d.key {
+			t.Errorf("feature %q: expected telemetry key %q, but found %q", d.feature, d.key, key)
+		}
+	}
+}
@@ -0,0 +1,515 @@
+// Copyright 2026 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package parser
+
+// lookaheadRule describes a token that the lexer replaces with another
+// token when the tokens around it match a pattern. This lets the grammar
+// only require one-token lookahead despite SQL requiring multi-token
+// lookahead in some cases.
+type lookaheadRule struct {
+	// name identifies the rule in tests.
+	name string
+	// token is the token that the rule applies to.
+	token int32
+	// before matches the tokens preceding the token, nearest first.
+	before []tokenPattern
+	// after matches the tokens following the token, nearest first.
+	after []tokenPattern
+	// match, if set, is an additional condition for patterns that cannot
+	// be expressed with before and after.
+	match func(tokens []sqlSymType, pos int) bool
+	// replacement is the token that the token is replaced with.
+	replacement int32
+	// errorMsg, if set, makes the rule report a lexical error with this
+	// message at the token errorOffset positions after the token, instead
+	// of replacing the token.
+	errorMsg    string
+	errorOffset int
+	// quote lists the keywords that the rule depends on, and that must
+	// therefore be quoted when used as identifiers. lexbase.LookaheadKeywords
+	// is generated from these lists by lookahead_keywords.awk, so each
+	// keyword must be a string literal on the quote line of its rule.
+	quote []string
+}
+
+// tokenPattern matches the id of a token. Positions before the start or
+// after the end of the statement have the id 0.
+type tokenPattern func(id int32) bool
+
+// oneOf returns a tokenPattern that matches any of the given ids.
+func oneOf(ids ...int32) tokenPattern {
+	return func(id int32) bool {
+		for _, i := range ids {
+			if id == i {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// noneOf returns a tokenPattern that matches any id except the given ones.
+func noneOf(ids ...int32) tokenPattern {
+	in := oneOf(ids...)
+	return func(id int32) bool { return !in(id) }
+}
+
+// nonPunct matches identifiers, keywords, literals and placeholders, i.e.
+// anything but punctuation.
+func nonPunct(id int32) bool {
+	return id > 255
+}
+
+// lookaheadRules are the lookahead rules applied by the lexer. For a given
+// token, the first matching rule applies.
+var lookaheadRules = []lookaheadRule{
+	// Introducing the "RETURNING NOTHING" syntax in CockroachDB was a
+	// terrible idea, given that it is not even used any more! We should
+	// really deprecate it and remove this special case.
+	{
+		name:        "returning nothing",
+		token:       NOTHING,
+		before:      []tokenPattern{oneOf(RETURNING)},
+		replacement: NOTHING_AFTER_RETURNING,
+	},
+
+	// The following complex rules are a consternation, really.
+	//
+	// They flow from a profoundly mistaken decision to allow the INDEX
+	// keyword inside the column definition list of CREATE, a place where
+	// PostgreSQL did not allow it, for a very good reason: applications
+	// legitimately want to name columns with the name "index".
+	//
+	// After this mistaken decision was first made, the INDEX keyword was
+	// also allowed in CockroachDB in another place where it is partially
+	// ambiguous with other identifiers: ORDER BY (`ORDER BY INDEX foo@bar`,
+	// ambiguous with `ORDER BY index`).
+	//
+	// Sadly it took a very long time before we realized this mistake, and
+	// by that time these uses of INDEX have become legitimate CockroachDB
+	// features.
+	//
+	// We are thus left with the need to disambiguate between:
+	//
+	// CREATE TABLE t(index a) -- column name "index", column type "a"
+	// CREATE TABLE t(index (a)) -- keyword INDEX, column name "a"
+	// CREATE TABLE t(index a (b)) -- keyword INDEX, index name "a", column name "b"
+	//
+	// Thankfully, a coldef for a column named "index" and an index
+	// specification differ unambiguously, *given sufficient lookahead*: an
+	// index specification always has an open '(' after INDEX, with or
+	// without an identifier in-between. A column definition never has this.
+	//
+	// Likewise, between:
+	//
+	// ORDER BY index
+	// ORDER BY index a@idx
+	// ORDER BY index a.b@idx
+	// ORDER BY index a.b.c@idx
+	//
+	// We can unambiguously distinguish by the presence of the '@' sign with
+	// a maximum of 6 token lookahead.
+	{
+		// CREATE ... (INDEX (
+		// CREATE ... (x INT, y INT, INDEX (
+		// SCRUB ... WITH OPTIONS INDEX (...
+		// SCRUB ... WITH OPTIONS a, INDEX (...
+		name:        "index before paren",
+		token:       INDEX,
+		before:      []tokenPattern{oneOf(',', '(', OPTIONS)},
+		after:       []tokenPattern{oneOf('(')},
+		replacement: INDEX_BEFORE_PAREN,
+	},
+	{
+		// CREATE ... (INVERTED INDEX (
+		// CREATE ... (x INT, y INT, VECTOR INDEX (
+		name:        "inverted or vector index before paren",
+		token:       INDEX,
+		before:      []tokenPattern{oneOf(INVERTED, VECTOR), oneOf(',', '(')},
+		after:       []tokenPattern{oneOf('(')},
+		replacement: INDEX_BEFORE_PAREN,
+	},
+	{
+		// CREATE ... (INDEX abc (
+		// CREATE ... (x INT, y INT, INDEX abc (
+		name:        "index before name then paren",
+		token:       INDEX,
+		before:      []tokenPattern{oneOf(',', '(')},
+		after:       []tokenPattern{nonPunct, oneOf('(')},
+		replacement: INDEX_BEFORE_NAME_THEN_PAREN,
+	},
+	{
+		// CREATE ... (INVERTED INDEX abc (
+		// CREATE ... (x INT, y INT, VECTOR INDEX abc (
+		name:        "inverted or vector index before name then paren",
+		token:       INDEX,
+		before:      []tokenPattern{oneOf(INVERTED, VECTOR), oneOf(',', '(')},
+		after:       []tokenPattern{nonPunct, oneOf('(')},
+		replacement: INDEX_BEFORE_NAME_THEN_PAREN,
+	},
+	// The rules above all require that the INDEX keyword be followed
+	// ultimately by an open parenthesis, with no '@' in-between. The rules
+	// below are strictly exclusive with this situation.
+	{
+		// SORT BY INDEX <objname> @
+		name:        "order by index before at",
+		token:       INDEX,
+		before:      []tokenPattern{oneOf(BY), oneOf(ORDER)},
+		match:       atSignAfterObjectName,
+		replacement: INDEX_AFTER_ORDER_BY_BEFORE_AT,
+	},
+	{
+		// SORT BY a, b, INDEX <objname> @
+		name:        "comma index before at",
+		token:       INDEX,
+		before:      []tokenPattern{oneOf(',')},
+		match:       atSignAfterObjectName,
+		replacement: INDEX_AFTER_ORDER_BY_BEFORE_AT,
+	},
+
+	// Comments are dropped by the scanner, so AS, OF and SYSTEM are adjacent
+	// here even if the input has comments between them.
+	{
+		name:        "as of system time",
+		token:       AS,
+		after:       []tokenPattern{oneOf(OF), oneOf(SYSTEM), oneOf(TIME, HELPTOKEN)},
+		replacement: AS_LA,
+		quote:       []string{"of"},
+	},
+	{
+		name:        "as of system without time",
+		token:       AS,
+		after:       []tokenPattern{oneOf(OF), oneOf(SYSTEM)},
+		errorMsg:    "AS OF SYSTEM must be followed by TIME",
+		errorOffset: 2,
+	},
+	{
+		// An alias named "of", as in "SELECT x AS of", cannot be followed by
+		// TIME, so this can only be a mistake.
+		name:        "as of time",
+		token:       AS,
+		after:       []tokenPattern{oneOf(OF), oneOf(TIME)},
+		errorMsg:    "AS OF must be followed by SYSTEM TIME",
+		errorOffset: 2,
+	},
+
+	{
+		name:        "not",
+		token:       NOT,
+		after:       []tokenPattern{oneOf(BETWEEN, IN, LIKE, ILIKE, SIMILAR)},
+		replacement: NOT_LA,
+		quote:       []string{"between", "ilike", "in", "like", "similar"},
+	},
+
+	// GENERATED, ALWAYS and BY are all unreserved, so the rewrite only
+	// happens when the following tokens can only be part of a generated
+	// column clause (GENERATED ALWAYS AS, GENERATED BY DEFAULT, or SET
+	// GENERATED ALWAYS in ALTER COLUMN). Otherwise, "SELECT generated always
+	// FROM t" would fail to parse.
+	{
+		name:        "set generated always",
+		token:       GENERATED,
+		before:      []tokenPattern{oneOf(SET)},
+		after:       []tokenPattern{oneOf(ALWAYS)},
+		replacement: GENERATED_ALWAYS,
+		quote:       []string{"generated"},
+	},
+	{
+		name:        "generated always as",
+		token:       GENERATED,
+		after:       []tokenPattern{oneOf(ALWAYS), oneOf(AS, HELPTOKEN)},
+		replacement: GENERATED_ALWAYS,
+	},
+	{
+		name:        "generated by default",
+		token:       GENERATED,
+		after:       []tokenPattern{oneOf(BY), oneOf(DEFAULT, HELPTOKEN)},
+		replacement: GENERATED_BY_DEFAULT,
+	},
+
+	// WITH_LA introduces WITH ORDINALITY, WITH TIME ZONE and WITH
+	// BUCKET_COUNT. A common table expression can also be named like these
+	// keywords, as in "WITH ordinality AS (SELECT 1) ...". The name of a CTE
+	// is followed by a column list or by AS and the query of the CTE, which
+	// never follow WITH ORDINALITY, WITH TIME ZONE or WITH BUCKET_COUNT, so
+	// WITH is left alone then.
+	{
+		name:        "with",
+		token:       WITH,
+		after:       []tokenPattern{oneOf(TIME, ORDINALITY, BUCKET_COUNT)},
+		match:       notCTENameAfterWith,
+		replacement: WITH_LA,
+		quote:       []string{"ordinality", "time"},
+	},
+	// NULLS FIRST and NULLS LAST follow an ordering expression, so NULLS at
+	// the start of an expression is a column named nulls, as in "SELECT
+	// nulls first FROM t" where first is an alias.
+	{
+		name:        "nulls",
+		token:       NULLS,
+		before:      []tokenPattern{noneOf(',', '(', BY, SELECT, DISTINCT, ALL, RETURNING)},
+		after:       []tokenPattern{oneOf(FIRST, LAST)},
+		replacement: NULLS_LA,
+	},
+
+	// Note that quoted identifiers are always scanned as IDENT, even if
+	// their text is a keyword, so the rules below never apply to a quoted
+	// name like RESET "all" or VIRTUAL CLUSTER "all".
+	{
+		name:        "reset all",
+		token:       RESET,
+		after:       []tokenPattern{oneOf(ALL)},
+		replacement: RESET_ALL,
+		quote:       []string{"reset"},
+	},
+	{
+		name:        "role all",
+		token:       ROLE,
+		after:       []tokenPattern{oneOf(ALL)},
+		replacement: ROLE_ALL,
+		quote:       []string{"role"},
+	},
+	{
+		name:        "user all",
+		token:       USER,
+		after:       []tokenPattern{oneOf(ALL)},
+		replacement: USER_ALL,
+		quote:       []string{"user"},
+	},
+	{
+		name:        "tenant all",
+		token:       TENANT,
+		after:       []tokenPattern{oneOf(ALL)},
+		replacement: TENANT_ALL,
+		quote:       []string{"tenant"},
+	},
+	{
+		name:        "cluster all",
+		token:       CLUSTER,
+		after:       []tokenPattern{oneOf(ALL)},
+		replacement: CLUSTER_ALL,
+	},
+
+	// ON starts a foreign key action (ON_LA UPDATE reference_action or ON_LA
+	// DELETE reference_action) according to the following decision table.
+	// Otherwise, ON UPDATE starts a column ON UPDATE expression.
+	//
+	//	before ON                 after ON                  result
+	//	---------                 --------                  ------
+	//	any                       DELETE                    foreign key action
+	//	ALTER [COLUMN] <col> SET  UPDATE <anything>         expression
+	//	any                       UPDATE NO ACTION          foreign key action
+	//	any                       UPDATE RESTRICT           foreign key action
+	//	any                       UPDATE CASCADE            foreign key action
+	//	any                       UPDATE SET NULL           foreign key action
+	//	any                       UPDATE SET DEFAULT        foreign key action
+	//	any                       UPDATE <anything else>    expression
+	//
+	// The table referenced by a foreign key can be named set, as in
+	// "REFERENCES set ON UPDATE CASCADE", so SET before ON only starts an
+	// expression after the column name of ALTER COLUMN.
+	//
+	// An expression starting with a column named no or set is therefore only
+	// mistaken for a foreign key action if it is followed by ACTION, or by
+	// NULL or DEFAULT respectively, which cannot continue an expression.
+	// Columns named restrict or cascade must be quoted in an ON UPDATE
+	// expression. Note that ON UPDATE DEFAULT is neither: the SET DEFAULT
+	// action requires SET.
+	//
+	// If you add a foreign key action to reference_action in sql.y, add it
+	// here too.
+	{
+		name:        "on delete",
+		token:       ON,
+		after:       []tokenPattern{oneOf(DELETE)},
+		replacement: ON_LA,
+		quote:       []string{"on"},
+	},
+	{
+		name:        "on update restrict or cascade",
+		token:       ON,
+		after:       []tokenPattern{oneOf(UPDATE), oneOf(RESTRICT, CASCADE)},
+		match:       notAlterColumnSetOn,
+		replacement: ON_LA,
+	},
+	{
+		name:        "on update no action",
+		token:       ON,
+		after:       []tokenPattern{oneOf(UPDATE), oneOf(NO), oneOf(ACTION)},
+		match:       notAlterColumnSetOn,
+		replacement: ON_LA,
+	},
+	{
+		name:        "on update set null or default",
+		token:       ON,
+		after:       []tokenPattern{oneOf(UPDATE), oneOf(SET), oneOf(NULL, DEFAULT)},
+		match:       notAlterColumnSetOn,
+		replacement: ON_LA,
+	},
+
+	// Do not use the lookahead rules for `SET tracing.custom ...` or `SET
+	// SESSION tracing.custom ...`.
+	{
+		name:        "set tracing",
+		token:       SET,
+		after:       []tokenPattern{oneOf(TRACING), noneOf('.')},
+		replacement: SET_TRACING,
+		quote:       []string{"set"},
+	},
+	{
+		name:        "set session tracing",
+		token:       SET,
+		after:       []tokenPattern{oneOf(SESSION), oneOf(TRACING), noneOf('.')},
+		replacement: SET_TRACING,
+	},
+}
+
+// lookaheadTable indexes lookahead rules by the token they apply to,
+// preserving their order. The lexer consults it for every token, so it is a
+// slice indexed by the token id, offset by the smallest id that has rules,
+// rather than a map. Punctuation never has rules, so most tokens are
+// dismissed by a bounds check.
+type lookaheadTable struct {
+	minToken int32
+	rules    [][]*lookaheadRule
+}
+
+// makeLookaheadTable indexes the given rules.
+func makeLookaheadTable(rules []lookaheadRule) lookaheadTable {
+	var t lookaheadTable
+	if len(rules) == 0 {
+		return t
+	}
+	minToken, maxToken := rules[0].token, rules[0].token
+	for i := range rules {
+		minToken = min(minToken, rules[i].token)
+		maxToken = max(maxToken, rules[i].token)
+	}
+	t.minToken = minToken
+	t.rules = make([][]*lookaheadRule, maxToken-minToken+1)
+	for i := range rules {
+		r := &rules[i]
+		t.rules[r.token-minToken] = append(t.rules[r.token-minToken], r)
+	}
+	return t
+}
+
+// rulesFor returns the rules that apply to the token with the given id.
+func (t *lookaheadTable) rulesFor(id int32) []*lookaheadRule {
+	i := int(id - t.minToken)
+	if i < 0 || i >= len(t.rules) {
+		return nil
+	}
+	return t.rules[i]
+}
+
+// lookaheadRulesByToken indexes lookaheadRules.
+var lookaheadRulesByToken = makeLookaheadTable(lookaheadRules)
+
+// tokenID returns the id of tokens[pos], or 0 if pos is out of range.
+func tokenID(tokens []sqlSymType, pos int) int32 {
+	if pos < 0 || pos >= len(tokens) {
+		return 0
+	}
+	return tokens[pos].id
+}
+
+// matches returns true if the rule applies to tokens[pos].
+func (r *lookaheadRule) matches(tokens []sqlSymType, pos int) bool {
+	for i, p := range r.before {
+		if !p(tokenID(tokens, pos-1-i)) {
+			return false
+		}
+	}
+	for i, p := range r.after {
+		if !p(tokenID(tokens, pos+1+i)) {
+			return false
+		}
+	}
+	return r.match == nil || r.match(tokens, pos)
+}
+
+// findLookaheadRule returns the rule that applies to tokens[pos], or nil if
+// there is none.
+func findLookaheadRule(tokens []sqlSymType, pos int) *lookaheadRule {
+	for _, r := range lookaheadRulesByToken.rulesFor(tokens[pos].id) {
+		if r.matches(tokens, pos) {
+			return r
+		}
+	}
+	return nil
+}
+
+// notCTENameAfterWith returns true unless the WITH keyword at tokens[pos]
+// starts the definition of a common table expression, see cteNameAfterWith.
+func notCTENameAfterWith(tokens []sqlSymType, pos int) bool {
+	return !cteNameAfterWith(tokens, pos)
+}
+
+// cteNameAfterWith returns true if the WITH keyword at tokens[pos] is followed
+// by the definition of a common table expression, i.e. a name followed by a
+// column list, or by AS and the parenthesized query of the CTE with an
+// optional materialization clause.
+func cteNameAfterWith(tokens []sqlSymType, pos int) bool {
+	switch tokenID(tokens, pos+2) {
+	case '(':
+		return true
+	case AS:
+		switch tokenID(tokens, pos+3) {
+		case '(', NOT:
+			return true
+		case MATERIALIZED:
+			if tokenID(tokens, pos+4) != '(' {
+				return false
+			}
+			// MATERIALIZED can also be an alias with a column list, as in
+			// "WITH ORDINALITY AS materialized (x, n)". The column list is
+			// made of names followed by a comma or a parenthesis, which the
+			// query of a CTE never is, unless it is a SELECT without targets.
+			switch tokenID(tokens, pos+6) {
+			case ',', ')':
+				return tokenID(tokens, pos+5) == SELECT
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// notAlterColumnSetOn returns true unless the ON keyword at tokens[pos]
+// follows SET in ALTER [COLUMN] <col> SET, where ON UPDATE is followed by an
+// expression. The column name cannot be REFERENCES, which is reserved, so
+// this does not match "<col> <type> REFERENCES set ON UPDATE" for a type
+// named alter.
+func notAlterColumnSetOn(tokens []sqlSymType, pos int) bool {
+	id := tokenID(tokens, pos-2)
+	return tokenID(tokens, pos-1) != SET || !nonPunct(id) || id == REFERENCES ||
+		!oneOf(ALTER, COLUMN)(tokenID(tokens, pos-3))
+}
+
+// atSignAfterObjectName returns true if the INDEX keyword at tokens[pos] is
+// followed by an object name and an '@' sign.
+func atSignAfterObjectName(tokens []sqlSymType, pos int) bool {
+	// An object name has one of the following forms:
+	//    name
+	//    name.name
+	//    name.name.name
+	// So it is between 1 and 5 tokens in length.
+	for i := pos + 1; i < len(tokens) && i < pos+7; i++ {
+		curToken := tokens[i].id
+		// An object name can only contain keyword/identifiers, and
+		// the punctuation '.'.
+		if curToken < 255 /* not ident/keyword */ && curToken != '.' && curToken != '@' {
+			// Definitely not object name.
+			return false
+		}
+		if curToken == '@' {
+			// The '@' cannot follow the INDEX keyword directly.
+			return i != pos+1
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+# Generates lexbase.LookaheadKeywords from the quote lists of the lookahead
+# rules in lookahead.go.
+
+BEGIN {
+  print "// Code generated by lookahead_keywords.awk. DO NOT EDIT."
+  print "// GENERATED FILE DO NOT EDIT"
+  print
+  print "package lexbase"
+  print
+  print "// LookaheadKeywords are the keywords for which the parser needs one token"
+  print "// of lookahead extra to determine their token type, so they must be quoted"
+  print "// when used as identifiers."
+  print "var LookaheadKeywords = []string{"
+
+  # This variable will be associated with a pipe for intermediate output.
+  sort = "env LC_ALL=C sort -u"
+}
+
+/^[ \t]*quote:[ \t]*\[\]string\{/ {
+  line = $0
+  while (match(line, /"[^"]*"/)) {
+    printf("\t%s,\n", substr(line, RSTART, RLENGTH)) | sort
+    line = substr(line, RSTART + RLENGTH)
+  }
+}
+
+END {
+  # Flush the intermediate output by closing the pipe.
+  close(sort)
+  print "}"
+}
@@ -40,9 +40,18 @@ type Parser struct {
 	lexer      lexer
 	parserImpl sqlParserImpl
 	tokBuf     [8]sqlSymType
-	stmtBuf    [1]statements.Statement[tree.Statement]
+	// tokens is the token buffer for statements that do not fit in tokBuf.
+	// It is retained across statements and parses; see releaseTokens.
+	tokens  []sqlSymType
+	stmtBuf [1]statements.Statement[tree.Statement]
 }
 
+// maxRetainedTokens is the largest token buffer capacity that a Parser
+// retains for reuse. Larger buffers are dropped after use, so that parsing a
+// single very large statement does not pin its memory for the lifetime of a
+// long-lived Parser.
+const maxRetainedTokens = 1024
+
 // INT8 is the historical interpretation of INT. This should be left
 // alone in the future, since there are many sql fragments stored
 // in various descriptors. Any user input that was created after
@@ -61,21 +70,163 @@ func NakedIntTypeFromDefaultIntSize(defaultIntSize int32) *types.T {
 	}
 }
 
+// SerialMode determines how the parser interprets the SERIAL type name.
+type SerialMode uint8
+
+const (
+	// SerialFollowsNakedInt interprets SERIAL as SERIAL4 if the naked INT
+	// type is INT4, and as SERIAL8 otherwise. This is the default.
+	SerialFollowsNakedInt SerialMode = iota
+	// SerialAlways4 interprets SERIAL as SERIAL4.
+	SerialAlways4
+	// SerialAlways8 interprets SERIAL as SERIAL8. This is useful to mirror
+	// sessions using a serial_normalization mode which upgrades all serial
+	// columns to INT8, e.g. rowid.
+	SerialAlways8
+)
+
+// serialType returns the type to use for SERIAL, given the naked INT type.
+func (m SerialMode) serialType(nakedIntType *types.T) *types.T {
+	switch m {
+	case SerialAlways4:
+		return &types.Serial4Type
+	case SerialAlways8:
+		return &types.Serial8Type
+	}
+	if nakedIntType.Width() == 32 {
+		return &types.Serial4Type
+	}
+	return &types.Serial8Type
+}
+
+// ParseOptions configures how the parser interprets the naked INT and SERIAL
+// type names. The zero value interprets both as 64-bit types, like Parse.
+type ParseOptions struct {
+	// NakedIntType is the type used for INT and INTEGER. If nil,
+	// defaultNakedIntType is used.
+	NakedIntType *types.T
+	// SerialMode determines the type used for SERIAL.
+	SerialMode SerialMode
+	// CanonicalErrors, if set, makes syntax errors omit the echo of the
+	// source SQL and refer to the class of the offending token (e.g.
+	// "identifier") rather than its text. This makes the errors easier to
+	// deduplicate and compare, for example when fuzzing. See CanonicalError.
+	CanonicalErrors bool
+
+	// PreserveCase, if set, records the original spelling of the keywords
+	// and unquoted identifiers of each statement in Statement.Spellings, so
+	// that FormatPreservingCase can render the statement with its original
+	// casing.
+	PreserveCase bool
+
+	// CollectNames, if set, records the names of the tables, sequences and
+	// functions referenced by each statement in Statement.ReferencedNames,
+	// for dependency analysis. Every name in a table, sequence or function
+	// position is recorded, including the objects that the statement
+	// creates, alters or drops. The names are collected where the grammar
+	// constructs them, so they are syntactic: they are not resolved.
+	CollectNames bool
+
+	// MeasureComplexity, if set, records a measure of the complexity of each
+	// statement in Statement.Complexity, computed from its tokens.
+	MeasureComplexity bool
+
+	// CollectTokens, if set, records the tokens of each statement in
+	// Statement.Tokens. Statement.TokenRange is recorded regardless.
+	CollectTokens bool
+
+	// scratch, if set, provides the containers of the parse results. See
+	// ParseOneWithScratch.
+	scratch *ParseScratch
+	// tokenBase is the index of the first token of the statement in the
+	// token stream of the input. It is maintained by parseStatements.
+	tokenBase int
+
+	// AnnotationBase is the annotation index after which the annotations
+	// of each statement are numbered, so that the annotations of statements
+	// parsed separately can share a single tree.Annotations container: the
+	// statements then use the indexes from AnnotationBase+1 to their
+	// NumAnnotations. It must not be negative.
+	AnnotationBase tree.AnnotationIdx
+}
+
+// nakedIntType returns the type to use for INT.
+func (o ParseOptions) nakedIntType() *types.T {
+	if o.NakedIntType == nil {
+		return defaultNakedIntType
+	}
+	return o.NakedIntType
+}
+
 // Parse parses the sql and returns a list of statements.
 func (p *Parser) Parse(sql string) (statements.Statements, error) {
-	return p.parseWithDepth(1, sql, defaultNakedIntType, discardComments)
+	return p.parseWithDepth(1, sql, ParseOptions{}, discardComments)
 }
 
 // ParseWithInt parses a sql statement string and returns a list of
 // Statements. The INT token will result in the specified TInt type.
 func (p *Parser) ParseWithInt(sql string, nakedIntType *types.T) (statements.Statements, error) {
-	return p.parseWithDepth(1, sql, nakedIntType, discardComments)
+	return p.parseWithDepth(1, sql, ParseOptions{NakedIntType: nakedIntType}, discardComments)
+}
+
+// ParseWithOptions parses a sql statement string and returns a list of
+// Statements. The INT and SERIAL types are interpreted according to opts.
+func (p *Parser) ParseWithOptions(sql string, opts ParseOptions) (statements.Statements, error) {
+	return p.parseWithDepth(1, sql, opts, discardComments)
 }
 
-func (p *Parser) parseOneWithInt(
-	sql string, nakedIntType *types.T, comments commentsMode,
+// ParseScratch holds containers that are reused by successive parses, so
+// that parsing a statement in a steady state does not allocate them. The
+// zero value is ready to use.
+//
+// The containers are owned by the scratch: the placeholder maps of a
+// statement parsed with a scratch are only valid until the scratch is used
+// again, and a scratch must not be used by concurrent parses nor read
+// concurrently with a parse.
+type ParseScratch struct {
+	placeholderTypeHints map[tree.PlaceholderIdx]statements.PlaceholderTypeHint
+	placeholderPositions map[tree.PlaceholderIdx]int32
+	annotations          tree.Annotations
+}
+
+// Annotations returns an annotations container for the given number of
+// annotations, e.g. the NumAnnotations of a statement. It reuses the memory
+// of the container returned by the previous call, which must no longer be
+// used.
+func (s *ParseScratch) Annotations(numAnnotations tree.AnnotationIdx) tree.Annotations {
+	if int(numAnnotations) > cap(s.annotations) {
+		s.annotations = tree.MakeAnnotations(numAnnotations)
+		return s.annotations
+	}
+	s.annotations = s.annotations[:numAnnotations]
+	clear(s.annotations)
+	return s.annotations
+}
+
+// ParseOneWithScratch is like ParseOneWithOptions, but the placeholder maps
+// of the statement reuse the memory of the given scratch. See ParseScratch
+// for the ownership rules.
+func (p *Parser) ParseOneWithScratch(
+	sql string, opts ParseOptions, scratch *ParseScratch,
 ) (statements.Statement[tree.Statement], error) {
-	stmts, err := p.parseWithDepth(1, sql, nakedIntType, comments)
+	opts.scratch = scratch
+	stmt, err := p.parseOneWithOptions(sql, opts, discardComments)
+	if err != nil {
+		return stmt, err
+	}
+	if stmt.PlaceholderTypeHints != nil {
+		scratch.placeholderTypeHints = stmt.PlaceholderTypeHints
+	}
+	if stmt.PlaceholderPositions != nil {
+		scratch.placeholderPositions = stmt.PlaceholderPositions
+	}
+	return stmt, nil
+}
+
+func (p *Parser) parseOneWithOptions(
+	sql string, opts ParseOptions, comments commentsMode,
+) (statements.Statement[tree.Statement], error) {
+	stmts, err := p.parseWithDepth(1, sql, opts, comments)
 	if err != nil {
 		return statements.Statement[tree.Statement]{}, err
 	}
@@ -87,14 +238,18 @@ func (p *Parser) parseOneWithInt(
 
 func (p *Parser) scanOneStmt() (sql string, tokens []sqlSymType, done bool) {
 	tokens = p.tokBuf[:0]
+	if cap(p.tokens) > len(p.tokBuf) {
+		tokens = p.tokens[:0]
+	}
 	tokens = append(tokens, sqlSymType{})
-	lval := &p.tokBuf[0]
+	lval := &tokens[0]
+	firstWarning := len(p.scanner.Warnings)
 
 	// Scan the first token.
 	for {
 		p.scanner.Scan(lval)
 		if lval.id == 0 {
-			return "", nil, true
+			return "", tokens[:0], true
 		}
 		if lval.id != ';' {
 			break
@@ -104,6 +259,12 @@ func (p *Parser) scanOneStmt() (sql string, tokens []sqlSymType, done bool) {
 	startPos := lval.pos
 	// We make the resulting token positions match the returned string.
 	lval.pos = 0
+	// Ditto for the positions of the warnings reported for this statement.
+	defer func() {
+		for i := firstWarning; i < len(p.scanner.Warnings); i++ {
+			p.scanner.Warnings[i].Pos -= startPos
+		}
+	}()
 	var preValID int32
 	// This is used to track the degree of nested `BEGIN ATOMIC ... END` function
 	// body context. When greater than zero, it means that we're scanning through
@@ -147,35 +308,144 @@ const (
 )
 
 func (p *Parser) parseWithDepth(
-	depth int, sql string, nakedIntType *types.T, cm commentsMode,
+	depth int, sql string, opts ParseOptions, cm commentsMode,
 ) (statements.Statements, error) {
+	stmts, _, err := p.parseStatements(depth, sql, opts, cm, false /* untilCopyData */)
+	return stmts, err
+}
+
+// parseStatements parses the statements of sql. If untilCopyData is set, it
+// stops after the first COPY ... FROM STDIN statement and returns the
+// position where the copy data starts, or -1 if there is no such statement.
+func (p *Parser) parseStatements(
+	depth int, sql string, opts ParseOptions, cm commentsMode, untilCopyData bool,
+) (_ statements.Statements, copyDataStart int, _ error) {
 	stmts := statements.Statements(p.stmtBuf[:0])
 	p.scanner.Init(sql)
 	if cm == retainComments {
 		p.scanner.RetainComments()
 	}
 	defer p.scanner.Cleanup()
+	copyDataStart = -1
 	for {
+		firstWarning := len(p.scanner.Warnings)
 		sql, tokens, done := p.scanOneStmt()
-		stmt, err := p.parse(depth+1, sql, tokens, nakedIntType)
+		stmt, err := p.parse(depth+1, sql, tokens, opts)
+		opts.tokenBase += len(tokens)
+		p.releaseTokens(tokens)
 		if err != nil {
-			return nil, err
+			return nil, -1, err
 		}
 		if stmt.AST != nil {
+			if w := p.scanner.Warnings[firstWarning:]; len(w) > 0 {
+				stmt.Warnings = w[:len(w):len(w)]
+			}
 			stmts = append(stmts, stmt)
+			if c, ok := stmt.AST.(*tree.CopyFrom); ok && c.Stdin && untilCopyData {
+				// The copy data starts on the line following the statement. It
+				// is not SQL, so scanning stops here.
+				in := p.scanner.In()
+				copyDataStart = len(in)
+				if i := strings.IndexByte(in[p.scanner.Pos():], '\n'); i >= 0 {
+					copyDataStart = p.scanner.Pos() + i + 1
+				}
+				break
+			}
 		}
 		if done {
 			break
 		}
 	}
-	return stmts, nil
+	return stmts, copyDataStart, nil
+}
+
+// collectTokens returns the description of the given tokens of sql, for
+// ParseOptions.CollectTokens.
+func collectTokens(sql string, tokens []sqlSymType) []statements.Token {
+	res := make([]statements.Token, len(tokens))
+	for i := range tokens {
+		res[i] = statements.Token{
+			ID:   tokens[i].id,
+			Pos:  tokens[i].pos,
+			Text: tokenSpelling(sql, tokens[i].pos),
+		}
+	}
+	return res
+}
+
+// CopyData describes the copy data that follows a COPY ... FROM STDIN
+// statement in the input of ParseUntilCopyData.
+type CopyData struct {
+	// Stmt is the COPY ... FROM STDIN statement.
+	Stmt statements.Statement[tree.Statement]
+	// Start is the position in the input where the copy data starts, i.e.
+	// the beginning of the line following the statement.
+	Start int
+}
+
+// ParseUntilCopyData is like Parse, but stops after the first COPY ... FROM
+// STDIN statement: the input that follows it, as in the output of pg_dump,
+// is copy data rather than SQL and must not be scanned. The statements up
+// to and including the COPY statement are returned, and copyData describes
+// where the copy data starts. copyData is nil if the input has no such
+// statement.
+//
+// The caller is expected to consume the copy data, e.g. up to the "\."
+// terminator located by CopyDataEnd, and to parse the remaining input
+// separately.
+func (p *Parser) ParseUntilCopyData(
+	sql string,
+) (stmts statements.Statements, copyData *CopyData, _ error) {
+	stmts, start, err := p.parseStatements(1, sql, ParseOptions{}, discardComments, true /* untilCopyData */)
+	if err != nil || start < 0 {
+		return stmts, nil, err
+	}
+	return stmts, &CopyData{Stmt: stmts[len(stmts)-1], Start: start}, nil
+}
+
+// CopyDataEnd returns the position in sql just after the end of the copy
+// data in the text format starting at position start, i.e. after the line
+// holding only the "\." terminator. If there is no terminator, ok is false.
+func CopyDataEnd(sql string, start int) (end int, ok bool) {
+	for pos := start; pos < len(sql); {
+		line, _, found := strings.Cut(sql[pos:], "\n")
+		next := pos + len(line)
+		if found {
+			next++
+		}
+		if strings.TrimSuffix(line, "\r") == `\.` {
+			return next, true
+		}
+		pos = next
+	}
+	return 0, false
+}
+
+// releaseTokens makes the tokens returned by scanOneStmt available for the
+// next statement. The tokens are zeroed first, so that the retained buffer
+// does not keep the input SQL or any AST values alive.
+func (p *Parser) releaseTokens(tokens []sqlSymType) {
+	if cap(tokens) > maxRetainedTokens {
+		p.tokens = nil
+		return
+	}
+	// scanOneStmt drops the terminating token from the returned slice, so
+	// clear one token past the end as well.
+	clear(tokens[:min(len(tokens)+1, cap(tokens))])
+	if cap(tokens) > len(p.tokBuf) {
+		p.tokens = tokens[:0]
+	}
 }
 
 // parse parses a statement from the given scanned tokens.
 func (p *Parser) parse(
-	depth int, sql string, tokens []sqlSymType, nakedIntType *types.T,
+	depth int, sql string, tokens []sqlSymType, opts ParseOptions,
 ) (statements.Statement[tree.Statement], error) {
-	p.lexer.init(sql, tokens, nakedIntType)
+	if opts.AnnotationBase < 0 {
+		return statements.Statement[tree.Statement]{}, errors.AssertionFailedf(
+			"negative annotation base %d", opts.AnnotationBase)
+	}
+	p.lexer.init(sql, tokens, opts)
 	defer p.lexer.cleanup()
 	if p.parserImpl.Parse(&p.lexer) != 0 {
 		if p.lexer.lastError == nil {
@@ -190,7 +460,12 @@ func (p *Parser) parse(
 		// with the "syntax." prefix.
 		// TODO(knz): move the auto-prefixing of feature names to a
 		// higher level in the call stack.
-		tkeys := errors.GetTelemetryKeys(err)
+		var tkeys []string
+		if unsupportedErr := (*tree.UnsupportedError)(nil); errors.As(err, &unsupportedErr) {
+			tkeys = []string{unsupportedErr.TelemetryKey()}
+		} else {
+			tkeys = errors.GetTelemetryKeys(err)
+		}
 		if len(tkeys) > 0 {
 			for i := range tkeys {
 				tkeys[i] = "syntax." + tkeys[i]
@@ -200,14 +475,48 @@ func (p *Parser) parse(
 
 		return statements.Statement[tree.Statement]{}, err
 	}
-
-	return statements.Statement[tree.Statement]{
-		AST:             p.lexer.stmt,
-		SQL:             sql,
-		Comments:        p.scanner.Comments,
-		NumPlaceholders: p.lexer.numPlaceholders,
-		NumAnnotations:  p.lexer.numAnnotations,
-	}, nil
+	if p.lexer.annotationOverflow {
+		return statements.Statement[tree.Statement]{}, pgerror.Newf(pgcode.ProgramLimitExceeded,
+			"statement too complex: more than %d annotations after annotation base %d",
+			tree.MaxAnnotations-opts.AnnotationBase, opts.AnnotationBase)
+	}
+
+	stmt := statements.Statement[tree.Statement]{
+		AST:                  p.lexer.stmt,
+		SQL:                  sql,
+		Comments:             p.scanner.Comments,
+		NumPlaceholders:      p.lexer.numPlaceholders,
+		UsedPlaceholders:     p.lexer.usedPlaceholders,
+		NumAnnotations:       p.lexer.numAnnotations,
+		PlaceholderTypeHints: p.lexer.placeholderTypeHints,
+		PlaceholderPositions: p.lexer.placeholderPositions,
+		ReferencedNames:      p.lexer.referencedNames,
+		TokenRange:           p.lexer.tokenRange,
+	}
+	if p.lexer.asOfSystemTimePos >= 0 {
+		stmt.AsOfSystemTime = true
+		stmt.AsOfSystemTimePos = p.lexer.asOfSystemTimePos
+	}
+	if opts.PreserveCase {
+		stmt.Spellings = wordSpellings(sql, tokens)
+	}
+	if opts.MeasureComplexity {
+		stmt.Complexity = measureComplexity(tokens)
+	}
+	if opts.CollectTokens {
+		stmt.Tokens = collectTokens(sql, tokens)
+	}
+	if s := opts.scratch; s != nil {
+		// The maps that remain empty are not part of the result, so they can
+		// be reused right away.
+		if stmt.PlaceholderTypeHints != nil && len(stmt.PlaceholderTypeHints) == 0 {
+			s.placeholderTypeHints, stmt.PlaceholderTypeHints = stmt.PlaceholderTypeHints, nil
+		}
+		if stmt.PlaceholderPositions != nil && len(stmt.PlaceholderPositions) == 0 {
+			s.placeholderPositions, stmt.PlaceholderPositions = stmt.PlaceholderPositions, nil
+		}
+	}
+	return stmt, nil
 }
 
 // unaryNegation constructs an AST node for a negation. This attempts
@@ -237,7 +546,21 @@ func Parse(sql string) (statements.Statements, error) {
 // Statements. The INT token will result in the specified TInt type.
 func ParseWithInt(sql string, nakedIntType *types.T) (statements.Statements, error) {
 	var p Parser
-	return p.parseWithDepth(1, sql, nakedIntType, discardComments)
+	return p.parseWithDepth(1, sql, ParseOptions{NakedIntType: nakedIntType}, discardComments)
+}
+
+// ParseWithOptions parses a sql statement string and returns a list of
+// Statements. The INT and SERIAL types are interpreted according to opts.
+func ParseWithOptions(sql string, opts ParseOptions) (statements.Statements, error) {
+	var p Parser
+	return p.parseWithDepth(1, sql, opts, discardComments)
+}
+
+// ParseUntilCopyData parses the statements of sql up to the copy data of
+// the first COPY ... FROM STDIN statement. See Parser.ParseUntilCopyData.
+func ParseUntilCopyData(sql string) (statements.Statements, *CopyData, error) {
+	var p Parser
+	return p.ParseUntilCopyData(sql)
 }
 
 // ParseOne parses a sql statement string, ensuring that it contains only a
@@ -254,7 +577,7 @@ func ParseOne(sql string) (statements.Statement[tree.Statement], error) {
 // comments in the returned statement's Comment field.
 func ParseOneRetainComments(sql string) (statements.Statement[tree.Statement], error) {
 	var p Parser
-	return p.parseOneWithInt(sql, defaultNakedIntType, retainComments)
+	return p.parseOneWithOptions(sql, ParseOptions{}, retainComments)
 }
 
 // ParseOneWithInt is similar to ParseOn but interprets the INT and SERIAL
@@ -263,7 +586,14 @@ func ParseOneWithInt(
 	sql string, nakedIntType *types.T,
 ) (statements.Statement[tree.Statement], error) {
 	var p Parser
-	return p.parseOneWithInt(sql, nakedIntType, discardComments)
+	return p.parseOneWithOptions(sql, ParseOptions{NakedIntType: nakedIntType}, discardComments)
+}
+
+// ParseOneWithOptions is similar to ParseOne but interprets the INT and
+// SERIAL types according to opts.
+func ParseOneWithOptions(sql string, opts ParseOptions) (statements.Statement[tree.Statement], error) {
+	var p Parser
+	return p.parseOneWithOptions(sql, opts, discardComments)
 }
 
 // ParseQualifiedTableName parses a possibly qualified table name. The
@@ -400,6 +730,110 @@ func ParseExprWithInt(sql string, nakedIntType *types.T) (tree.Expr, error) {
 	return exprs[0], nil
 }
 
+// ExprPlaceholders describes the placeholders referenced by an expression
+// parsed with ParseExprWithPlaceholders.
+type ExprPlaceholders struct {
+	// NumPlaceholders is 1 + the highest placeholder index referenced in the
+	// expression. See statements.Statement.NumPlaceholders.
+	NumPlaceholders int
+
+	// TypeHints maps the index of each placeholder that is directly wrapped
+	// in a cast, e.g. $1::INT, to the syntactic type of the cast. If the same
+	// placeholder is cast multiple times, the first cast is retained.
+	TypeHints map[tree.PlaceholderIdx]tree.ResolvableTypeReference
+
+	// Positions maps the index of each placeholder to the position of its
+	// first occurrence in the input expression.
+	Positions map[tree.PlaceholderIdx]int
+
+	// sql is the input expression.
+	sql string
+}
+
+// ErrIfAny returns an error if the expression referenced any placeholder.
+// The error points at the first placeholder in the input, and mentions the
+// context in which placeholders are not allowed (e.g. "computed column
+// expressions").
+func (ep *ExprPlaceholders) ErrIfAny(context string) error {
+	if ep.NumPlaceholders == 0 {
+		return nil
+	}
+	first := -1
+	var firstIdx tree.PlaceholderIdx
+	for idx, pos := range ep.Positions {
+		if first == -1 || pos < first {
+			first, firstIdx = pos, idx
+		}
+	}
+	err := pgerror.Newf(pgcode.Syntax, "placeholders are not allowed in %s", context)
+	if first == -1 {
+		return err
+	}
+	p := tree.Placeholder{Idx: firstIdx}
+	err = errors.Wrapf(err, "at or near \"%s\"", p.String())
+	return errors.WithDetail(err, sourceContext(ep.sql, int32(first), 1 /* width */))
+}
+
+// placeholderCastCollector collects the type hints for ExprPlaceholders.
+type placeholderCastCollector struct {
+	hints map[tree.PlaceholderIdx]tree.ResolvableTypeReference
+}
+
+var _ tree.Visitor = &placeholderCastCollector{}
+
+// VisitPre implements the tree.Visitor interface.
+func (v *placeholderCastCollector) VisitPre(expr tree.Expr) (recurse bool, newExpr tree.Expr) {
+	if cast, ok := expr.(*tree.CastExpr); ok {
+		if p, ok := cast.Expr.(*tree.Placeholder); ok {
+			if v.hints == nil {
+				v.hints = make(map[tree.PlaceholderIdx]tree.ResolvableTypeReference)
+			}
+			if _, ok := v.hints[p.Idx]; !ok {
+				v.hints[p.Idx] = cast.Type
+			}
+		}
+	}
+	return true, expr
+}
+
+// VisitPost implements the tree.Visitor interface.
+func (*placeholderCastCollector) VisitPost(expr tree.Expr) tree.Expr { return expr }
+
+// ParseExprWithPlaceholders is like ParseExprWithInt, but also reports the
+// placeholders referenced by the expression, along with the type hints
+// syntactically provided for them by casts.
+func ParseExprWithPlaceholders(
+	sql string, nakedIntType *types.T,
+) (tree.Expr, ExprPlaceholders, error) {
+	const prefix = "SET ROW ("
+	stmt, err := ParseOneWithInt(prefix+sql+")", nakedIntType)
+	if err != nil {
+		return nil, ExprPlaceholders{}, err
+	}
+	set, ok := stmt.AST.(*tree.SetVar)
+	if !ok {
+		return nil, ExprPlaceholders{}, errors.AssertionFailedf("expected a SET statement, but found %T", stmt)
+	}
+	if len(set.Values) != 1 {
+		return nil, ExprPlaceholders{}, errors.AssertionFailedf("expected 1 expression, found %d", len(set.Values))
+	}
+	expr := set.Values[0]
+	ep := ExprPlaceholders{NumPlaceholders: stmt.NumPlaceholders, sql: sql}
+	if ep.NumPlaceholders == 0 {
+		return expr, ep, nil
+	}
+
+	var v placeholderCastCollector
+	tree.WalkExprConst(&v, expr)
+	ep.TypeHints = v.hints
+
+	ep.Positions = make(map[tree.PlaceholderIdx]int, len(stmt.PlaceholderPositions))
+	for idx, pos := range stmt.PlaceholderPositions {
+		ep.Positions[idx] = int(pos) - len(prefix)
+	}
+	return expr, ep, nil
+}
+
 // GetTypeReferenceFromName turns a type name into a type
 // reference. This supports only “simple” (single-identifier)
 // references to built-in types, when the identifer has already been
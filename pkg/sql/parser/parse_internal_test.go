@@ -7,6 +7,7 @@ package parser
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -135,3 +136,42 @@ func TestScanOneStmt(t *testing.T) {
 		}
 	}
 }
+
+func TestParserReusesTokens(t *testing.T) {
+	var p Parser
+	// This statement has more tokens than fit in tokBuf.
+	const sql = `SELECT a, b, c FROM t WHERE a = 'foo' AND b = 1`
+	if _, err := p.Parse(sql); err != nil {
+		t.Fatal(err)
+	}
+	if cap(p.tokens) <= len(p.tokBuf) {
+		t.Fatalf("expected the token buffer to be retained, found capacity %d", cap(p.tokens))
+	}
+	buf := p.tokens[:cap(p.tokens)]
+	for i, tok := range buf {
+		if tok.str != "" || tok.union.val != nil {
+			t.Errorf("token %d retains %q, %v from the previous statement", i, tok.str, tok.union.val)
+		}
+	}
+
+	// The buffer is reused for the next statements.
+	if _, err := p.Parse(sql + `; ` + sql); err != nil {
+		t.Fatal(err)
+	}
+	if &p.tokens[:1][0] != &buf[0] {
+		t.Errorf("expected the token buffer to be reused")
+	}
+
+	// A statement with too many tokens does not pin its buffer.
+	var large strings.Builder
+	large.WriteString(`SELECT 1`)
+	for i := 0; i < maxRetainedTokens; i++ {
+		large.WriteString(` + 1`)
+	}
+	if _, err := p.Parse(large.String()); err != nil {
+		t.Fatal(err)
+	}
+	if p.tokens != nil {
+		t.Errorf("expected the token buffer to be dropped, found capacity %d", cap(p.tokens))
+	}
+}
@@ -8,17 +8,23 @@ package parser_test
 import (
 	"fmt"
 	"go/constant"
+	"math"
 	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/sql/lexbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser/statements"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/randgen"
+	"github.com/cockroachdb/cockroach/pkg/sql/scanner"
 	_ "github.com/cockroachdb/cockroach/pkg/sql/sem/builtins"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree/treebin"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree/treecmp"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/datapathutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
@@ -60,12 +66,14 @@ func TestParseDataDriven(t *testing.T) {
 						reparseWithoutLiterals = false
 					}
 				}
+				verifySplitStatements(t, d)
 				return sqlutils.VerifyParseFormat(t, d.Input, d.Pos, plpgsql, reparseWithoutLiterals)
 			case "parse-no-verify":
 				_, err := parser.Parse(d.Input)
 				if err != nil {
 					d.Fatalf(t, "%s\nunexpected error: %s", d.Pos, err)
 				}
+				verifySplitStatements(t, d)
 				return ""
 			case "error":
 				_, err := parser.Parse(d.Input)
@@ -73,6 +81,16 @@ func TestParseDataDriven(t *testing.T) {
 					d.Fatalf(t, "%s\nexpected error, found none", d.Pos)
 				}
 				return sqlutils.VerifyParseError(err)
+			case "tokens":
+				var buf strings.Builder
+				for _, tok := range parser.DescribeTokens(d.Input) {
+					fmt.Fprintf(&buf, "%d %s %s", tok.Pos, tok.Name, tok.Text)
+					if tok.Rewritten {
+						buf.WriteString(" (rewritten)")
+					}
+					buf.WriteByte('\n')
+				}
+				return buf.String()
 			}
 			d.Fatalf(t, "%s\nunsupported command: %s", d.Pos, d.Cmd)
 			return ""
@@ -80,6 +98,27 @@ func TestParseDataDriven(t *testing.T) {
 	})
 }
 
+// verifySplitStatements checks that parser.SplitStatements splits the input
+// of a test into the same statements as parser.Parse.
+func verifySplitStatements(t *testing.T, d *datadriven.TestData) {
+	stmts, err := parser.Parse(d.Input)
+	if err != nil {
+		return
+	}
+	pieces, err := parser.SplitStatements(d.Input)
+	if err != nil {
+		d.Fatalf(t, "%s\nunexpected error splitting statements: %s", d.Pos, err)
+	}
+	if len(pieces) != len(stmts) {
+		d.Fatalf(t, "%s\nsplit into %d statements, but parsed %d", d.Pos, len(pieces), len(stmts))
+	}
+	for i := range pieces {
+		if pieces[i].SQL != stmts[i].SQL {
+			d.Fatalf(t, "%s\nsplit statement %q, but parsed %q", d.Pos, pieces[i].SQL, stmts[i].SQL)
+		}
+	}
+}
+
 // TestParseTree checks that the implicit grouping done by the grammar
 // is properly reflected in the parse tree.
 func TestParseTree(t *testing.T) {
@@ -155,7 +194,7 @@ func TestParsePanic(t *testing.T) {
 		"(F(F(F(F(F(F(F(F(F((" +
 		"F(0"
 	_, err := parser.Parse(s)
-	expected := `at or near "EOF": syntax error`
+	expected := `at or near "\.\.\. F\(0": syntax error`
 	if !testutils.IsError(err, expected) {
 		t.Fatalf("expected %s, but found %v", expected, err)
 	}
@@ -202,6 +241,9 @@ func TestParsePrecedence(t *testing.T) {
 	regimatch := func(left, right tree.Expr) tree.Expr {
 		return &tree.ComparisonExpr{Operator: treecmp.MakeComparisonOperator(treecmp.RegIMatch), Left: left, Right: right}
 	}
+	between := func(not, symmetric bool, left, from, to tree.Expr) tree.Expr {
+		return &tree.RangeCond{Not: not, Symmetric: symmetric, Left: left, From: from, To: to}
+	}
 
 	one := tree.NewNumVal(constant.MakeInt64(1), "1", false /* negative */)
 	minusone := tree.NewNumVal(constant.MakeInt64(1), "1", true /* negative */)
@@ -331,6 +373,16 @@ func TestParsePrecedence(t *testing.T) {
 		// OR combined with self (left associative).
 		{`1 OR 2 OR 3`, or(or(one, two), three)},
 
+		// [NOT] BETWEEN [A]SYMMETRIC combined with higher and lower precedence.
+		{`1 BETWEEN SYMMETRIC 2 AND 3`, between(false, true, one, two, three)},
+		{`1 NOT BETWEEN SYMMETRIC 2 AND 3`, between(true, true, one, two, three)},
+		{`1 NOT BETWEEN ASYMMETRIC 2 AND 3`, between(true, false, one, two, three)},
+		{`1+2 BETWEEN SYMMETRIC 2+3 AND 3+1`, between(false, true, binary(treebin.Plus, one, two), binary(treebin.Plus, two, three), binary(treebin.Plus, three, one))},
+		{`1+2 NOT BETWEEN SYMMETRIC 2+3 AND 3+1`, between(true, true, binary(treebin.Plus, one, two), binary(treebin.Plus, two, three), binary(treebin.Plus, three, one))},
+		{`1 BETWEEN SYMMETRIC 2 AND 3 AND 1`, and(between(false, true, one, two, three), one)},
+		{`1 NOT BETWEEN SYMMETRIC 2 AND 3 AND 1`, and(between(true, true, one, two, three), one)},
+		{`NOT 1 NOT BETWEEN SYMMETRIC 2 AND 3`, not(between(true, true, one, two, three))},
+
 		// ~ and ~* should both be lower than ||.
 		{`'a' || 'b' ~ 'c'`, regmatch(concat(a, b), c)},
 		{`'a' || 'b' ~* 'c'`, regimatch(concat(a, b), c)},
@@ -604,6 +656,79 @@ func TestUnimplementedSyntax(t *testing.T) {
 	}
 }
 
+// TestUnsupportedError checks that the structured fields of the errors
+// reported for unsupported syntax are populated by each of the lexer's
+// helpers, and that the errors can be retrieved through the details added by
+// the parser.
+func TestUnsupportedError(t *testing.T) {
+	testData := []struct {
+		sql      string
+		expected tree.UnsupportedError
+		tkey     string
+	}{
+		// Unimplemented.
+		{
+			sql:      `CREATE ACCESS METHOD a`,
+			expected: tree.UnsupportedError{FeatureName: "create access method"},
+			tkey:     "syntax.create access method",
+		},
+		// UnimplementedWithIssue.
+		{
+			sql: `COPY x FROM STDIN WHERE a = b`,
+			expected: tree.UnsupportedError{
+				FeatureName: "https://github.com/cockroachdb/cockroach/issues/54580",
+				IssueNumber: 54580,
+			},
+			tkey: "syntax.#54580",
+		},
+		// UnimplementedWithIssueDetail.
+		{
+			sql: `ALTER TABLE a INHERITS b`,
+			expected: tree.UnsupportedError{
+				FeatureName: "alter table inherits",
+				IssueNumber: 22456,
+				Detail:      "alter table inherits",
+			},
+			tkey: "syntax.#22456.alter table inherits",
+		},
+		// PurposelyUnimplemented.
+		{
+			sql: `REINDEX TABLE a`,
+			expected: tree.UnsupportedError{
+				FeatureName: "reindex table",
+				Purposely:   true,
+			},
+			tkey: "syntax.sql.purposely_unimplemented.reindex_table",
+		},
+	}
+	for _, d := range testData {
+		t.Run(d.sql, func(t *testing.T) {
+			_, err := parser.Parse(d.sql)
+			require.Error(t, err)
+
+			var unsupportedErr *tree.UnsupportedError
+			require.True(t, errors.As(err, &unsupportedErr), "%+v", err)
+			require.Equal(t, d.expected.FeatureName, unsupportedErr.FeatureName)
+			require.Equal(t, d.expected.IssueNumber, unsupportedErr.IssueNumber)
+			require.Equal(t, d.expected.Detail, unsupportedErr.Detail)
+			require.Equal(t, d.expected.Purposely, unsupportedErr.Purposely)
+			require.Contains(t, errors.GetTelemetryKeys(err), d.tkey)
+
+			// The parser decorates the error with the position of the
+			// unsupported syntax.
+			require.Contains(t, err.Error(), "at or near")
+			require.NotEmpty(t, errors.FlattenDetails(err))
+			code := pgerror.GetPGCode(err)
+			require.True(t, code == pgcode.FeatureNotSupported || code == pgcode.Syntax, "%s", code)
+
+			// The error does not change the message it wraps.
+			require.Equal(t, unsupportedErr.Err.Error(), unsupportedErr.Error())
+			require.Equal(t, err.Error(), fmt.Sprintf("%v", err))
+			require.Contains(t, fmt.Sprintf("%+v", err), "unsupported feature: "+d.expected.FeatureName)
+		})
+	}
+}
+
 // TestParseSQL verifies that Statement.SQL is set correctly.
 func TestParseSQL(t *testing.T) {
 	testData := []struct {
@@ -677,39 +802,909 @@ func TestParseNumPlaceholders(t *testing.T) {
 	}
 }
 
-func TestParseOne(t *testing.T) {
-	_, err := parser.ParseOne("SELECT 1; SELECT 2")
-	if !testutils.IsError(err, "expected 1 statement") {
-		t.Errorf("unexpected error %s", err)
+// TestParseWarnings verifies that Statement.Warnings is set correctly.
+func TestParsePlaceholderTypeHints(t *testing.T) {
+	type hints = map[tree.PlaceholderIdx]statements.PlaceholderTypeHint
+	testData := []struct {
+		in  string
+		exp hints
+	}{
+		{in: `SELECT $1`, exp: nil},
+		{in: `SELECT $1 + 1, ($1)::INT8, ($1 + 1)::INT8`, exp: nil},
+		{
+			in: `SELECT $1::timestamptz, $2::int[]`,
+			exp: hints{
+				0: {TypeName: "TIMESTAMPTZ", Pos: 7},
+				1: {TypeName: "INT8[]", Pos: 24},
+			},
+		},
+		{
+			// Conflicting casts are recorded, once per type.
+			in: `SELECT CAST($1 AS STRING), $1::INT8, $1::STRING, $2 + 1, $1::INT8`,
+			exp: hints{
+				0: {TypeName: "STRING", Pos: 12, Conflicts: []statements.PlaceholderTypeHint{
+					{TypeName: "INT8", Pos: 27},
+				}},
+			},
+		},
+		{
+			in:  `INSERT INTO t VALUES ($1, $2::DECIMAL) RETURNING $2::DECIMAL`,
+			exp: hints{1: {TypeName: "DECIMAL", Pos: 26}},
+		},
+	}
+	var p parser.Parser
+	for _, d := range testData {
+		t.Run(d.in, func(t *testing.T) {
+			stmt, err := p.ParseWithOptions(d.in, parser.ParseOptions{})
+			require.NoError(t, err)
+			require.Len(t, stmt, 1)
+			require.Equal(t, d.exp, stmt[0].PlaceholderTypeHints)
+		})
 	}
 }
 
-func BenchmarkParse(b *testing.B) {
-	testCases := []struct {
-		name, query string
+func TestParseWarnings(t *testing.T) {
+	testData := []struct {
+		in  string
+		exp [][]scanner.Warning
+	}{
+		{in: `SELECT e'\n'`, exp: [][]scanner.Warning{nil}},
+		{in: `SELECT e'\d'`, exp: [][]scanner.Warning{
+			{{Pos: 9, Msg: `nonstandard use of escape in a string literal: \d`}},
+		}},
+		{in: `SELECT 1; SELECT 'a' ~ e'\d+'; SELECT e'\%'`, exp: [][]scanner.Warning{
+			nil,
+			{{Pos: 15, Msg: `nonstandard use of escape in a string literal: \d`}},
+			{{Pos: 9, Msg: `nonstandard use of escape in a string literal: \%`}},
+		}},
+	}
+	var p parser.Parser // Verify that the same parser can be reused.
+	for _, d := range testData {
+		t.Run(d.in, func(t *testing.T) {
+			stmts, err := p.Parse(d.in)
+			require.NoError(t, err)
+			var res [][]scanner.Warning
+			for i := range stmts {
+				res = append(res, stmts[i].Warnings)
+			}
+			require.Equal(t, d.exp, res)
+		})
+	}
+}
+
+// TestParseRadixIntegerLiterals verifies that integer literals using the 0x,
+// 0o and 0b prefixes interact properly with the naked INT type.
+func TestParseRadixIntegerLiterals(t *testing.T) {
+	testData := []struct {
+		in      string
+		expInt4 string
+		expInt8 string
+	}{
+		{
+			in:      `SELECT 0x1A::INT`,
+			expInt4: `SELECT 0x1A::INT4`,
+			expInt8: `SELECT 0x1A::INT8`,
+		},
+		{
+			in:      `SELECT 0o17::INT, 0b1010::INT2`,
+			expInt4: `SELECT 0o17::INT4, 0b1010::INT2`,
+			expInt8: `SELECT 0o17::INT8, 0b1010::INT2`,
+		},
+		{
+			in:      `SELECT 0x_7fff_ffff::INT`,
+			expInt4: `SELECT 0x7fffffff::INT4`,
+			expInt8: `SELECT 0x7fffffff::INT8`,
+		},
+		{
+			in:      `SELECT 0xffff_ffff_ffff_ffff::INT`,
+			expInt4: `SELECT 18446744073709551615::INT4`,
+			expInt8: `SELECT 18446744073709551615::INT8`,
+		},
+		{
+			in:      `CREATE TABLE t (a INT DEFAULT 0b1010, b INT8 DEFAULT 1_000)`,
+			expInt4: `CREATE TABLE t (a INT4 DEFAULT 0b1010, b INT8 DEFAULT 1000)`,
+			expInt8: `CREATE TABLE t (a INT8 DEFAULT 0b1010, b INT8 DEFAULT 1000)`,
+		},
+	}
+	for _, d := range testData {
+		t.Run(d.in, func(t *testing.T) {
+			for _, tc := range []struct {
+				typ *types.T
+				exp string
+			}{
+				{typ: types.Int4, exp: d.expInt4},
+				{typ: types.Int, exp: d.expInt8},
+			} {
+				stmt, err := parser.ParseOneWithInt(d.in, tc.typ)
+				require.NoError(t, err)
+				require.Equal(t, tc.exp, stmt.AST.String())
+			}
+		})
+	}
+}
+
+func TestParseExprWithPlaceholders(t *testing.T) {
+	testData := []struct {
+		in           string
+		numPlacehold int
+		hints        map[tree.PlaceholderIdx]string
+		positions    map[tree.PlaceholderIdx]int
 	}{
+		{in: `a + 1`},
 		{
-			"simple",
-			`SELECT a FROM t WHERE a = 1`,
+			in:           `$1 + $2`,
+			numPlacehold: 2,
+			positions:    map[tree.PlaceholderIdx]int{0: 0, 1: 5},
 		},
 		{
-			"string",
-			`SELECT a FROM t WHERE a = 'some-string' AND b = 'some-other-string'`,
+			in:           `$1::INT + $3::STRING::INT + ($1::FLOAT)`,
+			numPlacehold: 3,
+			hints:        map[tree.PlaceholderIdx]string{0: "INT8", 2: "STRING"},
+			positions:    map[tree.PlaceholderIdx]int{0: 0, 2: 10},
 		},
 		{
-			"tpcc-delivery",
-			`SELECT no_o_id FROM new_order WHERE no_w_id = $1 AND no_d_id = $2 ORDER BY no_o_id ASC LIMIT 1 FOR UPDATE`,
+			in:           `(a + $1)::INT`,
+			numPlacehold: 1,
+			positions:    map[tree.PlaceholderIdx]int{0: 5},
+		},
+	}
+	for _, d := range testData {
+		t.Run(d.in, func(t *testing.T) {
+			_, ep, err := parser.ParseExprWithPlaceholders(d.in, types.Int)
+			require.NoError(t, err)
+			require.Equal(t, d.numPlacehold, ep.NumPlaceholders)
+			var hints map[tree.PlaceholderIdx]string
+			for idx, typ := range ep.TypeHints {
+				if hints == nil {
+					hints = make(map[tree.PlaceholderIdx]string)
+				}
+				hints[idx] = typ.SQLString()
+			}
+			require.Equal(t, d.hints, hints)
+			require.Equal(t, d.positions, ep.Positions)
+		})
+	}
+
+	t.Run("error", func(t *testing.T) {
+		_, ep, err := parser.ParseExprWithPlaceholders(`a + 1`, types.Int)
+		require.NoError(t, err)
+		require.NoError(t, ep.ErrIfAny("computed column expressions"))
+
+		_, ep, err = parser.ParseExprWithPlaceholders(`a + $2 + $1`, types.Int)
+		require.NoError(t, err)
+		err = ep.ErrIfAny("computed column expressions")
+		require.EqualError(t, err,
+			`at or near "$2": placeholders are not allowed in computed column expressions`)
+		require.Equal(t, "source SQL:\na + $2 + $1\n    ^", errors.FlattenDetails(err))
+	})
+}
+
+// TestParseWithOptions verifies that the naked INT and SERIAL types are
+// interpreted according to the ParseOptions, and that the result round-trips
+// through formatting and re-parsing with the same options.
+func TestParseWithOptions(t *testing.T) {
+	int8Follows := parser.ParseOptions{}
+	int4Follows := parser.ParseOptions{NakedIntType: types.Int4}
+	int4Serial8 := parser.ParseOptions{NakedIntType: types.Int4, SerialMode: parser.SerialAlways8}
+	int8Serial4 := parser.ParseOptions{NakedIntType: types.Int, SerialMode: parser.SerialAlways4}
+
+	testData := []struct {
+		in  string
+		exp map[parser.ParseOptions]string
+	}{
+		{
+			in: `CREATE TABLE t (a INT, b INTEGER, c INT4, d INT8, e SERIAL, f SERIAL4, g INT DEFAULT 1::INT)`,
+			exp: map[parser.ParseOptions]string{
+				int8Follows: `CREATE TABLE t (a INT8, b INT8, c INT4, d INT8, e SERIAL8, f SERIAL4, g INT8 DEFAULT 1::INT8)`,
+				int4Follows: `CREATE TABLE t (a INT4, b INT4, c INT4, d INT8, e SERIAL4, f SERIAL4, g INT4 DEFAULT 1::INT4)`,
+				int4Serial8: `CREATE TABLE t (a INT4, b INT4, c INT4, d INT8, e SERIAL8, f SERIAL4, g INT4 DEFAULT 1::INT4)`,
+				int8Serial4: `CREATE TABLE t (a INT8, b INT8, c INT4, d INT8, e SERIAL4, f SERIAL4, g INT8 DEFAULT 1::INT8)`,
+			},
+		},
+		{
+			in: `SELECT '1'::INT, CAST(2 AS INTEGER), '3'::INT4, '4'::INT8, '5'::SERIAL`,
+			exp: map[parser.ParseOptions]string{
+				int8Follows: `SELECT '1'::INT8, CAST(2 AS INT8), '3'::INT4, '4'::INT8, '5'::INT8`,
+				int4Follows: `SELECT '1'::INT4, CAST(2 AS INT4), '3'::INT4, '4'::INT8, '5'::INT4`,
+				int4Serial8: `SELECT '1'::INT4, CAST(2 AS INT4), '3'::INT4, '4'::INT8, '5'::INT8`,
+				int8Serial4: `SELECT '1'::INT8, CAST(2 AS INT8), '3'::INT4, '4'::INT8, '5'::INT4`,
+			},
+		},
+	}
+	for _, d := range testData {
+		t.Run(d.in, func(t *testing.T) {
+			for opts, exp := range d.exp {
+				stmt, err := parser.ParseOneWithOptions(d.in, opts)
+				require.NoError(t, err)
+				require.Equal(t, exp, stmt.AST.String())
+
+				reparsed, err := parser.ParseOneWithOptions(stmt.AST.String(), opts)
+				require.NoError(t, err)
+				require.Equal(t, exp, reparsed.AST.String())
+			}
+		})
+	}
+}
+
+func TestParseCanonicalErrors(t *testing.T) {
+	testData := []struct {
+		in        string
+		canonical string
+		normal    string
+	}{
+		{`SELECT 1 ||/ 5`, `42601: at or near numeric literal: syntax error`, `at or near "SELECT 1 ||/ 5": syntax error`},
+		{`SELECT 'a' AS "a" "b"`, `42601: at or near identifier: syntax error`, `at or near "... 'a' AS "a" "b"": syntax error`},
+		{`SELECT FROM FROM`, `42601: at or near keyword: syntax error`, `at or near "SELECT FROM FROM": syntax error`},
+		{`SELECT 1 $1`, `42601: at or near placeholder: syntax error`, `at or near "SELECT 1 $1": syntax error`},
+		{`SELECT EXISTS(SELECT 1)[1]`, `42601: at or near operator: syntax error`, `at or near "... SELECT 1)[": syntax error`},
+		{`SELECT (1`, `42601: at or near EOF: syntax error`, `at or near "SELECT (1": syntax error`},
+		{
+			`SELECT 1_000_ FROM t`,
+			`42601: lexical error: trailing junk after numeric literal`,
+			`lexical error: trailing junk after numeric literal at or near "1_000_"`,
 		},
 		{
-			"account",
-			`BEGIN;
+			"SELECT `a` FROM t",
+			`42601: lexical error: backtick-quoted identifiers are not supported`,
+			"lexical error: backtick-quoted identifier `a` is not supported",
+		},
+	}
+	for _, d := range testData {
+		t.Run(d.in, func(t *testing.T) {
+			_, err := parser.ParseWithOptions(d.in, parser.ParseOptions{CanonicalErrors: true})
+			require.Error(t, err)
+			require.Equal(t, d.canonical, parser.CanonicalError(err))
+			require.Empty(t, errors.GetAllDetails(err))
+
+			// The errors returned without the option are unaffected.
+			_, err = parser.Parse(d.in)
+			require.Error(t, err)
+			require.Equal(t, d.normal, err.Error())
+			require.Contains(t, errors.FlattenDetails(err), "source SQL:")
+		})
+	}
+
+	require.Equal(t, "", parser.CanonicalError(nil))
+}
+
+func TestFormatPreservingCase(t *testing.T) {
+	testData := []struct {
+		in  string
+		exp string
+	}{
+		{`create table Foo (Id INT8 primary key, Name STRING not null)`, ``},
+		{`alter table Foo add column Bar INT8`, ``},
+		{`Create Index Foo_Idx on Foo (Bar desc)`, ``},
+		{`drop table if exists Foo cascade`, ``},
+		{`select A, B from Foo where A = 1`, ``},
+		{`alter table Foo rename column Bar to Baz`, ``},
+		{`create table "MixedCase" (X INT8, "Y" STRING)`, ``},
+		{`Select 'a' as Str from T`, ``},
+		// Words added or changed by the formatting keep their canonical
+		// spelling.
+		{`create table T (A int)`, `create table T (A INT8)`},
+		{`Select A from T U`, `Select A from T AS U`},
+		// A respelled word does not throw off the alignment of the words
+		// after it, even if one of them is spelled like the new word.
+		{`create table T (A integer, B int8)`, `create table T (A INT8, B int8)`},
+	}
+	for _, d := range testData {
+		t.Run(d.in, func(t *testing.T) {
+			exp := d.exp
+			if exp == "" {
+				exp = d.in
+			}
+			stmt, err := parser.ParseOneWithOptions(d.in, parser.ParseOptions{PreserveCase: true})
+			require.NoError(t, err)
+			out := parser.FormatPreservingCase(stmt)
+			require.Equal(t, exp, out)
+
+			// The output must parse to the same statement.
+			reparsed, err := parser.ParseOne(out)
+			require.NoError(t, err)
+			require.Equal(t, tree.AsString(stmt.AST), tree.AsString(reparsed.AST))
+
+			// Without the option, the casing is not preserved.
+			stmt, err = parser.ParseOne(d.in)
+			require.NoError(t, err)
+			require.Empty(t, stmt.Spellings)
+			require.Equal(t, tree.AsString(stmt.AST), parser.FormatPreservingCase(stmt))
+		})
+	}
+
+	// The words of large statements are aligned in linear time.
+	t.Run("large", func(t *testing.T) {
+		var cols []string
+		for i := 0; i < 5000; i++ {
+			cols = append(cols, fmt.Sprintf("Col%d int8", i))
+		}
+		in := `create table Big (` + strings.Join(cols, ", ") + `)`
+		stmt, err := parser.ParseOneWithOptions(in, parser.ParseOptions{PreserveCase: true})
+		require.NoError(t, err)
+		require.Equal(t, in, parser.FormatPreservingCase(stmt))
+	})
+}
+
+// TestParseAnnotationBase checks that statements parsed separately can share
+// an annotations container.
+func TestParseAnnotationBase(t *testing.T) {
+	annIdx := func(stmt statements.Statement[tree.Statement]) tree.AnnotationIdx {
+		return stmt.AST.(*tree.CommentOnColumn).ColumnItem.TableName.AnnIdx
+	}
+	first, err := parser.ParseOne(`COMMENT ON COLUMN a.x IS 'first'`)
+	require.NoError(t, err)
+	require.Equal(t, tree.AnnotationIdx(1), first.NumAnnotations)
+	require.Equal(t, tree.AnnotationIdx(1), annIdx(first))
+
+	second, err := parser.ParseOneWithOptions(
+		`COMMENT ON COLUMN b.y IS 'second'`, parser.ParseOptions{AnnotationBase: first.NumAnnotations},
+	)
+	require.NoError(t, err)
+	require.Equal(t, tree.AnnotationIdx(2), second.NumAnnotations)
+	require.Equal(t, tree.AnnotationIdx(2), annIdx(second))
+
+	ann := tree.MakeAnnotations(second.NumAnnotations)
+	ann.Set(annIdx(first), "first")
+	ann.Set(annIdx(second), "second")
+	require.Equal(t, "first", ann.Get(annIdx(first)))
+	require.Equal(t, "second", ann.Get(annIdx(second)))
+
+	// The annotation indexes do not wrap around.
+	opts := parser.ParseOptions{AnnotationBase: math.MaxInt32}
+	_, err = parser.ParseOneWithOptions(`COMMENT ON COLUMN a.x IS 'first'`, opts)
+	require.Error(t, err)
+	require.Equal(t, pgcode.ProgramLimitExceeded, pgerror.GetPGCode(err))
+	stmt, err := parser.ParseOneWithOptions(`SELECT 1`, opts)
+	require.NoError(t, err)
+	require.Equal(t, tree.AnnotationIdx(math.MaxInt32), stmt.NumAnnotations)
+
+	_, err = parser.ParseOneWithOptions(`SELECT 1`, parser.ParseOptions{AnnotationBase: -1})
+	require.True(t, errors.HasAssertionFailure(err))
+}
+
+// TestParseAnnotationsPerStatement checks that the annotations of the
+// statements of a multi-statement input are numbered separately, so that
+// each statement can be executed with a container of its own size.
+func TestParseAnnotationsPerStatement(t *testing.T) {
+	stmts, err := parser.Parse(`COMMENT ON COLUMN a.x IS 'a';
+SELECT 1;
+SELECT 1::s.t, 2::s.u, 3::v;
+COMMENT ON COLUMN b.y IS 'b'`)
+	require.NoError(t, err)
+	require.Len(t, stmts, 4)
+
+	require.Equal(t, []tree.AnnotationIdx{1, 0, 3, 1}, []tree.AnnotationIdx{
+		stmts[0].NumAnnotations, stmts[1].NumAnnotations,
+		stmts[2].NumAnnotations, stmts[3].NumAnnotations,
+	})
+	var idxs []tree.AnnotationIdx
+	for _, e := range stmts[2].AST.(*tree.Select).Select.(*tree.SelectClause).Exprs {
+		idxs = append(idxs, e.Expr.(*tree.CastExpr).Type.(*tree.UnresolvedObjectName).AnnIdx)
+	}
+	require.ElementsMatch(t, []tree.AnnotationIdx{1, 2, 3}, idxs)
+
+	// Each statement's annotations fit in a container of its own size.
+	for _, stmt := range []statements.Statement[tree.Statement]{stmts[0], stmts[3]} {
+		ann := tree.MakeAnnotations(stmt.NumAnnotations)
+		tn := stmt.AST.(*tree.CommentOnColumn).ColumnItem.TableName
+		tn.SetAnnotation(&ann, stmt.SQL)
+		require.Equal(t, stmt.SQL, tn.GetAnnotation(&ann))
+	}
+}
+
+// TestParseAnnotationLimit checks that a statement that exhausts the
+// annotation indexes is rejected instead of reusing indexes.
+func TestParseAnnotationLimit(t *testing.T) {
+	const n = 100
+	var buf strings.Builder
+	buf.WriteString("SELECT ")
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		fmt.Fprintf(&buf, "1::t%d", i)
+	}
+	sql := buf.String()
+
+	stmt, err := parser.ParseOne(sql)
+	require.NoError(t, err)
+	require.Equal(t, tree.AnnotationIdx(n), stmt.NumAnnotations)
+
+	// The statement uses up the last annotation indexes.
+	stmt, err = parser.ParseOneWithOptions(
+		sql, parser.ParseOptions{AnnotationBase: tree.MaxAnnotations - n},
+	)
+	require.NoError(t, err)
+	require.Equal(t, tree.MaxAnnotations, stmt.NumAnnotations)
+	seen := make(map[tree.AnnotationIdx]bool)
+	for _, e := range stmt.AST.(*tree.Select).Select.(*tree.SelectClause).Exprs {
+		idx := e.Expr.(*tree.CastExpr).Type.(*tree.UnresolvedObjectName).AnnIdx
+		require.Greater(t, idx, tree.MaxAnnotations-n)
+		require.False(t, seen[idx], "annotation index %d reused", idx)
+		seen[idx] = true
+	}
+
+	// The statement needs one more annotation index than there is left.
+	_, err = parser.ParseOneWithOptions(
+		sql, parser.ParseOptions{AnnotationBase: tree.MaxAnnotations - n + 1},
+	)
+	require.Error(t, err)
+	require.Equal(t, pgcode.ProgramLimitExceeded, pgerror.GetPGCode(err))
+	require.Contains(t, err.Error(), "statement too complex")
+}
+
+// TestParsePlaceholderLimit checks that placeholders are limited to the
+// number of parameters that the pgwire protocol can bind.
+func TestParsePlaceholderLimit(t *testing.T) {
+	stmt, err := parser.ParseOne(`SELECT $65535`)
+	require.NoError(t, err)
+	require.Equal(t, tree.MaxPlaceholderIdx, stmt.NumPlaceholders)
+
+	for _, placeholder := range []string{`$65536`, `$65537`} {
+		sql := `SELECT $1, ` + placeholder + ` + 1`
+		_, err = parser.ParseOne(sql)
+		require.Error(t, err)
+		require.Equal(t, pgcode.Syntax, pgerror.GetPGCode(err))
+		require.Equal(t, `lexical error: placeholder index must be between 1 and 65535 at or near "`+placeholder+`"`, err.Error())
+		require.Equal(t, "source SQL:\n"+sql+"\n           ^", errors.FlattenDetails(err))
+		require.Equal(t,
+			"split the statement into smaller batches, or pass many values in a single array placeholder",
+			errors.FlattenHints(err))
+	}
+}
+
+// TestParsePlaceholderPositions checks that the position of the first
+// occurrence of each placeholder is recorded, so that errors about a
+// placeholder can point at it.
+func TestParsePlaceholderPositions(t *testing.T) {
+	stmt, err := parser.ParseOne("SELECT $2 + $1,\n  $3 FROM t WHERE x = $2")
+	require.NoError(t, err)
+	require.Equal(t, map[tree.PlaceholderIdx]int32{0: 12, 1: 7, 2: 18}, stmt.PlaceholderPositions)
+
+	err = parser.WithPlaceholderContext(errors.New("missing argument"), stmt, 2)
+	require.Equal(t, "source SQL:\nSELECT $2 + $1,\n  $3 FROM t WHERE x = $2\n  ^^", errors.FlattenDetails(err))
+
+	// There is no context for a placeholder that does not occur.
+	err = parser.WithPlaceholderContext(errors.New("missing argument"), stmt, 3)
+	require.Empty(t, errors.FlattenDetails(err))
+
+	stmt, err = parser.ParseOne("SELECT 1")
+	require.NoError(t, err)
+	require.Nil(t, stmt.PlaceholderPositions)
+}
+
+// TestParseUsedPlaceholders checks that the placeholders that occur in a
+// statement are recorded, so that the gaps in their numbering can be told
+// apart from the placeholders that are used.
+func TestParseUsedPlaceholders(t *testing.T) {
+	testData := []struct {
+		sql     string
+		num     int
+		used    []int
+		missing []tree.PlaceholderIdx
+	}{
+		{`SELECT 1`, 0, nil, nil},
+		{`SELECT $1, $2, $3`, 3, []int{0, 1, 2}, nil},
+		{`SELECT $3`, 3, []int{2}, []tree.PlaceholderIdx{0, 1}},
+		{`SELECT $3, $1, $3`, 3, []int{0, 2}, []tree.PlaceholderIdx{1}},
+		{`PREPARE p AS SELECT $2`, 2, []int{1}, []tree.PlaceholderIdx{0}},
+	}
+	for _, d := range testData {
+		t.Run(d.sql, func(t *testing.T) {
+			stmt, err := parser.ParseOne(d.sql)
+			require.NoError(t, err)
+			require.Equal(t, d.num, stmt.NumPlaceholders)
+			require.Equal(t, d.used, stmt.UsedPlaceholders.Ordered())
+			require.Equal(t, d.missing, stmt.MissingPlaceholders())
+		})
+	}
+
+	// The set is not limited to small indexes.
+	stmt, err := parser.ParseOne(`SELECT $1, $100`)
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 99}, stmt.UsedPlaceholders.Ordered())
+	missing := stmt.MissingPlaceholders()
+	require.Len(t, missing, 98)
+	require.Equal(t, tree.PlaceholderIdx(1), missing[0])
+	require.Equal(t, tree.PlaceholderIdx(98), missing[97])
+}
+
+// TestParseAsOfSystemTime checks that the statements with an AS OF SYSTEM
+// TIME clause are flagged, along with the position of the clause.
+func TestParseAsOfSystemTime(t *testing.T) {
+	testData := []struct {
+		sql string
+		pos int32
+	}{
+		{`SELECT * FROM t`, -1},
+		{`SELECT * FROM t AS OF SYSTEM TIME '-1s'`, 16},
+		{`SELECT * FROM (SELECT * FROM t AS OF SYSTEM TIME '-1s') AS s`, 31},
+		{`BACKUP INTO 'nodelocal://1/b' AS OF SYSTEM TIME '-10s'`, 30},
+		{`RESTORE FROM LATEST IN 'nodelocal://1/b' AS OF SYSTEM TIME '-10s'`, 41},
+		{`BEGIN AS OF SYSTEM TIME '-1s'`, 6},
+		// An alias named "of" is not an AS OF SYSTEM TIME clause.
+		{`SELECT 1 AS of`, -1},
+	}
+	for _, d := range testData {
+		t.Run(d.sql, func(t *testing.T) {
+			stmt, err := parser.ParseOne(d.sql)
+			require.NoError(t, err)
+			require.Equal(t, d.pos >= 0, stmt.AsOfSystemTime)
+			if stmt.AsOfSystemTime {
+				require.Equal(t, d.pos, stmt.AsOfSystemTimePos)
+			}
+		})
+	}
+
+	// Each statement is flagged separately.
+	stmts, err := parser.Parse(`SELECT 1; SELECT * FROM t AS OF SYSTEM TIME '-1s'; SELECT 2`)
+	require.NoError(t, err)
+	require.Len(t, stmts, 3)
+	require.False(t, stmts[0].AsOfSystemTime)
+	require.True(t, stmts[1].AsOfSystemTime)
+	require.True(t, strings.HasPrefix(stmts[1].SQL[stmts[1].AsOfSystemTimePos:], "AS OF SYSTEM TIME"))
+	require.False(t, stmts[2].AsOfSystemTime)
+}
+
+// TestParseComplexity checks the complexity measured for some
+// representative statements.
+func TestParseComplexity(t *testing.T) {
+	testData := []struct {
+		sql      string
+		expected statements.Complexity
+	}{
+		{`SELECT 1`, statements.Complexity{Tokens: 2}},
+		{
+			`SELECT * FROM a JOIN b ON a.x = b.x LEFT JOIN c USING (y)`,
+			statements.Complexity{Tokens: 21, Joins: 2},
+		},
+		{
+			`SELECT * FROM t WHERE x IN (1, 2, 3) AND y NOT IN (4, (5), 6, 7)`,
+			statements.Complexity{Tokens: 29, MaxInListLen: 4},
+		},
+		{
+			`SELECT (SELECT max(x) FROM (SELECT x FROM t WHERE y IN (SELECT y FROM u)) AS s)`,
+			statements.Complexity{Tokens: 26, SubqueryDepth: 3},
+		},
+		{
+			`INSERT INTO t VALUES (1, 2), (3, 4)`,
+			statements.Complexity{Tokens: 15},
+		},
+	}
+	for _, d := range testData {
+		t.Run(d.sql, func(t *testing.T) {
+			stmt, err := parser.ParseOneWithOptions(d.sql, parser.ParseOptions{MeasureComplexity: true})
+			require.NoError(t, err)
+			require.Equal(t, d.expected, stmt.Complexity)
+		})
+	}
+
+	// The complexity is measured for each statement separately.
+	stmts, err := parser.ParseWithOptions(
+		`SELECT 1; SELECT * FROM a JOIN b ON true`, parser.ParseOptions{MeasureComplexity: true},
+	)
+	require.NoError(t, err)
+	require.Equal(t, statements.Complexity{Tokens: 2}, stmts[0].Complexity)
+	require.Equal(t, statements.Complexity{Tokens: 8, Joins: 1}, stmts[1].Complexity)
+}
+
+// TestParseTokens checks the token ranges and the tokens recorded for each
+// statement.
+func TestParseTokens(t *testing.T) {
+	stmts, err := parser.ParseWithOptions(
+		"SELECT 1;; INSERT INTO t VALUES ('it''s', $1)", parser.ParseOptions{CollectTokens: true},
+	)
+	require.NoError(t, err)
+	require.Len(t, stmts, 2)
+	// The semicolons are not part of the token stream.
+	require.Equal(t, statements.TokenRange{First: 0, Last: 1}, stmts[0].TokenRange)
+	require.Equal(t, statements.TokenRange{First: 2, Last: 10}, stmts[1].TokenRange)
+
+	var texts []string
+	for _, tok := range stmts[1].Tokens {
+		require.Equal(t, tok.Text, stmts[1].SQL[tok.Pos:int(tok.Pos)+len(tok.Text)])
+		texts = append(texts, tok.Text)
+	}
+	require.Equal(t,
+		[]string{"INSERT", "INTO", "t", "VALUES", "(", "'it''s'", ",", "$1", ")"}, texts)
+	require.Equal(t, int32(lexbase.IDENT), stmts[1].Tokens[2].ID)
+
+	// The token range is recorded regardless of CollectTokens.
+	stmt, err := parser.ParseOne(`SELECT a FROM t`)
+	require.NoError(t, err)
+	require.Equal(t, statements.TokenRange{First: 0, Last: 3}, stmt.TokenRange)
+	require.Nil(t, stmt.Tokens)
+}
+
+// TestParseOneWithScratch checks that the containers of a scratch are
+// reused by successive parses.
+func TestParseOneWithScratch(t *testing.T) {
+	var p parser.Parser
+	var scratch parser.ParseScratch
+	mapPtr := func(m interface{}) uintptr { return reflect.ValueOf(m).Pointer() }
+
+	first, err := p.ParseOneWithScratch(`SELECT $1::INT8, $2`, parser.ParseOptions{}, &scratch)
+	require.NoError(t, err)
+	require.Len(t, first.PlaceholderTypeHints, 1)
+	require.Equal(t, map[tree.PlaceholderIdx]int32{0: 7, 1: 17}, first.PlaceholderPositions)
+
+	// The maps of the first statement are reused for the second one, which
+	// makes the maps of the first statement invalid.
+	second, err := p.ParseOneWithScratch(`SELECT $1::STRING`, parser.ParseOptions{}, &scratch)
+	require.NoError(t, err)
+	require.Equal(t, "STRING", second.PlaceholderTypeHints[0].TypeName)
+	require.Equal(t, map[tree.PlaceholderIdx]int32{0: 7}, second.PlaceholderPositions)
+	require.Equal(t, mapPtr(first.PlaceholderTypeHints), mapPtr(second.PlaceholderTypeHints))
+	require.Equal(t, mapPtr(first.PlaceholderPositions), mapPtr(second.PlaceholderPositions))
+
+	// The statements without placeholders still have nil maps.
+	third, err := p.ParseOneWithScratch(`SELECT 1`, parser.ParseOptions{}, &scratch)
+	require.NoError(t, err)
+	require.Nil(t, third.PlaceholderTypeHints)
+	require.Nil(t, third.PlaceholderPositions)
+	fourth, err := p.ParseOneWithScratch(`SELECT $1::INT8`, parser.ParseOptions{}, &scratch)
+	require.NoError(t, err)
+	require.Equal(t, mapPtr(second.PlaceholderPositions), mapPtr(fourth.PlaceholderPositions))
+
+	// The statements of a multi-statement input do not share their maps.
+	_, err = p.ParseOneWithScratch(`SELECT $1::INT8; SELECT $1::STRING`, parser.ParseOptions{}, &scratch)
+	require.Error(t, err)
+	fifth, err := p.ParseOneWithScratch(`SELECT $2`, parser.ParseOptions{}, &scratch)
+	require.NoError(t, err)
+	require.Equal(t, map[tree.PlaceholderIdx]int32{1: 7}, fifth.PlaceholderPositions)
+
+	// The annotations container is reused and cleared.
+	ann := scratch.Annotations(3)
+	require.Len(t, ann, 3)
+	ann.Set(1, "a")
+	ann = scratch.Annotations(2)
+	require.Len(t, ann, 2)
+	require.Nil(t, ann.Get(1))
+	require.Len(t, scratch.Annotations(10), 10)
+}
+
+// TestParseScratchAnnotations checks that the annotations container of a
+// scratch reused across statements with different numbers of annotations is
+// always returned empty.
+func TestParseScratchAnnotations(t *testing.T) {
+	var p parser.Parser
+	var scratch parser.ParseScratch
+	for _, tc := range []struct {
+		sql string
+		num tree.AnnotationIdx
+	}{
+		{`SELECT 1::s.t, 2::s.u, 3::v`, 3},
+		{`SELECT 1::t`, 1},
+		{`SELECT 1::t, 2::u`, 2},
+		{`SELECT 1`, 0},
+		{`SELECT 1::t, 2::u, 3::v, 4::w`, 4},
+	} {
+		stmt, err := p.ParseOneWithScratch(tc.sql, parser.ParseOptions{}, &scratch)
+		require.NoError(t, err)
+		require.Equal(t, tc.num, stmt.NumAnnotations, tc.sql)
+		ann := scratch.Annotations(stmt.NumAnnotations)
+		require.Len(t, ann, int(tc.num), tc.sql)
+		for i := tree.AnnotationIdx(1); i <= stmt.NumAnnotations; i++ {
+			require.Nil(t, ann.Get(i), "%s: annotation %d", tc.sql, i)
+			ann.Set(i, tc.sql)
+		}
+	}
+}
+
+// TestParseUntilCopyData checks that a dump with COPY ... FROM STDIN
+// statements can be replayed by parsing the SQL around the copy data.
+func TestParseUntilCopyData(t *testing.T) {
+	const copyData = "1\tfoo\\tbar\n2\t'unterminated \\\\ \"\n3\t\\N\n"
+	dump := "SET client_encoding = 'UTF8';\n" +
+		"CREATE TABLE t (a INT8, b STRING);\n" +
+		"COPY public.t (a, b) FROM stdin;\n" +
+		copyData +
+		"\\.\n" +
+		"SELECT count(*) FROM t;\n"
+
+	// The copy data is not SQL.
+	_, err := parser.Parse(dump)
+	require.Error(t, err)
+
+	var sqls []string
+	var data []string
+	for rest := dump; ; {
+		stmts, cd, err := parser.ParseUntilCopyData(rest)
+		require.NoError(t, err)
+		for _, stmt := range stmts {
+			sqls = append(sqls, stmt.SQL)
+		}
+		if cd == nil {
+			break
+		}
+		require.Equal(t, stmts[len(stmts)-1], cd.Stmt)
+		end, ok := parser.CopyDataEnd(rest, cd.Start)
+		require.True(t, ok)
+		data = append(data, rest[cd.Start:end])
+		rest = rest[end:]
+	}
+	require.Equal(t, []string{
+		"SET client_encoding = 'UTF8'",
+		"CREATE TABLE t (a INT8, b STRING)",
+		"COPY public.t (a, b) FROM stdin",
+		"SELECT count(*) FROM t",
+	}, sqls)
+	require.Equal(t, []string{copyData + "\\.\n"}, data)
+
+	// Without a terminating semicolon, the copy data starts at the end of
+	// the input.
+	stmts, cd, err := parser.ParseUntilCopyData(`SELECT 1; COPY t FROM STDIN`)
+	require.NoError(t, err)
+	require.Len(t, stmts, 2)
+	require.Equal(t, len(`SELECT 1; COPY t FROM STDIN`), cd.Start)
+
+	// COPY ... TO STDOUT is not followed by copy data.
+	stmts, cd, err = parser.ParseUntilCopyData(`COPY t TO STDOUT; SELECT 1`)
+	require.NoError(t, err)
+	require.Len(t, stmts, 2)
+	require.Nil(t, cd)
+
+	_, ok := parser.CopyDataEnd("1\tfoo\n\\.x\n", 0)
+	require.False(t, ok)
+}
+
+// TestParseReferencedNames checks the names collected with
+// ParseOptions.CollectNames.
+func TestParseReferencedNames(t *testing.T) {
+	testData := []struct {
+		sql      string
+		expected []string
+	}{
+		{`SELECT 1`, nil},
+		{
+			`SELECT f(a), db.sch.g(b) FROM t, s.u AS v JOIN ONLY w USING (x)`,
+			[]string{`function f@7`, `function db.sch.g@13`, `table t@30`, `table s.u@33`, `table w@52`},
+		},
+		{
+			`SELECT 't', 'f()' FROM t /* u */ -- v()`,
+			[]string{`table t@23`},
+		},
+		{
+			`SELECT * FROM t WHERE EXISTS (SELECT 1 FROM u WHERE u.x = lower(t.y))`,
+			[]string{`table t@14`, `table u@44`, `function lower@58`},
+		},
+		{
+			`INSERT INTO t (a) SELECT nextval('seq') FROM u`,
+			[]string{`table t@12`, `function nextval@25`, `table u@45`},
+		},
+		{
+			`UPDATE t SET a = 1 WHERE b IN (SELECT c FROM "U")`,
+			[]string{`table t@7`, `table "U"@45`},
+		},
+		{`CREATE SEQUENCE s`, []string{`sequence s@16`}},
+		{`ALTER SEQUENCE s INCREMENT 2`, []string{`sequence s@15`}},
+		{`DROP SEQUENCE s1, s2`, []string{`sequence s1@14`, `sequence s2@18`}},
+		// The objects that a statement defines or drops are recorded like the
+		// objects that it reads, whatever their kind.
+		{`CREATE TABLE t (a INT REFERENCES u)`, []string{`table t@13`, `table u@33`}},
+		{`DROP TABLE t1, t2`, []string{`table t1@11`, `table t2@15`}},
+		{
+			`CREATE FUNCTION f() RETURNS INT LANGUAGE SQL AS 'SELECT 1'`,
+			[]string{`function f@16`},
+		},
+		{`DROP FUNCTION f(INT), g`, []string{`function f@14`, `function g@22`}},
+		// The relations of a locking clause are aliases, not tables.
+		{`SELECT * FROM t AS x FOR UPDATE OF x`, []string{`table t@14`}},
+	}
+	for _, d := range testData {
+		t.Run(d.sql, func(t *testing.T) {
+			stmt, err := parser.ParseOneWithOptions(d.sql, parser.ParseOptions{CollectNames: true})
+			require.NoError(t, err)
+			var res []string
+			for _, n := range stmt.ReferencedNames {
+				res = append(res, fmt.Sprintf("%s %s@%d", n.Kind, n.Name, n.Pos))
+			}
+			require.Equal(t, d.expected, res)
+
+			// The names are not collected by default.
+			stmt, err = parser.ParseOne(d.sql)
+			require.NoError(t, err)
+			require.Nil(t, stmt.ReferencedNames)
+		})
+	}
+}
+
+// TestParseRoleNamedAll checks that a quoted role name "all" is not confused
+// with the ALL keyword accepted by some role statements.
+func TestParseRoleNamedAll(t *testing.T) {
+	testData := []struct {
+		sql      string
+		roleName func(tree.Statement) string
+	}{
+		{
+			sql: `ALTER ROLE "all" SET search_path = 'abc'`,
+			roleName: func(stmt tree.Statement) string {
+				n := stmt.(*tree.AlterRoleSet)
+				require.False(t, n.AllRoles)
+				return n.RoleName.Name
+			},
+		},
+		{
+			sql: `ALTER USER "all" RESET ALL`,
+			roleName: func(stmt tree.Statement) string {
+				n := stmt.(*tree.AlterRoleSet)
+				require.False(t, n.AllRoles)
+				require.True(t, n.SetOrReset.ResetAll)
+				return n.RoleName.Name
+			},
+		},
+		{
+			sql: `SHOW DEFAULT SESSION VARIABLES FOR USER "all"`,
+			roleName: func(stmt tree.Statement) string {
+				n := stmt.(*tree.ShowDefaultSessionVariablesForRole)
+				require.False(t, n.All)
+				return n.Name.Name
+			},
+		},
+		{
+			sql: `DROP USER "all"`,
+			roleName: func(stmt tree.Statement) string {
+				return stmt.(*tree.DropRole).Names[0].Name
+			},
+		},
+	}
+	for _, d := range testData {
+		t.Run(d.sql, func(t *testing.T) {
+			stmt, err := parser.ParseOne(d.sql)
+			require.NoError(t, err)
+			require.Equal(t, "all", d.roleName(stmt.AST))
+			require.Equal(t, d.sql, stmt.AST.String())
+		})
+	}
+}
+
+func TestParseOne(t *testing.T) {
+	_, err := parser.ParseOne("SELECT 1; SELECT 2")
+	if !testutils.IsError(err, "expected 1 statement") {
+		t.Errorf("unexpected error %s", err)
+	}
+}
+
+// benchmarkQueries are the statements used by BenchmarkParse and
+// BenchmarkCheckLexical.
+var benchmarkQueries = []struct {
+	name, query string
+}{
+	{
+		"simple",
+		`SELECT a FROM t WHERE a = 1`,
+	},
+	{
+		"string",
+		`SELECT a FROM t WHERE a = 'some-string' AND b = 'some-other-string'`,
+	},
+	{
+		"tpcc-delivery",
+		`SELECT no_o_id FROM new_order WHERE no_w_id = $1 AND no_d_id = $2 ORDER BY no_o_id ASC LIMIT 1 FOR UPDATE`,
+	},
+	{
+		"account",
+		`BEGIN;
 			 UPDATE pgbench_accounts SET abalance = abalance + 77 WHERE aid = 5;
 			 SELECT abalance FROM pgbench_accounts WHERE aid = 5;
 			 INSERT INTO pgbench_history (tid, bid, aid, delta, mtime) VALUES (1, 2, 5, 77, CURRENT_TIMESTAMP);
 			 END`,
-		},
-	}
-	for _, tc := range testCases {
+	},
+	{
+		// Many of these tokens are rewritten by the lookahead rules of the
+		// lexer (see lookahead.go).
+		"lookahead",
+		`CREATE TABLE t (
+			 a INT NOT NULL, b INT, INDEX (a), INDEX b_idx (b),
+			 c INT GENERATED ALWAYS AS (a + 1) STORED,
+			 d INT REFERENCES u (x) ON DELETE CASCADE ON UPDATE SET NULL);
+			 SELECT a FROM t AS OF SYSTEM TIME '-1s'
+			 WHERE a NOT IN (1, 2) AND b NOT LIKE 'x%' ORDER BY b NULLS FIRST`,
+	},
+}
+
+func BenchmarkParse(b *testing.B) {
+	for _, tc := range benchmarkQueries {
 		b.Run(tc.name, func(b *testing.B) {
 			for i := 0; i < b.N; i++ {
 				if _, err := parser.Parse(tc.query); err != nil {
@@ -720,6 +1715,88 @@ func BenchmarkParse(b *testing.B) {
 	}
 }
 
+// BenchmarkCheckLexical is meant to be compared with BenchmarkParse: the
+// lexical check should be an order of magnitude faster than parsing.
+func BenchmarkCheckLexical(b *testing.B) {
+	for _, tc := range benchmarkQueries {
+		b.Run(tc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := parser.CheckLexical(tc.query); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkParseComplexity measures the overhead of
+// ParseOptions.MeasureComplexity, which should be negligible.
+func BenchmarkParseComplexity(b *testing.B) {
+	for _, tc := range benchmarkQueries {
+		b.Run(tc.name, func(b *testing.B) {
+			for _, measure := range []bool{false, true} {
+				b.Run(fmt.Sprintf("measure=%t", measure), func(b *testing.B) {
+					opts := parser.ParseOptions{MeasureComplexity: measure}
+					for i := 0; i < b.N; i++ {
+						if _, err := parser.ParseWithOptions(tc.query, opts); err != nil {
+							b.Fatal(err)
+						}
+					}
+				})
+			}
+		})
+	}
+}
+
+// BenchmarkParseOneWithScratch measures the allocations saved by reusing a
+// scratch to parse prepared-style statements.
+func BenchmarkParseOneWithScratch(b *testing.B) {
+	queries := []string{
+		`SELECT * FROM t WHERE a = $1::INT8 AND b = $2`,
+		`INSERT INTO t (a, b, c) VALUES ($1, $2::STRING, $3)`,
+		`UPDATE t SET b = $2::STRING WHERE a = $1`,
+	}
+	for _, useScratch := range []bool{false, true} {
+		b.Run(fmt.Sprintf("scratch=%t", useScratch), func(b *testing.B) {
+			b.ReportAllocs()
+			var p parser.Parser
+			var scratch parser.ParseScratch
+			for i := 0; i < b.N; i++ {
+				sql := queries[i%len(queries)]
+				var err error
+				if useScratch {
+					_, err = p.ParseOneWithScratch(sql, parser.ParseOptions{}, &scratch)
+				} else {
+					_, err = parser.ParseOneWithOptions(sql, parser.ParseOptions{})
+				}
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkParseReusedParser parses a loop of small statements with a single
+// long-lived Parser, which reuses its token buffer across statements.
+func BenchmarkParseReusedParser(b *testing.B) {
+	stmts := []string{
+		`SELECT a, b, c FROM t WHERE a = 1 AND b = 'foo'`,
+		`UPDATE t SET a = a + 1 WHERE b = $1 AND c = $2`,
+		`INSERT INTO t (a, b, c) VALUES ($1, $2, $3)`,
+		`DELETE FROM t WHERE a = $1 RETURNING b, c`,
+	}
+	b.ReportAllocs()
+	var p parser.Parser
+	for i := 0; i < b.N; i++ {
+		for _, sql := range stmts {
+			if _, err := p.Parse(sql); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
 func TestGetTypeFromValidSQLSyntax(t *testing.T) {
 	rng, _ := randutil.NewTestRand()
 
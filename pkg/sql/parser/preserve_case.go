@@ -0,0 +1,159 @@
+// Copyright 2026 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package parser
+
+import (
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/lexbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser/statements"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+)
+
+// FormatPreservingCase formats the statement like tree.AsString, but spells
+// keywords and unquoted identifiers the way they were spelled in the SQL the
+// statement was parsed from. This requires the statement to have been parsed
+// with ParseOptions.PreserveCase; otherwise the regular formatting is
+// returned.
+//
+// The formatted words are matched against the original ones by their
+// normalized form, so the result parses to the same statement. Words that
+// were added by the formatting, e.g. INT8 for INT, keep their canonical
+// spelling.
+func FormatPreservingCase(stmt statements.Statement[tree.Statement]) string {
+	out := tree.AsString(stmt.AST)
+	if len(stmt.Spellings) == 0 {
+		return out
+	}
+	words := scanWords(out)
+	matches := matchWords(words, stmt.Spellings)
+
+	var buf strings.Builder
+	last := 0
+	for i, w := range words {
+		if matches[i] < 0 {
+			continue
+		}
+		buf.WriteString(out[last:w.pos])
+		buf.WriteString(stmt.Spellings[matches[i]])
+		last = w.pos + w.len
+	}
+	buf.WriteString(out[last:])
+	return buf.String()
+}
+
+// word is a keyword or unquoted identifier in some SQL text.
+type word struct {
+	pos, len int
+	// norm is the normalized spelling of the word.
+	norm string
+}
+
+// isWord returns whether tok, scanned from sql, is a keyword or an unquoted
+// identifier.
+func isWord(sql string, tok *sqlSymType) bool {
+	switch tok.id {
+	case ERROR, SCONST, BCONST, BITCONST:
+		// String literals can start with a letter, as in e'...'.
+		return false
+	}
+	return int(tok.pos) < len(sql) && lexbase.IsIdentStart(int(sql[tok.pos]))
+}
+
+// wordLen returns the length of the word starting at position pos in sql.
+func wordLen(sql string, pos int32) int {
+	end := int(pos) + 1
+	for end < len(sql) && lexbase.IsIdentMiddle(int(sql[end])) {
+		end++
+	}
+	return end - int(pos)
+}
+
+// wordSpellings returns the original spelling of the keywords and unquoted
+// identifiers among the tokens scanned from sql.
+func wordSpellings(sql string, tokens []sqlSymType) []string {
+	var spellings []string
+	for i := range tokens {
+		if isWord(sql, &tokens[i]) {
+			pos := tokens[i].pos
+			spellings = append(spellings, sql[pos:int(pos)+wordLen(sql, pos)])
+		}
+	}
+	return spellings
+}
+
+// scanWords returns the keywords and unquoted identifiers in sql.
+func scanWords(sql string) []word {
+	s := makeSQLScanner(sql)
+	var words []word
+	for {
+		var lval sqlSymType
+		s.Scan(&lval)
+		if lval.id == 0 || lval.id == ERROR {
+			return words
+		}
+		if isWord(sql, &lval) {
+			n := wordLen(sql, lval.pos)
+			words = append(words, word{pos: int(lval.pos), len: n, norm: lexbase.NormalizeName(sql[lval.pos : int(lval.pos)+n])})
+		}
+	}
+}
+
+// maxWordSkew is the largest number of consecutive words that matchWords
+// expects the formatting to have added, dropped or respelled, e.g. INT8 for
+// INTEGER or the AS of an alias.
+const maxWordSkew = 8
+
+// matchWords aligns the words with the original spellings in a single pass
+// over both, comparing their normalized forms. It returns, for each word,
+// the index of the matching spelling, or -1 if the word has no match.
+//
+// The formatting keeps the words in the order of the input, and only adds,
+// drops or respells a few of them. So when the current word and spelling
+// differ, the alignment resumes at the nearest pair of positions, at most
+// maxWordSkew words or spellings ahead, where they agree again: either both
+// streams advanced (the words were respelled), or only the words (they were
+// added), or only the spellings (they were dropped). This takes time linear
+// in the number of words, unlike a longest common subsequence.
+func matchWords(words []word, spellings []string) []int {
+	norms := make([]string, len(spellings))
+	for i := range spellings {
+		norms[i] = lexbase.NormalizeName(spellings[i])
+	}
+	matchAt := func(i, j int) bool {
+		return i < len(words) && j < len(norms) && words[i].norm == norms[j]
+	}
+	matches := make([]int, len(words))
+	for i := range matches {
+		matches[i] = -1
+	}
+	for i, j := 0, 0; i < len(words) && j < len(norms); {
+		if matchAt(i, j) {
+			matches[i] = j
+			i++
+			j++
+			continue
+		}
+		skipWords, skipSpellings := 1, 0
+		for d := 1; d <= maxWordSkew; d++ {
+			if matchAt(i+d, j+d) {
+				skipWords, skipSpellings = d, d
+				break
+			}
+			if matchAt(i+d, j) {
+				skipWords, skipSpellings = d, 0
+				break
+			}
+			if matchAt(i, j+d) {
+				skipWords, skipSpellings = 0, d
+				break
+			}
+		}
+		i += skipWords
+		j += skipSpellings
+	}
+	return matches
+}
@@ -34,6 +34,67 @@ func SplitFirstStatement(sql string) (pos int, ok bool) {
 	}
 }
 
+// CheckLexical runs only the scanner over the given SQL and returns the
+// first lexical error, e.g. for an unterminated string literal, in the same
+// form as the parser would report it. The error detail points into sql. This
+// is much cheaper than parsing, and can be used to reject obviously broken
+// SQL early.
+//
+// Note that passing this check does NOT imply that the SQL is valid: the
+// grammar is not consulted, so syntax errors are only reported by Parse.
+func CheckLexical(sql string) error {
+	s := makeSQLScanner(sql)
+	var lval sqlSymType
+	for {
+		s.Scan(&lval)
+		switch lval.id {
+		case 0:
+			return nil
+		case lexbase.ERROR:
+			return PopulateErrorDetails(lval.id, lval.str, "" /* precedingText */, lval.pos, nil /* lastErr */, sql)
+		}
+	}
+}
+
+// StatementPiece is the text of one statement of a SQL string, as returned
+// by SplitStatements.
+type StatementPiece struct {
+	// SQL is the text of the statement, without the semicolon that
+	// terminates it.
+	SQL string
+	// Start and End delimit SQL in the input.
+	Start, End int
+}
+
+// SplitStatements splits the given SQL into statements without parsing
+// them. Semicolons only separate statements outside of string literals,
+// quoted identifiers, comments and the bodies of BEGIN ATOMIC ... END
+// functions, and empty statements are skipped, so the result corresponds
+// one-to-one to the statements returned by Parse for valid SQL.
+//
+// A lexical error, e.g. an unterminated string literal, is reported like
+// CheckLexical reports it, with its position in sql.
+func SplitStatements(sql string) ([]StatementPiece, error) {
+	var p Parser
+	p.scanner.Init(sql)
+	defer p.scanner.Cleanup()
+	var pieces []StatementPiece
+	for {
+		start := stmtStartPos(sql, p.scanner.Pos())
+		stmt, tokens, done := p.scanOneStmt()
+		if n := len(tokens); n > 0 && tokens[n-1].id == ERROR {
+			tok := tokens[n-1]
+			return nil, PopulateErrorDetails(tok.id, tok.str, "" /* precedingText */, int32(start)+tok.pos, nil /* lastErr */, sql)
+		}
+		if len(tokens) > 0 {
+			pieces = append(pieces, StatementPiece{SQL: stmt, Start: start, End: start + len(stmt)})
+		}
+		if done {
+			return pieces, nil
+		}
+	}
+}
+
 // Tokens decomposes the input into lexical tokens.
 func Tokens(sql string) (tokens []TokenString, ok bool) {
 	s := makeSQLScanner(sql)
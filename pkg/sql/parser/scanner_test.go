@@ -12,9 +12,12 @@ import (
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/sql/lexbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/scanner"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
 )
 
 func TestScanner(t *testing.T) {
@@ -202,6 +205,23 @@ func TestScanNumber(t *testing.T) {
 		{`1e+3`, `1e+3`, FCONST},
 		{`1e+3+`, `1e+3`, FCONST},
 		{`9223372036854775809`, `9223372036854775809`, ICONST},
+		{`0x1A`, `0x1A`, ICONST},
+		{`0X_1a`, `0X1a`, ICONST},
+		{`0o17`, `0o17`, ICONST},
+		{`0O17`, `0O17`, ICONST},
+		{`0b1010`, `0b1010`, ICONST},
+		{`0B1010`, `0B1010`, ICONST},
+		{`0b_1010_0101`, `0b10100101`, ICONST},
+		{`0xffff_ffff`, `0xffffffff`, ICONST},
+		{`1_000_000`, `1000000`, ICONST},
+		{`0_1`, `1`, ICONST},
+		{`1_000.000_1`, `1000.0001`, FCONST},
+		{`1e1_0`, `1e10`, FCONST},
+		{`0b1010.`, `0b1010`, ICONST},
+		{`0o17..2`, `0o17`, ICONST},
+		// Literals that do not fit in an INT8 are returned as decimal strings.
+		{`0xffff_ffff_ffff_ffff`, `18446744073709551615`, ICONST},
+		{`0b1_0000000000000000000000000000000000000000000000000000000000000000`, `18446744073709551616`, ICONST},
 	}
 	for _, d := range testData {
 		s := makeSQLScanner(d.sql)
@@ -316,8 +336,16 @@ world`},
 		{`$$hello
 world$$`, `hello
 world`},
+		{`$café$a$café$`, "a"},
+		{`$é$$$é$`, "$$"},
+		{`$_1$a$_1$`, "a"},
+		{`$a$$b$x$b$$a$`, "$b$x$b$"},
+		{`$a$$ab$$a$`, "$ab$"},
+		{`$ab$$a$$ab$`, "$a$"},
+		{`$ab$$abc$$ab$`, "$abc$"},
 		{`$$a`, `unterminated string`},
 		{`$a$a$$`, `unterminated string`},
+		{`$ab$a$a$`, `unterminated string`},
 	}
 	for _, d := range testData {
 		s := makeSQLScanner(d.sql)
@@ -329,6 +357,47 @@ world`},
 	}
 }
 
+func TestScanStringWarnings(t *testing.T) {
+	testData := []struct {
+		sql      string
+		expected string
+		warnings []scanner.Warning
+	}{
+		{`e'\d+'`, `d+`, []scanner.Warning{
+			{Pos: 2, Msg: `nonstandard use of escape in a string literal: \d`},
+		}},
+		{`e'100\%'`, `100%`, []scanner.Warning{
+			{Pos: 5, Msg: `nonstandard use of escape in a string literal: \%`},
+		}},
+		{`e'\d\%'`, `d%`, []scanner.Warning{
+			{Pos: 2, Msg: `nonstandard use of escape in a string literal: \d`},
+			{Pos: 4, Msg: `nonstandard use of escape in a string literal: \%`},
+		}},
+		{`b'\q'`, `q`, []scanner.Warning{
+			{Pos: 2, Msg: `nonstandard use of escape in a string literal: \q`},
+		}},
+		{`e'\n'`, "\n", nil},
+		{`e'\x41'`, `A`, nil},
+		{`e'\''`, `'`, nil},
+		{`e'a\\'`, `a\`, nil},
+		// Standard strings do not process escapes at all.
+		{`'\d'`, `\d`, nil},
+		// An escape at the end of the input is an error, not a warning.
+		{`e'a\`, `unterminated string`, nil},
+	}
+	for _, d := range testData {
+		s := makeSQLScanner(d.sql)
+		var lval = &sqlSymType{}
+		s.Scan(lval)
+		if d.expected != lval.Str() {
+			t.Errorf("%s: expected %q, but found %q", d.sql, d.expected, lval.Str())
+		}
+		if !reflect.DeepEqual(d.warnings, s.Warnings) {
+			t.Errorf("%s: expected warnings %v, but found %v", d.sql, d.warnings, s.Warnings)
+		}
+	}
+}
+
 func TestScanError(t *testing.T) {
 	testData := []struct {
 		sql string
@@ -348,12 +417,30 @@ func TestScanError(t *testing.T) {
 		{`x'beef\x41'`, "invalid hexadecimal bytes literal"},
 		{`X'beef\x41\x41'`, "invalid hexadecimal bytes literal"},
 		{`x'a'`, "invalid hexadecimal bytes literal"},
-		{`$0`, "placeholder index must be between 1 and 65536"},
-		{`$9223372036854775809`, "placeholder index must be between 1 and 65536"},
+		{`$a`, "invalid dollar-quoted string tag"},
+		{`$a b$`, "invalid dollar-quoted string tag"},
+		{`$a-b$x$a-b$`, "invalid dollar-quoted string tag"},
+		{`$café x$`, "invalid dollar-quoted string tag"},
+		{"$\xff$x$\xff$", "invalid dollar-quoted string tag"},
+		{`$0`, "placeholder index must be between 1 and 65535 at or near \"$0\""},
+		{`$9223372036854775809`, "placeholder index must be between 1 and 65535 at or near \"$9223372036854775809\""},
 		{`B'123'`, `"2" is not a valid binary digit`},
 		{`123foo`, "trailing junk after numeric literal at or near \"123f\""},
 		{`1.23foo`, "trailing junk after numeric literal at or near \"1.23f\""},
 		{`0x0afoo`, "trailing junk after numeric literal at or near \"0x0afo\""},
+		{`0b`, "invalid binary numeric literal"},
+		{`0o`, "invalid octal numeric literal"},
+		{`0x_`, "trailing junk after numeric literal at or near \"0x_\""},
+		{`0b12`, "invalid binary numeric literal: '2' is not a valid binary digit"},
+		{`0o18`, "invalid octal numeric literal: '8' is not a valid octal digit"},
+		{`0b102`, "invalid binary numeric literal: '2' is not a valid binary digit"},
+		{`0b1x`, "trailing junk after numeric literal at or near \"0b1x\""},
+		{`0o7g`, "trailing junk after numeric literal at or near \"0o7g\""},
+		{`1_`, "trailing junk after numeric literal at or near \"1_\""},
+		{`1__0`, "trailing junk after numeric literal at or near \"1_\""},
+		{`0x1_`, "trailing junk after numeric literal at or near \"0x1_\""},
+		{`1_.5`, "trailing junk after numeric literal at or near \"1_\""},
+		{`1._5`, "trailing junk after numeric literal at or near \"1._\""},
 	}
 	for _, d := range testData {
 		s := makeSQLScanner(d.sql)
@@ -419,3 +506,87 @@ func TestSplitFirstStatement(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckLexical(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	// Inputs without lexical errors pass the check, even if they are not
+	// grammatically valid.
+	for _, sql := range []string{
+		``,
+		`SELECT 1`,
+		`SELECT 'a', e'\n', $$b$$, $tag$c$tag$, "D" FROM t; SELECT 2`,
+		`SELECT 1 -- 'unterminated`,
+		`SELECT FROM FROM`,
+		"SELECT '`a`' /* `b` */",
+	} {
+		require.NoError(t, CheckLexical(sql), sql)
+	}
+
+	// Lexical errors are reported like the parser reports them.
+	for _, sql := range []string{
+		`SELECT 'abc`,
+		`SELECT "abc`,
+		`SELECT $a$abc`,
+		`SELECT 1_000_ FROM t`,
+		`SELECT 1 /* comment`,
+		"SELECT `a` FROM t",
+		"SELECT `a FROM t",
+	} {
+		err := CheckLexical(sql)
+		require.Error(t, err, sql)
+		_, parseErr := Parse(sql)
+		require.Error(t, parseErr, sql)
+		require.Equal(t, parseErr.Error(), err.Error())
+		require.Equal(t, errors.FlattenDetails(parseErr), errors.FlattenDetails(err))
+		require.Equal(t, errors.FlattenHints(parseErr), errors.FlattenHints(err))
+		require.Equal(t, pgerror.GetPGCode(parseErr), pgerror.GetPGCode(err))
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	testData := []struct {
+		sql      string
+		expected []string
+	}{
+		{``, nil},
+		{`;; -- nothing`, nil},
+		{`SELECT 1`, []string{`SELECT 1`}},
+		{`SELECT 1; SELECT 2;`, []string{`SELECT 1`, `SELECT 2`}},
+		{`SELECT ';', e'\';', "a;b" FROM t`, []string{`SELECT ';', e'\';', "a;b" FROM t`}},
+		{"SELECT 1 /* ; */; SELECT 2 -- ;\n", []string{`SELECT 1 /* ; */`, "SELECT 2 -- ;\n"}},
+		{`SELECT $$;$$, $a$ $$; $a$; SELECT 2`, []string{`SELECT $$;$$, $a$ $$; $a$`, `SELECT 2`}},
+		{
+			`CREATE FUNCTION f() RETURNS INT LANGUAGE SQL BEGIN ATOMIC SELECT 1; SELECT 2; END; SELECT f()`,
+			[]string{`CREATE FUNCTION f() RETURNS INT LANGUAGE SQL BEGIN ATOMIC SELECT 1; SELECT 2; END`, `SELECT f()`},
+		},
+	}
+	for _, d := range testData {
+		t.Run(d.sql, func(t *testing.T) {
+			pieces, err := SplitStatements(d.sql)
+			require.NoError(t, err)
+			var res []string
+			for _, p := range pieces {
+				require.Equal(t, p.SQL, d.sql[p.Start:p.End])
+				res = append(res, p.SQL)
+			}
+			require.Equal(t, d.expected, res)
+
+			stmts, err := Parse(d.sql)
+			require.NoError(t, err)
+			require.Len(t, stmts, len(pieces))
+		})
+	}
+
+	// Unterminated constructs are reported at their position in the input.
+	for _, sql := range []string{
+		`SELECT 1; SELECT 'abc`,
+		`SELECT 1; SELECT $a$abc`,
+		`SELECT 1; /* comment`,
+	} {
+		_, err := SplitStatements(sql)
+		require.Error(t, err, sql)
+		require.Contains(t, err.Error(), "lexical error", sql)
+		require.Equal(t, errors.FlattenDetails(CheckLexical(sql)), errors.FlattenDetails(err), sql)
+	}
+}
@@ -6,6 +6,7 @@
 package parser
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"strings"
@@ -29,7 +30,7 @@ func RunShowSyntax(
 	report func(ctx context.Context, field, msg string),
 	reportErr func(ctx context.Context, err error),
 ) {
-	if strings.HasSuffix(stmt, "??") {
+	if strings.HasSuffix(stmt, "??") && endsWithHelpToken(stmt) {
 		// A statement (or, more likely, a prefix to a statement) followed
 		// by the help token (??).
 		//
@@ -40,8 +41,7 @@ func RunShowSyntax(
 		prefix := strings.ToUpper(strings.TrimSpace(stmt[:len(stmt)-2]))
 		if h, ok := HelpMessages[prefix]; ok {
 			msg := HelpMessage{Command: prefix, HelpMessageBody: h}
-			msgs := msg.String()
-			err := errors.WithHint(pgerror.WithCandidateCode(errors.New(specialHelpErrorPrefix), pgcode.Syntax), msgs)
+			err := withHelpResponse(pgerror.WithCandidateCode(errors.New(specialHelpErrorPrefix), pgcode.Syntax), msg)
 			doErr(ctx, report, reportErr, err)
 			return
 		}
@@ -57,6 +57,24 @@ func RunShowSyntax(
 	}
 }
 
+// endsWithHelpToken returns true if the last token of the statement is the
+// help token, as opposed to e.g. a "??" at the end of a comment.
+func endsWithHelpToken(stmt string) bool {
+	s := makeSQLScanner(stmt)
+	var lastID int32
+	for {
+		var lval sqlSymType
+		s.Scan(&lval)
+		switch lval.id {
+		case 0:
+			return lastID == HELPTOKEN
+		case ERROR:
+			return false
+		}
+		lastID = lval.id
+	}
+}
+
 func doErr(
 	ctx context.Context,
 	report func(ctx context.Context, field, msg string),
@@ -87,4 +105,9 @@ func doErr(
 	if pqErr.Hint != "" {
 		report(ctx, "hint", pqErr.Hint)
 	}
+	if msg, ok := GetHelpResponse(err); ok {
+		var buf bytes.Buffer
+		msg.Format(&buf)
+		report(ctx, "help", buf.String())
+	}
 }
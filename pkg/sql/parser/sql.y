@@ -32,6 +32,7 @@ import (
 
     "github.com/cockroachdb/cockroach/pkg/geo/geopb"
     "github.com/cockroachdb/cockroach/pkg/security/username"
+    "github.com/cockroachdb/cockroach/pkg/sql/parser/statements"
     "github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
     "github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
     "github.com/cockroachdb/cockroach/pkg/sql/privilege"
@@ -1086,7 +1087,7 @@ func (u *sqlSymUnion) doBlockOption() tree.DoBlockOption {
 // The grammar thinks these are keywords, but they are not in any category
 // and so can never be entered directly. The filter in scan.go creates these
 // tokens when required (based on looking one token ahead).
-// Reference: pkg/sql/parser/lexer.go
+// Reference: pkg/sql/parser/lookahead.go
 //
 // - NOT_LA exists so that productions such as NOT LIKE can be given the same
 // precedence as LIKE; otherwise they'd effectively have the same precedence as
@@ -1099,7 +1100,7 @@ func (u *sqlSymUnion) doBlockOption() tree.DoBlockOption {
 // - ROLE_ALL and USER_ALL are used in ALTER ROLE and SHOW DEFAULT SESSION VARIABLES FOR ROLE statements that affect all
 // roles.
 // - ON_LA is needed for ON UPDATE and ON DELETE expressions for foreign key
-// references.
+// references. See the ON rules in lookaheadRules.
 // - TENANT_ALL is used to differentiate `ALTER TENANT <id>` from
 // `ALTER TENANT ALL`. Ditto `CLUSTER_ALL` and `CLUSTER ALL`.
 %token NOT_LA NULLS_LA WITH_LA AS_LA GENERATED_ALWAYS GENERATED_BY_DEFAULT RESET_ALL ROLE_ALL
@@ -1898,8 +1899,8 @@ stmt_without_legacy_transaction:
   preparable_stmt            // help texts in sub-rule
 | analyze_stmt               // EXTEND WITH HELP: ANALYZE
 | call_stmt
-| copy_stmt
-| comment_stmt
+| copy_stmt                  // EXTEND WITH HELP: COPY
+| comment_stmt               // EXTEND WITH HELP: COMMENT ON
 | execute_stmt               // EXTEND WITH HELP: EXECUTE
 | deallocate_stmt            // EXTEND WITH HELP: DEALLOCATE
 | discard_stmt               // EXTEND WITH HELP: DISCARD
@@ -1919,7 +1920,7 @@ stmt_without_legacy_transaction:
 | fetch_cursor_stmt          // EXTEND WITH HELP: FETCH
 | move_cursor_stmt           // EXTEND WITH HELP: MOVE
 | reindex_stmt
-| unlisten_stmt
+| unlisten_stmt              // EXTEND WITH HELP: UNLISTEN
 | show_commit_timestamp_stmt // EXTEND WITH HELP: SHOW COMMIT TIMESTAMP
 
 // %Help: ALTER
@@ -3744,7 +3745,7 @@ sconst_or_placeholder:
 | PLACEHOLDER
   {
     p := $1.placeholder()
-    sqllex.(*lexer).UpdateNumPlaceholders(p)
+    sqllex.(*lexer).UpdateNumPlaceholders(p, $<pos>1)
     $$.val = p
   }
 
@@ -4209,7 +4210,7 @@ string_or_placeholder:
 | PLACEHOLDER
   {
     p := $1.placeholder()
-    sqllex.(*lexer).UpdateNumPlaceholders(p)
+    sqllex.(*lexer).UpdateNumPlaceholders(p, $<pos>1)
     $$.val = p
   }
 
@@ -4283,6 +4284,14 @@ call_stmt:
 // 3) The current and preferred options using comma-separated generic identifiers instead of keywords.
 // We currently support only the #2 format.
 // See the comment for CopyStmt in https://github.com/postgres/postgres/blob/master/src/backend/parser/gram.y.
+
+// %Help: COPY - copy data between a table and the client
+// %Category: DML
+// %Text:
+// COPY <tablename> [ ( <colnames...> ) ] FROM STDIN [ WITH <option> [, ...] ]
+// COPY <tablename> [ ( <colnames...> ) ] TO STDOUT [ WITH <option> [, ...] ]
+// COPY ( <selectclause> ) TO STDOUT [ WITH <option> [, ...] ]
+// %SeeAlso: IMPORT, EXPORT, WEBDOCS/copy-from.html
 copy_stmt:
   COPY table_name opt_column_list FROM STDIN opt_with_copy_options opt_where_clause
   {
@@ -4328,6 +4337,7 @@ copy_stmt:
    {
      return unimplementedWithIssue(sqllex, 96590)
    }
+| COPY error // SHOW HELP: COPY
 
 opt_with_copy_options:
   opt_with copy_options_list
@@ -4630,6 +4640,13 @@ cancel_all_jobs_stmt:
   }
 | CANCEL ALL error // SHOW HELP: CANCEL ALL JOBS
 
+// %Help: COMMENT ON - set the comment of an object
+// %Category: DDL
+// %Text:
+// COMMENT ON { DATABASE | SCHEMA | TYPE | TABLE | INDEX } <name> IS { <string> | NULL }
+// COMMENT ON COLUMN <tablename>.<columnname> IS { <string> | NULL }
+// COMMENT ON CONSTRAINT <name> ON <tablename> IS { <string> | NULL }
+// %SeeAlso: WEBDOCS/comment-on.html
 comment_stmt:
   COMMENT ON DATABASE database_name IS comment_text
   {
@@ -4675,6 +4692,7 @@ comment_stmt:
   }
 | COMMENT ON EXTENSION error { return unimplementedWithIssueDetail(sqllex, 74777, "comment on extension") }
 | COMMENT ON FUNCTION error { return unimplementedWithIssueDetail(sqllex, 17511, "comment on function") }
+| COMMENT ON error // SHOW HELP: COMMENT ON
 
 comment_text:
   SCONST
@@ -5330,6 +5348,10 @@ opt_return_set:
 
 routine_create_name:
   db_object_name
+  {
+    sqllex.(*lexer).AddReferencedName(statements.ReferencedFunction, $1.unresolvedObjectName(), $<pos>1)
+    $$.val = $1.unresolvedObjectName()
+  }
 
 opt_routine_param_with_default_list:
   routine_param_with_default_list { $$.val = $1.routineParams() }
@@ -5681,6 +5703,7 @@ function_with_paramtypes_list:
 function_with_paramtypes:
   db_object_name func_params
   {
+    sqllex.(*lexer).AddReferencedName(statements.ReferencedFunction, $1.unresolvedObjectName(), $<pos>1)
     $$.val = tree.RoutineObj{
       FuncName: $1.unresolvedObjectName().ToRoutineName(),
       Params: $2.routineParams(),
@@ -5688,6 +5711,7 @@ function_with_paramtypes:
   }
   | db_object_name
   {
+    sqllex.(*lexer).AddReferencedName(statements.ReferencedFunction, $1.unresolvedObjectName(), $<pos>1)
     $$.val = tree.RoutineObj{
       FuncName: $1.unresolvedObjectName().ToRoutineName(),
     }
@@ -6702,10 +6726,28 @@ db_object_name_list:
   }
 
 table_name_list:
-  db_object_name_list
+  table_name
+  {
+    name := $1.unresolvedObjectName().ToTableName()
+    $$.val = tree.TableNames{name}
+  }
+| table_name_list ',' table_name
+  {
+    name := $3.unresolvedObjectName().ToTableName()
+    $$.val = append($1.tableNames(), name)
+  }
 
 sequence_name_list:
-  db_object_name_list
+  sequence_name
+  {
+    name := $1.unresolvedObjectName().ToTableName()
+    $$.val = tree.TableNames{name}
+  }
+| sequence_name_list ',' sequence_name
+  {
+    name := $3.unresolvedObjectName().ToTableName()
+    $$.val = append($1.tableNames(), name)
+  }
 
 view_name_list:
   db_object_name_list
@@ -11585,6 +11627,7 @@ reference_on_delete:
     $$.val = $3.referenceAction()
   }
 
+// If you add an action here, update the ON rules in lookaheadRules.
 reference_action:
 // NO ACTION is currently the default behavior. It is functionally the same as
 // RESTRICT.
@@ -13839,7 +13882,7 @@ for_locking_strength:
 
 opt_locked_rels:
   /* EMPTY */        { $$.val = tree.TableNames{} }
-| OF table_name_list { $$.val = $2.tableNames() }
+| OF db_object_name_list { $$.val = $2.tableNames() }
 
 opt_nowait_or_skip:
   /* EMPTY */ { $$.val = tree.LockWaitBlock }
@@ -14829,6 +14872,7 @@ opt_func_alias_clause:
 as_of_clause:
   AS_LA OF SYSTEM TIME a_expr
   {
+    sqllex.(*lexer).SetAsOfSystemTime($<pos>1)
     $$.val = tree.AsOfClause{Expr: $5.expr()}
   }
 
@@ -14944,7 +14988,9 @@ relation_expr_list:
     $$.val = append($1.tableNames(), name)
   }
 
-// UNLISTEN
+// %Help: UNLISTEN - stop listening for notifications
+// %Category: Misc
+// %Text: UNLISTEN { <channel> | * }
 unlisten_stmt:
    UNLISTEN type_name
     {
@@ -14954,6 +15000,7 @@ unlisten_stmt:
       {
           $$.val = &tree.Unlisten{ ChannelName:nil, Star: true}
       }
+| UNLISTEN error // SHOW HELP: UNLISTEN
 
 
 // Given "UPDATE foo set set ...", we have to decide without looking any
@@ -15129,12 +15176,7 @@ simple_typename:
     if $1 == "char" {
       $$.val = types.QChar
     } else if $1 == "serial" {
-        switch sqllex.(*lexer).nakedIntType.Width() {
-        case 32:
-          $$.val = &types.Serial4Type
-        default:
-          $$.val = &types.Serial8Type
-        }
+        $$.val = sqllex.(*lexer).nakedSerialType
     } else {
       // Check the the type is one of our "non-keyword" type names.
       // Otherwise, package it up as a type reference for later.
@@ -15695,7 +15737,9 @@ a_expr:
   c_expr
 | a_expr TYPECAST cast_target
   {
-    $$.val = &tree.CastExpr{Expr: $1.expr(), Type: $3.typeReference(), SyntaxMode: tree.CastShort}
+    cast := &tree.CastExpr{Expr: $1.expr(), Type: $3.typeReference(), SyntaxMode: tree.CastShort}
+    sqllex.(*lexer).UpdatePlaceholderTypeHints(cast)
+    $$.val = cast
   }
 | a_expr TYPEANNOTATE typename
   {
@@ -16131,7 +16175,9 @@ b_expr:
   c_expr
 | b_expr TYPECAST cast_target
   {
-    $$.val = &tree.CastExpr{Expr: $1.expr(), Type: $3.typeReference(), SyntaxMode: tree.CastShort}
+    cast := &tree.CastExpr{Expr: $1.expr(), Type: $3.typeReference(), SyntaxMode: tree.CastShort}
+    sqllex.(*lexer).UpdatePlaceholderTypeHints(cast)
+    $$.val = cast
   }
 | b_expr TYPEANNOTATE typename
   {
@@ -16372,7 +16418,7 @@ d_expr:
 | PLACEHOLDER
   {
     p := $1.placeholder()
-    sqllex.(*lexer).UpdateNumPlaceholders(p)
+    sqllex.(*lexer).UpdateNumPlaceholders(p, $<pos>1)
     $$.val = p
   }
 // TODO(knz/jordan): extend this for compound types. See explanation above.
@@ -16451,6 +16497,7 @@ func_application:
 func_application_name:
   func_name
   {
+    sqllex.(*lexer).AddReferencedName(statements.ReferencedFunction, $1.unresolvedName(), $<pos>1)
     $$.val = $1.resolvableFuncRefFromName()
   }
 | '[' FUNCTION iconst32 ']'
@@ -16481,12 +16528,7 @@ typed_literal:
       if typName == "char" {
         $$.val = &tree.CastExpr{Expr: tree.NewStrVal($2), Type: types.QChar, SyntaxMode: tree.CastPrepend}
       } else if typName == "serial" {
-        switch sqllex.(*lexer).nakedIntType.Width() {
-        case 32:
-          $$.val = &tree.CastExpr{Expr: tree.NewStrVal($2), Type: &types.Serial4Type, SyntaxMode: tree.CastPrepend}
-        default:
-          $$.val = &tree.CastExpr{Expr: tree.NewStrVal($2), Type: &types.Serial8Type, SyntaxMode: tree.CastPrepend}
-        }
+        $$.val = &tree.CastExpr{Expr: tree.NewStrVal($2), Type: sqllex.(*lexer).nakedSerialType, SyntaxMode: tree.CastPrepend}
       } else {
         // Check the the type is one of our "non-keyword" type names.
         // Otherwise, package it up as a type reference for later.
@@ -16614,7 +16656,9 @@ func_expr_common_subexpr:
   }
 | CAST '(' a_expr AS cast_target ')'
   {
-    $$.val = &tree.CastExpr{Expr: $3.expr(), Type: $5.typeReference(), SyntaxMode: tree.CastExplicit}
+    cast := &tree.CastExpr{Expr: $3.expr(), Type: $5.typeReference(), SyntaxMode: tree.CastExplicit}
+    sqllex.(*lexer).UpdatePlaceholderTypeHints(cast)
+    $$.val = cast
   }
 | ANNOTATE_TYPE '(' a_expr ',' typename ')'
   {
@@ -17741,11 +17785,16 @@ statistics_name:       name
 
 window_name:           name
 
-view_name:             table_name
+view_name:             db_object_name
 
 type_name:             db_object_name
 
-sequence_name:         db_object_name
+sequence_name:
+  db_object_name
+  {
+    sqllex.(*lexer).AddReferencedName(statements.ReferencedSequence, $1.unresolvedObjectName(), $<pos>1)
+    $$.val = $1.unresolvedObjectName()
+  }
 
 region_name:
   name
@@ -17802,7 +17851,12 @@ opt_schema_name:
 		$$.val = tree.ObjectNamePrefix{ExplicitSchema: false}
 	}
 
-table_name:            db_object_name
+table_name:
+  db_object_name
+  {
+    sqllex.(*lexer).AddReferencedName(statements.ReferencedTable, $1.unresolvedObjectName(), $<pos>1)
+    $$.val = $1.unresolvedObjectName()
+  }
 
 db_name:               db_object_name
 
@@ -6,8 +6,10 @@
 package statements
 
 import (
+	"github.com/cockroachdb/cockroach/pkg/sql/scanner"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/plpgsqltree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/intsets"
 )
 
 // Statement is the result of parsing a single statement. It contains the AST
@@ -38,9 +40,159 @@ type Statement[T any] struct {
 	// type-check error.
 	NumPlaceholders int
 
+	// UsedPlaceholders is the set of the indexes of the placeholders that
+	// occur in the statement. Unlike NumPlaceholders, it distinguishes
+	// between e.g. $1, $2, $3 and $3 alone.
+	UsedPlaceholders intsets.Fast
+
+	// PlaceholderTypeHints records, for each placeholder that is directly
+	// cast to a type, as in $1::INT8 or CAST($1 AS INT8), the type that the
+	// cast declares. It is nil if there are no such casts.
+	PlaceholderTypeHints map[tree.PlaceholderIdx]PlaceholderTypeHint
+
+	// PlaceholderPositions records, for each placeholder, the position in SQL
+	// of its first occurrence. It is nil if there are no placeholders.
+	PlaceholderPositions map[tree.PlaceholderIdx]int32
+
+	// AsOfSystemTime is set if the statement contains an AS OF SYSTEM TIME
+	// clause, at any level, including in subqueries.
+	AsOfSystemTime bool
+	// AsOfSystemTimePos is the position in SQL of the first AS OF SYSTEM TIME
+	// clause, if AsOfSystemTime is set.
+	AsOfSystemTimePos int32
+
 	// NumAnnotations indicates the number of annotations in the tree. It is equal
-	// to the maximum annotation index.
+	// to the maximum annotation index, which includes the annotation base the
+	// statement was parsed with, if any (see parser.ParseOptions), so that the
+	// container for the annotations can be allocated with
+	// tree.MakeAnnotations(NumAnnotations) without ever growing. The
+	// annotations are numbered separately for each statement of a
+	// multi-statement input, so NumAnnotations only accounts for the
+	// statement itself.
 	NumAnnotations tree.AnnotationIdx
+
+	// Warnings is the list of non-fatal diagnostics produced while scanning
+	// the statement, e.g. for unknown escapes in e'...' strings. The
+	// positions are relative to SQL. Clients may report these as notices.
+	Warnings []scanner.Warning
+
+	// Spellings is the list of keywords and unquoted identifiers of SQL, in
+	// order and spelled as in the input. It is only populated when parsing
+	// with parser.ParseOptions.PreserveCase, and is used by
+	// parser.FormatPreservingCase.
+	Spellings []string
+
+	// ReferencedNames lists the names of the tables, sequences and functions
+	// referenced by the statement, including in subqueries, in order of
+	// appearance. It is only populated when parsing with
+	// parser.ParseOptions.CollectNames.
+	ReferencedNames []ReferencedName
+
+	// Complexity measures the size and shape of the statement. It is only
+	// populated when parsing with parser.ParseOptions.MeasureComplexity.
+	Complexity Complexity
+
+	// TokenRange is the range of the tokens of the statement in the token
+	// stream of the input, which can be used to map the statements back to
+	// the tokens of an editor without scanning the input again.
+	TokenRange TokenRange
+	// Tokens lists the tokens of the statement, i.e. the tokens of the
+	// input from TokenRange.First to TokenRange.Last. It is only populated
+	// when parsing with parser.ParseOptions.CollectTokens.
+	Tokens []Token
+}
+
+// TokenRange is a range of indexes into the token stream of an input,
+// inclusive at both ends. The token stream is the sequence of the tokens of
+// all the statements of the input, excluding the semicolons that separate
+// them; the ranges of consecutive statements are thus contiguous.
+type TokenRange struct {
+	First, Last int
+}
+
+// Token is a token of a statement.
+type Token struct {
+	// ID is the id of the token as produced by the scanner, e.g.
+	// lexbase.IDENT. Note that the grammar may receive a different id for
+	// some keywords, depending on the tokens that follow.
+	ID int32
+	// Pos is the position of the token, relative to the SQL of the
+	// statement.
+	Pos int32
+	// Text is the token as spelled in the SQL of the statement.
+	Text string
+}
+
+// ReferencedName is the name of an object referenced by a statement. It is
+// syntactic: the name is not resolved, so it may, for example, designate a
+// view or a sequence in a table position.
+type ReferencedName struct {
+	Kind ReferencedNameKind
+	// Name is the name as formatted by tree.AsString, e.g. db.public.t.
+	Name string
+	// Pos is the position of the name, relative to the SQL of the statement.
+	Pos int32
+}
+
+// ReferencedNameKind is the kind of position in which a name is referenced.
+type ReferencedNameKind int8
+
+const (
+	// ReferencedTable is a name in a table position, e.g. in a FROM clause,
+	// as the target of an INSERT or in CREATE TABLE and DROP TABLE.
+	ReferencedTable ReferencedNameKind = iota
+	// ReferencedSequence is a name in a sequence position, e.g. in CREATE,
+	// ALTER or DROP SEQUENCE. Note that the sequences referenced by strings, as in
+	// nextval('s'), are not included.
+	ReferencedSequence
+	// ReferencedFunction is the name of a called function, or of a function
+	// or procedure in CREATE, ALTER or DROP FUNCTION and PROCEDURE.
+	ReferencedFunction
+)
+
+// String implements the fmt.Stringer interface.
+func (k ReferencedNameKind) String() string {
+	switch k {
+	case ReferencedTable:
+		return "table"
+	case ReferencedSequence:
+		return "sequence"
+	case ReferencedFunction:
+		return "function"
+	}
+	return "unknown"
+}
+
+// Complexity is a cheap measure of the complexity of a statement, available
+// before the statement is planned. Tokens is exact; the other counters are
+// derived from patterns of tokens and are heuristic: e.g. a parenthesis
+// counts as a subquery if a SELECT, VALUES, WITH or TABLE keyword follows it.
+type Complexity struct {
+	// Tokens is the number of tokens of the statement.
+	Tokens int
+	// Joins is the number of JOIN keywords.
+	Joins int
+	// SubqueryDepth is the maximum nesting depth of the subqueries. It is 0
+	// if there are no subqueries.
+	SubqueryDepth int
+	// MaxInListLen is the number of elements of the longest list on the right
+	// of an IN or NOT IN operator, not counting the subqueries.
+	MaxInListLen int
+}
+
+// PlaceholderTypeHint describes the casts applied directly to a placeholder
+// in a statement. These are the user's declaration of the type to be bound
+// to the placeholder, so they can be referenced when reporting mismatched
+// bind types.
+type PlaceholderTypeHint struct {
+	// TypeName is the target type of the first cast of the placeholder.
+	TypeName string
+	// Pos is the position of the placeholder in the first cast, relative to
+	// the SQL of the statement.
+	Pos int32
+	// Conflicts lists the casts of the placeholder to other types than
+	// TypeName, in order. Resolving them is left to the type checker.
+	Conflicts []PlaceholderTypeHint
 }
 
 // IsANSIDML returns true if the AST is one of the 4 DML statements,
@@ -95,3 +247,20 @@ type ParsedStmts interface {
 
 var _ ParsedStmts = Statements{}
 var _ ParsedStmts = PLpgStatement{}
+
+// MissingPlaceholders returns the indexes, in increasing order, of the
+// placeholders that do not occur in the statement although a placeholder
+// with a higher index does. Such gaps are not an error: the arguments bound
+// to them are simply unused.
+func (stmt Statement[T]) MissingPlaceholders() []tree.PlaceholderIdx {
+	if stmt.UsedPlaceholders.Len() == stmt.NumPlaceholders {
+		return nil
+	}
+	var missing []tree.PlaceholderIdx
+	for i := 0; i < stmt.NumPlaceholders; i++ {
+		if !stmt.UsedPlaceholders.Contains(i) {
+			missing = append(missing, tree.PlaceholderIdx(i))
+		}
+	}
+	return missing
+}
@@ -536,6 +536,13 @@ func (c *conn) handleParse(ctx context.Context, nakedIntSize *types.T) error {
 	if stmt.NumPlaceholders > numParams {
 		numParams = stmt.NumPlaceholders
 	}
+	if numParams > tree.MaxPlaceholderIdx {
+		// The parser rejects such statements; the count would not fit in the
+		// ParameterDescription message.
+		err := errors.AssertionFailedf("statement has %d placeholders, more than the maximum of %d",
+			numParams, tree.MaxPlaceholderIdx)
+		return c.stmtBuf.Push(ctx, sql.SendError{Err: err})
+	}
 
 	var sqlTypeHints tree.PlaceholderTypes
 	if len(inTypeHints) > 0 {
@@ -544,7 +544,9 @@ func (l *lexer) setErr(err error) {
 	err = pgerror.WithCandidateCode(err, pgcode.Syntax)
 	l.lastError = err
 	lastTok := l.lastToken()
-	l.lastError = parser.PopulateErrorDetails(lastTok.id, lastTok.str, lastTok.pos, l.lastError, l.in)
+	l.lastError = parser.PopulateErrorDetails(
+		lastTok.id, lastTok.str, "" /* precedingText */, lastTok.pos, l.lastError, l.in,
+	)
 }
 
 // setErrNoDetails is similar to setErr, but is used for an error that should
@@ -561,14 +563,18 @@ func (l *lexer) Error(e string) {
 	e = strings.TrimPrefix(e, "syntax error: ") // we'll add it again below.
 	err := pgerror.WithCandidateCode(errors.Newf("%s", e), pgcode.Syntax)
 	lastTok := l.lastToken()
-	l.lastError = parser.PopulateErrorDetails(lastTok.id, lastTok.str, lastTok.pos, err, l.in)
+	l.lastError = parser.PopulateErrorDetails(
+		lastTok.id, lastTok.str, "" /* precedingText */, lastTok.pos, err, l.in,
+	)
 }
 
 // Unimplemented wraps Error, setting lastUnimplementedError.
 func (l *lexer) Unimplemented(feature string) {
 	l.lastError = unimp.New(feature, "this syntax")
 	lastTok := l.lastToken()
-	l.lastError = parser.PopulateErrorDetails(lastTok.id, lastTok.str, lastTok.pos, l.lastError, l.in)
+	l.lastError = parser.PopulateErrorDetails(
+		lastTok.id, lastTok.str, "" /* precedingText */, lastTok.pos, l.lastError, l.in,
+	)
 	l.lastError = &tree.UnsupportedError{
 		Err:         l.lastError,
 		FeatureName: feature,
@@ -254,84 +254,6 @@ outer:
 	return true
 }
 
-// scanDollarQuotedString is similar to Scanner.scanDollarQuotedString, but uses
-// PL/pgSQL tokens.
-func (s *PLpgSQLScanner) scanDollarQuotedString(lval ScanSymType) bool {
-	s.lastAttemptedID = int32(lexbase.SCONST)
-	buf := s.buffer()
-	start := s.pos
-
-	foundStartTag := false
-	possibleEndTag := false
-	startTagIndex := -1
-	var startTag string
-
-outer:
-	for {
-		ch := s.peek()
-		switch ch {
-		case '$':
-			s.pos++
-			if foundStartTag {
-				if possibleEndTag {
-					if len(startTag) == startTagIndex {
-						// Found end tag.
-						buf = append(buf, s.in[start+len(startTag)+1:s.pos-len(startTag)-2]...)
-						break outer
-					} else {
-						// Was not the end tag but the current $ might be the start of the end tag we are looking for, so
-						// just reset the startTagIndex.
-						startTagIndex = 0
-					}
-				} else {
-					possibleEndTag = true
-					startTagIndex = 0
-				}
-			} else {
-				startTag = s.in[start : s.pos-1]
-				foundStartTag = true
-			}
-
-		case eof:
-			if foundStartTag {
-				// A start tag was found, therefore we expect an end tag before the eof, otherwise it is an error.
-				lval.SetID(lexbase.ERROR)
-				lval.SetStr(errUnterminated)
-			} else {
-				// This is not a dollar-quoted string, reset the pos back to the start.
-				s.pos = start
-			}
-			return false
-
-		default:
-			// If we haven't found a start tag yet, check whether the current characters is a valid for a tag.
-			if !foundStartTag && !sqllex.IsIdentStart(ch) && !sqllex.IsDigit(ch) {
-				return false
-			}
-			s.pos++
-			if possibleEndTag {
-				// Check whether this could be the end tag.
-				if startTagIndex >= len(startTag) || ch != int(startTag[startTagIndex]) {
-					// This is not the end tag we are looking for.
-					possibleEndTag = false
-					startTagIndex = -1
-				} else {
-					startTagIndex++
-				}
-			}
-		}
-	}
-
-	if !utf8.Valid(buf) {
-		lval.SetID(lexbase.ERROR)
-		lval.SetStr(errInvalidUTF8)
-		return false
-	}
-
-	lval.SetStr(s.finishString(buf))
-	return true
-}
-
 // scanNumber is similar to Scanner.scanNumber, but uses PL/pgSQL tokens.
 func (s *PLpgSQLScanner) scanNumber(lval ScanSymType, ch int) {
 	start := s.pos - 1
@@ -20,7 +20,11 @@ import (
 const eof = -1
 const errUnterminated = "unterminated string"
 const errInvalidUTF8 = "invalid UTF-8 byte sequence"
+const errInvalidDollarQuoteTag = "invalid dollar-quoted string tag"
 const errInvalidHexNumeric = "invalid hexadecimal numeric literal"
+const errInvalidOctalNumeric = "invalid octal numeric literal"
+const errInvalidBinaryNumeric = "invalid binary numeric literal"
+const errBacktick = "invalid character \"`\""
 const singleQuote = '\''
 const identQuote = '"'
 
@@ -56,6 +60,10 @@ type Scanner struct {
 	// Comments is the list of parsed comments from the SQL statement.
 	Comments []string
 
+	// Warnings is the list of non-fatal diagnostics encountered while
+	// scanning, for example unknown escapes in escape strings.
+	Warnings []Warning
+
 	// lastAttemptedID indicates the ID of the last attempted
 	// token. Used to recognizd which token an error was encountered
 	// on.
@@ -69,6 +77,15 @@ type Scanner struct {
 	retainComments bool
 }
 
+// Warning is a non-fatal diagnostic about the scanned input. Clients can
+// report it to the user, e.g. as a NOTICE.
+type Warning struct {
+	// Pos is the position in the input of the text the warning is about.
+	Pos int32
+	// Msg is the warning message.
+	Msg string
+}
+
 // SQLScanner is a scanner with a SQL specific scan function
 type SQLScanner struct {
 	Scanner
@@ -105,6 +122,7 @@ func (s *Scanner) RetainComments() {
 func (s *Scanner) Cleanup() {
 	s.bytesPrealloc = nil
 	s.Comments = nil
+	s.Warnings = nil
 	s.retainComments = false
 }
 
@@ -479,6 +497,18 @@ func (s *SQLScanner) Scan(lval ScanSymType) {
 		}
 		return
 
+	case '`':
+		// MySQL quotes identifiers with backticks, which are a common
+		// mistake; name the would-be identifier in the error.
+		lval.SetID(lexbase.ERROR)
+		if ident, ok := BacktickQuotedIdent(s.in, int(lval.Pos())); ok {
+			s.pos += len(ident) + 1
+			lval.SetStr(fmt.Sprintf("backtick-quoted identifier %s is not supported", s.in[lval.Pos():s.pos]))
+		} else {
+			lval.SetStr(errBacktick)
+		}
+		return
+
 	default:
 		if lexbase.IsDigit(ch) {
 			s.lastAttemptedID = int32(lexbase.ICONST)
@@ -495,6 +525,18 @@ func (s *SQLScanner) Scan(lval ScanSymType) {
 	// lval for above.
 }
 
+// BacktickQuotedIdent returns the identifier quoted in the MySQL style by
+// the backtick at position pos of sql, i.e. the text up to the next
+// backtick, provided that it is on the same line.
+func BacktickQuotedIdent(sql string, pos int) (ident string, ok bool) {
+	rest := sql[pos+1:]
+	end := strings.IndexAny(rest, "`\n")
+	if end < 0 || rest[end] != '`' {
+		return "", false
+	}
+	return rest[:end], true
+}
+
 func (s *Scanner) peek() int {
 	if s.pos >= len(s.in) {
 		return eof
@@ -692,28 +734,46 @@ func (s *Scanner) scanIdent(lval ScanSymType) {
 
 func (s *Scanner) scanNumber(lval ScanSymType, ch int) {
 	start := s.pos - 1
-	isHex := false
+	if ch == '0' {
+		switch s.peek() {
+		case 'x', 'X':
+			s.scanPrefixedInteger(lval, start, 16)
+			return
+		case 'o', 'O':
+			s.scanPrefixedInteger(lval, start, 8)
+			return
+		case 'b', 'B':
+			s.scanPrefixedInteger(lval, start, 2)
+			return
+		}
+	}
+
 	hasDecimal := ch == '.'
 	hasExponent := false
+	hasUnderscore := false
 
 	for {
 		ch := s.peek()
-		if (isHex && lexbase.IsHexDigit(ch)) || lexbase.IsDigit(ch) {
+		if lexbase.IsDigit(ch) {
 			s.pos++
 			continue
 		}
-		if ch == 'x' || ch == 'X' {
-			if isHex || s.in[start] != '0' || s.pos != start+1 {
-				lval.SetID(lexbase.ERROR)
-				lval.SetStr(errInvalidHexNumeric)
+		if ch == '_' {
+			// Underscores are allowed as digit separators, but only between
+			// two digits, e.g. 1_000_000.
+			if !lexbase.IsDigit(int(s.in[s.pos-1])) || !lexbase.IsDigit(s.peekN(1)) {
+				s.setTrailingJunkError(lval, start)
 				return
 			}
+			hasUnderscore = true
 			s.pos++
-			isHex = true
 			continue
 		}
-		if isHex {
-			break
+		if ch == 'x' || ch == 'X' {
+			// A valid 0x prefix has been handled above.
+			lval.SetID(lexbase.ERROR)
+			lval.SetStr(errInvalidHexNumeric)
+			return
 		}
 		if ch == '.' {
 			if hasDecimal || hasExponent {
@@ -758,6 +818,12 @@ func (s *Scanner) scanNumber(lval ScanSymType, ch int) {
 	}
 
 	lval.SetStr(s.in[start:s.pos])
+	if hasUnderscore {
+		// The digit separators carry no meaning; drop them so that the
+		// resulting string can be used as-is by the decimal conversions
+		// downstream.
+		lval.SetStr(strings.ReplaceAll(lval.Str(), "_", ""))
+	}
 	if hasDecimal || hasExponent {
 		lval.SetID(lexbase.FCONST)
 		floatConst := constant.MakeFromLiteral(lval.Str(), token.FLOAT, 0)
@@ -768,20 +834,12 @@ func (s *Scanner) scanNumber(lval ScanSymType, ch int) {
 		}
 		lval.SetUnionVal(NewNumValFn(floatConst, lval.Str(), false /* negative */))
 	} else {
-		if isHex && s.pos == start+2 {
-			lval.SetID(lexbase.ERROR)
-			lval.SetStr(errInvalidHexNumeric)
-			return
-		}
-
-		// Strip off leading zeros from non-hex (decimal) literals so that
+		// Strip off leading zeros from decimal literals so that
 		// constant.MakeFromLiteral doesn't inappropriately interpret the
 		// string as an octal literal. Note: we can't use strings.TrimLeft
 		// here, because it will truncate '0' to ''.
-		if !isHex {
-			for len(lval.Str()) > 1 && lval.Str()[0] == '0' {
-				lval.SetStr(lval.Str()[1:])
-			}
+		for len(lval.Str()) > 1 && lval.Str()[0] == '0' {
+			lval.SetStr(lval.Str()[1:])
 		}
 
 		lval.SetID(lexbase.ICONST)
@@ -795,6 +853,121 @@ func (s *Scanner) scanNumber(lval ScanSymType, ch int) {
 	}
 }
 
+// scanPrefixedInteger scans an integer literal written with a radix prefix:
+// 0x (hexadecimal), 0o (octal) or 0b (binary). The leading 0 has already been
+// consumed and s.pos points at the radix letter. Like PostgreSQL, underscores
+// are allowed as digit separators after the prefix and between digits.
+func (s *Scanner) scanPrefixedInteger(lval ScanSymType, start int, base int) {
+	// Skip over the radix letter.
+	s.pos++
+	numDigits := 0
+	hasUnderscore := false
+	for {
+		ch := s.peek()
+		if ch == '_' {
+			if !isDigitInBase(s.peekN(1), base) {
+				s.setTrailingJunkError(lval, start)
+				return
+			}
+			hasUnderscore = true
+			s.pos++
+			continue
+		}
+		if isDigitInBase(ch, base) {
+			numDigits++
+			s.pos++
+			continue
+		}
+		if base == 16 && (ch == 'x' || ch == 'X') {
+			lval.SetID(lexbase.ERROR)
+			lval.SetStr(errInvalidHexNumeric)
+			return
+		}
+		if lexbase.IsDigit(ch) {
+			// A decimal digit that is not valid in this base, e.g. 0b12 or 0o8.
+			lval.SetID(lexbase.ERROR)
+			lval.SetPos(int32(s.pos))
+			lval.SetStr(fmt.Sprintf("%s: %q is not a valid %s digit",
+				invalidPrefixedNumericError(base), rune(ch), radixName(base)))
+			return
+		}
+		break
+	}
+
+	if numDigits == 0 {
+		lval.SetID(lexbase.ERROR)
+		lval.SetStr(invalidPrefixedNumericError(base))
+		return
+	}
+
+	// Disallow identifier after numerical constants e.g. "0x1fg".
+	if lexbase.IsIdentStart(s.peek()) {
+		lval.SetID(lexbase.ERROR)
+		lval.SetStr(fmt.Sprintf("trailing junk after numeric literal at or near %q", s.in[start:s.pos+1]))
+		return
+	}
+
+	lval.SetStr(s.in[start:s.pos])
+	if hasUnderscore {
+		lval.SetStr(strings.ReplaceAll(lval.Str(), "_", ""))
+	}
+	intConst := constant.MakeFromLiteral(lval.Str(), token.INT, 0)
+	if intConst.Kind() == constant.Unknown {
+		lval.SetID(lexbase.ERROR)
+		lval.SetStr(fmt.Sprintf("could not make constant int from literal %q", lval.Str()))
+		return
+	}
+	if _, exact := constant.Int64Val(intConst); !exact {
+		// Values that do not fit in an INT8 end up being interpreted as
+		// DECIMAL downstream, which only understands decimal strings. Use
+		// the decimal representation, as we do for large decimal literals.
+		lval.SetStr(intConst.ExactString())
+	}
+	lval.SetID(lexbase.ICONST)
+	lval.SetUnionVal(NewNumValFn(intConst, lval.Str(), false /* negative */))
+}
+
+// setTrailingJunkError reports a misplaced digit separator in the numeric
+// literal starting at start. The error position points at the underscore.
+func (s *Scanner) setTrailingJunkError(lval ScanSymType, start int) {
+	lval.SetID(lexbase.ERROR)
+	lval.SetPos(int32(s.pos))
+	lval.SetStr(fmt.Sprintf("trailing junk after numeric literal at or near %q", s.in[start:s.pos+1]))
+}
+
+// isDigitInBase returns true if ch is a valid digit in the given base.
+func isDigitInBase(ch int, base int) bool {
+	switch base {
+	case 2:
+		return ch == '0' || ch == '1'
+	case 8:
+		return ch >= '0' && ch <= '7'
+	case 16:
+		return lexbase.IsHexDigit(ch)
+	}
+	return lexbase.IsDigit(ch)
+}
+
+func radixName(base int) string {
+	switch base {
+	case 2:
+		return "binary"
+	case 8:
+		return "octal"
+	}
+	return "hexadecimal"
+}
+
+func invalidPrefixedNumericError(base int) string {
+	switch base {
+	case 2:
+		return errInvalidBinaryNumeric
+	case 8:
+		return errInvalidOctalNumeric
+	}
+	return errInvalidHexNumeric
+}
+
 func (s *Scanner) scanPlaceholder(lval ScanSymType) {
 	s.lastAttemptedID = int32(lexbase.PLACEHOLDER)
 	start := s.pos
@@ -805,8 +978,11 @@ func (s *Scanner) scanPlaceholder(lval ScanSymType) {
 
 	placeholder, err := NewPlaceholderFn(lval.Str())
 	if err != nil {
+		// The error is kept in the union value, so that the parser can report
+		// its hints.
 		lval.SetID(lexbase.ERROR)
-		lval.SetStr(err.Error())
+		lval.SetStr(fmt.Sprintf("%s at or near %q", err.Error(), s.in[start-1:s.pos]))
+		lval.SetUnionVal(err)
 		return
 	}
 	lval.SetID(lexbase.PLACEHOLDER)
@@ -987,6 +1163,15 @@ outer:
 				// backslash. For example, e'\"' is equivalent to e'"', and
 				// e'\d\b' to e'd\b'. This is what Postgres does:
 				// http://www.postgresql.org/docs/9.4/static/sql-syntax-lexical.html#SQL-SYNTAX-STRINGS-ESCAPE
+				// Since this is rarely what the user intended (e.g. when
+				// writing a regular expression), we also record a warning.
+				if t != eof {
+					r, _ := utf8.DecodeRuneInString(s.in[s.pos:])
+					s.Warnings = append(s.Warnings, Warning{
+						Pos: int32(s.pos - 1),
+						Msg: fmt.Sprintf("nonstandard use of escape in a string literal: \\%c", r),
+					})
+				}
 				start = s.pos
 			}
 
@@ -1015,78 +1200,58 @@ outer:
 // tag is some arbitrary string.  e.g. $$a string$$ or $escaped$a string$escaped$.
 func (s *Scanner) scanDollarQuotedString(lval ScanSymType) bool {
 	s.lastAttemptedID = int32(lexbase.SCONST)
-	buf := s.buffer()
-	start := s.pos
-
-	foundStartTag := false
-	possibleEndTag := false
-	startTagIndex := -1
-	var startTag string
-
-outer:
-	for {
-		ch := s.peek()
-		switch ch {
-		case '$':
-			s.pos++
-			if foundStartTag {
-				if possibleEndTag {
-					if len(startTag) == startTagIndex {
-						// Found end tag.
-						buf = append(buf, s.in[start+len(startTag)+1:s.pos-len(startTag)-2]...)
-						break outer
-					} else {
-						// Was not the end tag but the current $ might be the start of the end tag we are looking for, so
-						// just reset the startTagIndex.
-						startTagIndex = 0
-					}
-				} else {
-					possibleEndTag = true
-					startTagIndex = 0
-				}
-			} else {
-				startTag = s.in[start : s.pos-1]
-				foundStartTag = true
-			}
-
-		case eof:
-			if foundStartTag {
-				// A start tag was found, therefore we expect an end tag before the eof, otherwise it is an error.
-				lval.SetID(lexbase.ERROR)
-				lval.SetStr(errUnterminated)
-			} else {
-				// This is not a dollar-quoted string, reset the pos back to the start.
-				s.pos = start
-			}
-			return false
+	str, errMsg, ok := s.scanDollarQuote()
+	if !ok {
+		return false
+	}
+	if errMsg != "" {
+		lval.SetID(lexbase.ERROR)
+		lval.SetStr(errMsg)
+		return false
+	}
+	lval.SetStr(str)
+	return true
+}
 
-		default:
-			// If we haven't found a start tag yet, check whether the current characters is a valid for a tag.
-			if !foundStartTag && !lexbase.IsIdentStart(ch) && !lexbase.IsDigit(ch) {
-				return false
-			}
+// scanDollarQuote scans a dollar-quoted string whose leading '$' has already
+// been consumed. It returns ok=false, leaving the position unchanged, if the
+// input does not start a dollar-quoted string. Otherwise it returns either the
+// contents of the string or, if the string is malformed, a lexical error
+// message.
+//
+// As in PostgreSQL, the tag of a dollar-quoted string is either empty or has
+// the form of an unquoted identifier, minus the '$' character. The string
+// ends at the first occurrence of its own delimiter: delimiters using a
+// different tag, including tags that share a prefix with it, are part of the
+// contents.
+func (s *Scanner) scanDollarQuote() (str string, errMsg string, ok bool) {
+	start := s.pos
+	if lexbase.IsIdentStart(s.peek()) {
+		s.pos++
+		for ch := s.peek(); ch != '$' && lexbase.IsIdentMiddle(ch); ch = s.peek() {
 			s.pos++
-			if possibleEndTag {
-				// Check whether this could be the end tag.
-				if startTagIndex >= len(startTag) || ch != int(startTag[startTagIndex]) {
-					// This is not the end tag we are looking for.
-					possibleEndTag = false
-					startTagIndex = -1
-				} else {
-					startTagIndex++
-				}
-			}
 		}
+		if s.peek() != '$' || !utf8.ValidString(s.in[start:s.pos]) {
+			// The input looks like a tag, but it is not terminated by '$'.
+			return "", errInvalidDollarQuoteTag, true
+		}
+	} else if s.peek() != '$' {
+		return "", "", false
 	}
+	s.pos++
+	delim := s.in[start-1 : s.pos]
 
+	end := strings.Index(s.in[s.pos:], delim)
+	if end == -1 {
+		s.pos = len(s.in)
+		return "", errUnterminated, true
+	}
+	buf := append(s.buffer(), s.in[s.pos:s.pos+end]...)
+	s.pos += end + len(delim)
 	if !utf8.Valid(buf) {
-		lval.SetID(lexbase.ERROR)
-		lval.SetStr(errInvalidUTF8)
-		return false
+		return "", errInvalidUTF8, true
 	}
-
-	lval.SetStr(s.finishString(buf))
-	return true
+	return s.finishString(buf), "", true
 }
 
 // HasMultipleStatements returns true if the sql string contains more than one
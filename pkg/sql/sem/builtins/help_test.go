@@ -11,12 +11,15 @@ import (
 	"testing"
 	"unicode"
 
+	"github.com/cockroachdb/cockroach/pkg/docs"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/builtins/builtinconstants"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/builtins/builtinsregistry"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/testutils/datapathutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/datadriven"
 )
 
 func TestHelpFunctions(t *testing.T) {
@@ -40,6 +43,9 @@ func TestHelpFunctions(t *testing.T) {
 			if !strings.HasPrefix(err.Error(), "help token in input") {
 				t.Fatal(err)
 			}
+			if msg, ok := parser.GetHelpResponse(err); !ok || msg.Function == "" {
+				t.Errorf("expected a function help response, got %v", err)
+			}
 			pgerr := pgerror.Flatten(err)
 			if !strings.HasPrefix(pgerr.Hint, "help:\n") {
 				t.Errorf("expected 'help: ' prefix, got %q", pgerr.Hint)
@@ -61,3 +67,23 @@ func TestHelpFunctions(t *testing.T) {
 		t.Errorf("Test saw %d builtins, probably load order is wrong", numTestsRun)
 	}
 }
+
+// TestFunctionHelpDetail checks the detailed help message produced for
+// \hf, which groups the overloads of a function by class.
+func TestFunctionHelpDetail(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	datadriven.RunTest(t, datapathutils.TestDataPath(t, "function_help"), func(t *testing.T, d *datadriven.TestData) string {
+		switch d.Cmd {
+		case "help":
+			_, err := parser.Parse("select " + strings.TrimSpace(d.Input) + "(??")
+			if err == nil {
+				d.Fatalf(t, "parser didn't trigger error")
+			}
+			help := strings.TrimPrefix(pgerror.Flatten(err).Hint, "help:\n")
+			return strings.ReplaceAll(help, docs.URLBase, "WEBDOCS")
+		default:
+			d.Fatalf(t, "unknown command %s", d.Cmd)
+			return ""
+		}
+	})
+}
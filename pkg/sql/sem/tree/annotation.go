@@ -5,6 +5,8 @@
 
 package tree
 
+import "math"
+
 // AnnotationIdx is the 1-based index of an annotation. AST nodes that can
 // be annotated store such an index (unique within that AST).
 type AnnotationIdx int32
@@ -12,6 +14,11 @@ type AnnotationIdx int32
 // NoAnnotation is the uninitialized annotation index.
 const NoAnnotation AnnotationIdx = 0
 
+// MaxAnnotations is the highest annotation index. A statement that needs more
+// annotations, including the ones of the statements it shares its container
+// with, cannot be parsed.
+const MaxAnnotations AnnotationIdx = math.MaxInt32
+
 // AnnotatedNode is embedded in AST nodes that have an annotation.
 type AnnotatedNode struct {
 	AnnIdx AnnotationIdx
@@ -786,12 +786,19 @@ func NewPlaceholder(name string) (*Placeholder, error) {
 		return nil, err
 	}
 	// The string is the number that follows $ which is a 1-based index ($1, $2,
-	// etc), while PlaceholderIdx is 0-based.
-	if uval == 0 || uval > MaxPlaceholderIdx+1 {
-		return nil, pgerror.Newf(
+	// etc), while PlaceholderIdx is 0-based. The pgwire protocol sends the
+	// number of parameters as a 16-bit integer, so $65535 is the highest
+	// placeholder that can be bound.
+	if uval == 0 || uval > MaxPlaceholderIdx {
+		err := pgerror.Newf(
 			pgcode.NumericValueOutOfRange,
-			"placeholder index must be between 1 and %d", MaxPlaceholderIdx+1,
+			"placeholder index must be between 1 and %d", MaxPlaceholderIdx,
 		)
+		if uval > MaxPlaceholderIdx {
+			err = errors.WithHint(err,
+				"split the statement into smaller batches, or pass many values in a single array placeholder")
+		}
+		return nil, err
 	}
 	return &Placeholder{Idx: PlaceholderIdx(uval - 1)}, nil
 }
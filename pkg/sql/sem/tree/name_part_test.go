@@ -26,10 +26,10 @@ func TestUnresolvedObjectName(t *testing.T) {
 		{`a`, `a`, `""."".a`, ``},
 		{`a.b`, `a.b`, `"".a.b`, ``},
 		{`a.b.c`, `a.b.c`, `a.b.c`, ``},
-		{`a.b.c.d`, ``, ``, `at or near "\.": syntax error`},
+		{`a.b.c.d`, ``, ``, `at or near "\.\.\. b\.c\.": syntax error`},
 		{`a.""`, ``, ``, `invalid table name: a\.""`},
 		{`a.b.""`, ``, ``, `invalid table name: a\.b\.""`},
-		{`a.b.c.""`, ``, ``, `at or near "\.": syntax error`},
+		{`a.b.c.""`, ``, ``, `at or near "\.\.\. b\.c\.": syntax error`},
 		{`a."".c`, ``, ``, `invalid table name: a\.""\.c`},
 
 		// CockroachDB extension: empty catalog name.
@@ -41,8 +41,8 @@ func TestUnresolvedObjectName(t *testing.T) {
 		{`x.user.y`, `x."user".y`, `x."user".y`, ``},
 		{`x.user`, `x."user"`, `"".x."user"`, ``},
 
-		{`foo@bar`, ``, ``, `at or near "@": syntax error`},
-		{`test.*`, ``, ``, `at or near "\*": syntax error`},
+		{`foo@bar`, ``, ``, `at or near "ALTER TABLE foo@": syntax error`},
+		{`test.*`, ``, ``, `at or near "\.\.\. TABLE test\.\*": syntax error`},
 	}
 
 	for _, tc := range testCases {
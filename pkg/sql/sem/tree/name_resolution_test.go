@@ -27,10 +27,10 @@ func TestClassifyTablePattern(t *testing.T) {
 		{`a`, `a`, `""."".a`, ``},
 		{`a.b`, `a.b`, `"".a.b`, ``},
 		{`a.b.c`, `a.b.c`, `a.b.c`, ``},
-		{`a.b.c.d`, ``, ``, `at or near "\.": syntax error`},
+		{`a.b.c.d`, ``, ``, `at or near "\.\.\. b\.c\.": syntax error`},
 		{`a.""`, ``, ``, `invalid table name: a\.""`},
 		{`a.b.""`, ``, ``, `invalid table name: a\.b\.""`},
-		{`a.b.c.""`, ``, ``, `at or near "\.": syntax error`},
+		{`a.b.c.""`, ``, ``, `at or near "\.\.\. b\.c\.": syntax error`},
 		{`a."".c`, ``, ``, `invalid table name: a\.""\.c`},
 		// CockroachDB extension: empty catalog name.
 		{`"".b.c`, `"".b.c`, `"".b.c`, ``},
@@ -44,10 +44,10 @@ func TestClassifyTablePattern(t *testing.T) {
 		{`*`, `*`, `""."".*`, ``},
 		{`a.*`, `a.*`, `"".a.*`, ``},
 		{`a.b.*`, `a.b.*`, `a.b.*`, ``},
-		{`a.b.c.*`, ``, ``, `at or near "\.": syntax error`},
-		{`a.b.*.c`, ``, ``, `at or near "\.": syntax error`},
-		{`a.*.b`, ``, ``, `at or near "\.": syntax error`},
-		{`*.b`, ``, ``, `at or near "\.": syntax error`},
+		{`a.b.c.*`, ``, ``, `at or near "\.\.\. b\.c\.": syntax error`},
+		{`a.b.*.c`, ``, ``, `at or near "\.\.\. b\.\*\.": syntax error`},
+		{`a.*.b`, ``, ``, `at or near "\.\.\. a\.\*\.": syntax error`},
+		{`*.b`, ``, ``, `at or near "\.\.\. SELECT ON \*\.": syntax error`},
 		{`"".*`, ``, ``, `invalid table name: "".\*`},
 		{`a."".*`, ``, ``, `invalid table name: a\.""\.\*`},
 		{`a.b."".*`, ``, ``, `invalid table name: a.b.""`},
@@ -59,7 +59,7 @@ func TestClassifyTablePattern(t *testing.T) {
 		{`"user".x.*`, `"user".x.*`, `"user".x.*`, ``},
 		{`x.user.*`, `x."user".*`, `x."user".*`, ``},
 
-		{`foo@bar`, ``, ``, `at or near "@": syntax error`},
+		{`foo@bar`, ``, ``, `at or near "\.\.\. SELECT ON foo@": syntax error`},
 	}
 
 	for _, tc := range testCases {
@@ -113,12 +113,12 @@ func TestClassifyColumnName(t *testing.T) {
 		{`a.b`, `a.b`, ``},
 		{`a.b.c`, `a.b.c`, ``},
 		{`a.b.c.d`, `a.b.c.d`, ``},
-		{`a.b.c.d.e`, ``, `at or near "\.": syntax error`},
+		{`a.b.c.d.e`, ``, `at or near "\.\.\. c\.d\.": syntax error`},
 		{`""`, ``, `invalid column name: ""`},
 		{`a.""`, ``, `invalid column name: a\.""`},
 		{`a.b.""`, ``, `invalid column name: a\.b\.""`},
 		{`a.b.c.""`, ``, `invalid column name: a\.b\.c\.""`},
-		{`a.b.c.d.""`, ``, `at or near "\.": syntax error`},
+		{`a.b.c.d.""`, ``, `at or near "\.\.\. c\.d\.": syntax error`},
 		{`"".a`, ``, `invalid column name: ""\.a`},
 		{`"".a.b`, ``, `invalid column name: ""\.a\.b`},
 		// CockroachDB extension: empty catalog name.
@@ -137,14 +137,14 @@ func TestClassifyColumnName(t *testing.T) {
 		{`a.*`, `a.*`, ``},
 		{`a.b.*`, `a.b.*`, ``},
 		{`a.b.c.*`, `a.b.c.*`, ``},
-		{`a.b.c.d.*`, ``, `at or near "\.": syntax error`},
-		{`a.b.*.c`, ``, `at or near "\.": syntax error`},
-		{`a.*.b`, ``, `at or near "\.": syntax error`},
-		{`*.b`, ``, `at or near "\.": syntax error`},
+		{`a.b.c.d.*`, ``, `at or near "\.\.\. c\.d\.": syntax error`},
+		{`a.b.*.c`, ``, `at or near "\.\.\. b\.\*\.": syntax error`},
+		{`a.*.b`, ``, `at or near "\.\.\. a\.\*\.": syntax error`},
+		{`*.b`, ``, `at or near "SELECT \*\.": syntax error`},
 		{`"".*`, ``, `invalid column name: "".\*`},
 		{`a."".*`, ``, `invalid column name: a\.""\.\*`},
 		{`a.b."".*`, ``, `invalid column name: a\.b\.""\.\*`},
-		{`a.b.c."".*`, ``, `at or near "\.": syntax error`},
+		{`a.b.c."".*`, ``, `at or near "\.\.\. c\.""\.": syntax error`},
 
 		{`"".a.*`, ``, `invalid column name: ""\.a.*`},
 		// CockroachDB extension: empty catalog name.
@@ -157,7 +157,7 @@ func TestClassifyColumnName(t *testing.T) {
 		{`"user".x.*`, `"user".x.*`, ``},
 		{`x.user.*`, `x.user.*`, ``},
 
-		{`foo@bar`, ``, `at or near "@": syntax error`},
+		{`foo@bar`, ``, `at or near "SELECT foo@": syntax error`},
 	}
 
 	for _, tc := range testCases {
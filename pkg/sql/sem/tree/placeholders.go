@@ -22,9 +22,10 @@ type PlaceholderIdx uint16
 
 var _ redact.SafeValue = PlaceholderIdx(0)
 
-// MaxPlaceholderIdx is the maximum allowed value of a PlaceholderIdx.
-// The pgwire protocol is limited to 2^16 placeholders, so we limit the IDs to
-// this range as well.
+// MaxPlaceholderIdx is the maximum number of placeholders of a statement, so
+// PlaceholderIdx values range from 0 to MaxPlaceholderIdx-1. The pgwire
+// protocol sends the number of parameters as a 16-bit integer, so we limit
+// the IDs to this range as well.
 const MaxPlaceholderIdx = math.MaxUint16
 
 // SafeValue implements the redact.SafeValue interface.
@@ -5,14 +5,34 @@
 
 package tree
 
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
 var _ error = &UnsupportedError{}
+var _ fmt.Formatter = &UnsupportedError{}
+var _ errors.Formatter = &UnsupportedError{}
 
 // UnsupportedError is an error object which is returned by some unimplemented SQL
 // statements. It is currently only used to skip over PGDUMP statements during
 // an import.
+//
+// The parser wraps it further with the position of the unsupported syntax, so
+// it should be retrieved with errors.As rather than with a type assertion.
 type UnsupportedError struct {
 	Err         error
 	FeatureName string
+	// IssueNumber is the number of the GitHub issue that tracks the feature, or
+	// 0 if there is none.
+	IssueNumber int
+	// Detail distinguishes the features tracked by the same issue. It is only
+	// set together with IssueNumber.
+	Detail string
+	// Purposely is set if the feature is not meant to ever be supported.
+	Purposely bool
 }
 
 func (u *UnsupportedError) Error() string {
@@ -24,3 +44,64 @@ func (u *UnsupportedError) Cause() error { return u.Err }
 
 // Unwrap implements wrapper.
 func (u *UnsupportedError) Unwrap() error { return u.Err }
+
+// Format implements fmt.Formatter.
+func (u *UnsupportedError) Format(s fmt.State, verb rune) { errors.FormatError(u, s, verb) }
+
+// FormatError implements errors.Formatter. The error does not add to the
+// message of the error it wraps; the feature is only mentioned in the details.
+func (u *UnsupportedError) FormatError(p errors.Printer) error {
+	if p.Detail() {
+		p.Printf("unsupported feature: %s", u.FeatureName)
+		if u.IssueNumber != 0 {
+			p.Printf("\ntracked by issue #%d", u.IssueNumber)
+			if u.Detail != "" {
+				p.Printf(" (%s)", u.Detail)
+			}
+		}
+	}
+	return u.Err
+}
+
+// TelemetryKey returns the name of the feature counter to increment when the
+// unsupported feature is used, without the prefix identifying the component
+// that reported it.
+func (u *UnsupportedError) TelemetryKey() string {
+	switch {
+	case u.Purposely:
+		return "sql.purposely_unimplemented." + normalizeFeatureName(u.FeatureName)
+	case u.IssueNumber != 0 && u.Detail != "":
+		return fmt.Sprintf("#%d.%s", u.IssueNumber, u.Detail)
+	case u.IssueNumber != 0:
+		return fmt.Sprintf("#%d", u.IssueNumber)
+	default:
+		return u.FeatureName
+	}
+}
+
+// maxFeatureNameLen caps the length of a feature name in a telemetry key.
+const maxFeatureNameLen = 64
+
+// normalizeFeatureName turns a feature name into a telemetry key component:
+// it is lowercased, each run of characters other than ASCII letters and
+// digits is replaced by an underscore, and it is truncated to
+// maxFeatureNameLen bytes.
+func normalizeFeatureName(feature string) string {
+	var buf strings.Builder
+	sep := false
+	for _, r := range strings.ToLower(feature) {
+		if ('a' <= r && r <= 'z') || ('0' <= r && r <= '9') {
+			if sep && buf.Len() > 0 {
+				buf.WriteByte('_')
+			}
+			sep = false
+			buf.WriteRune(r)
+		} else {
+			sep = true
+		}
+		if buf.Len() >= maxFeatureNameLen {
+			break
+		}
+	}
+	return buf.String()
+}
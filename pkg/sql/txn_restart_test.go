@@ -1502,7 +1502,7 @@ func TestRollbackToSavepointFromUnusualStates(t *testing.T) {
 	checkState(tx, ts)
 
 	// ROLLBACK TO SAVEPOINT from an Aborted txn should work.
-	if _, err := tx.Exec("BOGUS SQL STATEMENT"); !testutils.IsError(err, `at or near "bogus": syntax error`) {
+	if _, err := tx.Exec("BOGUS SQL STATEMENT"); !testutils.IsError(err, `at or near "BOGUS": syntax error`) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	if _, err := tx.Exec("ROLLBACK TO SAVEPOINT cockroach_restart"); err != nil {
@@ -9,10 +9,13 @@ package flagutil
 import (
 	"fmt"
 	"regexp"
+	"regexp/syntax"
+	"strings"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
 	"github.com/spf13/pflag"
 )
 
@@ -42,8 +45,12 @@ func Time(t *time.Time) pflag.Value {
 
 // Regexp returns a value which can be used with pflag.Var to create flags
 // for regexp variables. The flag attempts to compile its input to a regular
-// expression and assigns the result to *r.
-// If the flag is empty, r is set to nil.
+// expression and assigns the result to *r, so that an invalid expression is
+// reported when the flags are parsed, with the position of the error in the
+// expression. The matching is case-insensitive if the expression starts with
+// (?i).
+// If the flag is empty, r is set to nil, which the users of the flag take to
+// match everything.
 func Regexp(r **regexp.Regexp) pflag.Value {
 	return re{re: r}
 }
@@ -118,10 +125,58 @@ func (r re) Set(s string) error {
 	}
 	re, err := regexp.Compile(s)
 	if err != nil {
-		return err
+		return regexpError(s, err)
 	}
 	*r.re = re
 	return nil
 }
 
+// regexpError adds to err, the error of the compilation of expr, the
+// position of the erroneous part of expr, counted from 1.
+func regexpError(expr string, err error) error {
+	var serr *syntax.Error
+	if !errors.As(err, &serr) {
+		return err
+	}
+	if serr.Code == syntax.ErrTrailingBackslash {
+		return errors.Newf("invalid regexp %q at position %d: %s", expr, len(expr), serr.Code)
+	}
+	pos := regexpErrorPos(expr, serr)
+	if pos < 0 {
+		return errors.Newf("invalid regexp %q: %s: %s", expr, serr.Code, serr.Expr)
+	}
+	return errors.Newf("invalid regexp %q at position %d: %s: %s", expr, pos+1, serr.Code, serr.Expr)
+}
+
+// regexpErrorPos returns the offset in expr of serr.Expr, the erroneous part
+// of expr, or -1 if it cannot be found. Some errors report the rest of expr
+// from the error, which is then a suffix of expr: the same text can occur
+// earlier, as in "[c]x[c". The others report a part of expr at the first
+// error, which is the first occurrence of the part that is not escaped by
+// a backslash.
+func regexpErrorPos(expr string, serr *syntax.Error) int {
+	switch serr.Code {
+	case syntax.ErrMissingBracket, syntax.ErrInvalidUTF8:
+		if !strings.HasSuffix(expr, serr.Expr) {
+			return -1
+		}
+		return len(expr) - len(serr.Expr)
+	}
+	for from := 0; ; {
+		i := strings.Index(expr[from:], serr.Expr)
+		if i < 0 {
+			return -1
+		}
+		i += from
+		escaped := false
+		for j := i - 1; j >= 0 && expr[j] == '\\'; j-- {
+			escaped = !escaped
+		}
+		if !escaped {
+			return i
+		}
+		from = i + 1
+	}
+}
+
 func (r re) Type() string { return "regexp" }
@@ -141,6 +141,39 @@ func TestRegexpString(t *testing.T) {
 	}
 }
 
+func TestRegexpError(t *testing.T) {
+	for _, tc := range []struct {
+		in  string
+		exp string
+	}{
+		{"a+*", `invalid regexp "a+*" at position 2: invalid nested repetition operator: +*`},
+		{`foo\q`, `invalid regexp "foo\\q" at position 4: invalid escape sequence: \q`},
+		{"ab[c", `invalid regexp "ab[c" at position 3: missing closing ]: [c`},
+		{"(?i)ab(c", `invalid regexp "(?i)ab(c" at position 1: missing closing ): (?i)ab(c`},
+		{"x(?z)", `invalid regexp "x(?z)" at position 2: invalid or unsupported Perl syntax: (?z`},
+		// The erroneous part also occurs elsewhere in the expression.
+		{"[c]x[c", `invalid regexp "[c]x[c" at position 5: missing closing ]: [c`},
+		{"a**b**", `invalid regexp "a**b**" at position 2: invalid nested repetition operator: **`},
+		{`\\q\q`, `invalid regexp "\\\\q\\q" at position 4: invalid escape sequence: \q`},
+		{`ab\`, `invalid regexp "ab\\" at position 3: trailing backslash at end of expression`},
+	} {
+		var re *regexp.Regexp
+		err := Regexp(&re).Set(tc.in)
+		if err == nil || err.Error() != tc.exp {
+			t.Errorf("%s: expected error %q, got %v", tc.in, tc.exp, err)
+		}
+	}
+
+	// The matching is case-insensitive with the (?i) prefix.
+	var re *regexp.Regexp
+	if err := Regexp(&re).Set("(?i)^foo$"); err != nil {
+		t.Fatal(err)
+	}
+	if !re.MatchString("FoO") {
+		t.Errorf("expected (?i)^foo$ to match FoO")
+	}
+}
+
 func TestEmptyStringZeroes(t *testing.T) {
 	now := timeutil.Now()
 	re := regexp.MustCompile(".*")